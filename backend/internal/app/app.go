@@ -10,6 +10,7 @@ import (
 	"github.com/hasmcp/hasmcp-ce/backend/internal/controller/crud"
 	"github.com/hasmcp/hasmcp-ce/backend/internal/controller/mcp"
 	mcpjwt "github.com/hasmcp/hasmcp-ce/backend/internal/controller/mcp/jwt"
+	"github.com/hasmcp/hasmcp-ce/backend/internal/controller/mcpauth"
 	oauth2mcp "github.com/hasmcp/hasmcp-ce/backend/internal/controller/oauth2mcp"
 	oauth2mcpjwt "github.com/hasmcp/hasmcp-ce/backend/internal/controller/oauth2mcp/jwt"
 
@@ -24,10 +25,12 @@ import (
 	"github.com/hasmcp/hasmcp-ce/backend/internal/repository/storage"
 
 	"github.com/hasmcp/hasmcp-ce/backend/internal/service/config"
+	"github.com/hasmcp/hasmcp-ce/backend/internal/service/grpcc"
 	"github.com/hasmcp/hasmcp-ce/backend/internal/service/httpc"
 	"github.com/hasmcp/hasmcp-ce/backend/internal/service/idgen"
 	"github.com/hasmcp/hasmcp-ce/backend/internal/service/locksmith"
 	"github.com/hasmcp/hasmcp-ce/backend/internal/service/log"
+	"github.com/hasmcp/hasmcp-ce/backend/internal/service/mcpc"
 	"github.com/hasmcp/hasmcp-ce/backend/internal/service/memq"
 	"github.com/hasmcp/hasmcp-ce/backend/internal/service/pubsub"
 	"github.com/hasmcp/hasmcp-ce/backend/internal/service/server"
@@ -63,6 +66,7 @@ type (
 		Cache     cache.Controller
 		Crud      crud.Controller
 		Oauth2Mcp oauth2mcp.Controller
+		McpAuth   mcpauth.Controller
 	}
 
 	repositories struct {
@@ -98,6 +102,28 @@ func New() (*App, error) {
 		return nil, fmt.Errorf("%s: %w", "httpc", err)
 	}
 
+	// GRPCC
+	grpcc, err := grpcc.New(
+		grpcc.Params{
+			Config: config,
+			HTTPC:  httpc,
+		},
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", "grpcc", err)
+	}
+
+	// MCPC
+	mcpc, err := mcpc.New(
+		mcpc.Params{
+			Config: config,
+			HTTPC:  httpc,
+		},
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", "mcpc", err)
+	}
+
 	// IDGen
 	idgen, err := idgen.New(
 		idgen.Params{
@@ -175,6 +201,7 @@ func New() (*App, error) {
 	cache, err := cache.New(cache.Params{
 		Locksmith: locksmith,
 		Storage:   storage,
+		PubSub:    pubsub,
 	})
 	if err != nil {
 		return nil, fmt.Errorf("%s: %w", "cache", err)
@@ -191,11 +218,14 @@ func New() (*App, error) {
 		Config:    config,
 		IDGen:     idgen,
 		HTTPC:     httpc,
+		GRPCC:     grpcc,
+		MCPC:      mcpc,
 		Locksmith: locksmith,
 		Memq:      memq,
 		McpJWT:    mcpJWT,
 		Cache:     cache,
 		PubSub:    pubsub,
+		Storage:   storage,
 	})
 	if err != nil {
 		return nil, fmt.Errorf("%s: %w", "mcp", err)
@@ -204,6 +234,9 @@ func New() (*App, error) {
 	crud, err := crud.New(crud.Params{
 		Config:     config,
 		IDGen:      idgen,
+		HTTPC:      httpc,
+		GRPCC:      grpcc,
+		MCPC:       mcpc,
 		Locksmith:  locksmith,
 		Cache:      cache,
 		Repository: db,
@@ -214,6 +247,7 @@ func New() (*App, error) {
 	if err != nil {
 		return nil, err
 	}
+	mcp.UseVariableSaver(crud)
 
 	oauth2JWT, err := oauth2mcpjwt.New(oauth2mcpjwt.Params{
 		Config: config,
@@ -226,12 +260,27 @@ func New() (*App, error) {
 		Config:    config,
 		Locksmith: locksmith,
 		Crud:      crud,
+		Mcp:       mcp,
 		JWT:       oauth2JWT,
+		McpJWT:    mcpJWT,
+		Storage:   storage,
 	})
 	if err != nil {
 		return nil, fmt.Errorf("%s: %w", "oauth2mcp", err)
 	}
 
+	mcpAuth, err := mcpauth.New(mcpauth.Params{
+		Config:    config,
+		IDGen:     idgen,
+		Locksmith: locksmith,
+		Crud:      crud,
+		JWT:       mcpJWT,
+		Storage:   storage,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", "mcpauth", err)
+	}
+
 	server, err := server.New(server.Params{
 		Config: config,
 	})
@@ -260,9 +309,10 @@ func New() (*App, error) {
 	}
 
 	oauth2mcphandler, err := oauth2mcphandler.New(oauth2mcphandler.Params{
-		Config: config,
-		Server: server,
-		Oauth2: oauth2mcp,
+		Config:  config,
+		Server:  server,
+		Oauth2:  oauth2mcp,
+		McpAuth: mcpAuth,
 	})
 	if err != nil {
 		return nil, fmt.Errorf("%s: %w", "oauth2 handler", err)
@@ -297,6 +347,7 @@ func New() (*App, error) {
 			Cache:     cache,
 			Crud:      crud,
 			Oauth2Mcp: oauth2mcp,
+			McpAuth:   mcpAuth,
 		},
 
 		// Handlers
@@ -3,12 +3,16 @@ package cache
 import (
 	"context"
 	"encoding/hex"
+	"encoding/json"
+	"hash/fnv"
 	"sync"
 
 	entity "github.com/hasmcp/hasmcp-ce/backend/internal/data/entity/crud"
+	"github.com/hasmcp/hasmcp-ce/backend/internal/data/model"
 	modelmapper "github.com/hasmcp/hasmcp-ce/backend/internal/mapper/model"
 	"github.com/hasmcp/hasmcp-ce/backend/internal/repository/storage"
 	"github.com/hasmcp/hasmcp-ce/backend/internal/service/locksmith"
+	"github.com/hasmcp/hasmcp-ce/backend/internal/service/pubsub"
 	zlog "github.com/rs/zerolog/log"
 )
 
@@ -20,7 +24,9 @@ type (
 		GetProvider(ctx context.Context, id int64) (*entity.Provider, error)
 		GetResource(ctx context.Context, id int64) (*entity.Resource, error)
 		GetVariable(ctx context.Context, name string) (string, error)
+		GetUserVariable(ctx context.Context, subject, name string) (string, error)
 		Evict(ctx context.Context, objectType entity.ObjectType, id int64)
+		EvictUserVariables(ctx context.Context, subject string)
 		ReloadTool(ctx context.Context, id int64) (*entity.ProviderTool, error)
 		ReloadServer(ctx context.Context, id int64) (*entity.Server, error)
 		ReloadPrompt(ctx context.Context, id int64) (*entity.Prompt, error)
@@ -32,19 +38,28 @@ type (
 	controller struct {
 		locksmith locksmith.Service
 		storage   storage.Repository
-
-		variableRefs *sync.Map
-		variables    *sync.Map
-		tools        *sync.Map
-		providers    *sync.Map
-		resources    *sync.Map
-		prompts      *sync.Map
-		servers      *sync.Map
+		pubsub    pubsub.Service
+
+		variableRefs  *sync.Map
+		variables     *sync.Map
+		userVariables *sync.Map // map[subject]map[name]value
+		tools         *sync.Map
+		providers     *sync.Map
+		resources     *sync.Map
+		prompts       *sync.Map
+		servers       *sync.Map
 	}
 
 	Params struct {
 		Locksmith locksmith.Service
 		Storage   storage.Repository
+		PubSub    pubsub.Service
+	}
+
+	// eviction is shared with the other replicas
+	eviction struct {
+		ObjectType entity.ObjectType `json:"objectType"`
+		ID         int64             `json:"id"`
 	}
 
 	err string
@@ -88,21 +103,85 @@ func New(p Params) (Controller, error) {
 		variableRefs.Store(variable.ID, v.Name)
 	}
 
-	return &controller{
+	c := &controller{
 		locksmith: ls,
 		storage:   storage,
+		pubsub:    p.PubSub,
+
+		variableRefs:  &variableRefs,
+		variables:     &variables,
+		userVariables: &sync.Map{},
+		tools:         &tools,
+		servers:       &servers,
+		providers:     &providers,
+		prompts:       &prompts,
+		resources:     &resources,
+	}
+
+	err = c.subscribeReplicaEvictions(context.Background())
+	if err != nil {
+		return nil, err
+	}
 
-		variableRefs: &variableRefs,
-		variables:    &variables,
-		tools:        &tools,
-		servers:      &servers,
-		providers:    &providers,
-		prompts:      &prompts,
-		resources:    &resources,
-	}, nil
+	return c, nil
 }
 
 func (c *controller) Evict(ctx context.Context, objectType entity.ObjectType, id int64) {
+	c.evict(objectType, id)
+
+	data, _ := json.Marshal(eviction{
+		ObjectType: objectType,
+		ID:         id,
+	})
+	_, err := c.pubsub.Publish(ctx, pubsub.PublishRequest{
+		PubSubID: pubsub.IDReplicaCacheEvictions,
+		Event: &pubsub.Message{
+			Type: "cache_eviction",
+			Data: data,
+		},
+		ReplicasOnly: true,
+	})
+	if err != nil {
+		zlog.Error().Err(err).Msg("failed to share the cache eviction with replicas")
+	}
+}
+
+// subscribeReplicaEvictions applies the cache evictions of the other replicas
+func (c *controller) subscribeReplicaEvictions(ctx context.Context) error {
+	_, err := c.pubsub.Create(ctx, pubsub.CreatePubSubRequest{
+		ID: pubsub.IDReplicaCacheEvictions,
+	})
+	if err != nil {
+		return err
+	}
+
+	res, err := c.pubsub.Subscribe(ctx, pubsub.SubscribeRequest{
+		PubSubID: pubsub.IDReplicaCacheEvictions,
+	})
+	if err != nil {
+		return err
+	}
+
+	go func() {
+		for e := range res.Events {
+			msg, ok := e.(pubsub.Event)
+			if !ok {
+				continue
+			}
+			data, _ := msg.GetData().([]byte)
+
+			var ev eviction
+			if err := json.Unmarshal(data, &ev); err != nil {
+				zlog.Warn().Err(err).Msg("received malformed cache eviction")
+				continue
+			}
+			c.evict(ev.ObjectType, ev.ID)
+		}
+	}()
+	return nil
+}
+
+func (c *controller) evict(objectType entity.ObjectType, id int64) {
 	switch objectType {
 	case entity.ObjectTypeVariable:
 		if v, ok := c.variableRefs.Load(id); ok {
@@ -111,6 +190,14 @@ func (c *controller) Evict(ctx context.Context, objectType entity.ObjectType, id
 				c.variables.Delete(name)
 			}
 		}
+	case entity.ObjectTypeUserVariable:
+		// the users are evicted by the hash of their subject
+		c.userVariables.Range(func(k, _ any) bool {
+			if subject := k.(string); subjectID(subject) == id {
+				c.userVariables.Delete(subject)
+			}
+			return true
+		})
 	case entity.ObjectTypeProviderTool:
 		c.tools.Delete(id)
 	case entity.ObjectTypeProvider:
@@ -173,9 +260,19 @@ func (c *controller) ReloadServer(ctx context.Context, id int64) (*entity.Server
 			SecretPrefix:   p.SecretPrefix,
 			Name:           p.Name,
 			Description:    p.Description,
+			GraphQLSchema:  p.GraphQLSchema,
 			Oauth2Config:   p.Oauth2Config,
 
-			Tools: tools,
+			GRPCConfig:        p.GRPCConfig,
+			GRPCDescriptorSet: p.GRPCDescriptorSet,
+
+			AuthConfig:      p.AuthConfig,
+			SignerConfig:    p.SignerConfig,
+			MockConfig:      p.MockConfig,
+			LimitsConfig:    p.LimitsConfig,
+			EgressConfig:    p.EgressConfig,
+			TransportConfig: p.TransportConfig,
+			Tools:           tools,
 		}
 	}
 
@@ -305,8 +402,59 @@ func (c *controller) ReloadVariable(ctx context.Context, name string) (string, e
 	}
 	c.variableRefs.Store(v.ID, v.Name)
 
+	val, err := c.valueOf(ctx, *v)
+	if err != nil {
+		return "", err
+	}
+	c.variables.Store(v.Name, val)
+	return val, nil
+}
+
+// GetUserVariable returns the variable of the user, the variables of a user
+// are loaded together on the first lookup
+func (c *controller) GetUserVariable(ctx context.Context, subject, name string) (string, error) {
+	vars, ok := c.userVariables.Load(subject)
+	if !ok {
+		loaded, err := c.loadUserVariables(ctx, subject)
+		if err != nil {
+			return "", err
+		}
+		vars = loaded
+	}
+
+	v, ok := vars.(map[string]string)[name]
+	if !ok {
+		return "", ErrNotFound
+	}
+	return v, nil
+}
+
+// EvictUserVariables drops the variables of the user on all the replicas
+func (c *controller) EvictUserVariables(ctx context.Context, subject string) {
+	c.Evict(ctx, entity.ObjectTypeUserVariable, subjectID(subject))
+}
+
+func (c *controller) loadUserVariables(ctx context.Context, subject string) (map[string]string, error) {
+	models, err := c.storage.ListUserVariables(ctx, subject)
+	if err != nil {
+		return nil, err
+	}
+
+	vars := make(map[string]string, len(models))
+	for _, v := range models {
+		val, err := c.valueOf(ctx, v)
+		if err != nil {
+			return nil, err
+		}
+		vars[v.Name] = val
+	}
+	c.userVariables.Store(subject, vars)
+	return vars, nil
+}
+
+// valueOf returns the plain value of the variable, decrypting the secrets
+func (c *controller) valueOf(ctx context.Context, v model.Variable) (string, error) {
 	if entity.VariableType(v.Type) == entity.VariableTypeEnv {
-		c.variables.Store(v.Name, v.Value)
 		return v.Value, nil
 	}
 
@@ -329,11 +477,16 @@ func (c *controller) ReloadVariable(ctx context.Context, name string) (string, e
 		zlog.Error().Str("name", v.Name).Err(err).Msg("failed to decrypt the secret")
 		return "", err
 	}
-
-	c.variables.Store(v.Name, string(res.Plaintext))
 	return string(res.Plaintext), nil
 }
 
+// subjectID hashes the subject to share the user evictions with the replicas
+func subjectID(subject string) int64 {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(subject))
+	return int64(h.Sum64())
+}
+
 func (e err) Error() string {
 	return string(e)
 }
@@ -7,14 +7,20 @@ import (
 	"github.com/hasmcp/hasmcp-ce/backend/internal/repository/base"
 	"github.com/hasmcp/hasmcp-ce/backend/internal/repository/storage"
 	"github.com/hasmcp/hasmcp-ce/backend/internal/service/config"
+	"github.com/hasmcp/hasmcp-ce/backend/internal/service/grpcc"
+	"github.com/hasmcp/hasmcp-ce/backend/internal/service/httpc"
 	"github.com/hasmcp/hasmcp-ce/backend/internal/service/idgen"
 	"github.com/hasmcp/hasmcp-ce/backend/internal/service/locksmith"
+	"github.com/hasmcp/hasmcp-ce/backend/internal/service/mcpc"
 )
 
 type (
 	Params struct {
 		Config    config.Service
 		IDGen     idgen.Service
+		HTTPC     httpc.Service
+		GRPCC     grpcc.Service
+		MCPC      mcpc.Service
 		Locksmith locksmith.Service
 
 		Cache  cache.Controller
@@ -29,8 +35,15 @@ type (
 		VariableController
 		ProviderController
 		ProviderToolController
+		ProviderGraphQLController
+		ProviderGRPCController
+		ProviderMCPController
+		ProviderImportController
+		ProviderBreakerController
 		ServerController
 		ServerTokenController
+		ServerRecordingController
+		ServerCacheController
 		ServerToolController
 		PromptController
 		ResourceController
@@ -39,7 +52,12 @@ type (
 	}
 
 	controller struct {
+		cfg crudConfig
+
 		idgen     idgen.Service
+		httpc     httpc.Service
+		grpcc     grpcc.Service
+		mcpc      mcpc.Service
 		locksmith locksmith.Service
 
 		cache  cache.Controller
@@ -51,6 +69,7 @@ type (
 	}
 
 	crudConfig struct {
+		GraphQL graphQLConfig `yaml:"graphql"`
 	}
 )
 
@@ -66,7 +85,12 @@ func New(p Params) (Controller, error) {
 	}
 
 	c := &controller{
+		cfg: cfg,
+
 		idgen:     p.IDGen,
+		httpc:     p.HTTPC,
+		grpcc:     p.GRPCC,
+		mcpc:      p.MCPC,
 		locksmith: p.Locksmith,
 
 		cache:  p.Cache,
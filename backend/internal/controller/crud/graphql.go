@@ -0,0 +1,895 @@
+package crud
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	entity "github.com/hasmcp/hasmcp-ce/backend/internal/data/entity/crud"
+	erre "github.com/hasmcp/hasmcp-ce/backend/internal/data/entity/err"
+	"github.com/hasmcp/hasmcp-ce/backend/internal/data/model"
+	modelmapper "github.com/hasmcp/hasmcp-ce/backend/internal/mapper/model"
+	"gorm.io/gorm"
+)
+
+type ProviderGraphQLController interface {
+	GenerateProviderGraphQLTools(ctx context.Context, req entity.GenerateProviderGraphQLToolsRequest) (*entity.GenerateProviderGraphQLToolsResponse, error)
+}
+
+type (
+	graphQLConfig struct {
+		DefaultSelectionDepth int `yaml:"defaultSelectionDepth"`
+		MaxSelectionDepth     int `yaml:"maxSelectionDepth"`
+	}
+
+	graphQLIntrospection struct {
+		Schema *graphQLSchema `json:"__schema"`
+	}
+
+	graphQLSchema struct {
+		QueryType    *graphQLTypeRef `json:"queryType"`
+		MutationType *graphQLTypeRef `json:"mutationType"`
+		Types        []graphQLType   `json:"types"`
+
+		types map[string]*graphQLType
+	}
+
+	graphQLType struct {
+		Kind        string              `json:"kind"`
+		Name        string              `json:"name"`
+		Description string              `json:"description"`
+		Fields      []graphQLField      `json:"fields"`
+		InputFields []graphQLInputValue `json:"inputFields"`
+		EnumValues  []graphQLEnumValue  `json:"enumValues"`
+	}
+
+	graphQLField struct {
+		Name        string              `json:"name"`
+		Description string              `json:"description"`
+		Args        []graphQLInputValue `json:"args"`
+		Type        graphQLTypeRef      `json:"type"`
+	}
+
+	graphQLInputValue struct {
+		Name         string         `json:"name"`
+		Description  string         `json:"description"`
+		Type         graphQLTypeRef `json:"type"`
+		DefaultValue *string        `json:"defaultValue"`
+	}
+
+	graphQLEnumValue struct {
+		Name string `json:"name"`
+	}
+
+	graphQLTypeRef struct {
+		Kind   string          `json:"kind"`
+		Name   string          `json:"name"`
+		OfType *graphQLTypeRef `json:"ofType"`
+	}
+
+	// graphQLOperation is the header of an operation document
+	graphQLOperation struct {
+		Type      string
+		Name      string
+		Variables []graphQLInputValue
+	}
+
+	graphQLGeneratedOperation struct {
+		graphQLOperation
+		document string
+	}
+
+	graphQLLexer struct {
+		src string
+		pos int
+	}
+)
+
+const (
+	_graphQLTypeKindScalar      = "SCALAR"
+	_graphQLTypeKindUnion       = "UNION"
+	_graphQLTypeKindEnum        = "ENUM"
+	_graphQLTypeKindInputObject = "INPUT_OBJECT"
+	_graphQLTypeKindList        = "LIST"
+	_graphQLTypeKindNonNull     = "NON_NULL"
+
+	_graphQLOperationQuery        = "query"
+	_graphQLOperationMutation     = "mutation"
+	_graphQLOperationSubscription = "subscription"
+
+	_graphQLIntrospectionQuery = `query IntrospectionQuery {
+  __schema {
+    queryType { name }
+    mutationType { name }
+    types {
+      kind name description
+      fields(includeDeprecated: false) {
+        name description
+        args { name description type { ...TypeRef } defaultValue }
+        type { ...TypeRef }
+      }
+      inputFields { name description type { ...TypeRef } defaultValue }
+      enumValues(includeDeprecated: false) { name }
+    }
+  }
+}
+
+fragment TypeRef on __Type {
+  kind name
+  ofType { kind name ofType { kind name ofType { kind name ofType { kind name ofType { kind name ofType { kind name ofType { kind name } } } } } } }
+}`
+
+	_graphQLMaxInputObjectDepth = 8
+)
+
+// GenerateProviderGraphQLTools creates a tool per query and mutation field of
+// the provider schema. The schema is introspected from the provider endpoint
+// when it is not stored yet or a refresh is requested. Fields which already
+// have a tool are skipped.
+func (c *controller) GenerateProviderGraphQLTools(ctx context.Context, req entity.GenerateProviderGraphQLToolsRequest) (*entity.GenerateProviderGraphQLToolsResponse, error) {
+	depth := req.Depth
+	if depth == 0 {
+		depth = c.cfg.GraphQL.DefaultSelectionDepth
+	}
+	if depth < 1 || depth > c.cfg.GraphQL.MaxSelectionDepth {
+		return nil, erre.Error{
+			Code:    erre.ErrorCodeBadRequest,
+			Message: fmt.Sprintf("selection depth must be between 1 and %d", c.cfg.GraphQL.MaxSelectionDepth),
+			Data: map[string]any{
+				"depth": req.Depth,
+			},
+		}
+	}
+
+	provider, err := c.storage.GetProvider(ctx, req.ProviderID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, erre.Error{
+				Code:    erre.ErrorCodeNotFound,
+				Message: "provider not found",
+				Data: map[string]any{
+					"providerID": req.ProviderID,
+				},
+			}
+		}
+		return nil, erre.Error{
+			Code:    erre.ErrorCodeInternalServerError,
+			Message: "failed to get provider",
+			Data: map[string]any{
+				"reason":     err.Error(),
+				"providerID": req.ProviderID,
+			},
+		}
+	}
+	if entity.ApiType(provider.ApiType) != entity.ApiTypeGraphQL {
+		return nil, erre.Error{
+			Code:    erre.ErrorCodeBadRequest,
+			Message: "tools can only be generated for GRAPHQL providers",
+			Data: map[string]any{
+				"providerID": req.ProviderID,
+			},
+		}
+	}
+
+	rawSchema := []byte(provider.GraphQLSchema)
+	if req.Refresh || len(rawSchema) == 0 {
+		rawSchema, err = c.introspectGraphQL(ctx, entity.ReplaceVariables(ctx, provider.BaseURL, c.cache), req.Headers)
+		if err != nil {
+			return nil, erre.Error{
+				Code:    erre.ErrorCodeUnprocessableEntity,
+				Message: "failed to introspect the GraphQL schema",
+				Data: map[string]any{
+					"reason":     err.Error(),
+					"providerID": req.ProviderID,
+				},
+			}
+		}
+	}
+
+	schema, err := decodeGraphQLSchema(rawSchema)
+	if err != nil {
+		return nil, erre.Error{
+			Code:    erre.ErrorCodeUnprocessableEntity,
+			Message: "invalid GraphQL schema",
+			Data: map[string]any{
+				"reason":     err.Error(),
+				"providerID": req.ProviderID,
+			},
+		}
+	}
+
+	headers, err := json.Marshal(req.Headers)
+	if err != nil {
+		return nil, err
+	}
+
+	existing := make(map[string]struct{}, len(provider.Tools))
+	for _, t := range provider.Tools {
+		existing[t.Path] = struct{}{}
+	}
+
+	now := time.Now().UTC()
+	tools := make([]model.ProviderTool, 0)
+	for _, root := range []struct {
+		opType string
+		ref    *graphQLTypeRef
+	}{
+		{opType: _graphQLOperationQuery, ref: schema.QueryType},
+		{opType: _graphQLOperationMutation, ref: schema.MutationType},
+	} {
+		if root.ref == nil {
+			continue
+		}
+		t := schema.types[root.ref.Name]
+		if t == nil {
+			continue
+		}
+		for _, f := range t.Fields {
+			if strings.HasPrefix(f.Name, "__") {
+				continue
+			}
+			op := schema.operationOf(root.opType, f, depth)
+			path := root.opType + "/" + op.Name
+			if _, ok := existing[path]; ok {
+				continue
+			}
+			existing[path] = struct{}{}
+
+			tools = append(tools, model.ProviderTool{
+				ID:                c.idgen.Next(),
+				CreatedAt:         now,
+				UpdatedAt:         now,
+				ProviderID:        provider.ID,
+				Method:            uint8(entity.MethodTypePost),
+				Path:              path,
+				Name:              toolNameOf(f.Name),
+				Title:             truncate(f.Name, _validationAttrProviderToolTitleMaxLength),
+				Description:       truncate(f.Description, _validationAttrProviderToolDescMaxLength),
+				ReqBodyJSONSchema: schema.variablesJSONSchema(op.Variables),
+				Headers:           headers,
+				Operation:         op.document,
+			})
+		}
+	}
+
+	tools, skipped := c.validGeneratedTools(tools)
+
+	// Init transaction
+	ctx = c.storage.ContextWithTx(ctx)
+	for _, tool := range tools {
+		if err := c.storage.CreateProviderTool(ctx, tool); err != nil {
+			_ = c.storage.TxRollback(ctx)
+			return nil, erre.Error{
+				Code:    erre.ErrorCodeInternalServerError,
+				Message: "failed to create provider tool",
+				Data: map[string]any{
+					"reason":     err.Error(),
+					"providerID": provider.ID,
+					"path":       tool.Path,
+				},
+			}
+		}
+	}
+
+	// Updates version!
+	err = c.storage.UpdateProvider(ctx, provider.ID, map[model.ProviderAttribute]any{
+		model.ProviderAttributeGraphQLSchema: rawSchema,
+	})
+	if err != nil {
+		_ = c.storage.TxRollback(ctx)
+		return nil, erre.Error{
+			Code:    erre.ErrorCodeInternalServerError,
+			Message: "failed to update provider schema",
+			Data: map[string]any{
+				"reason":     err.Error(),
+				"providerID": provider.ID,
+			},
+		}
+	}
+
+	err = c.storage.TxCommit(ctx)
+	if err != nil {
+		return nil, erre.Error{
+			Code:    erre.ErrorCodeInternalServerError,
+			Message: "db transaction failed to generate provider tools",
+			Data: map[string]any{
+				"reason":     err.Error(),
+				"providerID": provider.ID,
+			},
+		}
+	}
+
+	c.cache.Evict(context.Background(), entity.ObjectTypeProvider, provider.ID)
+
+	return &entity.GenerateProviderGraphQLToolsResponse{
+		Tools:   modelmapper.FromProviderToolModelsToProviderToolEntities(tools),
+		Skipped: skipped,
+	}, nil
+}
+
+// prepareGraphQLTool derives the path and the request body schema of a
+// GraphQL tool from its operation document. The path is the operation type
+// and name, e.g. `query/GetUser`.
+func prepareGraphQLTool(e entity.ProviderTool, rawSchema []byte) (entity.ProviderTool, error) {
+	if strings.TrimSpace(e.Operation) == "" {
+		return e, erre.Error{
+			Code:    erre.ErrorCodeBadRequest,
+			Message: "operation is required for GRAPHQL provider tools",
+		}
+	}
+	if len(e.PathArgsJSONSchema) > 0 || len(e.QueryArgsJSONSchema) > 0 {
+		return e, erre.Error{
+			Code:    erre.ErrorCodeBadRequest,
+			Message: "GRAPHQL provider tools only accept the operation variables",
+		}
+	}
+
+	op, err := parseGraphQLOperation(e.Operation)
+	if err != nil {
+		return e, erre.Error{
+			Code:    erre.ErrorCodeBadRequest,
+			Message: "invalid GraphQL operation",
+			Data: map[string]any{
+				"reason": err.Error(),
+			},
+		}
+	}
+
+	schema := &graphQLSchema{types: map[string]*graphQLType{}}
+	if len(rawSchema) > 0 {
+		if s, err := decodeGraphQLSchema(rawSchema); err == nil {
+			schema = s
+		}
+	}
+
+	e.Method = entity.MethodTypePost
+	e.Path = op.Type + "/" + op.Name
+	if len(e.ReqBodyJSONSchema) == 0 {
+		e.ReqBodyJSONSchema = schema.variablesJSONSchema(op.Variables)
+	}
+	return e, nil
+}
+
+func (c *controller) introspectGraphQL(ctx context.Context, endpoint string, headers []entity.ToolHeader) ([]byte, error) {
+	body, err := json.Marshal(map[string]string{
+		"query": _graphQLIntrospectionQuery,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+	for _, h := range headers {
+		req.Header.Set(h.Key, entity.ReplaceVariables(ctx, h.Value, c.cache))
+	}
+
+	res, err := c.httpc.Call(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	resBody, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, err
+	}
+	if res.StatusCode >= http.StatusBadRequest {
+		return nil, fmt.Errorf("introspection failed with status %d", res.StatusCode)
+	}
+
+	var payload struct {
+		Data   json.RawMessage `json:"data"`
+		Errors json.RawMessage `json:"errors"`
+	}
+	if err := json.Unmarshal(resBody, &payload); err != nil {
+		return nil, err
+	}
+	if len(payload.Errors) > 0 && string(payload.Errors) != "null" {
+		return nil, fmt.Errorf("introspection failed: %s", payload.Errors)
+	}
+	return payload.Data, nil
+}
+
+// decodeGraphQLSchema decodes an introspection result with or without the
+// `data` envelope of the GraphQL response
+func decodeGraphQLSchema(raw []byte) (*graphQLSchema, error) {
+	var envelope struct {
+		Data *graphQLIntrospection `json:"data"`
+		graphQLIntrospection
+	}
+	if err := json.Unmarshal(raw, &envelope); err != nil {
+		return nil, err
+	}
+
+	schema := envelope.Schema
+	if envelope.Data != nil && envelope.Data.Schema != nil {
+		schema = envelope.Data.Schema
+	}
+	if schema == nil || len(schema.Types) == 0 {
+		return nil, errors.New("introspection result has no __schema types")
+	}
+
+	schema.types = make(map[string]*graphQLType, len(schema.Types))
+	for i := range schema.Types {
+		schema.types[schema.Types[i].Name] = &schema.Types[i]
+	}
+	return schema, nil
+}
+
+// operationOf builds an operation document for the root field with a
+// selection set limited to the given depth
+func (s *graphQLSchema) operationOf(opType string, f graphQLField, depth int) graphQLGeneratedOperation {
+	name := upperFirst(f.Name)
+
+	var b strings.Builder
+	b.WriteString(opType)
+	b.WriteString(" ")
+	b.WriteString(name)
+
+	if len(f.Args) > 0 {
+		vars := make([]string, len(f.Args))
+		args := make([]string, len(f.Args))
+		for i, a := range f.Args {
+			vars[i] = "$" + a.Name + ": " + a.Type.String()
+			args[i] = a.Name + ": $" + a.Name
+		}
+		b.WriteString("(" + strings.Join(vars, ", ") + ")")
+		b.WriteString(" { " + f.Name + "(" + strings.Join(args, ", ") + ")")
+	} else {
+		b.WriteString(" { " + f.Name)
+	}
+
+	if named := s.types[f.Type.named()]; named != nil && named.Kind != _graphQLTypeKindScalar && named.Kind != _graphQLTypeKindEnum {
+		selection := s.selectionOf(named, depth)
+		if selection == "" {
+			selection = "__typename"
+		}
+		b.WriteString(" { " + selection + " }")
+	}
+	b.WriteString(" }")
+
+	return graphQLGeneratedOperation{
+		graphQLOperation: graphQLOperation{
+			Type:      opType,
+			Name:      name,
+			Variables: f.Args,
+		},
+		document: b.String(),
+	}
+}
+
+// selectionOf selects the leaf fields of the type and the object fields until
+// the depth is reached. Fields which require arguments are skipped.
+func (s *graphQLSchema) selectionOf(t *graphQLType, depth int) string {
+	if t.Kind == _graphQLTypeKindUnion {
+		return "__typename"
+	}
+
+	selected := make([]string, 0, len(t.Fields))
+	for _, f := range t.Fields {
+		if hasRequiredArgs(f.Args) {
+			continue
+		}
+		named := s.types[f.Type.named()]
+		if named == nil || named.Kind == _graphQLTypeKindScalar || named.Kind == _graphQLTypeKindEnum {
+			selected = append(selected, f.Name)
+			continue
+		}
+		if depth <= 1 {
+			continue
+		}
+		if sub := s.selectionOf(named, depth-1); sub != "" {
+			selected = append(selected, f.Name+" { "+sub+" }")
+		}
+	}
+	return strings.Join(selected, " ")
+}
+
+// variablesJSONSchema converts the variable definitions into the request body
+// JSON schema, an empty schema is returned when there are no variables
+func (s *graphQLSchema) variablesJSONSchema(vars []graphQLInputValue) json.RawMessage {
+	if len(vars) == 0 {
+		return nil
+	}
+	data, _ := json.Marshal(s.objectJSONSchema(vars, 0))
+	return data
+}
+
+func (s *graphQLSchema) objectJSONSchema(fields []graphQLInputValue, depth int) map[string]any {
+	props := make(map[string]any, len(fields))
+	required := make([]string, 0, len(fields))
+	for _, f := range fields {
+		prop := s.jsonSchemaOf(f.Type, depth)
+		if f.Description != "" {
+			prop["description"] = f.Description
+		}
+		props[f.Name] = prop
+		if f.Type.Kind == _graphQLTypeKindNonNull && f.DefaultValue == nil {
+			required = append(required, f.Name)
+		}
+	}
+	sort.Strings(required)
+
+	schema := map[string]any{
+		"type":       "object",
+		"properties": props,
+	}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	return schema
+}
+
+func (s *graphQLSchema) jsonSchemaOf(t graphQLTypeRef, depth int) map[string]any {
+	switch t.Kind {
+	case _graphQLTypeKindNonNull:
+		if t.OfType != nil {
+			return s.jsonSchemaOf(*t.OfType, depth)
+		}
+	case _graphQLTypeKindList:
+		items := map[string]any{}
+		if t.OfType != nil {
+			items = s.jsonSchemaOf(*t.OfType, depth)
+		}
+		return map[string]any{
+			"type":  "array",
+			"items": items,
+		}
+	}
+
+	switch t.Name {
+	case "ID", "String":
+		return map[string]any{"type": "string"}
+	case "Int":
+		return map[string]any{"type": "integer"}
+	case "Float":
+		return map[string]any{"type": "number"}
+	case "Boolean":
+		return map[string]any{"type": "boolean"}
+	}
+
+	named := s.types[t.Name]
+	if named == nil {
+		return map[string]any{}
+	}
+	switch named.Kind {
+	case _graphQLTypeKindEnum:
+		values := make([]any, len(named.EnumValues))
+		for i, v := range named.EnumValues {
+			values[i] = v.Name
+		}
+		return map[string]any{
+			"type": "string",
+			"enum": values,
+		}
+	case _graphQLTypeKindInputObject:
+		if depth >= _graphQLMaxInputObjectDepth {
+			return map[string]any{"type": "object"}
+		}
+		return s.objectJSONSchema(named.InputFields, depth+1)
+	default:
+		schema := map[string]any{}
+		if named.Description != "" {
+			schema["description"] = named.Description
+		}
+		return schema
+	}
+}
+
+// String renders the type reference in the GraphQL type syntax
+func (t graphQLTypeRef) String() string {
+	switch t.Kind {
+	case _graphQLTypeKindNonNull:
+		if t.OfType != nil {
+			return t.OfType.String() + "!"
+		}
+	case _graphQLTypeKindList:
+		if t.OfType != nil {
+			return "[" + t.OfType.String() + "]"
+		}
+	}
+	return t.Name
+}
+
+func (t graphQLTypeRef) named() string {
+	if t.OfType != nil {
+		return t.OfType.named()
+	}
+	return t.Name
+}
+
+func hasRequiredArgs(args []graphQLInputValue) bool {
+	for _, a := range args {
+		if a.Type.Kind == _graphQLTypeKindNonNull && a.DefaultValue == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// toolNameOf converts the field or method name into a valid provider tool name
+func toolNameOf(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)) {
+			b.WriteRune(r)
+		}
+	}
+	name := b.String()
+	if name == "" || !unicode.IsLetter(rune(name[0])) {
+		name = "op" + name
+	}
+	name = strings.ToLower(name[:1]) + name[1:]
+	return truncate(name, 20)
+}
+
+func upperFirst(s string) string {
+	if s == "" {
+		return s
+	}
+	return strings.ToUpper(s[:1]) + s[1:]
+}
+
+func truncate(s string, max int) string {
+	if len(s) > max {
+		return s[:max]
+	}
+	return s
+}
+
+// parseGraphQLOperation parses the header of the single named query or
+// mutation operation in the document
+func parseGraphQLOperation(doc string) (*graphQLOperation, error) {
+	l := &graphQLLexer{src: doc}
+
+	var op *graphQLOperation
+	for tok := l.next(); tok != ""; tok = l.next() {
+		switch tok {
+		case _graphQLOperationQuery, _graphQLOperationMutation, _graphQLOperationSubscription:
+			if op != nil {
+				return nil, errors.New("document must contain a single operation")
+			}
+			if tok == _graphQLOperationSubscription {
+				return nil, errors.New("subscriptions are not supported")
+			}
+			parsed, err := l.operation(tok)
+			if err != nil {
+				return nil, err
+			}
+			op = parsed
+		case "fragment":
+			if err := l.skipUntilBlock(); err != nil {
+				return nil, err
+			}
+		case "{":
+			return nil, errors.New("operation name is required")
+		default:
+			return nil, fmt.Errorf("unexpected token %q", tok)
+		}
+	}
+
+	if op == nil {
+		return nil, errors.New("document has no operation")
+	}
+	return op, nil
+}
+
+func (l *graphQLLexer) operation(opType string) (*graphQLOperation, error) {
+	op := &graphQLOperation{Type: opType}
+
+	name := l.next()
+	if !isGraphQLName(name) {
+		return nil, errors.New("operation name is required")
+	}
+	op.Name = name
+
+	if l.peek() == "(" {
+		l.next()
+		for {
+			tok := l.next()
+			if tok == ")" {
+				break
+			}
+			if tok != "$" {
+				return nil, fmt.Errorf("unexpected token %q in variable definitions", tok)
+			}
+			v := graphQLInputValue{Name: l.next()}
+			if !isGraphQLName(v.Name) {
+				return nil, errors.New("invalid variable name")
+			}
+			if l.next() != ":" {
+				return nil, fmt.Errorf("variable %q has no type", v.Name)
+			}
+			t, err := l.typeRef()
+			if err != nil {
+				return nil, err
+			}
+			v.Type = *t
+			if l.peek() == "=" {
+				l.next()
+				def := l.value()
+				v.DefaultValue = &def
+			}
+			l.skipDirectives()
+			op.Variables = append(op.Variables, v)
+		}
+	}
+
+	if err := l.skipUntilBlock(); err != nil {
+		return nil, err
+	}
+	return op, nil
+}
+
+func (l *graphQLLexer) typeRef() (*graphQLTypeRef, error) {
+	var t *graphQLTypeRef
+	tok := l.next()
+	switch {
+	case tok == "[":
+		inner, err := l.typeRef()
+		if err != nil {
+			return nil, err
+		}
+		if l.next() != "]" {
+			return nil, errors.New("list type is not closed")
+		}
+		t = &graphQLTypeRef{Kind: _graphQLTypeKindList, OfType: inner}
+	case isGraphQLName(tok):
+		t = &graphQLTypeRef{Name: tok}
+	default:
+		return nil, fmt.Errorf("unexpected token %q in type", tok)
+	}
+
+	if l.peek() == "!" {
+		l.next()
+		t = &graphQLTypeRef{Kind: _graphQLTypeKindNonNull, OfType: t}
+	}
+	return t, nil
+}
+
+// value skips a constant value and returns its source
+func (l *graphQLLexer) value() string {
+	start := l.pos
+	tok := l.next()
+	if tok == "[" || tok == "{" {
+		open, close := tok, "]"
+		if open == "{" {
+			close = "}"
+		}
+		for depth := 1; depth > 0; {
+			switch l.next() {
+			case open:
+				depth++
+			case close:
+				depth--
+			case "":
+				depth = 0
+			}
+		}
+	}
+	return strings.TrimSpace(l.src[start:l.pos])
+}
+
+func (l *graphQLLexer) skipDirectives() {
+	for l.peek() == "@" {
+		l.next()
+		l.next()
+		if l.peek() == "(" {
+			for tok := l.next(); tok != ")" && tok != ""; tok = l.next() {
+			}
+		}
+	}
+}
+
+// skipUntilBlock skips the tokens until the selection set and the set itself
+func (l *graphQLLexer) skipUntilBlock() error {
+	for tok := l.next(); tok != "{"; tok = l.next() {
+		if tok == "" {
+			return errors.New("selection set is missing")
+		}
+	}
+	for depth := 1; depth > 0; {
+		switch l.next() {
+		case "{":
+			depth++
+		case "}":
+			depth--
+		case "":
+			return errors.New("selection set is not closed")
+		}
+	}
+	return nil
+}
+
+func (l *graphQLLexer) peek() string {
+	pos := l.pos
+	tok := l.next()
+	l.pos = pos
+	return tok
+}
+
+// next returns the next token ignoring the whitespaces, commas and comments,
+// an empty token marks the end of the document
+func (l *graphQLLexer) next() string {
+	for l.pos < len(l.src) {
+		ch := l.src[l.pos]
+		switch {
+		case ch == ' ' || ch == '\t' || ch == '\n' || ch == '\r' || ch == ',':
+			l.pos++
+		case ch == '#':
+			for l.pos < len(l.src) && l.src[l.pos] != '\n' {
+				l.pos++
+			}
+		case strings.HasPrefix(l.src[l.pos:], "..."):
+			l.pos += 3
+			return "..."
+		case strings.HasPrefix(l.src[l.pos:], `"""`):
+			start := l.pos
+			end := strings.Index(l.src[l.pos+3:], `"""`)
+			if end < 0 {
+				l.pos = len(l.src)
+			} else {
+				l.pos += end + 6
+			}
+			return l.src[start:l.pos]
+		case ch == '"':
+			start := l.pos
+			l.pos++
+			for l.pos < len(l.src) && l.src[l.pos] != '"' {
+				if l.src[l.pos] == '\\' {
+					l.pos++
+				}
+				l.pos++
+			}
+			l.pos++
+			if l.pos > len(l.src) {
+				l.pos = len(l.src)
+			}
+			return l.src[start:l.pos]
+		case strings.IndexByte("!$&():=@[]{}|", ch) >= 0:
+			l.pos++
+			return string(ch)
+		default:
+			start := l.pos
+			for l.pos < len(l.src) && isGraphQLNameChar(l.src[l.pos]) {
+				l.pos++
+			}
+			if start == l.pos {
+				// unknown character
+				l.pos++
+			}
+			return l.src[start:l.pos]
+		}
+	}
+	return ""
+}
+
+func isGraphQLName(tok string) bool {
+	if tok == "" || !(tok[0] == '_' || unicode.IsLetter(rune(tok[0]))) {
+		return false
+	}
+	for i := 0; i < len(tok); i++ {
+		if !isGraphQLNameChar(tok[i]) || tok[i] == '-' || tok[i] == '.' || tok[i] == '+' {
+			return false
+		}
+	}
+	return true
+}
+
+func isGraphQLNameChar(ch byte) bool {
+	return ch == '_' || ch == '-' || ch == '.' || ch == '+' ||
+		(ch >= '0' && ch <= '9') || (ch >= 'a' && ch <= 'z') || (ch >= 'A' && ch <= 'Z')
+}
@@ -0,0 +1,539 @@
+package crud
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	entity "github.com/hasmcp/hasmcp-ce/backend/internal/data/entity/crud"
+	erre "github.com/hasmcp/hasmcp-ce/backend/internal/data/entity/err"
+	"github.com/hasmcp/hasmcp-ce/backend/internal/data/model"
+	modelmapper "github.com/hasmcp/hasmcp-ce/backend/internal/mapper/model"
+	"github.com/hasmcp/hasmcp-ce/backend/internal/service/grpcc"
+	"google.golang.org/grpc/metadata"
+	reflectionpb "google.golang.org/grpc/reflection/grpc_reflection_v1"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"gorm.io/gorm"
+)
+
+type ProviderGRPCController interface {
+	ImportProviderGRPCTools(ctx context.Context, req entity.ImportProviderGRPCToolsRequest) (*entity.ImportProviderGRPCToolsResponse, error)
+}
+
+const (
+	_grpcReflectionServicePrefix = "grpc.reflection."
+	_grpcReflectionTimeout       = 30 * time.Second
+	_grpcMaxMessageDepth         = 8
+)
+
+// ImportProviderGRPCTools creates a tool per unary method of the provider
+// services. The methods are discovered from the uploaded descriptor set, the
+// stored one or the server reflection when a refresh is requested. Methods
+// which already have a tool are skipped.
+func (c *controller) ImportProviderGRPCTools(ctx context.Context, req entity.ImportProviderGRPCToolsRequest) (*entity.ImportProviderGRPCToolsResponse, error) {
+	provider, err := c.storage.GetProvider(ctx, req.ProviderID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, erre.Error{
+				Code:    erre.ErrorCodeNotFound,
+				Message: "provider not found",
+				Data: map[string]any{
+					"providerID": req.ProviderID,
+				},
+			}
+		}
+		return nil, erre.Error{
+			Code:    erre.ErrorCodeInternalServerError,
+			Message: "failed to get provider",
+			Data: map[string]any{
+				"reason":     err.Error(),
+				"providerID": req.ProviderID,
+			},
+		}
+	}
+	if entity.ApiType(provider.ApiType) != entity.ApiTypeGRPC {
+		return nil, erre.Error{
+			Code:    erre.ErrorCodeBadRequest,
+			Message: "tools can only be imported for GRPC providers",
+			Data: map[string]any{
+				"providerID": req.ProviderID,
+			},
+		}
+	}
+
+	descriptorSet := req.DescriptorSet
+	if len(descriptorSet) == 0 && !req.Refresh {
+		descriptorSet = provider.GRPCDescriptorSet
+	}
+	if len(descriptorSet) == 0 {
+		descriptorSet, err = c.reflectGRPCDescriptorSet(ctx, modelmapper.FromProviderModelToProviderEntity(*provider), req.Headers)
+		if err != nil {
+			return nil, erre.Error{
+				Code:    erre.ErrorCodeUnprocessableEntity,
+				Message: "failed to discover the gRPC services through the server reflection",
+				Data: map[string]any{
+					"reason":     err.Error(),
+					"providerID": req.ProviderID,
+				},
+			}
+		}
+	}
+
+	files, err := decodeGRPCDescriptorSet(descriptorSet)
+	if err != nil {
+		return nil, erre.Error{
+			Code:    erre.ErrorCodeUnprocessableEntity,
+			Message: "invalid gRPC descriptor set",
+			Data: map[string]any{
+				"reason":     err.Error(),
+				"providerID": req.ProviderID,
+			},
+		}
+	}
+
+	headers, err := json.Marshal(req.Headers)
+	if err != nil {
+		return nil, err
+	}
+
+	existing := make(map[string]struct{}, len(provider.Tools))
+	for _, t := range provider.Tools {
+		existing[t.Path] = struct{}{}
+	}
+
+	now := time.Now().UTC()
+	tools := make([]model.ProviderTool, 0)
+	files.RangeFiles(func(fd protoreflect.FileDescriptor) bool {
+		services := fd.Services()
+		for i := 0; i < services.Len(); i++ {
+			svc := services.Get(i)
+			if strings.HasPrefix(string(svc.FullName()), _grpcReflectionServicePrefix) {
+				continue
+			}
+			methods := svc.Methods()
+			for j := 0; j < methods.Len(); j++ {
+				m := methods.Get(j)
+				if m.IsStreamingClient() || m.IsStreamingServer() {
+					continue
+				}
+				path := grpcMethodPath(m)
+				if _, ok := existing[path]; ok {
+					continue
+				}
+				existing[path] = struct{}{}
+
+				description := grpcComments(m)
+				if description == "" {
+					description = "Calls " + path
+				}
+				reqSchema, _ := json.Marshal(grpcMessageJSONSchema(m.Input(), 0))
+				resSchema, _ := json.Marshal(grpcMessageJSONSchema(m.Output(), 0))
+				tools = append(tools, model.ProviderTool{
+					ID:                c.idgen.Next(),
+					CreatedAt:         now,
+					UpdatedAt:         now,
+					ProviderID:        provider.ID,
+					Method:            uint8(entity.MethodTypePost),
+					Path:              path,
+					Name:              toolNameOf(string(m.Name())),
+					Title:             truncate(string(svc.Name())+" "+string(m.Name()), _validationAttrProviderToolTitleMaxLength),
+					Description:       truncate(description, _validationAttrProviderToolDescMaxLength),
+					ReqBodyJSONSchema: reqSchema,
+					ResBodyJSONSchema: resSchema,
+					Headers:           headers,
+				})
+			}
+		}
+		return true
+	})
+
+	tools, skipped := c.validGeneratedTools(tools)
+
+	// Init transaction
+	ctx = c.storage.ContextWithTx(ctx)
+	for _, tool := range tools {
+		if err := c.storage.CreateProviderTool(ctx, tool); err != nil {
+			_ = c.storage.TxRollback(ctx)
+			return nil, erre.Error{
+				Code:    erre.ErrorCodeInternalServerError,
+				Message: "failed to create provider tool",
+				Data: map[string]any{
+					"reason":     err.Error(),
+					"providerID": provider.ID,
+					"path":       tool.Path,
+				},
+			}
+		}
+	}
+
+	// Updates version!
+	err = c.storage.UpdateProvider(ctx, provider.ID, map[model.ProviderAttribute]any{
+		model.ProviderAttributeGRPCDescriptorSet: descriptorSet,
+	})
+	if err != nil {
+		_ = c.storage.TxRollback(ctx)
+		return nil, erre.Error{
+			Code:    erre.ErrorCodeInternalServerError,
+			Message: "failed to update provider descriptor set",
+			Data: map[string]any{
+				"reason":     err.Error(),
+				"providerID": provider.ID,
+			},
+		}
+	}
+
+	err = c.storage.TxCommit(ctx)
+	if err != nil {
+		return nil, erre.Error{
+			Code:    erre.ErrorCodeInternalServerError,
+			Message: "db transaction failed to import provider tools",
+			Data: map[string]any{
+				"reason":     err.Error(),
+				"providerID": provider.ID,
+			},
+		}
+	}
+
+	c.cache.Evict(context.Background(), entity.ObjectTypeProvider, provider.ID)
+
+	return &entity.ImportProviderGRPCToolsResponse{
+		Tools:   modelmapper.FromProviderToolModelsToProviderToolEntities(tools),
+		Skipped: skipped,
+	}, nil
+}
+
+// prepareGRPCTool verifies that the tool path is a unary method of the stored
+// descriptor set and derives the request and response body schemas
+func prepareGRPCTool(e entity.ProviderTool, descriptorSet []byte) (entity.ProviderTool, error) {
+	if len(e.PathArgsJSONSchema) > 0 || len(e.QueryArgsJSONSchema) > 0 {
+		return e, erre.Error{
+			Code:    erre.ErrorCodeBadRequest,
+			Message: "GRPC provider tools only accept the request message",
+		}
+	}
+	if len(descriptorSet) == 0 {
+		return e, erre.Error{
+			Code:    erre.ErrorCodeBadRequest,
+			Message: "GRPC provider has no descriptor set, import the tools first",
+		}
+	}
+
+	files, err := decodeGRPCDescriptorSet(descriptorSet)
+	if err != nil {
+		return e, erre.Error{
+			Code:    erre.ErrorCodeUnprocessableEntity,
+			Message: "invalid gRPC descriptor set",
+			Data: map[string]any{
+				"reason": err.Error(),
+			},
+		}
+	}
+
+	m, err := findGRPCMethod(files, e.Path)
+	if err != nil {
+		return e, erre.Error{
+			Code:    erre.ErrorCodeBadRequest,
+			Message: "path must be a unary method of the provider, e.g. /package.Service/Method",
+			Data: map[string]any{
+				"reason": err.Error(),
+				"path":   e.Path,
+			},
+		}
+	}
+
+	e.Method = entity.MethodTypePost
+	if len(e.ReqBodyJSONSchema) == 0 {
+		e.ReqBodyJSONSchema, _ = json.Marshal(grpcMessageJSONSchema(m.Input(), 0))
+	}
+	if len(e.ResBodyJSONSchema) == 0 {
+		e.ResBodyJSONSchema, _ = json.Marshal(grpcMessageJSONSchema(m.Output(), 0))
+	}
+	return e, nil
+}
+
+func (c *controller) reflectGRPCDescriptorSet(ctx context.Context, provider entity.Provider, headers []entity.ToolHeader) ([]byte, error) {
+	target, err := grpcc.Target(entity.ReplaceVariables(ctx, provider.BaseURL, c.cache))
+	if err != nil {
+		return nil, err
+	}
+
+	// the reflection connection is not reused, the descriptor set is only
+	// fetched on the imports and the refreshes
+	opts, err := c.mcp.ProviderGRPCConnOptions(ctx, &provider)
+	if err != nil {
+		return nil, err
+	}
+	opts.Key = fmt.Sprintf("reflection/%d", c.idgen.Next())
+	conn, err := c.grpcc.Conn(target, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer c.grpcc.Evict(opts.Key)
+
+	md := metadata.MD{}
+	for _, h := range headers {
+		md.Append(strings.ToLower(h.Key), entity.ReplaceVariables(ctx, h.Value, c.cache))
+	}
+
+	ctx, cancel := context.WithTimeout(metadata.NewOutgoingContext(ctx, md), _grpcReflectionTimeout)
+	defer cancel()
+
+	stream, err := reflectionpb.NewServerReflectionClient(conn).ServerReflectionInfo(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = stream.CloseSend()
+	}()
+
+	call := func(req *reflectionpb.ServerReflectionRequest) (*reflectionpb.ServerReflectionResponse, error) {
+		if err := stream.Send(req); err != nil {
+			return nil, err
+		}
+		res, err := stream.Recv()
+		if err != nil {
+			return nil, err
+		}
+		if e := res.GetErrorResponse(); e != nil {
+			return nil, fmt.Errorf("reflection error %d: %s", e.GetErrorCode(), e.GetErrorMessage())
+		}
+		return res, nil
+	}
+
+	res, err := call(&reflectionpb.ServerReflectionRequest{
+		MessageRequest: &reflectionpb.ServerReflectionRequest_ListServices{},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	files := make(map[string]*descriptorpb.FileDescriptorProto)
+	collect := func(res *reflectionpb.ServerReflectionResponse) error {
+		for _, raw := range res.GetFileDescriptorResponse().GetFileDescriptorProto() {
+			var fd descriptorpb.FileDescriptorProto
+			if err := proto.Unmarshal(raw, &fd); err != nil {
+				return err
+			}
+			files[fd.GetName()] = &fd
+		}
+		return nil
+	}
+
+	for _, svc := range res.GetListServicesResponse().GetService() {
+		if strings.HasPrefix(svc.GetName(), _grpcReflectionServicePrefix) {
+			continue
+		}
+		res, err := call(&reflectionpb.ServerReflectionRequest{
+			MessageRequest: &reflectionpb.ServerReflectionRequest_FileContainingSymbol{
+				FileContainingSymbol: svc.GetName(),
+			},
+		})
+		if err != nil {
+			return nil, err
+		}
+		if err := collect(res); err != nil {
+			return nil, err
+		}
+	}
+
+	// the servers may skip the dependencies which were already sent
+	for missing := missingGRPCDependencies(files); len(missing) > 0; missing = missingGRPCDependencies(files) {
+		for _, name := range missing {
+			res, err := call(&reflectionpb.ServerReflectionRequest{
+				MessageRequest: &reflectionpb.ServerReflectionRequest_FileByFilename{
+					FileByFilename: name,
+				},
+			})
+			if err != nil {
+				return nil, err
+			}
+			if err := collect(res); err != nil {
+				return nil, err
+			}
+			if _, ok := files[name]; !ok {
+				return nil, fmt.Errorf("dependency %q is not served", name)
+			}
+		}
+	}
+
+	set := &descriptorpb.FileDescriptorSet{
+		File: make([]*descriptorpb.FileDescriptorProto, 0, len(files)),
+	}
+	for _, fd := range files {
+		set.File = append(set.File, fd)
+	}
+	return proto.Marshal(set)
+}
+
+func missingGRPCDependencies(files map[string]*descriptorpb.FileDescriptorProto) []string {
+	missing := make([]string, 0)
+	seen := make(map[string]struct{})
+	for _, fd := range files {
+		for _, dep := range fd.GetDependency() {
+			if _, ok := files[dep]; ok {
+				continue
+			}
+			if _, ok := seen[dep]; ok {
+				continue
+			}
+			seen[dep] = struct{}{}
+			missing = append(missing, dep)
+		}
+	}
+	return missing
+}
+
+// decodeGRPCDescriptorSet decodes a serialized FileDescriptorSet into a file
+// registry
+func decodeGRPCDescriptorSet(raw []byte) (*protoregistry.Files, error) {
+	var set descriptorpb.FileDescriptorSet
+	if err := proto.Unmarshal(raw, &set); err != nil {
+		return nil, err
+	}
+	if len(set.GetFile()) == 0 {
+		return nil, errors.New("descriptor set has no files")
+	}
+	return protodesc.NewFiles(&set)
+}
+
+// findGRPCMethod finds the unary method of the full method path
+// `/package.Service/Method`
+func findGRPCMethod(files *protoregistry.Files, path string) (protoreflect.MethodDescriptor, error) {
+	svcName, methodName, ok := strings.Cut(strings.TrimPrefix(path, "/"), "/")
+	if !ok {
+		return nil, errors.New("path must be /package.Service/Method")
+	}
+	d, err := files.FindDescriptorByName(protoreflect.FullName(svcName))
+	if err != nil {
+		return nil, err
+	}
+	svc, ok := d.(protoreflect.ServiceDescriptor)
+	if !ok {
+		return nil, fmt.Errorf("%s is not a service", svcName)
+	}
+	m := svc.Methods().ByName(protoreflect.Name(methodName))
+	if m == nil {
+		return nil, fmt.Errorf("%s has no method %s", svcName, methodName)
+	}
+	if m.IsStreamingClient() || m.IsStreamingServer() {
+		return nil, errors.New("streaming methods are not supported")
+	}
+	return m, nil
+}
+
+func grpcMethodPath(m protoreflect.MethodDescriptor) string {
+	return "/" + string(m.Parent().FullName()) + "/" + string(m.Name())
+}
+
+func grpcComments(d protoreflect.Descriptor) string {
+	return strings.TrimSpace(d.ParentFile().SourceLocations().ByDescriptor(d).LeadingComments)
+}
+
+// grpcMessageJSONSchema converts the message into a JSON schema following the
+// protobuf JSON mapping
+func grpcMessageJSONSchema(md protoreflect.MessageDescriptor, depth int) map[string]any {
+	switch md.FullName() {
+	case "google.protobuf.Timestamp":
+		return map[string]any{"type": "string", "format": "date-time"}
+	case "google.protobuf.Duration", "google.protobuf.FieldMask":
+		return map[string]any{"type": "string"}
+	case "google.protobuf.Struct":
+		return map[string]any{"type": "object"}
+	case "google.protobuf.ListValue":
+		return map[string]any{"type": "array"}
+	case "google.protobuf.Value":
+		return map[string]any{}
+	case "google.protobuf.StringValue", "google.protobuf.BytesValue",
+		"google.protobuf.Int64Value", "google.protobuf.UInt64Value":
+		return map[string]any{"type": "string"}
+	case "google.protobuf.Int32Value", "google.protobuf.UInt32Value":
+		return map[string]any{"type": "integer"}
+	case "google.protobuf.FloatValue", "google.protobuf.DoubleValue":
+		return map[string]any{"type": "number"}
+	case "google.protobuf.BoolValue":
+		return map[string]any{"type": "boolean"}
+	}
+
+	if depth >= _grpcMaxMessageDepth {
+		return map[string]any{"type": "object"}
+	}
+
+	fields := md.Fields()
+	props := make(map[string]any, fields.Len())
+	required := make([]string, 0)
+	for i := 0; i < fields.Len(); i++ {
+		fd := fields.Get(i)
+		prop := grpcFieldJSONSchema(fd, depth)
+		if comments := grpcComments(fd); comments != "" {
+			prop["description"] = comments
+		}
+		props[fd.JSONName()] = prop
+		if fd.Cardinality() == protoreflect.Required {
+			required = append(required, fd.JSONName())
+		}
+	}
+
+	schema := map[string]any{
+		"type":       "object",
+		"properties": props,
+	}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	return schema
+}
+
+func grpcFieldJSONSchema(fd protoreflect.FieldDescriptor, depth int) map[string]any {
+	if fd.IsMap() {
+		return map[string]any{
+			"type":                 "object",
+			"additionalProperties": grpcKindJSONSchema(fd.MapValue(), depth),
+		}
+	}
+	schema := grpcKindJSONSchema(fd, depth)
+	if fd.IsList() {
+		return map[string]any{
+			"type":  "array",
+			"items": schema,
+		}
+	}
+	return schema
+}
+
+func grpcKindJSONSchema(fd protoreflect.FieldDescriptor, depth int) map[string]any {
+	switch fd.Kind() {
+	case protoreflect.BoolKind:
+		return map[string]any{"type": "boolean"}
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind,
+		protoreflect.Uint32Kind, protoreflect.Fixed32Kind:
+		return map[string]any{"type": "integer"}
+	case protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind,
+		protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		// 64 bit integers are strings on the protobuf JSON mapping
+		return map[string]any{"type": "string", "format": "int64"}
+	case protoreflect.FloatKind, protoreflect.DoubleKind:
+		return map[string]any{"type": "number"}
+	case protoreflect.StringKind:
+		return map[string]any{"type": "string"}
+	case protoreflect.BytesKind:
+		return map[string]any{"type": "string", "contentEncoding": "base64"}
+	case protoreflect.EnumKind:
+		values := fd.Enum().Values()
+		enum := make([]any, values.Len())
+		for i := 0; i < values.Len(); i++ {
+			enum[i] = string(values.Get(i).Name())
+		}
+		return map[string]any{"type": "string", "enum": enum}
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		return grpcMessageJSONSchema(fd.Message(), depth+1)
+	default:
+		return map[string]any{}
+	}
+}
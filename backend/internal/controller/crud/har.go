@@ -0,0 +1,160 @@
+package crud
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+type (
+	harFile struct {
+		Log struct {
+			Entries []harEntry `json:"entries"`
+		} `json:"log"`
+	}
+
+	harEntry struct {
+		Request      harRequest  `json:"request"`
+		Response     harResponse `json:"response"`
+		ResourceType string      `json:"_resourceType"`
+	}
+
+	harRequest struct {
+		Method      string         `json:"method"`
+		URL         string         `json:"url"`
+		Headers     []harNameValue `json:"headers"`
+		QueryString []harNameValue `json:"queryString"`
+		PostData    *struct {
+			MimeType string `json:"mimeType"`
+			Text     string `json:"text"`
+		} `json:"postData"`
+	}
+
+	harResponse struct {
+		Status  int `json:"status"`
+		Content struct {
+			MimeType string `json:"mimeType"`
+			Text     string `json:"text"`
+			Encoding string `json:"encoding"`
+		} `json:"content"`
+	}
+
+	harNameValue struct {
+		Name  string `json:"name"`
+		Value string `json:"value"`
+	}
+)
+
+var (
+	// _regexHARIdentifier matches the path segments which are identifiers,
+	// numbers, UUIDs or long hex strings
+	_regexHARIdentifier = regexp.MustCompile(`^(?:\d+|[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}|[0-9a-fA-F]{16,})$`)
+)
+
+// decodeHAR decodes the JSON API calls of a HAR file. The identifier path
+// segments become path arguments so that the calls of the same endpoint share
+// a tool.
+func decodeHAR(raw []byte) (*importedCollection, error) {
+	var har harFile
+	if err := json.Unmarshal(raw, &har); err != nil {
+		return nil, err
+	}
+	if len(har.Log.Entries) == 0 {
+		return nil, errors.New("HAR file contains no entries")
+	}
+
+	collection := &importedCollection{}
+	for _, e := range har.Log.Entries {
+		if !isHARAPICall(e) {
+			continue
+		}
+		parsed, err := url.Parse(e.Request.URL)
+		if err != nil || parsed.Host == "" {
+			continue
+		}
+
+		r := importedRequest{
+			origin:   strings.ToLower(parsed.Scheme + "://" + parsed.Host),
+			method:   strings.ToUpper(e.Request.Method),
+			pathArgs: make(map[string]string),
+		}
+
+		segments := strings.Split(parsed.Path, "/")
+		for i, s := range segments {
+			if !_regexHARIdentifier.MatchString(s) {
+				continue
+			}
+			name := "id"
+			if n := len(r.pathArgs); n > 0 {
+				name += strconv.Itoa(n + 1)
+			}
+			segments[i] = "{" + name + "}"
+			r.pathArgs[name] = ""
+		}
+		r.path = strings.Join(segments, "/")
+		if r.path == "" {
+			r.path = "/"
+		}
+		r.name = strings.ToLower(r.method) + " " + _regexOpenAPIPathParam.ReplaceAllString(r.path, "by $1")
+		r.title = r.method + " " + r.path
+		r.description = "Calls " + r.method + " " + r.path
+
+		seen := make(map[string]struct{})
+		for _, q := range e.Request.QueryString {
+			if _, ok := seen[q.Name]; ok || q.Name == "" {
+				continue
+			}
+			seen[q.Name] = struct{}{}
+			r.queryArgs = append(r.queryArgs, importedArg{name: q.Name})
+		}
+
+		for _, h := range e.Request.Headers {
+			header, ok := importedHeaderOf(h.Name, h.Value)
+			if !ok {
+				continue
+			}
+			// the browsers send many headers, only the custom ones and the
+			// credentials are kept
+			lower := strings.ToLower(header.key)
+			if !header.secret && !strings.HasPrefix(lower, "x-") && lower != "content-type" {
+				continue
+			}
+			r.headers = append(r.headers, header)
+		}
+
+		if e.Request.PostData != nil && strings.Contains(e.Request.PostData.MimeType, "json") {
+			r.reqBody = decodeJSONExample(e.Request.PostData.Text)
+		}
+		if e.Response.Status >= 200 && e.Response.Status < 300 {
+			text := e.Response.Content.Text
+			if e.Response.Content.Encoding == "base64" {
+				decoded, _ := base64.StdEncoding.DecodeString(text)
+				text = string(decoded)
+			}
+			r.resBody = decodeJSONExample(text)
+		}
+
+		collection.requests = append(collection.requests, r)
+	}
+	if len(collection.requests) == 0 {
+		return nil, errors.New("HAR file contains no JSON API calls")
+	}
+	return collection, nil
+}
+
+// isHARAPICall filters the document, script and asset loads
+func isHARAPICall(e harEntry) bool {
+	switch e.ResourceType {
+	case "", "xhr", "fetch":
+	default:
+		return false
+	}
+	if strings.Contains(e.Response.Content.MimeType, "json") {
+		return true
+	}
+	return e.Request.PostData != nil && strings.Contains(e.Request.PostData.MimeType, "json")
+}
@@ -0,0 +1,485 @@
+package crud
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	entity "github.com/hasmcp/hasmcp-ce/backend/internal/data/entity/crud"
+	erre "github.com/hasmcp/hasmcp-ce/backend/internal/data/entity/err"
+	"github.com/hasmcp/hasmcp-ce/backend/internal/data/model"
+	modelmapper "github.com/hasmcp/hasmcp-ce/backend/internal/mapper/model"
+)
+
+type ProviderImportController interface {
+	ImportProvider(ctx context.Context, req entity.ImportProviderRequest) (*entity.ImportProviderResponse, error)
+	ImportProviderTools(ctx context.Context, req entity.ImportProviderToolsRequest) (*entity.ImportProviderToolsResponse, error)
+}
+
+type (
+	// importedCollection is a decoded Postman collection or HAR file
+	importedCollection struct {
+		name        string
+		description string
+		requests    []importedRequest
+		skipped     []entity.ImportSkippedOperation
+	}
+
+	// importedRequest is an example request with its example response
+	importedRequest struct {
+		origin      string // scheme://host, empty when it is not resolved
+		method      string
+		path        string // with {name} path arguments
+		name        string
+		title       string
+		description string
+		tags        []string
+		headers     []importedHeader
+		pathArgs    map[string]string // name to description
+		queryArgs   []importedArg
+		reqBody     any // decoded JSON example
+		resBody     any // decoded JSON example
+	}
+
+	importedHeader struct {
+		key    string
+		value  string
+		secret bool // the literal value is replaced with a variable reference
+	}
+
+	importedArg struct {
+		name        string
+		description string
+	}
+)
+
+const (
+	_importDefaultProviderName = "Imported"
+)
+
+var (
+	// _importSkippedHeaders are set by the transport or the clients
+	_importSkippedHeaders = map[string]struct{}{
+		"accept-encoding":   {},
+		"cache-control":     {},
+		"connection":        {},
+		"content-length":    {},
+		"dnt":               {},
+		"host":              {},
+		"if-modified-since": {},
+		"if-none-match":     {},
+		"keep-alive":        {},
+		"origin":            {},
+		"postman-token":     {},
+		"pragma":            {},
+		"priority":          {},
+		"referer":           {},
+		"te":                {},
+		"trailer":           {},
+		"transfer-encoding": {},
+		"upgrade":           {},
+		"user-agent":        {},
+	}
+
+	// _importSecretHeaderWords mark the headers carrying credentials
+	_importSecretHeaderWords = []string{
+		"auth", "cookie", "credential", "key", "password", "secret", "session", "signature", "token",
+	}
+)
+
+// ImportProvider creates a REST provider with a tool per distinct example
+// request. The most common origin of the requests becomes the base URL unless
+// it is given. The provider and its tools are created in a single transaction.
+func (c *controller) ImportProvider(ctx context.Context, req entity.ImportProviderRequest) (*entity.ImportProviderResponse, error) {
+	if len(req.Document) == 0 {
+		return nil, erre.Error{
+			Code:    erre.ErrorCodeBadRequest,
+			Message: "document is required",
+		}
+	}
+
+	var collection *importedCollection
+	var err error
+	switch req.Format {
+	case entity.ImportFormatPostman:
+		collection, err = decodePostmanCollection(req.Document)
+	case entity.ImportFormatHAR:
+		collection, err = decodeHAR(req.Document)
+	default:
+		return nil, erre.Error{
+			Code:    erre.ErrorCodeBadRequest,
+			Message: "invalid import format",
+		}
+	}
+	if err != nil {
+		return nil, erre.Error{
+			Code:    erre.ErrorCodeUnprocessableEntity,
+			Message: "invalid " + strings.ToLower(req.Format.String()) + " document",
+			Data: map[string]any{
+				"reason": err.Error(),
+			},
+		}
+	}
+
+	origin := mostCommonOrigin(collection.requests)
+	baseURL := strings.TrimSuffix(req.BaseURL, "/")
+	if baseURL == "" {
+		baseURL = origin
+	}
+	if baseURL == "" {
+		return nil, erre.Error{
+			Code:    erre.ErrorCodeUnprocessableEntity,
+			Message: "base URL could not be detected, set the baseURL",
+		}
+	}
+	var basePath, baseHost string
+	if parsed, err := url.Parse(baseURL); err == nil {
+		basePath = strings.TrimSuffix(parsed.Path, "/")
+		baseHost = strings.TrimPrefix(parsed.Hostname(), "www.")
+	}
+
+	name := req.Name
+	if name == "" && collection.name != "" {
+		name = providerNameOf(collection.name)
+	} else if name == "" {
+		label, _, _ := strings.Cut(baseHost, ".")
+		name = providerNameOf(label)
+	}
+	description := req.Description
+	if description == "" {
+		description = truncate(collection.description, _validationAttrProviderDescriptionMaxLength)
+	}
+	visibilityType := req.VisibilityType
+	if visibilityType == entity.VisibilityTypeInvalid {
+		visibilityType = entity.VisibilityTypeInternal
+	}
+
+	p := entity.Provider{
+		ApiType:        entity.ApiTypeRest,
+		VisibilityType: visibilityType,
+		BaseURL:        baseURL,
+		Name:           name,
+		Description:    description,
+	}
+	if err := c.validateCreateProviderRequest(entity.CreateProviderRequest{Provider: p}); err != nil {
+		return nil, erre.Error{
+			Code:    erre.ErrorCodeBadRequest,
+			Message: err.Error(),
+		}
+	}
+
+	secretPrefix := buildSecretPrefix(baseURL)
+	skipped := collection.skipped
+	seen := make(map[string]struct{})
+	tools := make([]entity.ProviderTool, 0, len(collection.requests))
+	for _, r := range collection.requests {
+		skip := func(reason string) {
+			skipped = append(skipped, entity.ImportSkippedOperation{
+				Method: r.method,
+				Path:   r.path,
+				Reason: reason,
+			})
+		}
+		switch {
+		case r.origin == "" && req.BaseURL == "":
+			skip("base URL of the request is not resolved")
+			continue
+		case r.origin != "" && r.origin != origin:
+			skip("request has a different base URL " + r.origin)
+			continue
+		}
+		if basePath != "" && strings.HasPrefix(r.path, basePath+"/") {
+			r.path = strings.TrimPrefix(r.path, basePath)
+		}
+
+		key := r.method + " " + r.path
+		if _, ok := seen[key]; ok {
+			continue
+		}
+
+		tool := r.tool(secretPrefix)
+		if err := c.validateCreateProviderToolRequest(entity.CreateProviderToolRequest{Tool: tool}); err != nil {
+			skip(err.Error())
+			continue
+		}
+		seen[key] = struct{}{}
+		tools = append(tools, tool)
+	}
+
+	now := time.Now().UTC()
+	id := c.idgen.Next()
+	provider := model.Provider{
+		ID:             id,
+		CreatedAt:      now,
+		UpdatedAt:      now,
+		Version:        _providerInitialVersion,
+		ApiType:        uint8(p.ApiType),
+		VisibilityType: uint8(p.VisibilityType),
+		BaseURL:        p.BaseURL,
+		SecretPrefix:   secretPrefix,
+		Name:           p.Name,
+		Description:    p.Description,
+		Oauth2Config: model.ProviderOauth2Config{
+			ID:         id,
+			ProviderID: id,
+		},
+		Tools: make([]model.ProviderTool, len(tools)),
+	}
+	for i, t := range tools {
+		headers, err := json.Marshal(t.Headers)
+		if err != nil {
+			return nil, err
+		}
+		provider.Tools[i] = model.ProviderTool{
+			ID:                  c.idgen.Next(),
+			CreatedAt:           now,
+			UpdatedAt:           now,
+			ProviderID:          id,
+			Method:              uint8(t.Method),
+			Path:                t.Path,
+			Name:                t.Name,
+			Title:               t.Title,
+			Description:         t.Description,
+			PathArgsJSONSchema:  t.PathArgsJSONSchema,
+			QueryArgsJSONSchema: t.QueryArgsJSONSchema,
+			ReqBodyJSONSchema:   t.ReqBodyJSONSchema,
+			ResBodyJSONSchema:   t.ResBodyJSONSchema,
+			Headers:             headers,
+			Tags:                strings.Join(t.Tags, ","),
+		}
+	}
+
+	// Init transaction
+	ctx = c.storage.ContextWithTx(ctx)
+	providerOnly := provider
+	providerOnly.Tools = nil
+	if err := c.storage.CreateProvider(ctx, providerOnly); err != nil {
+		_ = c.storage.TxRollback(ctx)
+		return nil, erre.Error{
+			Code:    erre.ErrorCodeInternalServerError,
+			Message: "failed to create provider",
+			Data: map[string]any{
+				"reason": err.Error(),
+			},
+		}
+	}
+	for _, tool := range provider.Tools {
+		if err := c.storage.CreateProviderTool(ctx, tool); err != nil {
+			_ = c.storage.TxRollback(ctx)
+			return nil, erre.Error{
+				Code:    erre.ErrorCodeInternalServerError,
+				Message: "failed to create provider tool",
+				Data: map[string]any{
+					"reason": err.Error(),
+					"path":   tool.Path,
+				},
+			}
+		}
+	}
+
+	err = c.storage.TxCommit(ctx)
+	if err != nil {
+		return nil, erre.Error{
+			Code:    erre.ErrorCodeInternalServerError,
+			Message: "db transaction failed to import provider",
+			Data: map[string]any{
+				"reason": err.Error(),
+			},
+		}
+	}
+
+	return &entity.ImportProviderResponse{
+		Provider: modelmapper.FromProviderModelToProviderEntity(provider),
+		Skipped:  skipped,
+	}, nil
+}
+
+// tool converts the example request into a tool, the schemas are inferred
+// from the example payloads
+func (r importedRequest) tool(secretPrefix string) entity.ProviderTool {
+	tool := entity.ProviderTool{
+		Method:      entity.StringToMethodType(r.method),
+		Path:        r.path,
+		Name:        toolNameOf(camelCaseOf(r.name)),
+		Title:       truncate(r.title, _validationAttrProviderToolTitleMaxLength),
+		Description: truncate(r.description, _validationAttrProviderToolDescMaxLength),
+		Headers:     make([]entity.ToolHeader, 0, len(r.headers)),
+		Tags:        r.tags,
+	}
+	if tool.Description == "" {
+		tool.Description = "No description provided."
+	}
+
+	hasContentType := false
+	for _, h := range r.headers {
+		value := h.value
+		if h.secret {
+			value = "${" + variableNameOf(secretPrefix, h.key) + "}"
+			// keep the authorization scheme, e.g. `Bearer ${VAR}`
+			if scheme, _, ok := strings.Cut(h.value, " "); ok && strings.HasSuffix(strings.ToLower(h.key), "authorization") {
+				value = scheme + " " + value
+			}
+		}
+		if strings.EqualFold(h.key, "Content-Type") {
+			hasContentType = true
+		}
+		tool.Headers = append(tool.Headers, entity.ToolHeader{Key: h.key, Value: value})
+	}
+	if r.reqBody != nil && !hasContentType {
+		tool.Headers = append(tool.Headers, entity.ToolHeader{Key: "Content-Type", Value: "application/json"})
+	}
+
+	if len(r.pathArgs) > 0 {
+		names := make([]string, 0, len(r.pathArgs))
+		props := make(map[string]any, len(r.pathArgs))
+		for name, description := range r.pathArgs {
+			if description == "" {
+				description = descriptionOfName(name)
+			}
+			names = append(names, name)
+			props[name] = map[string]any{
+				"type":        "string",
+				"description": description,
+			}
+		}
+		sort.Strings(names)
+		tool.PathArgsJSONSchema, _ = json.Marshal(map[string]any{
+			"$schema":    _openAPIJSONSchemaDraft,
+			"type":       "object",
+			"properties": props,
+			"required":   names,
+		})
+	}
+
+	if len(r.queryArgs) > 0 {
+		props := make(map[string]any, len(r.queryArgs))
+		for _, a := range r.queryArgs {
+			prop := map[string]any{"type": "string"}
+			if a.description != "" {
+				prop["description"] = a.description
+			}
+			props[a.name] = prop
+		}
+		tool.QueryArgsJSONSchema, _ = json.Marshal(map[string]any{
+			"type":       "object",
+			"properties": props,
+			"required":   []string{},
+		})
+	}
+
+	if r.reqBody != nil {
+		tool.ReqBodyJSONSchema, _ = json.Marshal(inferRootJSONSchema(r.reqBody))
+	}
+	if r.resBody != nil {
+		tool.ResBodyJSONSchema, _ = json.Marshal(inferRootJSONSchema(r.resBody))
+	}
+	return tool
+}
+
+// importedHeaderOf filters the transport headers and marks the credentials
+func importedHeaderOf(key, value string) (importedHeader, bool) {
+	lower := strings.ToLower(strings.TrimSpace(key))
+	if _, ok := _importSkippedHeaders[lower]; ok || lower == "" ||
+		strings.HasPrefix(lower, ":") || strings.HasPrefix(lower, "sec-") {
+		return importedHeader{}, false
+	}
+	return importedHeader{
+		key:    strings.TrimSpace(key),
+		value:  value,
+		secret: isSecretHeader(lower),
+	}, true
+}
+
+func isSecretHeader(lower string) bool {
+	for _, w := range _importSecretHeaderWords {
+		if strings.Contains(lower, w) {
+			return true
+		}
+	}
+	return false
+}
+
+// mostCommonOrigin is the origin of the most requests, ties are broken by
+// the first seen
+func mostCommonOrigin(requests []importedRequest) string {
+	counts := make(map[string]int)
+	var origin string
+	for _, r := range requests {
+		if r.origin == "" {
+			continue
+		}
+		counts[r.origin]++
+		if counts[r.origin] > counts[origin] {
+			origin = r.origin
+		}
+	}
+	return origin
+}
+
+// decodeJSONExample decodes the example payload, the non JSON payloads are
+// ignored
+func decodeJSONExample(s string) any {
+	s = strings.TrimSpace(s)
+	if s == "" || (s[0] != '{' && s[0] != '[') {
+		return nil
+	}
+	var v any
+	if err := json.Unmarshal([]byte(s), &v); err != nil {
+		return nil
+	}
+	return v
+}
+
+func inferRootJSONSchema(v any) map[string]any {
+	schema := inferJSONSchema(v)
+	schema["$schema"] = _openAPIJSONSchemaDraft
+	return schema
+}
+
+// inferJSONSchema infers the JSON schema of an example value. The array items
+// are inferred from the first element.
+func inferJSONSchema(v any) map[string]any {
+	switch t := v.(type) {
+	case map[string]any:
+		props := make(map[string]any, len(t))
+		for k, val := range t {
+			props[k] = inferJSONSchema(val)
+		}
+		return map[string]any{
+			"type":       "object",
+			"properties": props,
+		}
+	case []any:
+		if len(t) == 0 {
+			return map[string]any{"type": "array"}
+		}
+		return map[string]any{
+			"type":  "array",
+			"items": inferJSONSchema(t[0]),
+		}
+	case string:
+		return map[string]any{"type": "string"}
+	case float64:
+		if t == math.Trunc(t) {
+			return map[string]any{"type": "integer"}
+		}
+		return map[string]any{"type": "number"}
+	case bool:
+		return map[string]any{"type": "boolean"}
+	default:
+		return map[string]any{}
+	}
+}
+
+// providerNameOf converts s into a valid provider name
+func providerNameOf(s string) string {
+	name := upperFirst(camelCaseOf(s))
+	if name == "" {
+		return _importDefaultProviderName
+	}
+	return truncate(name, 16)
+}
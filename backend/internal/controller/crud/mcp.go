@@ -0,0 +1,236 @@
+package crud
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	entity "github.com/hasmcp/hasmcp-ce/backend/internal/data/entity/crud"
+	erre "github.com/hasmcp/hasmcp-ce/backend/internal/data/entity/err"
+	"github.com/hasmcp/hasmcp-ce/backend/internal/data/model"
+	modelmapper "github.com/hasmcp/hasmcp-ce/backend/internal/mapper/model"
+	"github.com/hasmcp/hasmcp-ce/backend/internal/service/mcpc"
+	"gorm.io/gorm"
+)
+
+type ProviderMCPController interface {
+	ImportProviderMCPTools(ctx context.Context, req entity.ImportProviderMCPToolsRequest) (*entity.ImportProviderMCPToolsResponse, error)
+}
+
+type (
+	mcpTool struct {
+		Name         string          `json:"name"`
+		Title        string          `json:"title,omitempty"`
+		Description  string          `json:"description,omitempty"`
+		InputSchema  json.RawMessage `json:"inputSchema,omitempty"`
+		OutputSchema json.RawMessage `json:"outputSchema,omitempty"`
+		Annotations  struct {
+			Title string `json:"title,omitempty"`
+		} `json:"annotations,omitempty"`
+	}
+
+	mcpListToolsResult struct {
+		Tools      []mcpTool `json:"tools"`
+		NextCursor string    `json:"nextCursor,omitempty"`
+	}
+)
+
+const (
+	_mcpMethodToolsList   = "tools/list"
+	_mcpToolsListMaxPages = 20
+)
+
+// ImportProviderMCPTools creates a tool per tool of the upstream MCP server
+// with the upstream input schema as the body schema. Upstream tools which
+// already have a tool are skipped.
+func (c *controller) ImportProviderMCPTools(ctx context.Context, req entity.ImportProviderMCPToolsRequest) (*entity.ImportProviderMCPToolsResponse, error) {
+	provider, err := c.storage.GetProvider(ctx, req.ProviderID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, erre.Error{
+				Code:    erre.ErrorCodeNotFound,
+				Message: "provider not found",
+				Data: map[string]any{
+					"providerID": req.ProviderID,
+				},
+			}
+		}
+		return nil, erre.Error{
+			Code:    erre.ErrorCodeInternalServerError,
+			Message: "failed to get provider",
+			Data: map[string]any{
+				"reason":     err.Error(),
+				"providerID": req.ProviderID,
+			},
+		}
+	}
+	if entity.ApiType(provider.ApiType) != entity.ApiTypeMCP {
+		return nil, erre.Error{
+			Code:    erre.ErrorCodeBadRequest,
+			Message: "tools can only be imported for MCP providers",
+			Data: map[string]any{
+				"providerID": req.ProviderID,
+			},
+		}
+	}
+
+	upstreamTools, err := c.listMCPTools(ctx, entity.ReplaceVariables(ctx, provider.BaseURL, c.cache), req.Headers)
+	if err != nil {
+		return nil, erre.Error{
+			Code:    erre.ErrorCodeUnprocessableEntity,
+			Message: "failed to list the tools of the upstream MCP server",
+			Data: map[string]any{
+				"reason":     err.Error(),
+				"providerID": req.ProviderID,
+			},
+		}
+	}
+
+	headers, err := json.Marshal(req.Headers)
+	if err != nil {
+		return nil, err
+	}
+
+	existing := make(map[string]struct{}, len(provider.Tools))
+	for _, t := range provider.Tools {
+		existing[t.Path] = struct{}{}
+	}
+
+	now := time.Now().UTC()
+	tools := make([]model.ProviderTool, 0, len(upstreamTools))
+	for _, t := range upstreamTools {
+		if t.Name == "" {
+			continue
+		}
+		if _, ok := existing[t.Name]; ok {
+			continue
+		}
+		existing[t.Name] = struct{}{}
+
+		title := t.Title
+		if title == "" {
+			title = t.Annotations.Title
+		}
+		if title == "" {
+			title = t.Name
+		}
+		description := t.Description
+		if description == "" {
+			description = "Calls " + t.Name
+		}
+		inputSchema := t.InputSchema
+		if len(inputSchema) == 0 || string(inputSchema) == "null" {
+			inputSchema = json.RawMessage(`{"type":"object"}`)
+		}
+		var outputSchema json.RawMessage
+		if len(t.OutputSchema) > 0 && string(t.OutputSchema) != "null" {
+			outputSchema = t.OutputSchema
+		}
+
+		tools = append(tools, model.ProviderTool{
+			ID:                c.idgen.Next(),
+			CreatedAt:         now,
+			UpdatedAt:         now,
+			ProviderID:        provider.ID,
+			Method:            uint8(entity.MethodTypePost),
+			Path:              t.Name,
+			Name:              toolNameOf(t.Name),
+			Title:             truncate(title, _validationAttrProviderToolTitleMaxLength),
+			Description:       truncate(description, _validationAttrProviderToolDescMaxLength),
+			ReqBodyJSONSchema: inputSchema,
+			ResBodyJSONSchema: outputSchema,
+			Headers:           headers,
+		})
+	}
+
+	tools, skipped := c.validGeneratedTools(tools)
+
+	// Init transaction
+	ctx = c.storage.ContextWithTx(ctx)
+	for _, tool := range tools {
+		if err := c.storage.CreateProviderTool(ctx, tool); err != nil {
+			_ = c.storage.TxRollback(ctx)
+			return nil, erre.Error{
+				Code:    erre.ErrorCodeInternalServerError,
+				Message: "failed to create provider tool",
+				Data: map[string]any{
+					"reason":     err.Error(),
+					"providerID": provider.ID,
+					"path":       tool.Path,
+				},
+			}
+		}
+	}
+
+	// Updates version!
+	err = c.storage.UpdateProvider(ctx, provider.ID, map[model.ProviderAttribute]any{})
+	if err != nil {
+		_ = c.storage.TxRollback(ctx)
+		return nil, erre.Error{
+			Code:    erre.ErrorCodeInternalServerError,
+			Message: "failed to update provider",
+			Data: map[string]any{
+				"reason":     err.Error(),
+				"providerID": provider.ID,
+			},
+		}
+	}
+
+	err = c.storage.TxCommit(ctx)
+	if err != nil {
+		return nil, erre.Error{
+			Code:    erre.ErrorCodeInternalServerError,
+			Message: "db transaction failed to import provider tools",
+			Data: map[string]any{
+				"reason":     err.Error(),
+				"providerID": provider.ID,
+			},
+		}
+	}
+
+	c.cache.Evict(context.Background(), entity.ObjectTypeProvider, provider.ID)
+
+	return &entity.ImportProviderMCPToolsResponse{
+		Tools:   modelmapper.FromProviderToolModelsToProviderToolEntities(tools),
+		Skipped: skipped,
+	}, nil
+}
+
+// listMCPTools follows the tools/list pages of the upstream MCP server
+func (c *controller) listMCPTools(ctx context.Context, endpoint string, headers []entity.ToolHeader) ([]mcpTool, error) {
+	h := http.Header{}
+	for _, th := range headers {
+		h.Set(th.Key, entity.ReplaceVariables(ctx, th.Value, c.cache))
+	}
+
+	tools := make([]mcpTool, 0)
+	var cursor string
+	for page := 0; page < _mcpToolsListMaxPages; page++ {
+		var params any
+		if cursor != "" {
+			params = map[string]string{"cursor": cursor}
+		}
+		raw, err := c.mcpc.Call(ctx, mcpc.CallRequest{
+			URL:     endpoint,
+			Headers: h,
+			Method:  _mcpMethodToolsList,
+			Params:  params,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		var res mcpListToolsResult
+		if err := json.Unmarshal(raw, &res); err != nil {
+			return nil, err
+		}
+		tools = append(tools, res.Tools...)
+		if res.NextCursor == "" {
+			break
+		}
+		cursor = res.NextCursor
+	}
+	return tools, nil
+}
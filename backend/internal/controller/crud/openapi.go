@@ -0,0 +1,1181 @@
+package crud
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	entity "github.com/hasmcp/hasmcp-ce/backend/internal/data/entity/crud"
+	erre "github.com/hasmcp/hasmcp-ce/backend/internal/data/entity/err"
+	"github.com/hasmcp/hasmcp-ce/backend/internal/data/model"
+	modelmapper "github.com/hasmcp/hasmcp-ce/backend/internal/mapper/model"
+	zlog "github.com/rs/zerolog/log"
+	"gopkg.in/yaml.v3"
+	"gorm.io/gorm"
+)
+
+type (
+	// openAPIDocument is a decoded OpenAPI 3.x or Swagger 2.0 document
+	openAPIDocument struct {
+		root    map[string]any
+		swagger bool
+	}
+
+	openAPIParameter struct {
+		name        string
+		in          string
+		description string
+		required    bool
+		schema      map[string]any
+	}
+)
+
+const (
+	_importMaxSpecSize  = 16 << 20
+	_openAPIMaxRefDepth = 16
+
+	_openAPIJSONSchemaDraft = "http://json-schema.org/draft-07/schema#"
+)
+
+var (
+	// _openAPIMethods are the operation keys of a path item in the import order
+	_openAPIMethods = []string{"get", "head", "post", "put", "patch", "delete", "options", "trace"}
+
+	// _swaggerSchemaKeys are the JSON schema keywords of the Swagger 2.0 non
+	// body parameters
+	_swaggerSchemaKeys = []string{
+		"type", "format", "items", "enum", "default", "pattern",
+		"minimum", "maximum", "exclusiveMinimum", "exclusiveMaximum", "multipleOf",
+		"minLength", "maxLength", "minItems", "maxItems", "uniqueItems",
+	}
+
+	_regexOpenAPIPathParam    = regexp.MustCompile(`\{([^{}]+)\}`)
+	_regexVariableNameInvalid = regexp.MustCompile(`[^A-Z0-9_]+`)
+)
+
+// ImportProviderTools builds the tools of the OpenAPI operations and diffs
+// them against the provider tools by method and path. The diff is applied in
+// a single transaction when requested; changed tools are updated in place so
+// that their server associations are kept, removed tools are deleted.
+func (c *controller) ImportProviderTools(ctx context.Context, req entity.ImportProviderToolsRequest) (*entity.ImportProviderToolsResponse, error) {
+	provider, err := c.storage.GetProvider(ctx, req.ProviderID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, erre.Error{
+				Code:    erre.ErrorCodeNotFound,
+				Message: "provider not found",
+				Data: map[string]any{
+					"providerID": req.ProviderID,
+				},
+			}
+		}
+		return nil, erre.Error{
+			Code:    erre.ErrorCodeInternalServerError,
+			Message: "failed to get provider",
+			Data: map[string]any{
+				"reason":     err.Error(),
+				"providerID": req.ProviderID,
+			},
+		}
+	}
+	if entity.ApiType(provider.ApiType) != entity.ApiTypeRest {
+		return nil, erre.Error{
+			Code:    erre.ErrorCodeBadRequest,
+			Message: "OpenAPI documents can only be imported for REST providers",
+			Data: map[string]any{
+				"providerID": req.ProviderID,
+			},
+		}
+	}
+
+	raw := req.Spec
+	if len(raw) == 0 {
+		if req.URL == "" {
+			return nil, erre.Error{
+				Code:    erre.ErrorCodeBadRequest,
+				Message: "either spec or url is required",
+			}
+		}
+		raw, err = c.fetchSpec(ctx, req.URL)
+		if err != nil {
+			return nil, erre.Error{
+				Code:    erre.ErrorCodeUnprocessableEntity,
+				Message: "failed to fetch the OpenAPI document",
+				Data: map[string]any{
+					"reason": err.Error(),
+					"url":    req.URL,
+				},
+			}
+		}
+	}
+
+	doc, err := decodeOpenAPIDocument(raw)
+	if err != nil {
+		return nil, erre.Error{
+			Code:    erre.ErrorCodeUnprocessableEntity,
+			Message: "invalid OpenAPI document",
+			Data: map[string]any{
+				"reason": err.Error(),
+			},
+		}
+	}
+
+	// the most required security scheme becomes the provider auth config,
+	// an existing config is kept
+	auth, oauth2Endpoints, authScheme := doc.authConfig(provider.SecretPrefix)
+	providerAttrs := make(map[model.ProviderAttribute]any)
+	if auth != nil && modelmapper.FromProviderModelToProviderEntity(*provider).AuthConfig == nil {
+		authConfig, err := json.Marshal(auth)
+		if err != nil {
+			return nil, err
+		}
+		providerAttrs[model.ProviderAttributeAuthConfig] = authConfig
+		if oauth2Endpoints != nil && provider.Oauth2Config.AuthURL == "" && provider.Oauth2Config.TokenURL == "" {
+			oauth2Config := provider.Oauth2Config
+			oauth2Config.ID = provider.ID
+			oauth2Config.ProviderID = provider.ID
+			oauth2Config.AuthURL = oauth2Endpoints.AuthURL
+			oauth2Config.TokenURL = oauth2Endpoints.TokenURL
+			oauth2Config.GrantType = uint8(oauth2Endpoints.GrantType)
+			providerAttrs[model.ProviderAttributeOauth2Config] = oauth2Config
+		}
+	} else {
+		auth = nil
+	}
+
+	imported, skipped := doc.tools(provider.SecretPrefix, authScheme)
+	tools := make([]entity.ProviderTool, 0, len(imported))
+	for _, t := range imported {
+		t.ProviderID = provider.ID
+		if err := c.validateCreateProviderToolRequest(entity.CreateProviderToolRequest{Tool: t}); err != nil {
+			skipped = append(skipped, entity.ImportSkippedOperation{
+				Method: t.Method.String(),
+				Path:   t.Path,
+				Reason: err.Error(),
+			})
+			continue
+		}
+		tools = append(tools, t)
+	}
+
+	res := diffProviderTools(modelmapper.FromProviderToolModelsToProviderToolEntities(provider.Tools), tools)
+	res.Version = provider.Version
+	res.Skipped = skipped
+	res.AuthConfig = auth
+	if !req.Apply {
+		return res, nil
+	}
+
+	if req.Version != 0 && req.Version != provider.Version {
+		return nil, erre.Error{
+			Code:    erre.ErrorCodeConflict,
+			Message: "provider has changed since the dry run",
+			Data: map[string]any{
+				"providerID":      provider.ID,
+				"version":         req.Version,
+				"providerVersion": provider.Version,
+			},
+		}
+	}
+
+	res.Applied = true
+	if len(res.Added) == 0 && len(res.Changed) == 0 && len(res.Removed) == 0 && len(providerAttrs) == 0 {
+		return res, nil
+	}
+
+	err = c.applyProviderToolsDiff(ctx, provider.ID, res, providerAttrs)
+	if err != nil {
+		return nil, err
+	}
+	res.Version = provider.Version + 1
+
+	return res, nil
+}
+
+func (c *controller) applyProviderToolsDiff(ctx context.Context, providerID int64, diff *entity.ImportProviderToolsResponse, providerAttrs map[model.ProviderAttribute]any) error {
+	serverIDsByToolID := make(map[int64][]int64)
+	for _, ch := range diff.Changed {
+		ids, err := c.storage.ListServerIDsByToolID(ctx, ch.Tool.ID)
+		if err != nil {
+			zlog.Error().Err(err).Msg("failed to list server ids by tool id")
+		}
+		serverIDsByToolID[ch.Tool.ID] = ids
+	}
+	for _, t := range diff.Removed {
+		ids, err := c.storage.ListServerIDsByToolID(ctx, t.ID)
+		if err != nil {
+			zlog.Error().Err(err).Msg("failed to list server ids by tool id")
+		}
+		serverIDsByToolID[t.ID] = ids
+	}
+
+	// Init transaction
+	ctx = c.storage.ContextWithTx(ctx)
+
+	now := time.Now().UTC()
+	for i, t := range diff.Added {
+		headers, err := json.Marshal(t.Headers)
+		if err != nil {
+			_ = c.storage.TxRollback(ctx)
+			return err
+		}
+		tool := model.ProviderTool{
+			ID:                  c.idgen.Next(),
+			CreatedAt:           now,
+			UpdatedAt:           now,
+			ProviderID:          providerID,
+			Method:              uint8(t.Method),
+			Path:                t.Path,
+			Name:                t.Name,
+			Title:               t.Title,
+			Description:         t.Description,
+			PathArgsJSONSchema:  t.PathArgsJSONSchema,
+			QueryArgsJSONSchema: t.QueryArgsJSONSchema,
+			ReqBodyJSONSchema:   t.ReqBodyJSONSchema,
+			ResBodyJSONSchema:   t.ResBodyJSONSchema,
+			Headers:             headers,
+			Oauth2Scopes:        strings.Join(t.Oauth2Scopes, ","),
+			Tags:                strings.Join(t.Tags, ","),
+		}
+		if err := c.storage.CreateProviderTool(ctx, tool); err != nil {
+			_ = c.storage.TxRollback(ctx)
+			return erre.Error{
+				Code:    erre.ErrorCodeInternalServerError,
+				Message: "failed to create provider tool",
+				Data: map[string]any{
+					"reason":     err.Error(),
+					"providerID": providerID,
+					"path":       t.Path,
+				},
+			}
+		}
+		diff.Added[i] = modelmapper.FromProviderToolModelToProviderToolEntity(tool)
+	}
+
+	for _, ch := range diff.Changed {
+		t := ch.Tool
+		headers, err := json.Marshal(t.Headers)
+		if err != nil {
+			_ = c.storage.TxRollback(ctx)
+			return err
+		}
+		err = c.storage.UpdateProviderTool(ctx, t.ID, map[model.ProviderToolAttribute]any{
+			model.ProviderToolAttributeName:                t.Name,
+			model.ProviderToolAttributeTitle:               t.Title,
+			model.ProviderToolAttributeDescription:         t.Description,
+			model.ProviderToolAttributePathArgsJSONSchema:  t.PathArgsJSONSchema,
+			model.ProviderToolAttributeQueryArgsJSONSchema: t.QueryArgsJSONSchema,
+			model.ProviderToolAttributeReqBodyJSONSchema:   t.ReqBodyJSONSchema,
+			model.ProviderToolAttributeResBodyJSONSchema:   t.ResBodyJSONSchema,
+			model.ProviderToolAttributeHeaders:             headers,
+			model.ProviderToolAttributeOauth2Scopes:        strings.Join(t.Oauth2Scopes, ","),
+			model.ProviderToolAttributeTags:                strings.Join(t.Tags, ","),
+		})
+		if err != nil {
+			_ = c.storage.TxRollback(ctx)
+			return erre.Error{
+				Code:    erre.ErrorCodeInternalServerError,
+				Message: "failed to update provider tool",
+				Data: map[string]any{
+					"reason": err.Error(),
+					"toolID": t.ID,
+				},
+			}
+		}
+	}
+
+	for _, t := range diff.Removed {
+		if err := c.storage.DeleteProviderTool(ctx, t.ID); err != nil {
+			_ = c.storage.TxRollback(ctx)
+			return erre.Error{
+				Code:    erre.ErrorCodeInternalServerError,
+				Message: "failed to delete provider tool",
+				Data: map[string]any{
+					"reason": err.Error(),
+					"toolID": t.ID,
+				},
+			}
+		}
+	}
+
+	// Updates version!
+	err := c.storage.UpdateProvider(ctx, providerID, providerAttrs)
+	if err != nil {
+		_ = c.storage.TxRollback(ctx)
+		return erre.Error{
+			Code:    erre.ErrorCodeInternalServerError,
+			Message: "failed to update provider version due to tool import",
+			Data: map[string]any{
+				"reason":     err.Error(),
+				"providerID": providerID,
+			},
+		}
+	}
+
+	err = c.storage.TxCommit(ctx)
+	if err != nil {
+		return erre.Error{
+			Code:    erre.ErrorCodeInternalServerError,
+			Message: "db transaction failed to import provider tools",
+			Data: map[string]any{
+				"reason":     err.Error(),
+				"providerID": providerID,
+			},
+		}
+	}
+
+	freshCtx := context.Background()
+	c.cache.Evict(freshCtx, entity.ObjectTypeProvider, providerID)
+	for _, ch := range diff.Changed {
+		c.cache.Evict(freshCtx, entity.ObjectTypeProviderTool, ch.Tool.ID)
+		for _, id := range serverIDsByToolID[ch.Tool.ID] {
+			_ = c.mcp.HandleChanges(freshCtx, entity.ResourceChange{
+				ObjectType:      entity.ObjectTypeProviderTool,
+				EventType:       entity.ObjectEventTypeUpdate,
+				ResoureID:       ch.Tool.ID,
+				ResourceOwnerID: id,
+			})
+		}
+	}
+	for _, t := range diff.Removed {
+		c.cache.Evict(freshCtx, entity.ObjectTypeProviderTool, t.ID)
+		for _, id := range serverIDsByToolID[t.ID] {
+			c.cache.Evict(freshCtx, entity.ObjectTypeServer, id)
+			_ = c.mcp.HandleChanges(freshCtx, entity.ResourceChange{
+				ObjectType:      entity.ObjectTypeProviderTool,
+				EventType:       entity.ObjectEventTypeDelete,
+				ResoureID:       t.ID,
+				ResourceOwnerID: id,
+			})
+		}
+	}
+
+	return nil
+}
+
+func (c *controller) fetchSpec(ctx context.Context, specURL string) ([]byte, error) {
+	if err := validateURL(specURL); err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, specURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json, application/yaml;q=0.9, */*;q=0.8")
+
+	res, err := c.httpc.Call(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode >= http.StatusBadRequest {
+		return nil, fmt.Errorf("fetch failed with status %d", res.StatusCode)
+	}
+	body, err := io.ReadAll(io.LimitReader(res.Body, _importMaxSpecSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(body) > _importMaxSpecSize {
+		return nil, fmt.Errorf("document exceeds %d bytes", _importMaxSpecSize)
+	}
+	return body, nil
+}
+
+// diffProviderTools matches the imported tools with the existing ones by
+// method and path
+func diffProviderTools(existing, imported []entity.ProviderTool) *entity.ImportProviderToolsResponse {
+	key := func(t entity.ProviderTool) string {
+		return t.Method.String() + " " + t.Path
+	}
+
+	byKey := make(map[string]entity.ProviderTool, len(existing))
+	for _, t := range existing {
+		if _, ok := byKey[key(t)]; !ok {
+			byKey[key(t)] = t
+		}
+	}
+
+	res := &entity.ImportProviderToolsResponse{
+		Added:   make([]entity.ProviderTool, 0),
+		Changed: make([]entity.ProviderToolChange, 0),
+		Removed: make([]entity.ProviderTool, 0),
+	}
+	matched := make(map[int64]struct{}, len(imported))
+	for _, t := range imported {
+		old, ok := byKey[key(t)]
+		if !ok {
+			res.Added = append(res.Added, t)
+			continue
+		}
+		if _, ok := matched[old.ID]; ok {
+			// duplicated operation in the document
+			continue
+		}
+		matched[old.ID] = struct{}{}
+
+		t.ID = old.ID
+		if fields := changedToolFields(old, t); len(fields) > 0 {
+			res.Changed = append(res.Changed, entity.ProviderToolChange{
+				Tool:   t,
+				Fields: fields,
+			})
+		}
+	}
+	for _, t := range existing {
+		if _, ok := matched[t.ID]; !ok {
+			res.Removed = append(res.Removed, t)
+		}
+	}
+	return res
+}
+
+func changedToolFields(old, t entity.ProviderTool) []string {
+	fields := make([]string, 0)
+	if old.Name != t.Name {
+		fields = append(fields, "name")
+	}
+	if old.Title != t.Title {
+		fields = append(fields, "title")
+	}
+	if old.Description != t.Description {
+		fields = append(fields, "description")
+	}
+	if !jsonEqual(old.PathArgsJSONSchema, t.PathArgsJSONSchema) {
+		fields = append(fields, "pathArgsJSONSchema")
+	}
+	if !jsonEqual(old.QueryArgsJSONSchema, t.QueryArgsJSONSchema) {
+		fields = append(fields, "queryArgsJSONSchema")
+	}
+	if !jsonEqual(old.ReqBodyJSONSchema, t.ReqBodyJSONSchema) {
+		fields = append(fields, "reqBodyJSONSchema")
+	}
+	if !jsonEqual(old.ResBodyJSONSchema, t.ResBodyJSONSchema) {
+		fields = append(fields, "resBodyJSONSchema")
+	}
+	if len(old.Headers)+len(t.Headers) > 0 && !reflect.DeepEqual(old.Headers, t.Headers) {
+		fields = append(fields, "headers")
+	}
+	if strings.Join(old.Oauth2Scopes, ",") != strings.Join(t.Oauth2Scopes, ",") {
+		fields = append(fields, "oauth2Scopes")
+	}
+	if strings.Join(old.Tags, ",") != strings.Join(t.Tags, ",") {
+		fields = append(fields, "tags")
+	}
+	return fields
+}
+
+func jsonEqual(a, b []byte) bool {
+	if isEmptyJSON(a) || isEmptyJSON(b) {
+		return isEmptyJSON(a) == isEmptyJSON(b)
+	}
+	var va, vb any
+	if json.Unmarshal(a, &va) != nil || json.Unmarshal(b, &vb) != nil {
+		return string(a) == string(b)
+	}
+	return reflect.DeepEqual(va, vb)
+}
+
+func isEmptyJSON(b []byte) bool {
+	return len(b) == 0 || string(b) == "null"
+}
+
+// decodeOpenAPIDocument decodes a JSON or YAML document of OpenAPI 3.x or
+// Swagger 2.0
+func decodeOpenAPIDocument(raw []byte) (*openAPIDocument, error) {
+	var v any
+	if err := yaml.Unmarshal(raw, &v); err != nil {
+		return nil, err
+	}
+	root, ok := normalizeYAML(v).(map[string]any)
+	if !ok {
+		return nil, errors.New("document must be an object")
+	}
+
+	doc := &openAPIDocument{root: root}
+	if version, _ := root["openapi"].(string); strings.HasPrefix(version, "3.") {
+		doc.swagger = false
+	} else if version, _ := root["swagger"].(string); strings.HasPrefix(version, "2.") {
+		doc.swagger = true
+	} else {
+		return nil, errors.New("only OpenAPI 3.x and Swagger 2.0 documents are supported")
+	}
+
+	if paths, _ := root["paths"].(map[string]any); len(paths) == 0 {
+		return nil, errors.New("document contains no paths")
+	}
+	return doc, nil
+}
+
+// normalizeYAML converts the YAML maps into the JSON compatible maps
+func normalizeYAML(v any) any {
+	switch t := v.(type) {
+	case map[string]any:
+		for k, val := range t {
+			t[k] = normalizeYAML(val)
+		}
+		return t
+	case map[any]any:
+		m := make(map[string]any, len(t))
+		for k, val := range t {
+			m[fmt.Sprint(k)] = normalizeYAML(val)
+		}
+		return m
+	case []any:
+		for i, val := range t {
+			t[i] = normalizeYAML(val)
+		}
+		return t
+	default:
+		return v
+	}
+}
+
+// tools builds a tool per operation. The header parameters and the security
+// requirements other than the provider auth scheme become headers
+// referencing the variables of the provider secret prefix.
+func (d *openAPIDocument) tools(secretPrefix, authScheme string) ([]entity.ProviderTool, []entity.ImportSkippedOperation) {
+	paths, _ := d.root["paths"].(map[string]any)
+	keys := make([]string, 0, len(paths))
+	for k := range paths {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	tools := make([]entity.ProviderTool, 0)
+	skipped := make([]entity.ImportSkippedOperation, 0)
+	for _, path := range keys {
+		item, _ := d.resolve(paths[path], nil).(map[string]any)
+		if item == nil {
+			continue
+		}
+		for _, method := range _openAPIMethods {
+			op, _ := item[method].(map[string]any)
+			if op == nil {
+				continue
+			}
+			tool, err := d.tool(path, method, item, op, secretPrefix, authScheme)
+			if err != nil {
+				skipped = append(skipped, entity.ImportSkippedOperation{
+					Method: strings.ToUpper(method),
+					Path:   path,
+					Reason: err.Error(),
+				})
+				continue
+			}
+			tools = append(tools, tool)
+		}
+	}
+	return tools, skipped
+}
+
+func (d *openAPIDocument) tool(path, method string, item, op map[string]any, secretPrefix, authScheme string) (entity.ProviderTool, error) {
+	params := d.parameters(item, op)
+
+	summary := stringOf(op["summary"])
+	description := stringOf(op["description"])
+	if description == "" {
+		description = summary
+	}
+	if description == "" {
+		description = "No description provided."
+	}
+
+	name := stringOf(op["operationId"])
+	if name == "" {
+		name = summary
+	}
+	if name == "" {
+		name = method + " " + _regexOpenAPIPathParam.ReplaceAllString(path, "by $1")
+	}
+
+	tool := entity.ProviderTool{
+		Method:      entity.StringToMethodType(strings.ToUpper(method)),
+		Path:        path,
+		Name:        toolNameOf(camelCaseOf(name)),
+		Title:       truncate(summary, _validationAttrProviderToolTitleMaxLength),
+		Description: truncate(description, _validationAttrProviderToolDescMaxLength),
+		Headers:     make([]entity.ToolHeader, 0),
+	}
+	if tags, ok := op["tags"].([]any); ok {
+		for _, tag := range tags {
+			// tags are stored comma separated
+			if t := strings.ReplaceAll(stringOf(tag), ",", " "); t != "" {
+				tool.Tags = append(tool.Tags, t)
+			}
+		}
+	}
+
+	headerKeys := make(map[string]struct{})
+	addHeader := func(key, value string) {
+		if _, ok := headerKeys[strings.ToLower(key)]; ok {
+			return
+		}
+		headerKeys[strings.ToLower(key)] = struct{}{}
+		tool.Headers = append(tool.Headers, entity.ToolHeader{Key: key, Value: value})
+	}
+	for _, p := range params {
+		if p.in == "header" {
+			addHeader(p.name, "${"+variableNameOf(secretPrefix, p.name)+"}")
+		}
+	}
+
+	scopes := make([]string, 0)
+	seenScopes := make(map[string]struct{})
+	for _, requirement := range d.security(op) {
+		schemeNames := make([]string, 0, len(requirement))
+		for name := range requirement {
+			schemeNames = append(schemeNames, name)
+		}
+		sort.Strings(schemeNames)
+		for _, schemeName := range schemeNames {
+			rawScopes := requirement[schemeName]
+			scheme := d.securityScheme(schemeName)
+			if scheme == nil {
+				continue
+			}
+			varName := variableNameOf(secretPrefix, schemeName)
+			switch stringOf(scheme["type"]) {
+			case "oauth2", "openIdConnect":
+				// the scopes are requested by the provider oauth2 config
+				list, _ := rawScopes.([]any)
+				for _, s := range list {
+					scope := stringOf(s)
+					if _, ok := seenScopes[scope]; ok || scope == "" {
+						continue
+					}
+					seenScopes[scope] = struct{}{}
+					scopes = append(scopes, scope)
+				}
+			case "apiKey":
+				if schemeName == authScheme {
+					continue
+				}
+				if stringOf(scheme["in"]) == "header" {
+					addHeader(stringOf(scheme["name"]), "${"+varName+"}")
+				}
+			case "http":
+				if schemeName == authScheme {
+					continue
+				}
+				switch strings.ToLower(stringOf(scheme["scheme"])) {
+				case "bearer":
+					addHeader("Authorization", "Bearer ${"+varName+"}")
+				case "basic":
+					addHeader("Authorization", "Basic ${"+varName+"}")
+				}
+			case "basic":
+				if schemeName == authScheme {
+					continue
+				}
+				addHeader("Authorization", "Basic ${"+varName+"}")
+			}
+		}
+	}
+	sort.Strings(scopes)
+	if len(scopes) > 0 {
+		tool.Oauth2Scopes = scopes
+	}
+
+	if schema := d.pathArgsSchema(path, params); schema != nil {
+		tool.PathArgsJSONSchema, _ = json.Marshal(schema)
+	}
+	if schema := d.queryArgsSchema(params); schema != nil {
+		tool.QueryArgsJSONSchema, _ = json.Marshal(schema)
+	}
+	if schema := d.requestBodySchema(op, params); schema != nil {
+		tool.ReqBodyJSONSchema, _ = json.Marshal(schema)
+	}
+	if schema := d.responseBodySchema(op); schema != nil {
+		tool.ResBodyJSONSchema, _ = json.Marshal(schema)
+	}
+
+	if tool.Method == entity.MethodTypeInvalid {
+		return tool, fmt.Errorf("unsupported method %s", method)
+	}
+	return tool, nil
+}
+
+// parameters merges the path item parameters with the operation ones, the
+// operation parameters override by name and location
+func (d *openAPIDocument) parameters(item, op map[string]any) []openAPIParameter {
+	params := make([]openAPIParameter, 0)
+	index := make(map[string]int)
+	for _, list := range []any{item["parameters"], op["parameters"]} {
+		raw, _ := list.([]any)
+		for _, rp := range raw {
+			p, _ := d.resolve(rp, nil).(map[string]any)
+			if p == nil {
+				continue
+			}
+			param := openAPIParameter{
+				name:        stringOf(p["name"]),
+				in:          stringOf(p["in"]),
+				description: stringOf(p["description"]),
+			}
+			param.required, _ = p["required"].(bool)
+			if schema, ok := p["schema"].(map[string]any); ok {
+				param.schema = schema
+			} else if d.swagger && param.in != "body" {
+				param.schema = make(map[string]any)
+				for _, k := range _swaggerSchemaKeys {
+					if v, ok := p[k]; ok {
+						param.schema[k] = v
+					}
+				}
+			}
+
+			k := param.in + ":" + param.name
+			if i, ok := index[k]; ok {
+				params[i] = param
+				continue
+			}
+			index[k] = len(params)
+			params = append(params, param)
+		}
+	}
+	return params
+}
+
+// pathArgsSchema lists the path template parameters as required strings
+func (d *openAPIDocument) pathArgsSchema(path string, params []openAPIParameter) map[string]any {
+	descriptions := make(map[string]string)
+	for _, p := range params {
+		if p.in == "path" {
+			descriptions[p.name] = p.description
+		}
+	}
+
+	props := make(map[string]any)
+	required := make([]string, 0)
+	for _, m := range _regexOpenAPIPathParam.FindAllStringSubmatch(path, -1) {
+		name := m[1]
+		if _, ok := props[name]; ok {
+			continue
+		}
+		description := descriptions[name]
+		if description == "" {
+			description = descriptionOfName(name)
+		}
+		props[name] = map[string]any{
+			"type":        "string",
+			"description": description,
+		}
+		required = append(required, name)
+	}
+	if len(required) == 0 {
+		return nil
+	}
+	return map[string]any{
+		"$schema":    _openAPIJSONSchemaDraft,
+		"type":       "object",
+		"properties": props,
+		"required":   required,
+	}
+}
+
+func (d *openAPIDocument) queryArgsSchema(params []openAPIParameter) map[string]any {
+	props := make(map[string]any)
+	required := make([]string, 0)
+	for _, p := range params {
+		if p.in != "query" {
+			continue
+		}
+		prop := toJSONSchema(d.resolve(p.schema, nil))
+		if _, ok := prop["type"]; !ok {
+			prop["type"] = "string"
+		}
+		if p.description != "" {
+			prop["description"] = p.description
+		}
+		props[p.name] = prop
+		if p.required {
+			required = append(required, p.name)
+		}
+	}
+	if len(props) == 0 {
+		return nil
+	}
+	return map[string]any{
+		"type":       "object",
+		"properties": props,
+		"required":   required,
+	}
+}
+
+func (d *openAPIDocument) requestBodySchema(op map[string]any, params []openAPIParameter) map[string]any {
+	if d.swagger {
+		for _, p := range params {
+			if p.in == "body" && p.schema != nil {
+				return toJSONSchema(p.schema)
+			}
+		}
+		return nil
+	}
+
+	body, _ := d.resolve(op["requestBody"], nil).(map[string]any)
+	if body == nil {
+		return nil
+	}
+	schema := jsonContentSchema(body["content"])
+	if schema == nil {
+		return nil
+	}
+	return toJSONSchema(schema)
+}
+
+// responseBodySchema is the JSON schema of the first successful response
+func (d *openAPIDocument) responseBodySchema(op map[string]any) map[string]any {
+	responses, _ := d.resolve(op["responses"], nil).(map[string]any)
+	codes := make([]string, 0, len(responses))
+	for code := range responses {
+		if strings.HasPrefix(code, "2") {
+			codes = append(codes, code)
+		}
+	}
+	sort.Strings(codes)
+
+	for _, code := range codes {
+		res, _ := responses[code].(map[string]any)
+		if res == nil {
+			continue
+		}
+		var schema any
+		if d.swagger {
+			schema = res["schema"]
+		} else {
+			schema = jsonContentSchema(res["content"])
+		}
+		if schema != nil {
+			return toJSONSchema(schema)
+		}
+	}
+	return nil
+}
+
+func (d *openAPIDocument) security(op map[string]any) []map[string]any {
+	raw, ok := op["security"].([]any)
+	if !ok {
+		raw, _ = d.root["security"].([]any)
+	}
+	requirements := make([]map[string]any, 0, len(raw))
+	for _, r := range raw {
+		if m, ok := r.(map[string]any); ok {
+			requirements = append(requirements, m)
+		}
+	}
+	return requirements
+}
+
+// authConfig builds the provider auth config of the security scheme required
+// by the most operations and returns the scheme name. The oauth2 endpoints are
+// returned for the authorization code and client credentials flows.
+func (d *openAPIDocument) authConfig(secretPrefix string) (*entity.ProviderAuthConfig, *entity.ProviderOauth2Config, string) {
+	counts := make(map[string]int)
+	paths, _ := d.root["paths"].(map[string]any)
+	for _, rawItem := range paths {
+		item, _ := d.resolve(rawItem, nil).(map[string]any)
+		for _, method := range _openAPIMethods {
+			op, _ := item[method].(map[string]any)
+			if op == nil {
+				continue
+			}
+			for _, requirement := range d.security(op) {
+				for name := range requirement {
+					counts[name]++
+				}
+			}
+		}
+	}
+	names := make([]string, 0, len(counts))
+	for name := range counts {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if counts[names[i]] != counts[names[j]] {
+			return counts[names[i]] > counts[names[j]]
+		}
+		return names[i] < names[j]
+	})
+
+	for _, name := range names {
+		scheme := d.securityScheme(name)
+		if scheme == nil {
+			continue
+		}
+		varName := variableNameOf(secretPrefix, name)
+		switch stringOf(scheme["type"]) {
+		case "apiKey":
+			in := entity.StringToAuthLocation(stringOf(scheme["in"]))
+			if in == entity.AuthLocationInvalid || stringOf(scheme["name"]) == "" {
+				continue
+			}
+			return &entity.ProviderAuthConfig{
+				Type:  entity.AuthTypeAPIKey,
+				In:    in,
+				Name:  stringOf(scheme["name"]),
+				Value: "${" + varName + "}",
+			}, nil, name
+		case "http":
+			switch strings.ToLower(stringOf(scheme["scheme"])) {
+			case "bearer":
+				return &entity.ProviderAuthConfig{
+					Type:  entity.AuthTypeBearer,
+					Value: "${" + varName + "}",
+				}, nil, name
+			case "basic":
+				return basicAuthConfigOf(varName), nil, name
+			}
+		case "basic":
+			return basicAuthConfigOf(varName), nil, name
+		case "oauth2":
+			return &entity.ProviderAuthConfig{Type: entity.AuthTypeOauth2}, d.oauth2Endpoints(scheme), name
+		case "openIdConnect":
+			return &entity.ProviderAuthConfig{Type: entity.AuthTypeOauth2}, nil, name
+		}
+	}
+	return nil, nil, ""
+}
+
+// oauth2Endpoints reads the endpoints of the authorization code flow, or of
+// the client credentials flow when the API is machine to machine only
+func (d *openAPIDocument) oauth2Endpoints(scheme map[string]any) *entity.ProviderOauth2Config {
+	var code, clientCredentials map[string]any
+	if !d.swagger {
+		flows, _ := scheme["flows"].(map[string]any)
+		code, _ = flows["authorizationCode"].(map[string]any)
+		clientCredentials, _ = flows["clientCredentials"].(map[string]any)
+	} else {
+		switch stringOf(scheme["flow"]) {
+		case "accessCode":
+			code = scheme
+		case "application":
+			clientCredentials = scheme
+		}
+	}
+
+	authURL, tokenURL := stringOf(code["authorizationUrl"]), stringOf(code["tokenUrl"])
+	if validateURL(authURL) == nil && validateURL(tokenURL) == nil {
+		return &entity.ProviderOauth2Config{
+			GrantType: entity.GrantTypeAuthorizationCode,
+			AuthURL:   authURL,
+			TokenURL:  tokenURL,
+		}
+	}
+
+	tokenURL = stringOf(clientCredentials["tokenUrl"])
+	if validateURL(tokenURL) == nil {
+		return &entity.ProviderOauth2Config{
+			GrantType: entity.GrantTypeClientCredentials,
+			TokenURL:  tokenURL,
+		}
+	}
+	return nil
+}
+
+func basicAuthConfigOf(varName string) *entity.ProviderAuthConfig {
+	return &entity.ProviderAuthConfig{
+		Type:     entity.AuthTypeBasic,
+		Username: "${" + varName + "_USERNAME}",
+		Password: "${" + varName + "_PASSWORD}",
+	}
+}
+
+func (d *openAPIDocument) securityScheme(name string) map[string]any {
+	var schemes map[string]any
+	if d.swagger {
+		schemes, _ = d.root["securityDefinitions"].(map[string]any)
+	} else {
+		components, _ := d.root["components"].(map[string]any)
+		schemes, _ = components["securitySchemes"].(map[string]any)
+	}
+	scheme, _ := d.resolve(schemes[name], nil).(map[string]any)
+	return scheme
+}
+
+// resolve returns a copy of the node with its local references replaced.
+// Recursive and too deep references are cut with a plain object schema.
+func (d *openAPIDocument) resolve(node any, seen map[string]struct{}) any {
+	switch t := node.(type) {
+	case map[string]any:
+		if ref, ok := t["$ref"].(string); ok {
+			if _, ok := seen[ref]; ok || len(seen) >= _openAPIMaxRefDepth {
+				return map[string]any{
+					"type":        "object",
+					"description": "recursive reference to " + ref,
+				}
+			}
+			target, err := d.pointer(ref)
+			if err != nil {
+				return map[string]any{
+					"description": "unresolved reference " + ref,
+				}
+			}
+			if seen == nil {
+				seen = make(map[string]struct{})
+			}
+			seen[ref] = struct{}{}
+			resolved := d.resolve(target, seen)
+			delete(seen, ref)
+
+			// the siblings of the reference override the target
+			if m, ok := resolved.(map[string]any); ok && len(t) > 1 {
+				for k, v := range t {
+					if k != "$ref" {
+						m[k] = d.resolve(v, seen)
+					}
+				}
+			}
+			return resolved
+		}
+		m := make(map[string]any, len(t))
+		for k, v := range t {
+			m[k] = d.resolve(v, seen)
+		}
+		return m
+	case []any:
+		list := make([]any, len(t))
+		for i, v := range t {
+			list[i] = d.resolve(v, seen)
+		}
+		return list
+	default:
+		return node
+	}
+}
+
+// pointer finds the node of a local JSON pointer reference
+func (d *openAPIDocument) pointer(ref string) (any, error) {
+	if !strings.HasPrefix(ref, "#/") {
+		return nil, errors.New("only local references are supported")
+	}
+	var node any = d.root
+	for _, part := range strings.Split(ref[2:], "/") {
+		if unescaped, err := url.PathUnescape(part); err == nil {
+			part = unescaped
+		}
+		part = strings.ReplaceAll(strings.ReplaceAll(part, "~1", "/"), "~0", "~")
+		m, ok := node.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("%s not found", ref)
+		}
+		if node, ok = m[part]; !ok {
+			return nil, fmt.Errorf("%s not found", ref)
+		}
+	}
+	return node, nil
+}
+
+// jsonContentSchema picks the schema of the JSON media type
+func jsonContentSchema(content any) any {
+	media, _ := content.(map[string]any)
+	if m, ok := media["application/json"].(map[string]any); ok {
+		return m["schema"]
+	}
+	types := make([]string, 0, len(media))
+	for k := range media {
+		if strings.Contains(k, "json") {
+			types = append(types, k)
+		}
+	}
+	sort.Strings(types)
+	for _, k := range types {
+		if m, ok := media[k].(map[string]any); ok && m["schema"] != nil {
+			return m["schema"]
+		}
+	}
+	return nil
+}
+
+// toJSONSchema rewrites the OpenAPI only keywords into their JSON schema
+// equivalents
+func toJSONSchema(node any) map[string]any {
+	m, ok := convertOpenAPISchema(node).(map[string]any)
+	if !ok {
+		return map[string]any{}
+	}
+	return m
+}
+
+func convertOpenAPISchema(node any) any {
+	switch t := node.(type) {
+	case map[string]any:
+		m := make(map[string]any, len(t))
+		for k, v := range t {
+			m[k] = convertOpenAPISchema(v)
+		}
+		if typ, ok := m["type"].(string); ok && typ == "file" {
+			m["type"] = "string"
+			m["format"] = "binary"
+		}
+		if nullable, ok := m["nullable"].(bool); ok {
+			delete(m, "nullable")
+			if typ, ok := m["type"].(string); ok && nullable {
+				m["type"] = []any{typ, "null"}
+			}
+		}
+		return m
+	case []any:
+		list := make([]any, len(t))
+		for i, v := range t {
+			list[i] = convertOpenAPISchema(v)
+		}
+		return list
+	default:
+		return node
+	}
+}
+
+func stringOf(v any) string {
+	s, _ := v.(string)
+	return strings.TrimSpace(s)
+}
+
+// camelCaseOf joins the words of s in camel case
+func camelCaseOf(s string) string {
+	words := strings.FieldsFunc(s, func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9')
+	})
+	for i := 1; i < len(words); i++ {
+		words[i] = upperFirst(words[i])
+	}
+	return strings.Join(words, "")
+}
+
+// variableNameOf builds the variable name of the provider secrets, e.g.
+// `GITHUB_X_API_KEY`
+func variableNameOf(prefix, name string) string {
+	name = _regexVariableNameInvalid.ReplaceAllString(strings.ToUpper(name), "_")
+	if prefix == "" {
+		return name
+	}
+	return prefix + "_" + name
+}
+
+// descriptionOfName converts `organizationId` or `domain_id` into words
+func descriptionOfName(name string) string {
+	var b strings.Builder
+	for i, r := range name {
+		switch {
+		case r == '_' || r == '-':
+			b.WriteRune(' ')
+		case r >= 'A' && r <= 'Z':
+			if i > 0 {
+				b.WriteRune(' ')
+			}
+			b.WriteRune(r + ('a' - 'A'))
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return strings.TrimSpace(b.String())
+}
@@ -0,0 +1,384 @@
+package crud
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/url"
+	"regexp"
+	"strings"
+
+	entity "github.com/hasmcp/hasmcp-ce/backend/internal/data/entity/crud"
+)
+
+type (
+	postmanCollection struct {
+		Info struct {
+			Name        string          `json:"name"`
+			Description json.RawMessage `json:"description"`
+			Schema      string          `json:"schema"`
+		} `json:"info"`
+		Item     []postmanItem     `json:"item"`
+		Variable []postmanKeyValue `json:"variable"`
+		Auth     *postmanAuth      `json:"auth"`
+	}
+
+	// postmanItem is either a folder with items or a request
+	postmanItem struct {
+		Name        string            `json:"name"`
+		Description json.RawMessage   `json:"description"`
+		Item        []postmanItem     `json:"item"`
+		Request     *postmanRequest   `json:"request"`
+		Response    []postmanResponse `json:"response"`
+		Auth        *postmanAuth      `json:"auth"`
+	}
+
+	postmanRequest struct {
+		Method      string            `json:"method"`
+		URL         postmanURL        `json:"url"`
+		Header      []postmanKeyValue `json:"header"`
+		Body        *postmanBody      `json:"body"`
+		Auth        *postmanAuth      `json:"auth"`
+		Description json.RawMessage   `json:"description"`
+	}
+
+	postmanURL struct {
+		Raw      string            `json:"raw"`
+		Protocol string            `json:"protocol"`
+		Host     []string          `json:"host"`
+		Path     []string          `json:"path"`
+		Query    []postmanKeyValue `json:"query"`
+		Variable []postmanKeyValue `json:"variable"`
+	}
+
+	postmanKeyValue struct {
+		Key         string          `json:"key"`
+		Value       any             `json:"value"`
+		Disabled    bool            `json:"disabled"`
+		Description json.RawMessage `json:"description"`
+	}
+
+	postmanBody struct {
+		Mode string `json:"mode"`
+		Raw  string `json:"raw"`
+	}
+
+	postmanResponse struct {
+		Code int    `json:"code"`
+		Body string `json:"body"`
+	}
+
+	postmanAuth struct {
+		Type   string            `json:"type"`
+		Bearer []postmanKeyValue `json:"bearer"`
+		APIKey []postmanKeyValue `json:"apikey"`
+		Basic  []postmanKeyValue `json:"basic"`
+	}
+)
+
+var (
+	_regexPostmanVariable = regexp.MustCompile(`\{\{\s*([^{}]+?)\s*\}\}`)
+
+	// _regexPostmanJSONVariable matches the JSON strings and the unquoted
+	// variables of a raw body
+	_regexPostmanJSONVariable = regexp.MustCompile(`"(?:[^"\\]|\\.)*"|\{\{[^{}]+\}\}`)
+)
+
+// UnmarshalJSON accepts the url both as a string and as an object
+func (u *postmanURL) UnmarshalJSON(data []byte) error {
+	var raw string
+	if err := json.Unmarshal(data, &raw); err == nil {
+		u.Raw = raw
+		return nil
+	}
+	type alias postmanURL
+	return json.Unmarshal(data, (*alias)(u))
+}
+
+// UnmarshalJSON accepts the request both as a URL string and as an object
+func (r *postmanRequest) UnmarshalJSON(data []byte) error {
+	var raw string
+	if err := json.Unmarshal(data, &raw); err == nil {
+		r.Method = "GET"
+		r.URL.Raw = raw
+		return nil
+	}
+	type alias postmanRequest
+	return json.Unmarshal(data, (*alias)(r))
+}
+
+// decodePostmanCollection decodes a Postman v2.1 collection. The folders
+// become the tags of their requests and the `{{var}}` references become
+// `${VAR}` variable references.
+func decodePostmanCollection(raw []byte) (*importedCollection, error) {
+	var pc postmanCollection
+	if err := json.Unmarshal(raw, &pc); err != nil {
+		return nil, err
+	}
+	if pc.Info.Schema != "" && !strings.Contains(pc.Info.Schema, "/v2.") {
+		return nil, errors.New("only Postman v2.1 collections are supported")
+	}
+	if len(pc.Item) == 0 {
+		return nil, errors.New("collection contains no requests")
+	}
+
+	vars := make(map[string]string, len(pc.Variable))
+	for _, v := range pc.Variable {
+		if s := postmanValue(v.Value); s != "" && !v.Disabled {
+			vars[v.Key] = s
+		}
+	}
+
+	collection := &importedCollection{
+		name:        pc.Info.Name,
+		description: postmanText(pc.Info.Description),
+	}
+	var walk func(items []postmanItem, tags []string, auth *postmanAuth)
+	walk = func(items []postmanItem, tags []string, auth *postmanAuth) {
+		for _, item := range items {
+			itemAuth := auth
+			if item.Auth != nil {
+				itemAuth = item.Auth
+			}
+			if item.Request == nil {
+				folderTags := append(append([]string{}, tags...), strings.ReplaceAll(item.Name, ",", " "))
+				walk(item.Item, folderTags, itemAuth)
+				continue
+			}
+			r, err := postmanImportedRequest(item, tags, itemAuth, vars)
+			if err != nil {
+				collection.skipped = append(collection.skipped, entity.ImportSkippedOperation{
+					Method: strings.ToUpper(item.Request.Method),
+					Path:   item.Request.URL.Raw,
+					Reason: err.Error(),
+				})
+				continue
+			}
+			collection.requests = append(collection.requests, r)
+		}
+	}
+	walk(pc.Item, nil, pc.Auth)
+	return collection, nil
+}
+
+func postmanImportedRequest(item postmanItem, tags []string, auth *postmanAuth, vars map[string]string) (importedRequest, error) {
+	req := item.Request
+	if req.Auth != nil {
+		auth = req.Auth
+	}
+
+	r := importedRequest{
+		method:      strings.ToUpper(req.Method),
+		name:        item.Name,
+		title:       item.Name,
+		description: postmanText(req.Description),
+		tags:        tags,
+		pathArgs:    make(map[string]string),
+	}
+	if r.method == "" {
+		r.method = "GET"
+	}
+	if r.description == "" {
+		r.description = postmanText(item.Description)
+	}
+	if r.description == "" {
+		r.description = item.Name
+	}
+
+	// the variables of the base URL are resolved with the collection values,
+	// the rest become path arguments
+	raw := req.URL.Raw
+	if raw == "" {
+		raw = strings.Join(req.URL.Host, ".")
+		if req.URL.Protocol != "" {
+			raw = req.URL.Protocol + "://" + raw
+		}
+		if len(req.URL.Path) > 0 {
+			raw += "/" + strings.Join(req.URL.Path, "/")
+		}
+	}
+	raw, _, _ = strings.Cut(raw, "?")
+	raw, _, _ = strings.Cut(raw, "#")
+	raw = _regexPostmanVariable.ReplaceAllStringFunc(raw, func(m string) string {
+		name := _regexPostmanVariable.FindStringSubmatch(m)[1]
+		if v, ok := vars[name]; ok {
+			return strings.TrimSuffix(v, "/")
+		}
+		return m
+	})
+
+	var path string
+	if strings.HasPrefix(raw, "{{") {
+		// unresolved base URL, the baseURL of the request is used
+		_, rest, _ := strings.Cut(raw, "}}")
+		path = rest
+	} else {
+		if !strings.Contains(raw, "://") {
+			raw = "https://" + raw
+		}
+		scheme, rest, _ := strings.Cut(raw, "://")
+		host, p, _ := strings.Cut(rest, "/")
+		if strings.Contains(host, "{{") {
+			return r, errors.New("host of the request has unresolved variables")
+		}
+		r.origin = strings.ToLower(scheme) + "://" + host
+		path = "/" + p
+	}
+
+	descriptions := make(map[string]string, len(req.URL.Variable))
+	for _, v := range req.URL.Variable {
+		descriptions[v.Key] = postmanText(v.Description)
+	}
+	segments := strings.Split(path, "/")
+	for i, s := range segments {
+		switch {
+		case strings.HasPrefix(s, ":") && len(s) > 1:
+			name := s[1:]
+			segments[i] = "{" + name + "}"
+			r.pathArgs[name] = descriptions[name]
+		case _regexPostmanVariable.MatchString(s):
+			segments[i] = _regexPostmanVariable.ReplaceAllStringFunc(s, func(m string) string {
+				name := camelCaseOf(_regexPostmanVariable.FindStringSubmatch(m)[1])
+				r.pathArgs[name] = descriptions[name]
+				return "{" + name + "}"
+			})
+		}
+	}
+	r.path = strings.Join(segments, "/")
+	if r.path == "" {
+		r.path = "/"
+	}
+	if unescaped, err := url.PathUnescape(r.path); err == nil {
+		r.path = unescaped
+	}
+
+	for _, q := range req.URL.Query {
+		if q.Disabled || q.Key == "" {
+			continue
+		}
+		r.queryArgs = append(r.queryArgs, importedArg{
+			name:        q.Key,
+			description: postmanText(q.Description),
+		})
+	}
+
+	hasAuthorization := false
+	for _, h := range req.Header {
+		if h.Disabled {
+			continue
+		}
+		header, ok := postmanHeaderOf(h.Key, postmanValue(h.Value))
+		if !ok {
+			continue
+		}
+		if strings.EqualFold(header.key, "Authorization") {
+			hasAuthorization = true
+		}
+		r.headers = append(r.headers, header)
+	}
+	if auth != nil && !hasAuthorization {
+		if header, ok := postmanAuthHeader(auth); ok {
+			r.headers = append(r.headers, header)
+		}
+	}
+
+	if req.Body != nil && req.Body.Mode == "raw" {
+		r.reqBody = decodeJSONExample(_regexPostmanJSONVariable.ReplaceAllStringFunc(req.Body.Raw, func(m string) string {
+			if strings.HasPrefix(m, "{{") {
+				return `""`
+			}
+			return m
+		}))
+	}
+	for _, res := range item.Response {
+		if res.Code >= 200 && res.Code < 300 || res.Code == 0 {
+			if r.resBody = decodeJSONExample(res.Body); r.resBody != nil {
+				break
+			}
+		}
+	}
+	return r, nil
+}
+
+// postmanHeaderOf converts the `{{var}}` references into the variable
+// references, the literal credentials are replaced later
+func postmanHeaderOf(key, value string) (importedHeader, bool) {
+	header, ok := importedHeaderOf(key, value)
+	if !ok {
+		return header, false
+	}
+	if _regexPostmanVariable.MatchString(value) {
+		header.value = postmanVariables(value)
+		header.secret = false
+	}
+	return header, true
+}
+
+// postmanAuthHeader converts the inherited auth into a header
+func postmanAuthHeader(auth *postmanAuth) (importedHeader, bool) {
+	params := func(kvs []postmanKeyValue) map[string]string {
+		m := make(map[string]string, len(kvs))
+		for _, kv := range kvs {
+			m[kv.Key] = postmanValue(kv.Value)
+		}
+		return m
+	}
+
+	switch strings.ToLower(auth.Type) {
+	case "bearer":
+		token := params(auth.Bearer)["token"]
+		return postmanHeaderOf("Authorization", "Bearer "+token)
+	case "apikey":
+		p := params(auth.APIKey)
+		if p["in"] == "query" || p["key"] == "" {
+			return importedHeader{}, false
+		}
+		return postmanHeaderOf(p["key"], p["value"])
+	case "basic":
+		p := params(auth.Basic)
+		if _regexPostmanVariable.MatchString(p["username"] + p["password"]) {
+			// the encoded credentials can not reference variables
+			return importedHeader{key: "Authorization", value: "Basic " + p["username"], secret: true}, true
+		}
+		credentials := base64.StdEncoding.EncodeToString([]byte(p["username"] + ":" + p["password"]))
+		return importedHeader{key: "Authorization", value: "Basic " + credentials, secret: true}, true
+	default:
+		return importedHeader{}, false
+	}
+}
+
+// postmanVariables replaces the `{{var}}` references with `${VAR}`
+func postmanVariables(s string) string {
+	return _regexPostmanVariable.ReplaceAllStringFunc(s, func(m string) string {
+		return "${" + variableNameOf("", _regexPostmanVariable.FindStringSubmatch(m)[1]) + "}"
+	})
+}
+
+// postmanText reads the descriptions which are either strings or objects
+// with a content
+func postmanText(raw json.RawMessage) string {
+	if len(raw) == 0 {
+		return ""
+	}
+	var s string
+	if err := json.Unmarshal(raw, &s); err == nil {
+		return strings.TrimSpace(s)
+	}
+	var d struct {
+		Content string `json:"content"`
+	}
+	_ = json.Unmarshal(raw, &d)
+	return strings.TrimSpace(d.Content)
+}
+
+func postmanValue(v any) string {
+	switch t := v.(type) {
+	case string:
+		return t
+	case nil:
+		return ""
+	default:
+		b, _ := json.Marshal(t)
+		return string(b)
+	}
+}
@@ -2,8 +2,14 @@ package crud
 
 import (
 	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
 	"encoding/hex"
+	"encoding/json"
 	"errors"
+	"fmt"
 	"net/url"
 	"regexp"
 	"strings"
@@ -13,6 +19,8 @@ import (
 	erre "github.com/hasmcp/hasmcp-ce/backend/internal/data/entity/err"
 	"github.com/hasmcp/hasmcp-ce/backend/internal/data/model"
 	modelmapper "github.com/hasmcp/hasmcp-ce/backend/internal/mapper/model"
+	"github.com/hasmcp/hasmcp-ce/backend/internal/service/grpcc"
+	"github.com/hasmcp/hasmcp-ce/backend/internal/service/httpc"
 	"github.com/hasmcp/hasmcp-ce/backend/internal/service/locksmith"
 
 	zlog "github.com/rs/zerolog/log"
@@ -33,6 +41,17 @@ const (
 	_validationAttrProviderBaseURLMaxLength     = 255
 	_validationAttrProviderDocumentURLMaxLength = 255
 	_validationAttrProviderIconURLMaxLength     = 255
+	_validationAttrProviderAuthNameMaxLength    = 128
+	_validationAttrProviderAuthValueMaxLength   = 1024
+	_validationAttrProviderMaxConcurrency       = 1000
+	_validationAttrProviderRatePerSecondMax     = 10000
+	_validationAttrProviderBurstMax             = 10000
+	_validationAttrProviderMaxWaitMax           = 300
+	_validationAttrProviderBreakerFailuresMax   = 1000
+	_validationAttrProviderBreakerCooldownMax   = 3600
+	_validationAttrProviderEgressEntriesMax     = 64
+	_validationAttrProviderPEMMaxLength         = 65536
+	_validationAttrProviderPinsMax              = 16
 
 	_providerInitialVersion = int32(1)
 )
@@ -48,27 +67,57 @@ func (c *controller) CreateProvider(ctx context.Context, req entity.CreateProvid
 
 	p := req.Provider
 
-	var clientSecretEncrypted, clientSecretEncryptionNonce string
-	if p.Oauth2Config.ClientSecret != "" {
-		res, err := c.locksmith.Encrypt(ctx, &locksmith.EncryptRequest{
-			Plaintext: []byte(p.Oauth2Config.ClientSecret),
-		})
-		if err != nil {
-			return nil, erre.Error{
-				Code:    erre.ErrorCodeUnprocessableEntity,
-				Message: "crud: failed to encrypt the client secret",
-				Data: map[string]any{
-					"reason": err.Error(),
-				},
-			}
-		}
+	clientSecretEncrypted, clientSecretEncryptionNonce, err := c.encryptSecret(ctx, "client secret", p.Oauth2Config.ClientSecret)
+	if err != nil {
+		return nil, err
+	}
+	passwordEncrypted, passwordEncryptionNonce, err := c.encryptSecret(ctx, "password", p.Oauth2Config.Password)
+	if err != nil {
+		return nil, err
+	}
+	privateKeyEncrypted, privateKeyEncryptionNonce, err := c.encryptSecret(ctx, "private key", p.Oauth2Config.PrivateKey)
+	if err != nil {
+		return nil, err
+	}
+
+	var grpcConfig json.RawMessage
+	if p.GRPCConfig != nil {
+		grpcConfig, _ = json.Marshal(p.GRPCConfig)
+	}
 
-		clientSecretEncrypted = hex.EncodeToString(res.Ciphertext)
-		clientSecretEncryptionNonce = hex.EncodeToString(res.Nonce)
+	var authConfig json.RawMessage
+	if p.AuthConfig != nil && p.AuthConfig.Type != entity.AuthTypeNone {
+		authConfig, _ = json.Marshal(p.AuthConfig)
+	}
+
+	var signerConfig json.RawMessage
+	if p.SignerConfig != nil && p.SignerConfig.Type != entity.SignerTypeNone {
+		signerConfig, _ = json.Marshal(p.SignerConfig)
+	}
+
+	var mockConfig json.RawMessage
+	if p.MockConfig != nil {
+		mockConfig, _ = json.Marshal(p.MockConfig)
+	}
+
+	var limitsConfig json.RawMessage
+	if p.LimitsConfig != nil {
+		limitsConfig, _ = json.Marshal(p.LimitsConfig)
+	}
+
+	var egressConfig json.RawMessage
+	if p.EgressConfig != nil {
+		egressConfig, _ = json.Marshal(p.EgressConfig)
+	}
+
+	var transportConfig json.RawMessage
+	if p.TransportConfig != nil {
+		transportConfig, _ = json.Marshal(p.TransportConfig)
 	}
 
 	now := time.Now().UTC()
 	id := c.idgen.Next()
+	warnInsecureTransport(id, p.TransportConfig)
 	provider := model.Provider{
 		ID:             id,
 		CreatedAt:      now,
@@ -82,6 +131,18 @@ func (c *controller) CreateProvider(ctx context.Context, req entity.CreateProvid
 		SecretPrefix:   buildSecretPrefix(p.BaseURL),
 		Name:           p.Name,
 		Description:    p.Description,
+		GraphQLSchema:  p.GraphQLSchema,
+
+		GRPCConfig:        grpcConfig,
+		GRPCDescriptorSet: p.GRPCDescriptorSet,
+
+		AuthConfig:      authConfig,
+		SignerConfig:    signerConfig,
+		MockConfig:      mockConfig,
+		LimitsConfig:    limitsConfig,
+		EgressConfig:    egressConfig,
+		TransportConfig: transportConfig,
+
 		Oauth2Config: model.ProviderOauth2Config{
 			ID:                          id,
 			ProviderID:                  id,
@@ -90,10 +151,19 @@ func (c *controller) CreateProvider(ctx context.Context, req entity.CreateProvid
 			ClientSecretEncryptionNonce: clientSecretEncryptionNonce,
 			AuthURL:                     p.Oauth2Config.AuthURL,
 			TokenURL:                    p.Oauth2Config.TokenURL,
+			GrantType:                   uint8(p.Oauth2Config.GrantType),
+			Audience:                    p.Oauth2Config.Audience,
+			Username:                    p.Oauth2Config.Username,
+			PasswordEncrypted:           passwordEncrypted,
+			PasswordEncryptionNonce:     passwordEncryptionNonce,
+			Issuer:                      p.Oauth2Config.Issuer,
+			Subject:                     p.Oauth2Config.Subject,
+			PrivateKeyEncrypted:         privateKeyEncrypted,
+			PrivateKeyEncryptionNonce:   privateKeyEncryptionNonce,
 		},
 	}
 
-	err := c.storage.CreateProvider(ctx, provider)
+	err = c.storage.CreateProvider(ctx, provider)
 	if err != nil {
 		return nil, err
 	}
@@ -210,37 +280,104 @@ func (c *controller) UpdateProvider(ctx context.Context, req entity.UpdateProvid
 	if p.IconURL != "" {
 		attrs[model.ProviderAttributeIconURL] = p.IconURL
 	}
+	if len(p.GraphQLSchema) > 0 {
+		attrs[model.ProviderAttributeGraphQLSchema] = p.GraphQLSchema
+	}
+	if p.GRPCConfig != nil {
+		grpcConfig, err := json.Marshal(p.GRPCConfig)
+		if err != nil {
+			return nil, err
+		}
+		attrs[model.ProviderAttributeGRPCConfig] = grpcConfig
+	}
+	if len(p.GRPCDescriptorSet) > 0 {
+		attrs[model.ProviderAttributeGRPCDescriptorSet] = p.GRPCDescriptorSet
+	}
+	if p.AuthConfig != nil {
+		if err := c.keepMaskedAuthConfig(ctx, p.ID, p.AuthConfig); err != nil {
+			return nil, err
+		}
+		authConfig, err := json.Marshal(p.AuthConfig)
+		if err != nil {
+			return nil, err
+		}
+		attrs[model.ProviderAttributeAuthConfig] = authConfig
+	}
+	if p.SignerConfig != nil {
+		signerConfig, err := json.Marshal(p.SignerConfig)
+		if err != nil {
+			return nil, err
+		}
+		attrs[model.ProviderAttributeSignerConfig] = signerConfig
+	}
+	if p.MockConfig != nil {
+		mockConfig, err := json.Marshal(p.MockConfig)
+		if err != nil {
+			return nil, err
+		}
+		attrs[model.ProviderAttributeMockConfig] = mockConfig
+	}
+	if p.LimitsConfig != nil {
+		limitsConfig, err := json.Marshal(p.LimitsConfig)
+		if err != nil {
+			return nil, err
+		}
+		attrs[model.ProviderAttributeLimitsConfig] = limitsConfig
+	}
+	if p.EgressConfig != nil {
+		egressConfig, err := json.Marshal(p.EgressConfig)
+		if err != nil {
+			return nil, err
+		}
+		attrs[model.ProviderAttributeEgressConfig] = egressConfig
+	}
+	if p.TransportConfig != nil {
+		transportConfig, err := json.Marshal(p.TransportConfig)
+		if err != nil {
+			return nil, err
+		}
+		attrs[model.ProviderAttributeTransportConfig] = transportConfig
+		warnInsecureTransport(req.Provider.ID, p.TransportConfig)
+	}
 
-	if p.Oauth2Config.AuthURL != "" && p.Oauth2Config.TokenURL != "" &&
-		p.Oauth2Config.ClientID != "" && p.Oauth2Config.ClientSecret != "" && p.Oauth2Config.ClientSecret != "***" {
-		var clientSecretEncrypted, clientSecretEncryptionNonce string
-		if p.Oauth2Config.ClientSecret != "" {
-			res, err := c.locksmith.Encrypt(ctx, &locksmith.EncryptRequest{
-				Plaintext: []byte(p.Oauth2Config.ClientSecret),
-			})
-			if err != nil {
-				return nil, erre.Error{
-					Code:    erre.ErrorCodeUnprocessableEntity,
-					Message: "crud: failed to encrypt the client secret",
-					Data: map[string]any{
-						"reason": err.Error(),
-					},
-				}
-			}
-
-			clientSecretEncrypted = hex.EncodeToString(res.Ciphertext)
-			clientSecretEncryptionNonce = hex.EncodeToString(res.Nonce)
+	if p.Oauth2Config.TokenURL != "" && (p.Oauth2Config.ClientID != "" || p.Oauth2Config.Issuer != "") {
+		oauth2Config := &model.ProviderOauth2Config{
+			ID:         req.Provider.ID,
+			ProviderID: req.Provider.ID,
+			ClientID:   p.Oauth2Config.ClientID,
+			AuthURL:    p.Oauth2Config.AuthURL,
+			TokenURL:   p.Oauth2Config.TokenURL,
+			GrantType:  uint8(p.Oauth2Config.GrantType),
+			Audience:   p.Oauth2Config.Audience,
+			Username:   p.Oauth2Config.Username,
+			Issuer:     p.Oauth2Config.Issuer,
+			Subject:    p.Oauth2Config.Subject,
 		}
 
-		attrs[model.ProviderAttributeOauth2Config] = &model.ProviderOauth2Config{
-			ID:                          req.Provider.ID,
-			ProviderID:                  req.Provider.ID,
-			ClientID:                    p.Oauth2Config.ClientID,
-			ClientSecretEncrypted:       clientSecretEncrypted,
-			ClientSecretEncryptionNonce: clientSecretEncryptionNonce,
-			AuthURL:                     p.Oauth2Config.AuthURL,
-			TokenURL:                    p.Oauth2Config.TokenURL,
+		var err error
+		oauth2Config.ClientSecretEncrypted, oauth2Config.ClientSecretEncryptionNonce, err = c.updateSecret(ctx, p.ID, "client secret", p.Oauth2Config.ClientSecret,
+			func(o model.ProviderOauth2Config) (string, string, string) {
+				return o.ClientSecretEncrypted, o.ClientSecretEncryptionNonce, ""
+			})
+		if err != nil {
+			return nil, err
+		}
+		oauth2Config.PasswordEncrypted, oauth2Config.PasswordEncryptionNonce, err = c.updateSecret(ctx, p.ID, "password", p.Oauth2Config.Password,
+			func(o model.ProviderOauth2Config) (string, string, string) {
+				return o.PasswordEncrypted, o.PasswordEncryptionNonce, o.Password
+			})
+		if err != nil {
+			return nil, err
 		}
+		oauth2Config.PrivateKeyEncrypted, oauth2Config.PrivateKeyEncryptionNonce, err = c.updateSecret(ctx, p.ID, "private key", p.Oauth2Config.PrivateKey,
+			func(o model.ProviderOauth2Config) (string, string, string) {
+				return o.PrivateKeyEncrypted, o.PrivateKeyEncryptionNonce, o.PrivateKey
+			})
+		if err != nil {
+			return nil, err
+		}
+
+		attrs[model.ProviderAttributeOauth2Config] = oauth2Config
 	}
 
 	err := c.storage.UpdateProvider(ctx, p.ID, attrs)
@@ -289,6 +426,45 @@ func (c *controller) UpdateProvider(ctx context.Context, req entity.UpdateProvid
 	}, nil
 }
 
+// encryptSecret encrypts the oauth2 secret, the empty secret is kept empty
+func (c *controller) encryptSecret(ctx context.Context, name, secret string) (encrypted, nonce string, err error) {
+	if secret == "" {
+		return "", "", nil
+	}
+	res, err := c.locksmith.Encrypt(ctx, &locksmith.EncryptRequest{
+		Plaintext: []byte(secret),
+	})
+	if err != nil {
+		return "", "", erre.Error{
+			Code:    erre.ErrorCodeUnprocessableEntity,
+			Message: "crud: failed to encrypt the " + name,
+			Data: map[string]any{
+				"reason": err.Error(),
+			},
+		}
+	}
+	return hex.EncodeToString(res.Ciphertext), hex.EncodeToString(res.Nonce), nil
+}
+
+// updateSecret encrypts the oauth2 secret of the update, the masked secret
+// keeps the stored one. The stored plaintext of the configs saved before the
+// encryption is encrypted instead.
+func (c *controller) updateSecret(ctx context.Context, providerID int64, name, secret string,
+	stored func(model.ProviderOauth2Config) (encrypted, nonce, plaintext string)) (string, string, error) {
+	if secret != "***" {
+		return c.encryptSecret(ctx, name, secret)
+	}
+	current, err := c.storage.GetProvider(ctx, providerID)
+	if err != nil {
+		return "", "", err
+	}
+	encrypted, nonce, plaintext := stored(current.Oauth2Config)
+	if encrypted == "" && plaintext != "" {
+		return c.encryptSecret(ctx, name, plaintext)
+	}
+	return encrypted, nonce, nil
+}
+
 func (c *controller) validateUpdateProviderRequest(req entity.UpdateProviderRequest) error {
 	p := req.Provider
 	var anyChanges bool
@@ -334,16 +510,66 @@ func (c *controller) validateUpdateProviderRequest(req entity.UpdateProviderRequ
 		}
 	}
 
-	if p.Oauth2Config.AuthURL != "" {
+	if len(p.GraphQLSchema) > 0 {
 		anyChanges = true
-		if err := validateURL(p.Oauth2Config.AuthURL); err != nil {
+		if _, err := decodeGraphQLSchema(p.GraphQLSchema); err != nil {
+			return fmt.Errorf("invalid GraphQL schema: %w", err)
+		}
+	}
+
+	if p.GRPCConfig != nil {
+		anyChanges = true
+	}
+
+	if len(p.GRPCDescriptorSet) > 0 {
+		anyChanges = true
+		if _, err := decodeGRPCDescriptorSet(p.GRPCDescriptorSet); err != nil {
+			return fmt.Errorf("invalid gRPC descriptor set: %w", err)
+		}
+	}
+
+	if p.AuthConfig != nil {
+		anyChanges = true
+		if err := validateProviderAuthConfig(p.AuthConfig); err != nil {
 			return err
 		}
 	}
 
-	if p.Oauth2Config.TokenURL != "" {
+	if p.SignerConfig != nil {
+		anyChanges = true
+		if err := validateProviderSignerConfig(p.SignerConfig); err != nil {
+			return err
+		}
+	}
+
+	if p.MockConfig != nil {
+		anyChanges = true
+	}
+
+	if p.LimitsConfig != nil {
 		anyChanges = true
-		if err := validateURL(p.Oauth2Config.TokenURL); err != nil {
+		if err := validateProviderLimitsConfig(p.LimitsConfig); err != nil {
+			return err
+		}
+	}
+
+	if p.EgressConfig != nil {
+		anyChanges = true
+		if err := validateProviderEgressConfig(p.EgressConfig); err != nil {
+			return err
+		}
+	}
+
+	if p.TransportConfig != nil {
+		anyChanges = true
+		if err := validateProviderTransportConfig(p.TransportConfig); err != nil {
+			return err
+		}
+	}
+
+	if p.Oauth2Config.AuthURL != "" || p.Oauth2Config.TokenURL != "" {
+		anyChanges = true
+		if err := validateProviderOauth2Config(p.Oauth2Config); err != nil {
 			return err
 		}
 	}
@@ -364,10 +590,68 @@ func (c *controller) validateCreateProviderRequest(req entity.CreateProviderRequ
 		return errors.New("invalid API type")
 	}
 
+	if len(p.GraphQLSchema) > 0 {
+		if p.ApiType != entity.ApiTypeGraphQL {
+			return errors.New("GraphQL schema is only supported by GRAPHQL providers")
+		}
+		if _, err := decodeGraphQLSchema(p.GraphQLSchema); err != nil {
+			return fmt.Errorf("invalid GraphQL schema: %w", err)
+		}
+	}
+
+	if p.GRPCConfig != nil && p.ApiType != entity.ApiTypeGRPC {
+		return errors.New("gRPC config is only supported by GRPC providers")
+	}
+
+	if len(p.GRPCDescriptorSet) > 0 {
+		if p.ApiType != entity.ApiTypeGRPC {
+			return errors.New("gRPC descriptor set is only supported by GRPC providers")
+		}
+		if _, err := decodeGRPCDescriptorSet(p.GRPCDescriptorSet); err != nil {
+			return fmt.Errorf("invalid gRPC descriptor set: %w", err)
+		}
+	}
+
 	if p.VisibilityType == entity.VisibilityTypeInvalid {
 		return errors.New("invalid visibility type")
 	}
 
+	if p.AuthConfig != nil {
+		if err := validateProviderAuthConfig(p.AuthConfig); err != nil {
+			return err
+		}
+	}
+
+	if p.SignerConfig != nil {
+		if p.ApiType == entity.ApiTypeGRPC {
+			return errors.New("request signing is not supported by GRPC providers")
+		}
+		if err := validateProviderSignerConfig(p.SignerConfig); err != nil {
+			return err
+		}
+	}
+
+	if p.LimitsConfig != nil {
+		if err := validateProviderLimitsConfig(p.LimitsConfig); err != nil {
+			return err
+		}
+	}
+
+	if p.EgressConfig != nil {
+		if err := validateProviderEgressConfig(p.EgressConfig); err != nil {
+			return err
+		}
+	}
+
+	if p.TransportConfig != nil {
+		if p.SignerConfig != nil && p.SignerConfig.Type == entity.SignerTypeMTLS && p.TransportConfig.ClientCert != "" {
+			return errors.New("client certificate is already set by the MTLS signer")
+		}
+		if err := validateProviderTransportConfig(p.TransportConfig); err != nil {
+			return err
+		}
+	}
+
 	if p.BaseURL == "" {
 		return errors.New("base URL is required")
 	}
@@ -375,7 +659,12 @@ func (c *controller) validateCreateProviderRequest(req entity.CreateProviderRequ
 		return errors.New("base URL exceeds maximum length")
 	}
 
-	if err := validateURL(p.BaseURL); err != nil {
+	baseURL := withVariablePlaceholders(p.BaseURL)
+	if p.ApiType == entity.ApiTypeGRPC {
+		if _, err := grpcc.Target(baseURL); err != nil {
+			return err
+		}
+	} else if err := validateURL(baseURL); err != nil {
 		return err
 	}
 
@@ -415,14 +704,8 @@ func (c *controller) validateCreateProviderRequest(req entity.CreateProviderRequ
 		return errors.New("description exceeds maximum length")
 	}
 
-	if p.Oauth2Config.AuthURL != "" {
-		if err := validateURL(p.Oauth2Config.AuthURL); err != nil {
-			return err
-		}
-	}
-
-	if p.Oauth2Config.TokenURL != "" {
-		if err := validateURL(p.Oauth2Config.TokenURL); err != nil {
+	if p.Oauth2Config.AuthURL != "" || p.Oauth2Config.TokenURL != "" {
+		if err := validateProviderOauth2Config(p.Oauth2Config); err != nil {
 			return err
 		}
 	}
@@ -431,12 +714,40 @@ func (c *controller) validateCreateProviderRequest(req entity.CreateProviderRequ
 }
 
 func buildSecretPrefix(u string) string {
-	parsed, _ := url.Parse(u)
-	host := parsed.Hostname()
+	var host string
+	if strings.Contains(u, "${") {
+		host = templatedHostOf(u)
+	} else {
+		parsed, _ := url.Parse(u)
+		host = parsed.Hostname()
+	}
 	host = strings.TrimPrefix(host, "www.")
 	return strings.ToUpper(strings.Replace(host, ".", "_", -1))
 }
 
+// templatedHostOf returns the host of the base URL with its variable
+// references replaced by their names, e.g. API_HOST for
+// https://${API_HOST}:${PORT}/v1, so that the providers of the templated
+// hosts do not share the secrets of a placeholder host
+func templatedHostOf(u string) string {
+	_, authority, _ := strings.Cut(u, "://")
+	if i := strings.IndexAny(authority, "/?#"); i >= 0 {
+		authority = authority[:i]
+	}
+	authority = authority[strings.LastIndex(authority, "@")+1:]
+	if i := strings.LastIndex(authority, ":"); i >= 0 && !strings.HasSuffix(authority, "]") {
+		authority = authority[:i]
+	}
+	return entity.RegexVariableReference.ReplaceAllString(authority, "$1")
+}
+
+// withVariablePlaceholders replaces the `${NAME}` references of the URL,
+// which are resolved on the calls, with a placeholder valid as a host label,
+// a port and a path segment
+func withVariablePlaceholders(u string) string {
+	return entity.RegexVariableReference.ReplaceAllString(u, "0")
+}
+
 func validateURL(u string) error {
 	if u == "" {
 		return errors.New("URL is required")
@@ -453,3 +764,285 @@ func validateURL(u string) error {
 	}
 	return nil
 }
+
+// keepMaskedAuthConfig replaces the masked API key, bearer token and password
+// of the update with the stored ones
+func (c *controller) keepMaskedAuthConfig(ctx context.Context, providerID int64, a *entity.ProviderAuthConfig) error {
+	if a.Value != "***" && a.Password != "***" {
+		return nil
+	}
+	current, err := c.storage.GetProvider(ctx, providerID)
+	if err != nil {
+		return err
+	}
+	stored := modelmapper.FromProviderModelToProviderEntity(*current).AuthConfig
+	if stored == nil || stored.Type != a.Type {
+		return erre.Error{
+			Code:    erre.ErrorCodeUnprocessableEntity,
+			Message: "crud: the masked auth secret has no stored value",
+			Data: map[string]any{
+				"providerID": providerID,
+			},
+		}
+	}
+	if a.Value == "***" {
+		a.Value = stored.Value
+	}
+	if a.Password == "***" {
+		a.Password = stored.Password
+	}
+	return nil
+}
+
+func validateProviderAuthConfig(a *entity.ProviderAuthConfig) error {
+	if a.Type <= entity.AuthTypeInvalid || a.Type >= entity.AuthTypeInvalidMax {
+		return errors.New("invalid auth type")
+	}
+	if len(a.Name) > _validationAttrProviderAuthNameMaxLength {
+		return errors.New("auth name exceeds maximum length")
+	}
+	if len(a.Value) > _validationAttrProviderAuthValueMaxLength ||
+		len(a.Username) > _validationAttrProviderAuthValueMaxLength ||
+		len(a.Password) > _validationAttrProviderAuthValueMaxLength {
+		return errors.New("auth value exceeds maximum length")
+	}
+
+	switch a.Type {
+	case entity.AuthTypeAPIKey:
+		if a.In <= entity.AuthLocationInvalid || a.In >= entity.AuthLocationInvalidMax {
+			return errors.New("invalid API key location")
+		}
+		if a.Name == "" {
+			return errors.New("API key name is required")
+		}
+		if a.Value == "" {
+			return errors.New("API key value is required")
+		}
+	case entity.AuthTypeBasic:
+		if a.Username == "" {
+			return errors.New("basic auth username is required")
+		}
+	case entity.AuthTypeBearer:
+		if a.Value == "" {
+			return errors.New("bearer token is required")
+		}
+	}
+	return nil
+}
+
+func validateProviderOauth2Config(o entity.ProviderOauth2Config) error {
+	if o.GrantType <= entity.GrantTypeInvalid || o.GrantType >= entity.GrantTypeInvalidMax {
+		return errors.New("invalid oauth2 grant type")
+	}
+	if o.AuthURL != "" {
+		if err := validateURL(o.AuthURL); err != nil {
+			return err
+		}
+	}
+	if o.TokenURL != "" {
+		if err := validateURL(o.TokenURL); err != nil {
+			return err
+		}
+	}
+	if o.GrantType.Interactive() {
+		return nil
+	}
+
+	if o.TokenURL == "" {
+		return errors.New("oauth2 token URL is required")
+	}
+	if len(o.Audience) > _validationAttrProviderAuthNameMaxLength {
+		return errors.New("oauth2 audience exceeds maximum length")
+	}
+	if len(o.Username) > _validationAttrProviderAuthValueMaxLength ||
+		len(o.Password) > _validationAttrProviderAuthValueMaxLength {
+		return errors.New("oauth2 credentials exceed maximum length")
+	}
+	if len(o.PrivateKey) > _validationAttrProviderPEMMaxLength {
+		return fmt.Errorf("oauth2 private key must be at most %d characters", _validationAttrProviderPEMMaxLength)
+	}
+
+	switch o.GrantType {
+	case entity.GrantTypeClientCredentials:
+		if o.ClientID == "" || o.ClientSecret == "" {
+			return errors.New("client ID and client secret are required")
+		}
+	case entity.GrantTypeJWTBearer:
+		if o.ClientID == "" && o.Issuer == "" {
+			return errors.New("client ID or assertion issuer is required")
+		}
+		if o.PrivateKey == "" {
+			return errors.New("assertion private key is required")
+		}
+	case entity.GrantTypePassword:
+		if o.ClientID == "" {
+			return errors.New("client ID is required")
+		}
+		if o.Username == "" || o.Password == "" {
+			return errors.New("username and password are required")
+		}
+	}
+	return nil
+}
+
+func validateProviderLimitsConfig(l *entity.ProviderLimitsConfig) error {
+	if l.MaxConcurrency < 0 || l.MaxConcurrency > _validationAttrProviderMaxConcurrency {
+		return fmt.Errorf("max concurrency must be between 0 and %d", _validationAttrProviderMaxConcurrency)
+	}
+	if l.RatePerSecond < 0 || l.RatePerSecond > _validationAttrProviderRatePerSecondMax {
+		return fmt.Errorf("rate per second must be between 0 and %d", _validationAttrProviderRatePerSecondMax)
+	}
+	if l.Burst < 0 || l.Burst > _validationAttrProviderBurstMax {
+		return fmt.Errorf("burst must be between 0 and %d", _validationAttrProviderBurstMax)
+	}
+	if l.MaxWaitSeconds < 0 || l.MaxWaitSeconds > _validationAttrProviderMaxWaitMax {
+		return fmt.Errorf("max wait must be between 0 and %d seconds", _validationAttrProviderMaxWaitMax)
+	}
+	if l.BreakerFailures < 0 || l.BreakerFailures > _validationAttrProviderBreakerFailuresMax {
+		return fmt.Errorf("breaker failures must be between 0 and %d", _validationAttrProviderBreakerFailuresMax)
+	}
+	if l.BreakerCooldownSeconds < 0 || l.BreakerCooldownSeconds > _validationAttrProviderBreakerCooldownMax {
+		return fmt.Errorf("breaker cooldown must be between 0 and %d seconds", _validationAttrProviderBreakerCooldownMax)
+	}
+	return nil
+}
+
+func validateProviderEgressConfig(e *entity.ProviderEgressConfig) error {
+	if len(e.AllowedCIDRs) > _validationAttrProviderEgressEntriesMax ||
+		len(e.AllowedHosts) > _validationAttrProviderEgressEntriesMax ||
+		len(e.AllowedPorts) > _validationAttrProviderEgressEntriesMax {
+		return fmt.Errorf("egress allows at most %d CIDRs, hosts and ports each", _validationAttrProviderEgressEntriesMax)
+	}
+	err := httpc.ValidateEgressAllow(httpc.EgressAllow{
+		CIDRs: e.AllowedCIDRs,
+		Hosts: e.AllowedHosts,
+		Ports: e.AllowedPorts,
+	})
+	if err != nil {
+		return fmt.Errorf("invalid egress config: %w", err)
+	}
+	return nil
+}
+
+// validateProviderTransportConfig checks the settings, the values referring to
+// the variables are checked once they are resolved
+func validateProviderTransportConfig(t *entity.ProviderTransportConfig) error {
+	if len(t.CACert) > _validationAttrProviderPEMMaxLength ||
+		len(t.ClientCert) > _validationAttrProviderPEMMaxLength ||
+		len(t.ClientKey) > _validationAttrProviderPEMMaxLength {
+		return fmt.Errorf("PEM materials must be at most %d characters", _validationAttrProviderPEMMaxLength)
+	}
+	if t.CACert != "" && !strings.Contains(t.CACert, "${") {
+		if !x509.NewCertPool().AppendCertsFromPEM([]byte(t.CACert)) {
+			return errors.New("CA bundle is not a valid PEM")
+		}
+	}
+	if (t.ClientCert == "") != (t.ClientKey == "") {
+		return errors.New("client certificate and key are required together")
+	}
+	if t.ClientCert != "" && !strings.Contains(t.ClientCert+t.ClientKey, "${") {
+		if _, err := tls.X509KeyPair([]byte(t.ClientCert), []byte(t.ClientKey)); err != nil {
+			return fmt.Errorf("invalid client certificate: %w", err)
+		}
+	}
+
+	switch t.MinTLSVersion {
+	case "", "1.2", "1.3":
+	default:
+		return errors.New("minimum TLS version must be 1.2 or 1.3")
+	}
+
+	if len(t.PinnedSHA256) > _validationAttrProviderPinsMax {
+		return fmt.Errorf("at most %d pinned SHA-256 hashes are allowed", _validationAttrProviderPinsMax)
+	}
+	for _, pin := range t.PinnedSHA256 {
+		sum, err := base64.StdEncoding.DecodeString(pin)
+		if err != nil || len(sum) != sha256.Size {
+			return fmt.Errorf("pinned SHA-256 must be a base64 SHA-256 hash: %s", pin)
+		}
+	}
+
+	if t.ProxyURL != "" && !strings.Contains(t.ProxyURL, "${") {
+		u, err := url.Parse(t.ProxyURL)
+		if err != nil || u.Host == "" {
+			return errors.New("proxy URL is malformed")
+		}
+		switch u.Scheme {
+		case "http", "https", "socks5", "socks5h":
+		default:
+			return errors.New("proxy URL scheme must be http, https, socks5 or socks5h")
+		}
+	}
+	return nil
+}
+
+// warnInsecureTransport logs the providers skipping the certificate
+// verification on every save
+func warnInsecureTransport(providerID int64, t *entity.ProviderTransportConfig) {
+	if t == nil || !t.InsecureSkipVerify {
+		return
+	}
+	zlog.Warn().Int64("providerID", providerID).
+		Msg("crud: TLS certificate verification is DISABLED for the provider, its upstream requests can be intercepted, never use it in production")
+}
+
+func validateProviderSignerConfig(s *entity.ProviderSignerConfig) error {
+	if s.Type <= entity.SignerTypeInvalid || s.Type >= entity.SignerTypeInvalidMax {
+		return errors.New("invalid signer type")
+	}
+
+	switch s.Type {
+	case entity.SignerTypeAWSSigV4:
+		if s.Region == "" || s.Service == "" {
+			return errors.New("AWS region and service are required")
+		}
+		if s.AccessKeyID == "" || s.SecretAccessKey == "" {
+			return errors.New("AWS access key ID and secret access key are required")
+		}
+		if err := validateSecretReference("AWS secret access key", s.SecretAccessKey); err != nil {
+			return err
+		}
+		if s.SessionToken != "" {
+			if err := validateSecretReference("AWS session token", s.SessionToken); err != nil {
+				return err
+			}
+		}
+	case entity.SignerTypeHMAC:
+		switch s.Algorithm {
+		case "", "SHA1", "SHA256", "SHA512":
+		default:
+			return errors.New("invalid HMAC algorithm")
+		}
+		switch s.Encoding {
+		case "", "HEX", "BASE64":
+		default:
+			return errors.New("invalid HMAC signature encoding")
+		}
+		if s.Secret == "" {
+			return errors.New("HMAC secret is required")
+		}
+		if err := validateSecretReference("HMAC secret", s.Secret); err != nil {
+			return err
+		}
+		if s.Header == "" {
+			return errors.New("HMAC signature header is required")
+		}
+	case entity.SignerTypeMTLS:
+		if s.ClientCert == "" || s.ClientKey == "" {
+			return errors.New("client certificate and key are required")
+		}
+		if err := validateSecretReference("client key", s.ClientKey); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validateSecretReference requires the secret to be a `${NAME}` reference, the
+// secret itself is kept in a secret variable instead of the provider
+func validateSecretReference(name, s string) error {
+	if !entity.IsVariableReference(s) {
+		return fmt.Errorf("%s must be a `${NAME}` reference of a secret variable", name)
+	}
+	return nil
+}
@@ -0,0 +1,38 @@
+package crud
+
+import (
+	"context"
+
+	"github.com/hasmcp/hasmcp-ce/backend/internal/controller/mcp"
+	entity "github.com/hasmcp/hasmcp-ce/backend/internal/data/entity/crud"
+	erre "github.com/hasmcp/hasmcp-ce/backend/internal/data/entity/err"
+)
+
+type ProviderBreakerController interface {
+	GetProviderBreaker(ctx context.Context, req entity.GetProviderBreakerRequest) (*entity.GetProviderBreakerResponse, error)
+}
+
+// GetProviderBreaker returns the circuit breaker state of the provider on the
+// replica serving the request
+func (c *controller) GetProviderBreaker(ctx context.Context, req entity.GetProviderBreakerRequest) (*entity.GetProviderBreakerResponse, error) {
+	if req.ProviderID <= 0 {
+		return nil, erre.Error{
+			Code:    erre.ErrorCodeBadRequest,
+			Message: "invalid provider ID",
+		}
+	}
+
+	// Check if provider exists
+	_, err := c.GetProvider(ctx, entity.GetProviderRequest{
+		ID: req.ProviderID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &entity.GetProviderBreakerResponse{
+		Breaker: c.mcp.GetProviderBreaker(ctx, mcp.GetProviderBreakerRequest{
+			ProviderID: req.ProviderID,
+		}),
+	}, nil
+}
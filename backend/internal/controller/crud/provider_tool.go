@@ -29,13 +29,52 @@ const (
 	_validationAttrProviderToolPathMaxLength  = 128
 	_validationAttrProviderToolDescMaxLength  = 4096
 	_validationAttrProviderToolTitleMaxLength = 64
+	_validationAttrToolMockExamplesMaxCount   = 32
+	_validationAttrToolMockResponseMaxLength  = 64 * 1024
+	_validationAttrToolAsyncIntervalMax       = 300
+	_validationAttrToolAsyncMaxWaitMax        = 3600
+	_validationAttrToolPaginationMaxPages     = 100
+	_validationAttrToolPaginationMaxItems     = 10000
+	_validationAttrToolCacheTTLMax            = 86400
+	_validationAttrToolCacheVaryHeadersMax    = 16
 )
 
 var (
 	_regexValidationProviderToolName = regexp.MustCompile(`^[a-z][a-zA-Z0-9]{0,19}$`)
+	_regexValidationJSONPath         = regexp.MustCompile(`^\$(\.[^.\[\]]+|\[\d+\]|\['[^']+'\])*$`)
+	_regexValidationHeaderName       = regexp.MustCompile("^[A-Za-z0-9!#$%&'*+.^_`|~-]+$")
 )
 
 func (c *controller) CreateProviderTool(ctx context.Context, req entity.CreateProviderToolRequest) (*entity.CreateProviderToolResponse, error) {
+	provider, err := c.storage.GetProvider(ctx, req.Tool.ProviderID)
+	if err != nil {
+		return nil, erre.Error{
+			Code:    erre.ErrorCodeNotFound,
+			Message: "provider not found",
+			Data: map[string]any{
+				"reason":     err.Error(),
+				"providerID": req.Tool.ProviderID,
+			},
+		}
+	}
+
+	if entity.ApiType(provider.ApiType) == entity.ApiTypeGraphQL {
+		req.Tool, err = prepareGraphQLTool(req.Tool, provider.GraphQLSchema)
+		if err != nil {
+			return nil, err
+		}
+	} else if req.Tool.Operation != "" {
+		return nil, erre.Error{
+			Code:    erre.ErrorCodeBadRequest,
+			Message: "operation is only supported by GRAPHQL provider tools",
+		}
+	} else if entity.ApiType(provider.ApiType) == entity.ApiTypeGRPC {
+		req.Tool, err = prepareGRPCTool(req.Tool, provider.GRPCDescriptorSet)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	if err := c.validateCreateProviderToolRequest(req); err != nil {
 		return nil, err
 	}
@@ -46,6 +85,30 @@ func (c *controller) CreateProviderTool(ctx context.Context, req entity.CreatePr
 	if err != nil {
 		return nil, err
 	}
+	var mockExamples json.RawMessage
+	if len(e.MockExamples) > 0 {
+		if mockExamples, err = json.Marshal(e.MockExamples); err != nil {
+			return nil, err
+		}
+	}
+	var asyncConfig json.RawMessage
+	if e.AsyncConfig != nil {
+		if asyncConfig, err = json.Marshal(e.AsyncConfig); err != nil {
+			return nil, err
+		}
+	}
+	var paginationConfig json.RawMessage
+	if e.PaginationConfig != nil {
+		if paginationConfig, err = json.Marshal(e.PaginationConfig); err != nil {
+			return nil, err
+		}
+	}
+	var cacheConfig json.RawMessage
+	if e.CacheConfig != nil {
+		if cacheConfig, err = json.Marshal(e.CacheConfig); err != nil {
+			return nil, err
+		}
+	}
 	now := time.Now().UTC()
 	tool := model.ProviderTool{
 		ID:                  c.idgen.Next(),
@@ -63,6 +126,12 @@ func (c *controller) CreateProviderTool(ctx context.Context, req entity.CreatePr
 		ResBodyJSONSchema:   e.ResBodyJSONSchema,
 		Headers:             headers,
 		Oauth2Scopes:        strings.Join(e.Oauth2Scopes, ","),
+		Operation:           e.Operation,
+		Tags:                strings.Join(e.Tags, ","),
+		MockExamples:        mockExamples,
+		AsyncConfig:         asyncConfig,
+		PaginationConfig:    paginationConfig,
+		CacheConfig:         cacheConfig,
 	}
 
 	// Init transaction
@@ -133,13 +202,40 @@ func (c *controller) GetProviderTool(ctx context.Context, req entity.GetProvider
 }
 
 func (c *controller) UpdateProviderTool(ctx context.Context, req entity.UpdateProviderToolRequest) (*entity.UpdateProviderToolResponse, error) {
+	attrs := make(map[model.ProviderToolAttribute]any)
+	if req.Tool.Operation != "" {
+		provider, err := c.storage.GetProvider(ctx, req.Tool.ProviderID)
+		if err != nil {
+			return nil, erre.Error{
+				Code:    erre.ErrorCodeNotFound,
+				Message: "provider not found",
+				Data: map[string]any{
+					"reason":     err.Error(),
+					"providerID": req.Tool.ProviderID,
+				},
+			}
+		}
+		if entity.ApiType(provider.ApiType) != entity.ApiTypeGraphQL {
+			return nil, erre.Error{
+				Code:    erre.ErrorCodeBadRequest,
+				Message: "operation is only supported by GRAPHQL provider tools",
+			}
+		}
+
+		req.Tool, err = prepareGraphQLTool(req.Tool, provider.GraphQLSchema)
+		if err != nil {
+			return nil, err
+		}
+		attrs[model.ProviderToolAttributeOperation] = req.Tool.Operation
+		attrs[model.ProviderToolAttributePath] = req.Tool.Path
+	}
+
 	if err := c.validateUpdateProviderToolRequest(req); err != nil {
 		return nil, err
 	}
 
 	e := req.Tool
 
-	attrs := make(map[model.ProviderToolAttribute]any)
 	if e.Name != "" {
 		attrs[model.ProviderToolAttributeName] = e.Name
 	}
@@ -167,6 +263,9 @@ func (c *controller) UpdateProviderTool(ctx context.Context, req entity.UpdatePr
 	if len(e.Oauth2Scopes) > 0 {
 		attrs[model.ProviderToolAttributeOauth2Scopes] = strings.Join(e.Oauth2Scopes, ",")
 	}
+	if e.Tags != nil {
+		attrs[model.ProviderToolAttributeTags] = strings.Join(e.Tags, ",")
+	}
 	if e.Headers != nil {
 		headers, err := json.Marshal(e.Headers)
 		if err != nil {
@@ -174,6 +273,34 @@ func (c *controller) UpdateProviderTool(ctx context.Context, req entity.UpdatePr
 		}
 		attrs[model.ProviderToolAttributeHeaders] = headers
 	}
+	if e.MockExamples != nil {
+		mockExamples, err := json.Marshal(e.MockExamples)
+		if err != nil {
+			return nil, err
+		}
+		attrs[model.ProviderToolAttributeMockExamples] = mockExamples
+	}
+	if e.AsyncConfig != nil {
+		asyncConfig, err := json.Marshal(e.AsyncConfig)
+		if err != nil {
+			return nil, err
+		}
+		attrs[model.ProviderToolAttributeAsyncConfig] = asyncConfig
+	}
+	if e.PaginationConfig != nil {
+		paginationConfig, err := json.Marshal(e.PaginationConfig)
+		if err != nil {
+			return nil, err
+		}
+		attrs[model.ProviderToolAttributePaginationConfig] = paginationConfig
+	}
+	if e.CacheConfig != nil {
+		cacheConfig, err := json.Marshal(e.CacheConfig)
+		if err != nil {
+			return nil, err
+		}
+		attrs[model.ProviderToolAttributeCacheConfig] = cacheConfig
+	}
 
 	// Init transaction
 	ctx = c.storage.ContextWithTx(ctx)
@@ -318,6 +445,26 @@ func (c *controller) DeleteProviderTool(ctx context.Context, req entity.DeletePr
 	return nil
 }
 
+// validGeneratedTools validates the tools generated from a schema the same way
+// as the created tools, the invalid ones are skipped with their reasons
+func (c *controller) validGeneratedTools(tools []model.ProviderTool) ([]model.ProviderTool, []entity.ImportSkippedOperation) {
+	valid := make([]model.ProviderTool, 0, len(tools))
+	var skipped []entity.ImportSkippedOperation
+	for _, t := range tools {
+		e := modelmapper.FromProviderToolModelToProviderToolEntity(t)
+		if err := c.validateCreateProviderToolRequest(entity.CreateProviderToolRequest{Tool: e}); err != nil {
+			skipped = append(skipped, entity.ImportSkippedOperation{
+				Method: e.Method.String(),
+				Path:   e.Path,
+				Reason: err.Error(),
+			})
+			continue
+		}
+		valid = append(valid, t)
+	}
+	return valid, skipped
+}
+
 func (c *controller) validateCreateProviderToolRequest(req entity.CreateProviderToolRequest) error {
 	e := req.Tool
 	if e.Method == entity.MethodTypeInvalid {
@@ -435,12 +582,37 @@ func (c *controller) validateCreateProviderToolRequest(req entity.CreateProvider
 		}
 	}
 
-	return nil
+	if err := validateToolMockExamples(e.MockExamples); err != nil {
+		return err
+	}
+	if err := validateToolAsyncConfig(e.AsyncConfig); err != nil {
+		return err
+	}
+	if err := validateToolPaginationConfig(e.PaginationConfig); err != nil {
+		return err
+	}
+	if e.CacheConfig != nil && e.Method != entity.MethodTypeGet && e.Method != entity.MethodTypeHead {
+		return erre.Error{
+			Code:    erre.ErrorCodeBadRequest,
+			Message: "cache is only supported for the GET and HEAD tools",
+			Data: map[string]any{
+				"method": e.Method.String(),
+			},
+		}
+	}
+	return validateToolCacheConfig(e.CacheConfig)
 }
 
 func (c *controller) validateUpdateProviderToolRequest(req entity.UpdateProviderToolRequest) error {
 	e := req.Tool
 	var anyChanges bool
+	if e.Operation != "" {
+		anyChanges = true
+	}
+	if e.Tags != nil {
+		anyChanges = true
+	}
+
 	if len(e.Description) > 0 {
 		anyChanges = true
 		if len(e.Description) > _validationAttrProviderToolDescMaxLength {
@@ -539,6 +711,34 @@ func (c *controller) validateUpdateProviderToolRequest(req entity.UpdateProvider
 		anyChanges = true
 	}
 
+	if e.MockExamples != nil {
+		if err := validateToolMockExamples(e.MockExamples); err != nil {
+			return err
+		}
+		anyChanges = true
+	}
+
+	if e.AsyncConfig != nil {
+		if err := validateToolAsyncConfig(e.AsyncConfig); err != nil {
+			return err
+		}
+		anyChanges = true
+	}
+
+	if e.PaginationConfig != nil {
+		if err := validateToolPaginationConfig(e.PaginationConfig); err != nil {
+			return err
+		}
+		anyChanges = true
+	}
+
+	if e.CacheConfig != nil {
+		if err := validateToolCacheConfig(e.CacheConfig); err != nil {
+			return err
+		}
+		anyChanges = true
+	}
+
 	if !anyChanges {
 		return erre.Error{
 			Code:    erre.ErrorCodeBadRequest,
@@ -547,3 +747,236 @@ func (c *controller) validateUpdateProviderToolRequest(req entity.UpdateProvider
 	}
 	return nil
 }
+
+func validateToolMockExamples(examples []entity.ToolMockExample) error {
+	if len(examples) > _validationAttrToolMockExamplesMaxCount {
+		return erre.Error{
+			Code:    erre.ErrorCodeBadRequest,
+			Message: fmt.Sprintf("mock examples must be at most %d", _validationAttrToolMockExamplesMaxCount),
+		}
+	}
+
+	for _, ex := range examples {
+		if len(ex.Response) > _validationAttrToolMockResponseMaxLength {
+			return erre.Error{
+				Code:    erre.ErrorCodeBadRequest,
+				Message: fmt.Sprintf("mock example response exceeds maximum length of %d", _validationAttrToolMockResponseMaxLength),
+				Data: map[string]any{
+					"example": ex.Name,
+				},
+			}
+		}
+		if !json.Valid(ex.Response) {
+			return erre.Error{
+				Code:    erre.ErrorCodeBadRequest,
+				Message: "mock example response must be a valid JSON",
+				Data: map[string]any{
+					"example": ex.Name,
+				},
+			}
+		}
+		for _, r := range ex.Rules {
+			if r.In == entity.ToolArgumentLocationInvalid || r.In > entity.ToolArgumentLocationBody {
+				return erre.Error{
+					Code:    erre.ErrorCodeBadRequest,
+					Message: "mock rule location must be one of PATH, QUERY or BODY",
+					Data: map[string]any{
+						"example": ex.Name,
+						"name":    r.Name,
+					},
+				}
+			}
+			if r.Name == "" {
+				return erre.Error{
+					Code:    erre.ErrorCodeBadRequest,
+					Message: "mock rule argument name is required",
+					Data: map[string]any{
+						"example": ex.Name,
+					},
+				}
+			}
+			if r.Value == nil && r.Pattern == "" {
+				return erre.Error{
+					Code:    erre.ErrorCodeBadRequest,
+					Message: "mock rule requires a value or a pattern",
+					Data: map[string]any{
+						"example": ex.Name,
+						"name":    r.Name,
+					},
+				}
+			}
+			if r.Pattern != "" {
+				if _, err := regexp.Compile(r.Pattern); err != nil {
+					return erre.Error{
+						Code:    erre.ErrorCodeBadRequest,
+						Message: "invalid mock rule pattern",
+						Data: map[string]any{
+							"reason":  err.Error(),
+							"example": ex.Name,
+							"name":    r.Name,
+						},
+					}
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func validateToolAsyncConfig(cfg *entity.ToolAsyncConfig) error {
+	if cfg == nil {
+		return nil
+	}
+
+	if cfg.IntervalSeconds < 0 || cfg.IntervalSeconds > _validationAttrToolAsyncIntervalMax {
+		return erre.Error{
+			Code:    erre.ErrorCodeBadRequest,
+			Message: fmt.Sprintf("async interval must be between 0 and %d seconds", _validationAttrToolAsyncIntervalMax),
+			Data: map[string]any{
+				"intervalSeconds": cfg.IntervalSeconds,
+			},
+		}
+	}
+	if cfg.MaxWaitSeconds < 0 || cfg.MaxWaitSeconds > _validationAttrToolAsyncMaxWaitMax {
+		return erre.Error{
+			Code:    erre.ErrorCodeBadRequest,
+			Message: fmt.Sprintf("async max wait must be between 0 and %d seconds", _validationAttrToolAsyncMaxWaitMax),
+			Data: map[string]any{
+				"maxWaitSeconds": cfg.MaxWaitSeconds,
+			},
+		}
+	}
+
+	paths := map[string]string{
+		"statusURLPath": cfg.StatusURLPath,
+		"resultURLPath": cfg.ResultURLPath,
+	}
+	if cfg.Success != nil {
+		paths["success"] = cfg.Success.Path
+	}
+	if cfg.Failure != nil {
+		paths["failure"] = cfg.Failure.Path
+	}
+	for name, path := range paths {
+		if path == "" && (name == "statusURLPath" || name == "resultURLPath") {
+			continue
+		}
+		if !_regexValidationJSONPath.MatchString(path) {
+			return erre.Error{
+				Code:    erre.ErrorCodeBadRequest,
+				Message: "async path must be a JSONPath like $.status or $.links[0].href",
+				Data: map[string]any{
+					"name": name,
+					"path": path,
+				},
+			}
+		}
+	}
+	return nil
+}
+
+func validateToolPaginationConfig(cfg *entity.ToolPaginationConfig) error {
+	if cfg == nil {
+		return nil
+	}
+
+	if cfg.Type == entity.PaginationTypeInvalid || cfg.Type >= entity.PaginationTypeInvalidMax {
+		return erre.Error{
+			Code:    erre.ErrorCodeBadRequest,
+			Message: "pagination type must be one of LINK, CURSOR, OFFSET or PAGE",
+		}
+	}
+	if cfg.MaxPages < 0 || cfg.MaxPages > _validationAttrToolPaginationMaxPages {
+		return erre.Error{
+			Code:    erre.ErrorCodeBadRequest,
+			Message: fmt.Sprintf("pagination max pages must be between 0 and %d", _validationAttrToolPaginationMaxPages),
+			Data: map[string]any{
+				"maxPages": cfg.MaxPages,
+			},
+		}
+	}
+	if cfg.MaxItems < 0 || cfg.MaxItems > _validationAttrToolPaginationMaxItems {
+		return erre.Error{
+			Code:    erre.ErrorCodeBadRequest,
+			Message: fmt.Sprintf("pagination max items must be between 0 and %d", _validationAttrToolPaginationMaxItems),
+			Data: map[string]any{
+				"maxItems": cfg.MaxItems,
+			},
+		}
+	}
+	if cfg.PageSize < 0 || cfg.StartPage < 0 {
+		return erre.Error{
+			Code:    erre.ErrorCodeBadRequest,
+			Message: "pagination page size and start page must not be negative",
+		}
+	}
+
+	if cfg.ItemsPath != "" && !_regexValidationJSONPath.MatchString(cfg.ItemsPath) {
+		return erre.Error{
+			Code:    erre.ErrorCodeBadRequest,
+			Message: "pagination items path must be a JSONPath like $.data.items",
+			Data: map[string]any{
+				"itemsPath": cfg.ItemsPath,
+			},
+		}
+	}
+
+	switch cfg.Type {
+	case entity.PaginationTypeCursor:
+		if !_regexValidationJSONPath.MatchString(cfg.CursorPath) {
+			return erre.Error{
+				Code:    erre.ErrorCodeBadRequest,
+				Message: "pagination cursor path must be a JSONPath like $.meta.nextCursor",
+				Data: map[string]any{
+					"cursorPath": cfg.CursorPath,
+				},
+			}
+		}
+		fallthrough
+	case entity.PaginationTypeOffset, entity.PaginationTypePage:
+		if cfg.Param == "" {
+			return erre.Error{
+				Code:    erre.ErrorCodeBadRequest,
+				Message: fmt.Sprintf("pagination param is required for the %s type", cfg.Type.String()),
+			}
+		}
+	}
+	return nil
+}
+
+func validateToolCacheConfig(cfg *entity.ToolCacheConfig) error {
+	if cfg == nil {
+		return nil
+	}
+
+	if cfg.TTLSeconds < 0 || cfg.TTLSeconds > _validationAttrToolCacheTTLMax {
+		return erre.Error{
+			Code:    erre.ErrorCodeBadRequest,
+			Message: fmt.Sprintf("cache TTL must be between 0 and %d seconds", _validationAttrToolCacheTTLMax),
+			Data: map[string]any{
+				"ttlSeconds": cfg.TTLSeconds,
+			},
+		}
+	}
+	if len(cfg.VaryHeaders) > _validationAttrToolCacheVaryHeadersMax {
+		return erre.Error{
+			Code:    erre.ErrorCodeBadRequest,
+			Message: fmt.Sprintf("cache vary headers must have at most %d items", _validationAttrToolCacheVaryHeadersMax),
+			Data: map[string]any{
+				"varyHeadersCount": len(cfg.VaryHeaders),
+			},
+		}
+	}
+	for _, h := range cfg.VaryHeaders {
+		if !_regexValidationHeaderName.MatchString(h) {
+			return erre.Error{
+				Code:    erre.ErrorCodeBadRequest,
+				Message: "cache vary header must be a valid header name",
+				Data: map[string]any{
+					"header": h,
+				},
+			}
+		}
+	}
+	return nil
+}
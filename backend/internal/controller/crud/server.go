@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"regexp"
+	"strings"
 	"time"
 
 	entity "github.com/hasmcp/hasmcp-ce/backend/internal/data/entity/crud"
@@ -27,7 +28,7 @@ const (
 
 	_validationAttrServerNameMaxLength         = 16
 	_validationAttrServerInstructionsMaxLength = 4096
-	_validationAttrServerProvidersMax          = 1
+	_validationAttrServerProvidersMax          = 16
 )
 
 var (
@@ -45,6 +46,16 @@ func (c *controller) CreateServer(ctx context.Context, req entity.CreateServerRe
 	server.CreatedAt = now
 	server.UpdatedAt = now
 	server.Version = _initialServerVersion
+	if server.InputSchemaMode == entity.InputSchemaModeInvalid {
+		server.InputSchemaMode = entity.InputSchemaModeNested
+	}
+	if server.TrafficMode == entity.TrafficModeInvalid {
+		server.TrafficMode = entity.TrafficModeLive
+	}
+
+	if err := c.validateServerToolNames(ctx, server); err != nil {
+		return nil, err
+	}
 
 	s := modelmapper.FromServerEntityServerModel(server)
 
@@ -62,13 +73,29 @@ func (c *controller) UpdateServer(ctx context.Context, req entity.UpdateServerRe
 	}
 
 	// Check if server exists
-	_, err := c.GetServer(ctx, entity.GetServerRequest{
+	current, err := c.GetServer(ctx, entity.GetServerRequest{
 		ID: req.Server.ID,
 	})
 	if err != nil {
 		return nil, err
 	}
 
+	if req.Server.InputSchemaMode == entity.InputSchemaModeInvalid {
+		req.Server.InputSchemaMode = current.Server.InputSchemaMode
+	}
+	if req.Server.TrafficMode == entity.TrafficModeInvalid {
+		req.Server.TrafficMode = current.Server.TrafficMode
+	}
+
+	// Keep the tool overrides of the tools which stay on the server
+	if req.Server.ToolOverrides == nil {
+		req.Server.ToolOverrides = current.Server.ToolOverrides
+	}
+
+	if err := c.validateServerToolNames(ctx, req.Server); err != nil {
+		return nil, err
+	}
+
 	s := modelmapper.FromServerEntityServerModel(req.Server)
 
 	ctx = c.storage.ContextWithTx(ctx)
@@ -80,6 +107,9 @@ func (c *controller) UpdateServer(ctx context.Context, req entity.UpdateServerRe
 		model.ServerAttributeResources:                  s.Resources,
 		model.ServerAttributePrompts:                    s.Prompts,
 		model.ServerAttributeRequestHeadersProxyEnabled: s.RequestHeadersProxyEnabled,
+		model.ServerAttributeInputSchemaMode:            s.InputSchemaMode,
+		model.ServerAttributeMockEnabled:                s.MockEnabled,
+		model.ServerAttributeTrafficMode:                s.TrafficMode,
 	})
 
 	if err != nil {
@@ -213,6 +243,67 @@ func (c *controller) DeleteServer(ctx context.Context, req entity.DeleteServerRe
 	return nil
 }
 
+// validateServerToolNames rejects the tools of the different providers which
+// are exposed with the same name on the server
+func (c *controller) validateServerToolNames(ctx context.Context, s entity.Server) error {
+	type exposedTool struct {
+		toolID     int64
+		providerID int64
+	}
+
+	names := make(map[string]exposedTool)
+	for _, p := range s.Providers {
+		if len(p.Tools) == 0 {
+			continue
+		}
+		provider, err := c.storage.GetProvider(ctx, p.ID)
+		if err != nil {
+			return erre.Error{
+				Code:    erre.ErrorCodeUnprocessableEntity,
+				Message: "provider does not exist",
+				Data: map[string]any{
+					"reason":     err.Error(),
+					"providerID": p.ID,
+				},
+			}
+		}
+		toolNames := make(map[int64]string, len(provider.Tools))
+		for _, t := range provider.Tools {
+			toolNames[t.ID] = t.Name
+		}
+
+		for _, t := range p.Tools {
+			name := s.ToolOverrides[t.ID].Name
+			if name == "" {
+				name = toolNames[t.ID]
+			}
+			if name == "" {
+				continue
+			}
+
+			key := strings.ToLower(name)
+			existing, ok := names[key]
+			if ok && existing.providerID != p.ID {
+				return erre.Error{
+					Code:    erre.ErrorCodeConflict,
+					Message: "tool name collides with a tool of another provider on the server",
+					Data: map[string]any{
+						"name":                  name,
+						"toolID":                t.ID,
+						"providerID":            p.ID,
+						"conflictingToolID":     existing.toolID,
+						"conflictingProviderID": existing.providerID,
+					},
+				}
+			}
+			if !ok {
+				names[key] = exposedTool{toolID: t.ID, providerID: p.ID}
+			}
+		}
+	}
+	return nil
+}
+
 func (c *controller) validateCreateServerRequest(req entity.CreateServerRequest) error {
 	s := req.Server
 	if len(s.Name) == 0 || len(s.Name) > _validationAttrServerNameMaxLength {
@@ -0,0 +1,35 @@
+package crud
+
+import (
+	"context"
+
+	"github.com/hasmcp/hasmcp-ce/backend/internal/controller/mcp"
+	entity "github.com/hasmcp/hasmcp-ce/backend/internal/data/entity/crud"
+	erre "github.com/hasmcp/hasmcp-ce/backend/internal/data/entity/err"
+)
+
+type ServerCacheController interface {
+	PurgeServerCache(ctx context.Context, req entity.PurgeServerCacheRequest) error
+}
+
+func (c *controller) PurgeServerCache(ctx context.Context, req entity.PurgeServerCacheRequest) error {
+	if req.ServerID <= 0 || req.ToolID < 0 {
+		return erre.Error{
+			Code:    erre.ErrorCodeBadRequest,
+			Message: "invalid server or tool ID",
+		}
+	}
+
+	// Check if server exists
+	_, err := c.GetServer(ctx, entity.GetServerRequest{
+		ID: req.ServerID,
+	})
+	if err != nil {
+		return err
+	}
+
+	return c.mcp.PurgeResponseCache(ctx, mcp.PurgeResponseCacheRequest{
+		ServerID: req.ServerID,
+		ToolID:   req.ToolID,
+	})
+}
@@ -0,0 +1,137 @@
+package crud
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	entity "github.com/hasmcp/hasmcp-ce/backend/internal/data/entity/crud"
+	erre "github.com/hasmcp/hasmcp-ce/backend/internal/data/entity/err"
+	"github.com/hasmcp/hasmcp-ce/backend/internal/data/model"
+	modelmapper "github.com/hasmcp/hasmcp-ce/backend/internal/mapper/model"
+)
+
+const (
+	_validationAttrServerRecordingsMax = 1000
+)
+
+type ServerRecordingController interface {
+	ListServerRecordings(ctx context.Context, req entity.ListServerRecordingsRequest) (*entity.ListServerRecordingsResponse, error)
+	ImportServerRecordings(ctx context.Context, req entity.ImportServerRecordingsRequest) (*entity.ImportServerRecordingsResponse, error)
+	DeleteServerRecordings(ctx context.Context, req entity.DeleteServerRecordingsRequest) error
+}
+
+func (c *controller) ListServerRecordings(ctx context.Context, req entity.ListServerRecordingsRequest) (*entity.ListServerRecordingsResponse, error) {
+	if req.ServerID <= 0 {
+		return nil, fmt.Errorf("invalid server ID")
+	}
+
+	dts, err := c.storage.ListServerRecordings(ctx, req.ServerID)
+	if err != nil {
+		return nil, erre.Error{
+			Code:    erre.ErrorCodeInternalServerError,
+			Message: "failed to list server recordings",
+			Data: map[string]any{
+				"reason":   err.Error(),
+				"serverID": req.ServerID,
+			},
+		}
+	}
+
+	return &entity.ListServerRecordingsResponse{
+		Recordings: modelmapper.FromServerRecordingModelsToServerRecordingEntities(dts),
+	}, nil
+}
+
+// ImportServerRecordings adds the exported recordings to the server, the
+// recordings get new ids so that they can be imported to another server
+func (c *controller) ImportServerRecordings(ctx context.Context, req entity.ImportServerRecordingsRequest) (*entity.ImportServerRecordingsResponse, error) {
+	if err := c.validateImportServerRecordingsRequest(req); err != nil {
+		return nil, err
+	}
+
+	// Check if server exists
+	_, err := c.GetServer(ctx, entity.GetServerRequest{
+		ID: req.ServerID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	recordings := make([]entity.ServerRecording, len(req.Recordings))
+	dts := make([]model.ServerRecording, len(req.Recordings))
+	for i, r := range req.Recordings {
+		r.ID = c.idgen.Next()
+		r.CreatedAt = now
+		r.ServerID = req.ServerID
+		recordings[i] = r
+		dts[i] = modelmapper.FromServerRecordingEntityToServerRecordingModel(r)
+	}
+
+	if err := c.storage.CreateServerRecordings(ctx, dts); err != nil {
+		return nil, erre.Error{
+			Code:    erre.ErrorCodeInternalServerError,
+			Message: "failed to import server recordings",
+			Data: map[string]any{
+				"reason":   err.Error(),
+				"serverID": req.ServerID,
+			},
+		}
+	}
+
+	return &entity.ImportServerRecordingsResponse{
+		Recordings: recordings,
+	}, nil
+}
+
+func (c *controller) DeleteServerRecordings(ctx context.Context, req entity.DeleteServerRecordingsRequest) error {
+	if req.ServerID <= 0 {
+		return erre.Error{
+			Code:    erre.ErrorCodeBadRequest,
+			Message: "invalid server ID",
+		}
+	}
+
+	if err := c.storage.DeleteServerRecordings(ctx, req.ServerID); err != nil {
+		return erre.Error{
+			Code:    erre.ErrorCodeInternalServerError,
+			Message: "failed to delete server recordings",
+			Data: map[string]any{
+				"reason":   err.Error(),
+				"serverID": req.ServerID,
+			},
+		}
+	}
+	return nil
+}
+
+func (c *controller) validateImportServerRecordingsRequest(req entity.ImportServerRecordingsRequest) error {
+	if req.ServerID <= 0 {
+		return erre.Error{
+			Code:    erre.ErrorCodeBadRequest,
+			Message: "invalid server ID",
+		}
+	}
+	if len(req.Recordings) == 0 || len(req.Recordings) > _validationAttrServerRecordingsMax {
+		return erre.Error{
+			Code:    erre.ErrorCodeBadRequest,
+			Message: fmt.Sprintf("recordings must have between 1 and %d items", _validationAttrServerRecordingsMax),
+			Data: map[string]any{
+				"recordingsCount": len(req.Recordings),
+			},
+		}
+	}
+	for i, r := range req.Recordings {
+		if r.ToolID <= 0 {
+			return erre.Error{
+				Code:    erre.ErrorCodeBadRequest,
+				Message: "recording tool ID is required",
+				Data: map[string]any{
+					"index": i,
+				},
+			}
+		}
+	}
+	return nil
+}
@@ -51,6 +51,7 @@ func (c *controller) CreateServerToken(
 			Scope:    scope,
 			RegisteredClaims: jwtv5.RegisteredClaims{
 				ID:        c.idgen.NextString(),
+				Subject:   t.Subject,
 				ExpiresAt: jwtv5.NewNumericDate(t.ExpiresAt),
 				Issuer:    _jwtTokenIssuer,
 				IssuedAt:  jwtv5.NewNumericDate(time.Now()),
@@ -74,6 +75,7 @@ func (c *controller) CreateServerToken(
 			CreatedAt:   t.CreatedAt,
 			ExpiresAt:   t.ExpiresAt,
 			Scope:       scope,
+			Subject:     t.Subject,
 			ActualValue: []byte(tokenRes.Token),
 		},
 	}, nil
@@ -100,5 +102,16 @@ func (c *controller) validateCreateServerTokenRequest(req entity.CreateServerTok
 			},
 		}
 	}
+
+	if len(t.Subject) > _validationAttrVariableSubjectMaxLength {
+		return erre.Error{
+			Code:    erre.ErrorCodeBadRequest,
+			Message: "subject exceeds maximum length",
+			Data: map[string]any{
+				"serverID": t.ServerID,
+				"max":      _validationAttrVariableSubjectMaxLength,
+			},
+		}
+	}
 	return nil
 }
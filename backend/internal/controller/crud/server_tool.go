@@ -8,11 +8,17 @@ import (
 	entity "github.com/hasmcp/hasmcp-ce/backend/internal/data/entity/crud"
 	erre "github.com/hasmcp/hasmcp-ce/backend/internal/data/entity/err"
 	"github.com/hasmcp/hasmcp-ce/backend/internal/data/model"
+	modelmapper "github.com/hasmcp/hasmcp-ce/backend/internal/mapper/model"
 	"gorm.io/gorm"
 )
 
+const (
+	_validationAttrServerToolOverrideNameMaxLength = 32
+)
+
 type ServerToolController interface {
 	CreateServerTool(ctx context.Context, req entity.CreateServerToolRequest) (*entity.CreateServerToolResponse, error)
+	UpdateServerTool(ctx context.Context, req entity.UpdateServerToolRequest) (*entity.UpdateServerToolResponse, error)
 	DeleteServerTool(ctx context.Context, req entity.DeleteServerToolRequest) error
 	ListServerTools(ctx context.Context, req entity.ListServerToolsRequest) (*entity.ListServerToolsResponse, error)
 }
@@ -26,7 +32,7 @@ func (c *controller) CreateServerTool(
 	e := req.Tool
 
 	// Check if server exists
-	_, err := c.GetServer(ctx, entity.GetServerRequest{
+	current, err := c.GetServer(ctx, entity.GetServerRequest{
 		ID: e.ServerID,
 	})
 	if err != nil {
@@ -46,10 +52,25 @@ func (c *controller) CreateServerTool(
 		}
 	}
 
+	server := withServerTool(current.Server, tool.ProviderID, e.ToolID, e.Override)
+	if len(server.Providers) > _validationAttrServerProvidersMax {
+		return nil, erre.Error{
+			Code:    erre.ErrorCodeBadRequest,
+			Message: fmt.Sprintf("max providers allowed per MCP server is set to %d", _validationAttrServerProvidersMax),
+			Data: map[string]any{
+				"providersCount": len(server.Providers),
+			},
+		}
+	}
+	if err := c.validateServerToolNames(ctx, server); err != nil {
+		return nil, err
+	}
+
 	dt := model.ServerTool{
 		ServerID:   e.ServerID,
 		ProviderID: tool.ProviderID,
 		ToolID:     e.ToolID,
+		Override:   modelmapper.FromServerToolOverrideEntityToModel(e.Override),
 	}
 	err = c.storage.AddToolToServer(ctx, dt)
 	if err != nil {
@@ -83,7 +104,75 @@ func (c *controller) CreateServerTool(
 		ResourceOwnerID: e.ServerID,
 	})
 
-	return &entity.CreateServerToolResponse{}, nil
+	return &entity.CreateServerToolResponse{
+		Tool: modelmapper.FromServerToolModelToServerToolEntity(dt),
+	}, nil
+}
+
+func (c *controller) UpdateServerTool(
+	ctx context.Context, req entity.UpdateServerToolRequest) (*entity.UpdateServerToolResponse, error) {
+	if err := c.validateUpdateServerToolRequest(req); err != nil {
+		return nil, err
+	}
+
+	e := req.Tool
+	if e.Override.Name != "" {
+		current, err := c.GetServer(ctx, entity.GetServerRequest{
+			ID: e.ServerID,
+		})
+		if err != nil {
+			return nil, err
+		}
+		server := current.Server
+		server.ToolOverrides = make(map[int64]entity.ServerToolOverride, len(current.Server.ToolOverrides)+1)
+		for id, o := range current.Server.ToolOverrides {
+			server.ToolOverrides[id] = o
+		}
+		server.ToolOverrides[e.ToolID] = e.Override
+		if err := c.validateServerToolNames(ctx, server); err != nil {
+			return nil, err
+		}
+	}
+
+	dt := model.ServerTool{
+		ServerID: e.ServerID,
+		ToolID:   e.ToolID,
+		Override: modelmapper.FromServerToolOverrideEntityToModel(e.Override),
+	}
+	err := c.storage.UpdateServerToolOverride(ctx, dt)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, erre.Error{
+				Code:    erre.ErrorCodeNotFound,
+				Message: "server tool not found",
+				Data: map[string]any{
+					"serverID": e.ServerID,
+					"toolID":   e.ToolID,
+				},
+			}
+		}
+		return nil, erre.Error{
+			Code:    erre.ErrorCodeInternalServerError,
+			Message: "failed to update server tool",
+			Data: map[string]any{
+				"reason":   err.Error(),
+				"serverID": e.ServerID,
+				"toolID":   e.ToolID,
+			},
+		}
+	}
+
+	c.cache.Evict(ctx, entity.ObjectTypeServer, e.ServerID)
+	_ = c.mcp.HandleChanges(ctx, entity.ResourceChange{
+		ObjectType:      entity.ObjectTypeServerTool,
+		EventType:       entity.ObjectEventTypeUpdate,
+		ResoureID:       e.ToolID,
+		ResourceOwnerID: e.ServerID,
+	})
+
+	return &entity.UpdateServerToolResponse{
+		Tool: e,
+	}, nil
 }
 
 func (c *controller) DeleteServerTool(ctx context.Context, req entity.DeleteServerToolRequest) error {
@@ -138,11 +227,7 @@ func (c *controller) ListServerTools(ctx context.Context, req entity.ListServerT
 
 	entities := make([]entity.ServerTool, 0, len(dts))
 	for _, dt := range dts {
-		entities = append(entities, entity.ServerTool{
-			ProviderID: dt.ProviderID,
-			ToolID:     dt.ToolID,
-			ServerID:   dt.ServerID,
-		})
+		entities = append(entities, modelmapper.FromServerToolModelToServerToolEntity(dt))
 	}
 
 	return &entity.ListServerToolsResponse{
@@ -150,6 +235,35 @@ func (c *controller) ListServerTools(ctx context.Context, req entity.ListServerT
 	}, nil
 }
 
+// withServerTool returns the server with the tool added to its provider
+func withServerTool(s entity.Server, providerID, toolID int64, override entity.ServerToolOverride) entity.Server {
+	providers := make([]entity.Provider, len(s.Providers), len(s.Providers)+1)
+	copy(providers, s.Providers)
+	index := -1
+	for i, p := range providers {
+		if p.ID == providerID {
+			index = i
+			break
+		}
+	}
+	if index < 0 {
+		index = len(providers)
+		providers = append(providers, entity.Provider{ID: providerID})
+	}
+	tools := make([]entity.ProviderTool, len(providers[index].Tools), len(providers[index].Tools)+1)
+	copy(tools, providers[index].Tools)
+	providers[index].Tools = append(tools, entity.ProviderTool{ID: toolID})
+	s.Providers = providers
+
+	overrides := make(map[int64]entity.ServerToolOverride, len(s.ToolOverrides)+1)
+	for id, o := range s.ToolOverrides {
+		overrides[id] = o
+	}
+	overrides[toolID] = override
+	s.ToolOverrides = overrides
+	return s
+}
+
 func (c *controller) validateCreateServerToolRequest(req entity.CreateServerToolRequest) error {
 	e := req.Tool
 	if e.ServerID <= 0 {
@@ -158,6 +272,75 @@ func (c *controller) validateCreateServerToolRequest(req entity.CreateServerTool
 	if e.ToolID <= 0 {
 		return fmt.Errorf("tool ID must be greater than 0")
 	}
+	return validateServerToolOverride(e.Override)
+}
+
+func (c *controller) validateUpdateServerToolRequest(req entity.UpdateServerToolRequest) error {
+	e := req.Tool
+	if e.ServerID <= 0 {
+		return fmt.Errorf("server ID must be greater than 0")
+	}
+	if e.ToolID <= 0 {
+		return fmt.Errorf("tool ID must be greater than 0")
+	}
+	return validateServerToolOverride(e.Override)
+}
+
+func validateServerToolOverride(o entity.ServerToolOverride) error {
+	if len(o.Name) > _validationAttrServerToolOverrideNameMaxLength {
+		return erre.Error{
+			Code:    erre.ErrorCodeBadRequest,
+			Message: fmt.Sprintf("override name must be at most %d characters", _validationAttrServerToolOverrideNameMaxLength),
+		}
+	}
+
+	if o.InputSchemaMode >= entity.InputSchemaModeInvalidMax {
+		return erre.Error{
+			Code:    erre.ErrorCodeBadRequest,
+			Message: "input schema mode must be one of NESTED or FLAT",
+		}
+	}
+
+	seen := make(map[string]struct{}, len(o.Arguments))
+	for _, a := range o.Arguments {
+		if a.In == entity.ToolArgumentLocationInvalid || a.In > entity.ToolArgumentLocationBody {
+			return erre.Error{
+				Code:    erre.ErrorCodeBadRequest,
+				Message: "argument location must be one of PATH, QUERY or BODY",
+				Data: map[string]any{
+					"name": a.Name,
+				},
+			}
+		}
+		if a.Name == "" {
+			return erre.Error{
+				Code:    erre.ErrorCodeBadRequest,
+				Message: "argument name is required",
+			}
+		}
+		key := a.In.String() + "." + a.Name
+		if _, ok := seen[key]; ok {
+			return erre.Error{
+				Code:    erre.ErrorCodeBadRequest,
+				Message: "argument is overridden more than once",
+				Data: map[string]any{
+					"name": a.Name,
+					"in":   a.In.String(),
+				},
+			}
+		}
+		seen[key] = struct{}{}
+		if a.Hidden && a.Value == nil {
+			return erre.Error{
+				Code:    erre.ErrorCodeBadRequest,
+				Message: "hidden argument requires a value",
+				Data: map[string]any{
+					"name": a.Name,
+					"in":   a.In.String(),
+				},
+			}
+		}
+	}
 	return nil
 }
 
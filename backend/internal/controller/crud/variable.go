@@ -22,7 +22,8 @@ type VariableController interface {
 }
 
 const (
-	_validationAttrVariableNameMaxLength = 128
+	_validationAttrVariableNameMaxLength    = 128
+	_validationAttrVariableSubjectMaxLength = 128
 )
 
 var (
@@ -232,9 +233,15 @@ func (c *controller) UpdateVariable(ctx context.Context, req entity.UpdateVariab
 }
 
 func (c *controller) SaveVariable(ctx context.Context, req entity.SaveVariableRequest) error {
-	currentVariable, err := c.storage.GetVariableByName(ctx, req.Variable.Name)
-	if err == nil && currentVariable != nil && currentVariable.ID != 0 {
-		defer c.cache.Evict(ctx, entity.ObjectTypeVariable, currentVariable.ID)
+	subject := req.Variable.Subject
+	if subject != "" {
+		// the variables of a user are cached together
+		defer c.cache.EvictUserVariables(ctx, subject)
+	} else {
+		currentVariable, err := c.storage.GetVariableByName(ctx, req.Variable.Name)
+		if err == nil && currentVariable != nil && currentVariable.ID != 0 {
+			defer c.cache.Evict(ctx, entity.ObjectTypeVariable, currentVariable.ID)
+		}
 	}
 
 	if err := c.validateSaveVariableRequest(req); err != nil {
@@ -267,10 +274,11 @@ func (c *controller) SaveVariable(ctx context.Context, req entity.SaveVariableRe
 		Name:      variable.Name,
 		Value:     val,
 		Nonce:     nonce,
+		Subject:   subject,
 		CreatedAt: now,
 		UpdatedAt: now,
 	}
-	err = c.storage.SaveVariable(ctx, v)
+	err := c.storage.SaveVariable(ctx, v)
 	if err != nil {
 		return erre.Error{
 			Code:    500,
@@ -286,6 +294,9 @@ func (c *controller) validateCreateVariableRequest(req entity.CreateVariableRequ
 }
 
 func (c *controller) validateSaveVariableRequest(req entity.SaveVariableRequest) error {
+	if len(req.Variable.Subject) > _validationAttrVariableSubjectMaxLength {
+		return errors.New("subject exceeds maximum length")
+	}
 	return c.validateVariable(req.Variable)
 }
 
@@ -0,0 +1,342 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	protocol "github.com/hasmcp/hasmcp-ce/backend/internal/controller/mcp/protocol/p250618"
+	entity "github.com/hasmcp/hasmcp-ce/backend/internal/data/entity/crud"
+	"github.com/hasmcp/hasmcp-ce/backend/internal/data/entity/jsonrpc"
+)
+
+const (
+	_argsAsyncHandle = "asyncHandle"
+	_metaKeyAsync    = "hasmcp/async"
+
+	_asyncStatusPending   = "PENDING"
+	_asyncDefaultInterval = 2 * time.Second
+	_asyncDefaultMaxWait  = time.Minute
+	_asyncMinInterval     = 500 * time.Millisecond
+
+	_headerLocation   = "Location"
+	_headerRetryAfter = "Retry-After"
+)
+
+var (
+	_regexJSONPathSegment = regexp.MustCompile(`\.([^.\[\]]+)|\[(\d+)\]|\['([^']+)'\]`)
+
+	_asyncHandleSchema = map[string]any{
+		"type":        "string",
+		"description": "Handle of a pending operation returned by a previous call, the call waits for the operation instead of starting a new one",
+	}
+)
+
+// awaitAsync follows the operation accepted with the 202 status. The status
+// URL is taken from the body or the Location header of the accepted response.
+func (c *controller) awaitAsync(
+	ctx context.Context,
+	provider *entity.Provider,
+	tool *entity.ProviderTool,
+	headers http.Header,
+	authQuery url.Values,
+	res *http.Response,
+	resBody []byte,
+) (*protocol.CallToolResult, error) {
+	var location string
+	if path := tool.AsyncConfig.StatusURLPath; path != "" {
+		location = asyncStringAt(resBody, path)
+	} else {
+		location = res.Header.Get(_headerLocation)
+	}
+	if location == "" {
+		// nothing to follow, the accepted response is the result
+		return asyncResult(resBody, false), nil
+	}
+
+	statusURL, err := c.providerURLOf(provider, res.Request.URL, location)
+	if err != nil {
+		return nil, err
+	}
+	return c.pollAsync(ctx, provider, tool, headers, authQuery, statusURL, retryAfterOf(res, tool.AsyncConfig))
+}
+
+// resumeAsync waits for the operation of the handle returned by a previous
+// call which exceeded the wait
+func (c *controller) resumeAsync(
+	ctx context.Context,
+	provider *entity.Provider,
+	tool *entity.ProviderTool,
+	headers http.Header,
+	authQuery url.Values,
+	handle string,
+) (*protocol.CallToolResult, error) {
+	base, err := url.Parse(provider.BaseURL)
+	if err != nil {
+		return nil, jsonrpc.Error{
+			Code:    jsonrpc.ErrCodeInternalError,
+			Message: "Provider base URL is malformed",
+			Data: map[string]any{
+				"reason": err.Error(),
+			},
+		}
+	}
+	statusURL, err := c.providerURLOf(provider, base, handle)
+	if err != nil {
+		return nil, err
+	}
+	return c.pollAsync(ctx, provider, tool, headers, authQuery, statusURL, 0)
+}
+
+// pollAsync polls the status URL until the failure or the success predicate
+// matches. The progress is notified on every pending status and a pollable
+// handle is returned once the next poll would exceed the max wait.
+func (c *controller) pollAsync(
+	ctx context.Context,
+	provider *entity.Provider,
+	tool *entity.ProviderTool,
+	headers http.Header,
+	authQuery url.Values,
+	statusURL *url.URL,
+	wait time.Duration,
+) (*protocol.CallToolResult, error) {
+	cfg := tool.AsyncConfig
+	deadline := time.Now().Add(secondsOr(cfg.MaxWaitSeconds, _asyncDefaultMaxWait))
+
+	for attempt := 1; ; attempt++ {
+		if time.Now().Add(wait).After(deadline) {
+			return asyncPendingResult(statusURL), nil
+		}
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, jsonrpc.Error{
+				Code:    jsonrpc.ErrCodeInternalError,
+				Message: "Async operation wait is cancelled",
+				Data: map[string]any{
+					"reason":      ctx.Err().Error(),
+					"asyncHandle": statusURL.String(),
+				},
+			}
+		case <-timer.C:
+		}
+
+		res, body, err := c.getAsync(ctx, provider, tool, headers, authQuery, statusURL)
+		if err != nil {
+			return nil, err
+		}
+
+		// a redirect of the status URL points to the result of the operation
+		redirected := res.Request != nil && res.Request.URL.Path != statusURL.Path
+		switch {
+		case res.StatusCode >= http.StatusBadRequest, asyncPredicateMatches(cfg.Failure, body):
+			return asyncResult(body, true), nil
+		case redirected,
+			cfg.Success == nil && res.StatusCode != http.StatusAccepted,
+			asyncPredicateMatches(cfg.Success, body):
+			return c.asyncFinalResult(ctx, provider, tool, headers, authQuery, statusURL, body)
+		}
+
+		c.notifyProgress(ctx, float64(attempt), "Waiting for the operation to complete")
+		wait = retryAfterOf(res, cfg)
+	}
+}
+
+// asyncFinalResult fetches the result URL of the completed operation, the
+// status body is the result when there is no result URL
+func (c *controller) asyncFinalResult(
+	ctx context.Context,
+	provider *entity.Provider,
+	tool *entity.ProviderTool,
+	headers http.Header,
+	authQuery url.Values,
+	statusURL *url.URL,
+	body []byte,
+) (*protocol.CallToolResult, error) {
+	path := tool.AsyncConfig.ResultURLPath
+	if path == "" {
+		return asyncResult(body, false), nil
+	}
+	location := asyncStringAt(body, path)
+	if location == "" {
+		return asyncResult(body, false), nil
+	}
+
+	resultURL, err := c.providerURLOf(provider, statusURL, location)
+	if err != nil {
+		return nil, err
+	}
+	res, resBody, err := c.getAsync(ctx, provider, tool, headers, authQuery, resultURL)
+	if err != nil {
+		return nil, err
+	}
+	return asyncResult(resBody, res.StatusCode >= http.StatusBadRequest), nil
+}
+
+func (c *controller) getAsync(
+	ctx context.Context,
+	provider *entity.Provider,
+	tool *entity.ProviderTool,
+	headers http.Header,
+	authQuery url.Values,
+	target *url.URL,
+) (*http.Response, []byte, error) {
+	u := *target
+	withQuery(&u, authQuery)
+
+	req := &http.Request{
+		Method: http.MethodGet,
+		URL:    &u,
+		Header: headers.Clone(),
+		Body:   http.NoBody,
+	}
+	res, err := c.doHTTP(ctx, provider, tool.Oauth2Scopes, req, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer res.Body.Close()
+	body, _ := io.ReadAll(res.Body)
+	return res, body, nil
+}
+
+func asyncResult(body []byte, isError bool) *protocol.CallToolResult {
+	result := &protocol.CallToolResult{
+		Content: []protocol.ContentBlock{
+			protocol.TextContent{
+				Text: string(body),
+				Type: "text",
+			},
+		},
+	}
+	if isError {
+		result.IsError = &isError
+	}
+	return result
+}
+
+// asyncPendingResult returns the handle to wait for the operation with
+// another call of the tool
+func asyncPendingResult(statusURL *url.URL) *protocol.CallToolResult {
+	handle := statusURL.String()
+	text, _ := json.Marshal(map[string]any{
+		"status":         _asyncStatusPending,
+		_argsAsyncHandle: handle,
+		"message":        fmt.Sprintf("The operation is still running, call the tool again with the %s argument to keep waiting for it.", _argsAsyncHandle),
+	})
+	return &protocol.CallToolResult{
+		Meta: protocol.CallToolResultMeta{
+			_metaKeyAsync: map[string]any{
+				"status":         _asyncStatusPending,
+				_argsAsyncHandle: handle,
+			},
+		},
+		Content: []protocol.ContentBlock{
+			protocol.TextContent{
+				Text: string(text),
+				Type: "text",
+			},
+		},
+	}
+}
+
+// retryAfterOf returns the wait before the next poll, the Retry-After seconds
+// of the response take precedence over the configured interval
+func retryAfterOf(res *http.Response, cfg *entity.ToolAsyncConfig) time.Duration {
+	if seconds, err := strconv.Atoi(res.Header.Get(_headerRetryAfter)); err == nil && seconds >= 0 {
+		return max(time.Duration(seconds)*time.Second, _asyncMinInterval)
+	}
+	return max(secondsOr(cfg.IntervalSeconds, _asyncDefaultInterval), _asyncMinInterval)
+}
+
+func secondsOr(seconds int, def time.Duration) time.Duration {
+	if seconds <= 0 {
+		return def
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+// asyncPredicateMatches reports whether the value at the predicate path
+// equals one of its values, or exists when it has no values
+func asyncPredicateMatches(p *entity.ToolAsyncPredicate, body []byte) bool {
+	if p == nil {
+		return false
+	}
+	var doc any
+	if json.Unmarshal(body, &doc) != nil {
+		return false
+	}
+	v, ok := lookupJSONPath(doc, p.Path)
+	if !ok || v == nil {
+		return false
+	}
+	if len(p.Values) == 0 {
+		return true
+	}
+	for _, want := range p.Values {
+		if mockStringOf(v) == mockStringOf(want) {
+			return true
+		}
+	}
+	return false
+}
+
+func asyncStringAt(body []byte, path string) string {
+	var doc any
+	if json.Unmarshal(body, &doc) != nil {
+		return ""
+	}
+	v, _ := lookupJSONPath(doc, path)
+	s, _ := v.(string)
+	return s
+}
+
+// lookupJSONPath follows the member and the index segments of the JSONPath
+// like $.data.links[0]['href'], the filters and the wildcards are not
+// supported
+func lookupJSONPath(v any, path string) (any, bool) {
+	if !strings.HasPrefix(path, "$") {
+		return nil, false
+	}
+	rest := path[1:]
+	for rest != "" {
+		loc := _regexJSONPathSegment.FindStringSubmatchIndex(rest)
+		if loc == nil || loc[0] != 0 {
+			return nil, false
+		}
+		m := _regexJSONPathSegment.FindStringSubmatch(rest)
+		rest = rest[loc[1]:]
+
+		switch node := v.(type) {
+		case map[string]any:
+			key := m[1]
+			if m[3] != "" {
+				key = m[3]
+			}
+			if m[2] != "" && key == "" {
+				return nil, false
+			}
+			next, ok := node[key]
+			if !ok {
+				return nil, false
+			}
+			v = next
+		case []any:
+			i, err := strconv.Atoi(m[2])
+			if err != nil || i >= len(node) {
+				return nil, false
+			}
+			v = node[i]
+		default:
+			return nil, false
+		}
+	}
+	return v, true
+}
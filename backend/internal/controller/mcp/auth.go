@@ -0,0 +1,146 @@
+package mcp
+
+import (
+	"context"
+	"encoding/base64"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/hasmcp/hasmcp-ce/backend/internal/controller/cache"
+	entity "github.com/hasmcp/hasmcp-ce/backend/internal/data/entity/crud"
+	"github.com/hasmcp/hasmcp-ce/backend/internal/data/entity/jsonrpc"
+)
+
+const (
+	_headerAuthorization = "Authorization"
+	_headerCookie        = "Cookie"
+)
+
+type (
+	sessionCtxKey       struct{}
+	progressTokenCtxKey struct{}
+)
+
+// withSession binds the MCP session to the context to notify its stream
+func withSession(ctx context.Context, sessionID int64) context.Context {
+	return context.WithValue(ctx, sessionCtxKey{}, sessionID)
+}
+
+// sessionOf returns the MCP session bound to the context, zero if none
+func sessionOf(ctx context.Context) int64 {
+	sessionID, _ := ctx.Value(sessionCtxKey{}).(int64)
+	return sessionID
+}
+
+// withProgressToken binds the progress token of the request to the context
+func withProgressToken(ctx context.Context, token any) context.Context {
+	if token == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, progressTokenCtxKey{}, token)
+}
+
+// progressTokenOf returns the progress token of the request, nil if the
+// client did not ask for the progress
+func progressTokenOf(ctx context.Context) any {
+	return ctx.Value(progressTokenCtxKey{})
+}
+
+// applyProviderAuth sets the credentials of the provider auth config on the
+// upstream request headers and returns the query values to add to the URL.
+// The tool and caller headers take precedence so that the existing per-tool
+// Authorization headers keep working.
+func applyProviderAuth(ctx context.Context, provider *entity.Provider, headers http.Header, cache cache.Controller) url.Values {
+	auth := provider.AuthConfig
+	if auth == nil {
+		return nil
+	}
+
+	switch auth.Type {
+	case entity.AuthTypeAPIKey:
+		value := entity.ReplaceVariables(ctx, auth.Value, cache)
+		switch auth.In {
+		case entity.AuthLocationHeader:
+			if headers.Get(auth.Name) == "" {
+				headers.Set(auth.Name, value)
+			}
+		case entity.AuthLocationQuery:
+			return url.Values{auth.Name: []string{value}}
+		case entity.AuthLocationCookie:
+			cookie := (&http.Cookie{Name: auth.Name, Value: value}).String()
+			if existing := headers.Get(_headerCookie); existing != "" {
+				cookie = existing + "; " + cookie
+			}
+			headers.Set(_headerCookie, cookie)
+		}
+	case entity.AuthTypeBasic:
+		if headers.Get(_headerAuthorization) == "" {
+			credentials := entity.ReplaceVariables(ctx, auth.Username, cache) + ":" + entity.ReplaceVariables(ctx, auth.Password, cache)
+			headers.Set(_headerAuthorization, "Basic "+base64.StdEncoding.EncodeToString([]byte(credentials)))
+		}
+	case entity.AuthTypeBearer, entity.AuthTypeOauth2:
+		if headers.Get(_headerAuthorization) != "" {
+			return nil
+		}
+		if auth.Type == entity.AuthTypeOauth2 && auth.Value == "" &&
+			provider.Oauth2Config.TokenURL != "" && !provider.Oauth2Config.GrantType.Interactive() {
+			// the grant token is set at call time
+			return nil
+		}
+		value := auth.Value
+		if value == "" && auth.Type == entity.AuthTypeOauth2 {
+			// the access token variable saved by the oauth2 callback
+			value = "${" + provider.SecretPrefix + "_ACCESS_TOKEN}"
+		}
+		if value = entity.ReplaceVariables(ctx, value, cache); value != "" {
+			headers.Set(_headerAuthorization, "Bearer "+value)
+		}
+	}
+	return nil
+}
+
+// withQuery adds the query values which are not set on the URL
+func withQuery(u *url.URL, values url.Values) {
+	if len(values) == 0 {
+		return
+	}
+	query := u.Query()
+	for k, vals := range values {
+		if !query.Has(k) {
+			query[k] = vals
+		}
+	}
+	u.RawQuery = query.Encode()
+}
+
+// providerURLOf resolves the location of a status, a result or a next page
+// URL returned by the upstream. The URL must be on the provider origin since
+// the provider credentials are sent to it.
+func (c *controller) providerURLOf(provider *entity.Provider, base *url.URL, location string) (*url.URL, error) {
+	ref, err := url.Parse(location)
+	if err != nil {
+		return nil, jsonrpc.Error{
+			Code:    jsonrpc.ErrCodeInvalidParams,
+			Message: "Upstream URL is malformed",
+			Data: map[string]any{
+				"reason": err.Error(),
+				"url":    location,
+			},
+		}
+	}
+	u := base.ResolveReference(ref)
+
+	origin, err := url.Parse(provider.BaseURL)
+	if err != nil || !strings.EqualFold(u.Scheme, origin.Scheme) || !strings.EqualFold(u.Host, origin.Host) {
+		return nil, jsonrpc.Error{
+			Code:    jsonrpc.ErrCodeInvalidParams,
+			Message: "Upstream URL is not on the provider origin",
+			Data: map[string]any{
+				"url":        u.String(),
+				"providerID": provider.ID,
+			},
+		}
+	}
+	return u, nil
+}
@@ -0,0 +1,62 @@
+package mcp
+
+import (
+	"context"
+	"net/http"
+
+	protocol "github.com/hasmcp/hasmcp-ce/backend/internal/controller/mcp/protocol/p250618"
+	entity "github.com/hasmcp/hasmcp-ce/backend/internal/data/entity/crud"
+	"github.com/hasmcp/hasmcp-ce/backend/internal/service/httpc"
+)
+
+const (
+	_metaKeyEgress = "hasmcp/egress"
+)
+
+// withProviderEgress carries the explicit egress allow of the provider to the
+// upstream requests
+func withProviderEgress(ctx context.Context, provider *entity.Provider) context.Context {
+	cfg := provider.EgressConfig
+	if cfg == nil {
+		return ctx
+	}
+	return httpc.WithEgressAllow(ctx, httpc.EgressAllow{
+		CIDRs: cfg.AllowedCIDRs,
+		Hosts: cfg.AllowedHosts,
+		Ports: cfg.AllowedPorts,
+	})
+}
+
+// ProviderHTTPClient returns the client of the provider for the libraries
+// making the calls themselves, e.g. the oauth2 token requests
+func (c *controller) ProviderHTTPClient(ctx context.Context, provider *entity.Provider) (*http.Client, error) {
+	ctx = withProviderEgress(ctx, provider)
+	opts, err := c.transportOf(ctx, provider)
+	if err != nil {
+		return nil, err
+	}
+	return c.httpc.Client(ctx, opts), nil
+}
+
+// egressDeniedResult reports the egress violation of the tool call as the
+// tool error so that the model sees why the call did not reach the provider
+func egressDeniedResult(provider *entity.Provider, egressErr *httpc.EgressError) *protocol.CallToolResult {
+	isError := true
+	return &protocol.CallToolResult{
+		IsError: &isError,
+		Meta: protocol.CallToolResultMeta{
+			_metaKeyEgress: map[string]any{
+				"providerID": provider.ID,
+				"host":       egressErr.Host,
+				"address":    egressErr.Address,
+				"reason":     egressErr.Reason,
+			},
+		},
+		Content: []protocol.ContentBlock{
+			protocol.TextContent{
+				Text: "The request to the provider is denied by the egress policy: " + egressErr.Error(),
+				Type: "text",
+			},
+		},
+	}
+}
@@ -0,0 +1,108 @@
+package mcp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+
+	protocol "github.com/hasmcp/hasmcp-ce/backend/internal/controller/mcp/protocol/p250618"
+	entity "github.com/hasmcp/hasmcp-ce/backend/internal/data/entity/crud"
+	"github.com/hasmcp/hasmcp-ce/backend/internal/data/entity/jsonrpc"
+)
+
+type (
+	graphQLRequest struct {
+		Query     string          `json:"query"`
+		Variables json.RawMessage `json:"variables"`
+	}
+
+	graphQLResponse struct {
+		Data   json.RawMessage   `json:"data,omitempty"`
+		Errors []json.RawMessage `json:"errors,omitempty"`
+	}
+)
+
+// callGraphQL posts the operation with the body arguments as its variables.
+// GraphQL errors are returned as tool errors along with the partial data.
+func (c *controller) callGraphQL(
+	ctx context.Context,
+	provider *entity.Provider,
+	tool *entity.ProviderTool,
+	endpoint string,
+	headers http.Header,
+	variables json.RawMessage,
+) (*protocol.CallToolResult, error) {
+	if len(variables) == 0 || string(variables) == "null" {
+		variables = json.RawMessage("{}")
+	}
+	payload, err := json.Marshal(graphQLRequest{
+		Query:     tool.Operation,
+		Variables: variables,
+	})
+	if err != nil {
+		return nil, jsonrpc.Error{
+			Code:    jsonrpc.ErrCodeInvalidParams,
+			Message: "GraphQL variables are malformed",
+			Data: map[string]any{
+				"reason": err.Error(),
+			},
+		}
+	}
+
+	remoteReq, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(payload))
+	if err != nil {
+		return nil, jsonrpc.Error{
+			Code:    jsonrpc.ErrCodeInternalError,
+			Message: "GraphQL endpoint is malformed",
+			Data: map[string]any{
+				"reason": err.Error(),
+			},
+		}
+	}
+	for k, vals := range headers {
+		for _, v := range vals {
+			remoteReq.Header.Add(k, v)
+		}
+	}
+	remoteReq.Header.Set("Content-Type", "application/json")
+	if remoteReq.Header.Get("Accept") == "" {
+		remoteReq.Header.Set("Accept", "application/json")
+	}
+
+	res, err := c.doHTTP(ctx, provider, tool.Oauth2Scopes, remoteReq, payload)
+	if err != nil {
+		return nil, err
+	}
+
+	body := res.Body
+	defer body.Close()
+	resBody, _ := io.ReadAll(body)
+
+	var gqlRes graphQLResponse
+	isError := res.StatusCode >= http.StatusBadRequest
+	if err := json.Unmarshal(resBody, &gqlRes); err != nil {
+		isError = true
+	} else if len(gqlRes.Errors) > 0 {
+		isError = true
+	}
+
+	text := string(resBody)
+	if !isError && len(gqlRes.Data) > 0 {
+		text = string(gqlRes.Data)
+	}
+
+	result := &protocol.CallToolResult{
+		Content: []protocol.ContentBlock{
+			protocol.TextContent{
+				Text: text,
+				Type: "text",
+			},
+		},
+	}
+	if isError {
+		result.IsError = &isError
+	}
+	return result, nil
+}
@@ -0,0 +1,268 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	protocol "github.com/hasmcp/hasmcp-ce/backend/internal/controller/mcp/protocol/p250618"
+	entity "github.com/hasmcp/hasmcp-ce/backend/internal/data/entity/crud"
+	"github.com/hasmcp/hasmcp-ce/backend/internal/data/entity/jsonrpc"
+	"github.com/hasmcp/hasmcp-ce/backend/internal/service/grpcc"
+	"github.com/hasmcp/hasmcp-ce/backend/internal/service/httpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+type (
+	grpcFilesKey struct {
+		providerID int64
+		version    int32
+	}
+
+	grpcErrorResponse struct {
+		Code    string            `json:"code"`
+		Message string            `json:"message"`
+		Details []json.RawMessage `json:"details,omitempty"`
+	}
+)
+
+// callGRPC transcodes the body arguments into the request message, invokes
+// the unary method of the tool path and transcodes the response back to JSON.
+// Non OK statuses are returned as tool errors.
+func (c *controller) callGRPC(
+	ctx context.Context,
+	provider *entity.Provider,
+	tool *entity.ProviderTool,
+	headers http.Header,
+	bodyArgs json.RawMessage,
+) (*protocol.CallToolResult, error) {
+	files, err := c.grpcFilesOf(provider)
+	if err != nil {
+		return nil, jsonrpc.Error{
+			Code:    jsonrpc.ErrCodeInternalError,
+			Message: "gRPC provider descriptor set is invalid",
+			Data: map[string]any{
+				"reason":     err.Error(),
+				"providerID": provider.ID,
+			},
+		}
+	}
+
+	method, err := grpcMethodOf(files, tool.Path)
+	if err != nil {
+		return nil, jsonrpc.Error{
+			Code:    jsonrpc.ErrCodeInternalError,
+			Message: "gRPC method is not found",
+			Data: map[string]any{
+				"reason":   err.Error(),
+				"toolName": tool.Name,
+				"path":     tool.Path,
+			},
+		}
+	}
+
+	types := dynamicpb.NewTypes(files)
+	in := dynamicpb.NewMessage(method.Input())
+	if len(bodyArgs) > 0 && string(bodyArgs) != "null" {
+		err = protojson.UnmarshalOptions{Resolver: types}.Unmarshal(bodyArgs, in)
+		if err != nil {
+			return nil, jsonrpc.Error{
+				Code:    jsonrpc.ErrCodeInvalidParams,
+				Message: "gRPC request message is malformed",
+				Data: map[string]any{
+					"reason":   err.Error(),
+					"toolName": tool.Name,
+				},
+			}
+		}
+	}
+
+	target, err := grpcc.Target(provider.BaseURL)
+	if err != nil {
+		return nil, jsonrpc.Error{
+			Code:    jsonrpc.ErrCodeInternalError,
+			Message: "gRPC target is malformed",
+			Data: map[string]any{
+				"reason":   err.Error(),
+				"toolName": tool.Name,
+			},
+		}
+	}
+
+	opts, err := c.ProviderGRPCConnOptions(ctx, provider)
+	if err != nil {
+		return nil, err
+	}
+	out := dynamicpb.NewMessage(method.Output())
+	recorded := "grpc://" + target + tool.Path
+	if rp := replayerOf(ctx); rp != nil {
+		if err := rp.replayGRPC(recorded, in, out, types); err != nil {
+			if _, ok := status.FromError(err); !ok {
+				return nil, err
+			}
+			return grpcErrorResult(status.Convert(err), types), nil
+		}
+	} else {
+		conn, err := c.grpcc.Conn(target, opts)
+		if err != nil {
+			return nil, err
+		}
+
+		release, err := c.guardProvider(ctx, provider)
+		if err != nil {
+			return nil, err
+		}
+		err = conn.Invoke(metadata.NewOutgoingContext(ctx, grpcc.MetadataFromHeader(headers)), tool.Path, in, out)
+		release(grpcFailed(err), nil)
+		if rec := recorderOf(ctx); rec != nil {
+			rec.recordGRPC(recorded, headers, in, out, types, err)
+		}
+		if err != nil {
+			return grpcErrorResult(status.Convert(err), types), nil
+		}
+	}
+
+	resBody, err := protojson.MarshalOptions{Resolver: types}.Marshal(out)
+	if err != nil {
+		return nil, jsonrpc.Error{
+			Code:    jsonrpc.ErrCodeInternalError,
+			Message: "gRPC response message is malformed",
+			Data: map[string]any{
+				"reason":   err.Error(),
+				"toolName": tool.Name,
+			},
+		}
+	}
+
+	return &protocol.CallToolResult{
+		Content: []protocol.ContentBlock{
+			protocol.TextContent{
+				Text: string(resBody),
+				Type: "text",
+			},
+		},
+	}, nil
+}
+
+// ProviderGRPCConnOptions returns the connection options of the provider with
+// its egress allow and its transport config
+func (c *controller) ProviderGRPCConnOptions(ctx context.Context, provider *entity.Provider) (grpcc.ConnOptions, error) {
+	opts := grpcc.ConnOptions{
+		Key: grpcConnKeyOf(provider.ID),
+	}
+	if provider.GRPCConfig != nil {
+		opts.Plaintext = provider.GRPCConfig.Plaintext
+		opts.InsecureSkipVerify = provider.GRPCConfig.InsecureSkipVerify
+	}
+	if cfg := provider.EgressConfig; cfg != nil {
+		opts.Egress = httpc.EgressAllow{
+			CIDRs: cfg.AllowedCIDRs,
+			Hosts: cfg.AllowedHosts,
+			Ports: cfg.AllowedPorts,
+		}
+	}
+	transport, err := c.transportOf(ctx, provider)
+	if err != nil {
+		return opts, err
+	}
+	opts.Transport = transport
+	return opts, nil
+}
+
+// grpcConnKeyOf returns the key of the gRPC connections of the provider, they
+// are closed on the changes of the provider
+func grpcConnKeyOf(providerID int64) string {
+	return "provider/" + strconv.FormatInt(providerID, 10)
+}
+
+// grpcFilesOf decodes the provider descriptor set once per provider version
+func (c *controller) grpcFilesOf(provider *entity.Provider) (*protoregistry.Files, error) {
+	key := grpcFilesKey{providerID: provider.ID, version: provider.Version}
+	if files, ok := c.grpcFiles.Load(key); ok {
+		return files.(*protoregistry.Files), nil
+	}
+
+	if len(provider.GRPCDescriptorSet) == 0 {
+		return nil, errors.New("provider has no descriptor set")
+	}
+	var set descriptorpb.FileDescriptorSet
+	if err := proto.Unmarshal(provider.GRPCDescriptorSet, &set); err != nil {
+		return nil, err
+	}
+	files, err := protodesc.NewFiles(&set)
+	if err != nil {
+		return nil, err
+	}
+
+	// the older versions are never read again
+	c.grpcFiles.Range(func(k, _ any) bool {
+		if k.(grpcFilesKey).providerID == provider.ID {
+			c.grpcFiles.Delete(k)
+		}
+		return true
+	})
+	c.grpcFiles.Store(key, files)
+	return files, nil
+}
+
+func grpcMethodOf(files *protoregistry.Files, path string) (protoreflect.MethodDescriptor, error) {
+	svcName, methodName, ok := strings.Cut(strings.TrimPrefix(path, "/"), "/")
+	if !ok {
+		return nil, errors.New("path must be /package.Service/Method")
+	}
+	d, err := files.FindDescriptorByName(protoreflect.FullName(svcName))
+	if err != nil {
+		return nil, err
+	}
+	svc, ok := d.(protoreflect.ServiceDescriptor)
+	if !ok {
+		return nil, fmt.Errorf("%s is not a service", svcName)
+	}
+	m := svc.Methods().ByName(protoreflect.Name(methodName))
+	if m == nil {
+		return nil, fmt.Errorf("%s has no method %s", svcName, methodName)
+	}
+	return m, nil
+}
+
+func grpcErrorResult(st *status.Status, types *dynamicpb.Types) *protocol.CallToolResult {
+	res := grpcErrorResponse{
+		Code:    st.Code().String(),
+		Message: st.Message(),
+	}
+	for _, d := range st.Proto().GetDetails() {
+		detail, err := protojson.MarshalOptions{Resolver: types}.Marshal(d)
+		if err != nil {
+			// fallback to the global registry for the well-known details
+			detail, err = protojson.Marshal(d)
+			if err != nil {
+				continue
+			}
+		}
+		res.Details = append(res.Details, detail)
+	}
+
+	text, _ := json.Marshal(res)
+	isError := true
+	return &protocol.CallToolResult{
+		Content: []protocol.ContentBlock{
+			protocol.TextContent{
+				Text: string(text),
+				Type: "text",
+			},
+		},
+		IsError: &isError,
+	}
+}
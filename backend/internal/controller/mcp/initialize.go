@@ -6,7 +6,6 @@ import (
 	"regexp"
 	"strconv"
 	"strings"
-	"sync"
 	"time"
 
 	jwtv5 "github.com/golang-jwt/jwt/v5"
@@ -91,10 +90,19 @@ func (c *controller) CallInitialize(ctx context.Context, req CallSessionRequest)
 
 	// create session
 	sessionID := pubsubResp.ID
-	srv.sessions.Store(sessionID, &serverSession{
+	err = c.sessions.Store(ctx, req.ServerID, sessionID, &serverSession{
 		initializeParams: params,
 		pubsubID:         sessionID,
 	})
+	if err != nil {
+		return nil, jsonrpc.Error{
+			Code:    jsonrpc.ErrCodeInternalError,
+			Message: "Failed to store the session",
+			Data: map[string]any{
+				"reason": err.Error(),
+			},
+		}
+	}
 
 	result := protocol.InitializeResult{
 		ProtocolVersion: _serverProtocolVersion,
@@ -119,6 +127,7 @@ func (c *controller) CallInitialize(ctx context.Context, req CallSessionRequest)
 			InitializeParams: params,
 			RegisteredClaims: jwtv5.RegisteredClaims{
 				ID:        monoflake.ID(sessionID).String(),
+				Subject:   req.Subject,
 				ExpiresAt: jwtv5.NewNumericDate(time.Now().UTC().AddDate(1, 0, 0)),
 			},
 		},
@@ -165,17 +174,34 @@ func (c *controller) buildServer(ctx context.Context, serverID int64) (*server,
 	}
 
 	providerIDs := make([]int64, len(mcpsrv.Providers))
+	upstreamProviderIDs := make([]int64, 0)
 	toolIDs := make([]int64, 0)
 	tools := make(map[int64]protocol.Tool)
+	argRoutes := make(map[int64]map[string]argRoute)
+	asyncToolIDs := make(map[int64]struct{})
 	for i, p := range mcpsrv.Providers {
 		providerIDs[i] = p.ID
+		if p.ApiType == entity.ApiTypeMCP {
+			upstreamProviderIDs = append(upstreamProviderIDs, p.ID)
+		}
 		for _, e := range p.Tools {
 			toolIDs = append(toolIDs, e.ID)
+			override := mcpsrv.ToolOverrides[e.ID]
 			title := e.Title
+			if override.Title != "" {
+				title = override.Title
+			}
 			if title == "" {
 				title = entity.MethodType(e.Method).String() + " " + e.Path
 			}
 			name := e.Name
+			if override.Name != "" {
+				name = override.Name
+			}
+			description := e.Description
+			if override.Description != "" {
+				description = override.Description
+			}
 
 			inputSchemaProperties := protocol.ToolInputSchemaProperties{}
 			required := make([]string, 0, 3)
@@ -200,15 +226,44 @@ func (c *controller) buildServer(ctx context.Context, serverID int64) (*server,
 				required = append(required, "bodyArgs")
 			}
 
+			required = applySchemaOverride(inputSchemaProperties, required, override)
+
+			mode := mcpsrv.InputSchemaMode
+			if override.InputSchemaMode != entity.InputSchemaModeInvalid {
+				mode = override.InputSchemaMode
+			}
+			if mode == entity.InputSchemaModeFlat {
+				inputSchemaProperties, required, argRoutes[e.ID], err = flattenInputSchema(
+					inputSchemaProperties, required, e.Headers, c.cfg.FlatSchema.Prefixes)
+				if err != nil {
+					return nil, jsonrpc.Error{
+						Code:    jsonrpc.ErrCodeInternalError,
+						Message: "Tool input schema can not be flattened",
+						Data: map[string]any{
+							"reason":   err.Error(),
+							"toolID":   e.ID,
+							"serverID": serverID,
+						},
+					}
+				}
+			}
 			if len(required) == 0 {
 				required = nil
 			}
 
+			// the calls exceeding the async wait return a handle to wait again
+			if e.AsyncConfig != nil && p.ApiType == entity.ApiTypeRest {
+				if _, ok := inputSchemaProperties[_argsAsyncHandle]; !ok {
+					inputSchemaProperties[_argsAsyncHandle] = _asyncHandleSchema
+					asyncToolIDs[e.ID] = struct{}{}
+				}
+			}
+
 			tools[e.ID] = protocol.Tool{
 				// NOTE: Some of the clients still show the Name only instead of title.
 				// NOTE: Gemini-CLI expects the name starts with letter
 				Name:        toMcpName('T', e.ID, name, title, len(mcpsrv.Name)),
-				Description: stringPtr(e.Description),
+				Description: stringPtr(description),
 				Title:       stringPtr(title),
 				InputSchema: protocol.ToolInputSchema{
 					Type:       "object",
@@ -248,10 +303,15 @@ func (c *controller) buildServer(ctx context.Context, serverID int64) (*server,
 
 	return &server{
 		requestHeadersProxyEnabled: mcpsrv.RequestHeadersProxyEnabled,
+		mockEnabled:                mcpsrv.MockEnabled,
+		trafficMode:                mcpsrv.TrafficMode,
 		toolIDs:                    toolIDs,
+		toolOverrides:              mcpsrv.ToolOverrides,
+		argRoutes:                  argRoutes,
+		asyncToolIDs:               asyncToolIDs,
 		resourceIDs:                resourceIDs,
 		promptIDs:                  promptIDs,
-		sessions:                   &sync.Map{},
+		upstreamProviderIDs:        upstreamProviderIDs,
 		protocol: protocolComponents{
 			implementation: protocol.Implementation{
 				Name:    mcpsrv.Name,
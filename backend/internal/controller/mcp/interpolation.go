@@ -0,0 +1,124 @@
+package mcp
+
+import (
+	"context"
+	"net/url"
+	"slices"
+	"strings"
+
+	entity "github.com/hasmcp/hasmcp-ce/backend/internal/data/entity/crud"
+	"github.com/hasmcp/hasmcp-ce/backend/internal/data/entity/jsonrpc"
+)
+
+// the parts of the upstream request the variables are interpolated in
+const (
+	_interpolateInBaseURL = "baseURL"
+	_interpolateInPath    = "path"
+	_interpolateInQuery   = "query"
+	_interpolateInBody    = "body"
+	_interpolateInSigner  = "signer"
+)
+
+// interpolate replaces the `${NAME}` references of the template with the
+// escaped variable values. Unlike the headers, the unresolved variables fail
+// the call instead of being sent as they are.
+func (c *controller) interpolate(ctx context.Context, template, in string, escape func(string) string) (string, error) {
+	if !strings.Contains(template, "${") {
+		return template, nil
+	}
+
+	var unresolved []string
+	s := entity.RegexVariableReference.ReplaceAllStringFunc(template, func(ref string) string {
+		name := ref[2 : len(ref)-1]
+		v, err := entity.ResolveVariable(ctx, name, c.cache)
+		if err != nil {
+			if !slices.Contains(unresolved, name) {
+				unresolved = append(unresolved, name)
+			}
+			return ref
+		}
+		if escape != nil {
+			return escape(v)
+		}
+		return v
+	})
+	if len(unresolved) > 0 {
+		return "", jsonrpc.Error{
+			Code:    jsonrpc.ErrCodeInternalError,
+			Message: "Variables are not resolved",
+			Data: map[string]any{
+				"variables": unresolved,
+				"in":        in,
+			},
+		}
+	}
+	return s, nil
+}
+
+// interpolateProvider returns the provider with the variables of its base URL
+// replaced, the cached provider is shared so that a copy is returned
+func (c *controller) interpolateProvider(ctx context.Context, provider *entity.Provider) (*entity.Provider, error) {
+	if !strings.Contains(provider.BaseURL, "${") {
+		return provider, nil
+	}
+
+	// the variables may set the host and the port, so the values are not
+	// escaped and the result is parsed instead
+	baseURL, err := c.interpolate(ctx, provider.BaseURL, _interpolateInBaseURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	if u, err := url.Parse(baseURL); err != nil || u.Host == "" {
+		return nil, jsonrpc.Error{
+			Code:    jsonrpc.ErrCodeInternalError,
+			Message: "Provider base URL is malformed after the variables are interpolated",
+			Data: map[string]any{
+				"providerID": provider.ID,
+			},
+		}
+	}
+
+	interpolated := *provider
+	interpolated.BaseURL = baseURL
+	return &interpolated, nil
+}
+
+// interpolatePreset replaces the variables of a preset argument value, the
+// strings of the body values are replaced at any depth and are escaped by
+// the JSON encoding
+func (c *controller) interpolatePreset(ctx context.Context, val any, in entity.ToolArgumentLocation) (any, error) {
+	switch in {
+	case entity.ToolArgumentLocationPath:
+		s, _ := val.(string)
+		return c.interpolate(ctx, s, _interpolateInPath, url.PathEscape)
+	case entity.ToolArgumentLocationQuery:
+		s, _ := val.(string)
+		return c.interpolate(ctx, s, _interpolateInQuery, url.QueryEscape)
+	}
+
+	switch v := val.(type) {
+	case string:
+		return c.interpolate(ctx, v, _interpolateInBody, nil)
+	case map[string]any:
+		out := make(map[string]any, len(v))
+		for k, item := range v {
+			interpolated, err := c.interpolatePreset(ctx, item, in)
+			if err != nil {
+				return nil, err
+			}
+			out[k] = interpolated
+		}
+		return out, nil
+	case []any:
+		out := make([]any, len(v))
+		for i, item := range v {
+			interpolated, err := c.interpolatePreset(ctx, item, in)
+			if err != nil {
+				return nil, err
+			}
+			out[i] = interpolated
+		}
+		return out, nil
+	}
+	return val, nil
+}
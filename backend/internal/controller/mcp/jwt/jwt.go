@@ -58,12 +58,18 @@ type (
 	AuthResult struct {
 		ServerID    int64
 		Permissions map[string]struct{}
+		// Subject identifies the user of the token, empty for shared tokens
+		Subject string
+		// Audience is the resources of the token, empty for the server tokens
+		// created on the API
+		Audience []string
 	}
 
 	SessionResult struct {
 		ServerID         int64
 		SessionID        int64
 		InitializeParams protocol.InitializeRequestParams
+		Subject          string
 	}
 
 	jwtConfig struct {
@@ -143,6 +149,8 @@ func (c *controller) Authenticate(ctx context.Context, p AuthParams) (*AuthResul
 	return &AuthResult{
 		ServerID:    monoflake.IDFromBase62(claims.ServerID).Int64(),
 		Permissions: permissions,
+		Subject:     claims.Subject,
+		Audience:    claims.Audience,
 	}, nil
 }
 
@@ -177,5 +185,6 @@ func (c *controller) VerifySession(ctx context.Context, p AuthParams) (*SessionR
 		ServerID:         monoflake.IDFromBase62(claims.ServerID).Int64(),
 		SessionID:        monoflake.IDFromBase62(claims.ID).Int64(),
 		InitializeParams: claims.InitializeParams,
+		Subject:          claims.Subject,
 	}, nil
 }
@@ -0,0 +1,403 @@
+package mcp
+
+import (
+	"context"
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	entity "github.com/hasmcp/hasmcp-ce/backend/internal/data/entity/crud"
+	"github.com/hasmcp/hasmcp-ce/backend/internal/data/entity/jsonrpc"
+	"github.com/hasmcp/hasmcp-ce/backend/internal/service/httpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type (
+	GetProviderBreakerRequest struct {
+		ProviderID int64
+	}
+
+	// providerGuard applies the limits of a provider on this replica, the
+	// concurrency slots, the token bucket and the circuit breaker
+	providerGuard struct {
+		mu  sync.Mutex
+		cfg entity.ProviderLimitsConfig
+
+		slots    chan struct{} // nil when the concurrency is not limited
+		inFlight int
+
+		tokens         float64
+		refilledAt     time.Time
+		throttledUntil time.Time
+
+		state    entity.BreakerState
+		failures int
+		openedAt time.Time
+		trial    bool // the trial request of the half open circuit is in flight
+	}
+
+	// guardRelease ends a guarded upstream request with its outcome
+	guardRelease func(failed bool, res *http.Response)
+)
+
+const (
+	_limitsDefaultMaxWait         = 10 * time.Second
+	_limitsDefaultBreakerCooldown = 30 * time.Second
+
+	_headerRateLimitRemaining  = "X-RateLimit-Remaining"
+	_headerRateLimitReset      = "X-RateLimit-Reset"
+	_headerRateLimitRemaining2 = "RateLimit-Remaining"
+	_headerRateLimitReset2     = "RateLimit-Reset"
+
+	// the reset values above are unix times, the others are seconds to wait
+	_rateLimitResetEpochMin = 1_000_000_000
+)
+
+// noRelease is the release of the providers without limits
+func noRelease(bool, *http.Response) {}
+
+// GetProviderBreaker returns the circuit breaker state of the provider on
+// this replica, a provider without calls has a closed circuit
+func (c *controller) GetProviderBreaker(_ context.Context, req GetProviderBreakerRequest) entity.ProviderBreaker {
+	breaker := entity.ProviderBreaker{
+		ProviderID: req.ProviderID,
+		State:      entity.BreakerStateClosed,
+	}
+	val, ok := c.providerGuards.Load(req.ProviderID)
+	if !ok {
+		return breaker
+	}
+
+	g := val.(*providerGuard)
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	breaker.State = g.state
+	breaker.ConsecutiveFailures = g.failures
+	breaker.OpenedAt = g.openedAt
+	if g.state == entity.BreakerStateOpen {
+		breaker.RetryAt = g.openedAt.Add(g.cooldown())
+	}
+	breaker.InFlight = g.inFlight
+	if g.throttledUntil.After(time.Now()) {
+		breaker.ThrottledUntil = g.throttledUntil
+	}
+	return breaker
+}
+
+// guardProvider waits for the limits of the provider before an upstream
+// request. It fails fast while the circuit is open and when the wait for a
+// slot or a token would exceed the max wait.
+func (c *controller) guardProvider(ctx context.Context, provider *entity.Provider) (guardRelease, error) {
+	if provider.LimitsConfig == nil {
+		return noRelease, nil
+	}
+	g := c.guardOf(provider)
+
+	trial, err := g.admit(provider)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := g.waitToken(ctx, provider); err != nil {
+		g.abort(trial)
+		return nil, err
+	}
+
+	slots, err := g.waitSlot(ctx, provider)
+	if err != nil {
+		g.abort(trial)
+		return nil, err
+	}
+
+	var once sync.Once
+	return func(failed bool, res *http.Response) {
+		once.Do(func() {
+			if slots != nil {
+				<-slots
+			}
+			g.release(trial, failed, res)
+		})
+	}, nil
+}
+
+// guardOf returns the guard of the provider, the guard starts over when the
+// limits of the provider change
+func (c *controller) guardOf(provider *entity.Provider) *providerGuard {
+	cfg := *provider.LimitsConfig
+	if val, ok := c.providerGuards.Load(provider.ID); ok {
+		g := val.(*providerGuard)
+		g.mu.Lock()
+		same := g.cfg == cfg
+		g.mu.Unlock()
+		if same {
+			return g
+		}
+	}
+
+	g := &providerGuard{
+		cfg:        cfg,
+		tokens:     float64(burstOf(cfg)),
+		refilledAt: time.Now(),
+		state:      entity.BreakerStateClosed,
+	}
+	if cfg.MaxConcurrency > 0 {
+		g.slots = make(chan struct{}, cfg.MaxConcurrency)
+	}
+	c.providerGuards.Store(provider.ID, g)
+	return g
+}
+
+// admit checks the circuit, the first request after the cooldown of an open
+// circuit is let through as the trial request
+func (g *providerGuard) admit(provider *entity.Provider) (bool, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	switch g.state {
+	case entity.BreakerStateOpen:
+		retryAt := g.openedAt.Add(g.cooldown())
+		if time.Now().Before(retryAt) {
+			return false, circuitOpenError(provider, g.failures, time.Until(retryAt))
+		}
+		g.state = entity.BreakerStateHalfOpen
+		fallthrough
+	case entity.BreakerStateHalfOpen:
+		if g.trial {
+			return false, circuitOpenError(provider, g.failures, 0)
+		}
+		g.trial = true
+		return true, nil
+	}
+	return false, nil
+}
+
+// waitToken takes a token of the bucket, the upstream throttling is waited
+// for on top of the rate
+func (g *providerGuard) waitToken(ctx context.Context, provider *entity.Provider) error {
+	g.mu.Lock()
+	now := time.Now()
+	wait := time.Duration(0)
+	if g.throttledUntil.After(now) {
+		wait = g.throttledUntil.Sub(now)
+	}
+	if rate := g.cfg.RatePerSecond; rate > 0 {
+		burst := float64(burstOf(g.cfg))
+		g.tokens = math.Min(burst, g.tokens+now.Sub(g.refilledAt).Seconds()*rate)
+		g.refilledAt = now
+		if g.tokens < 1 {
+			wait = max(wait, time.Duration((1-g.tokens)/rate*float64(time.Second)))
+		}
+	}
+	maxWait := secondsOr(g.cfg.MaxWaitSeconds, _limitsDefaultMaxWait)
+	if wait > maxWait {
+		g.mu.Unlock()
+		return jsonrpc.Error{
+			Code:    jsonrpc.ErrCodeInternalError,
+			Message: "Provider rate limit is exceeded",
+			Data: map[string]any{
+				"providerID":        provider.ID,
+				"retryAfterSeconds": int(math.Ceil(wait.Seconds())),
+			},
+		}
+	}
+	if g.cfg.RatePerSecond > 0 {
+		// the token is reserved so that the concurrent waits queue up
+		g.tokens--
+	}
+	g.mu.Unlock()
+
+	if wait <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		if g.cfg.RatePerSecond > 0 {
+			// the reserved token is not used by the cancelled call
+			g.mu.Lock()
+			g.tokens++
+			g.mu.Unlock()
+		}
+		return jsonrpc.Error{
+			Code:    jsonrpc.ErrCodeInternalError,
+			Message: "Provider rate limit wait is cancelled",
+			Data: map[string]any{
+				"reason":     ctx.Err().Error(),
+				"providerID": provider.ID,
+			},
+		}
+	case <-timer.C:
+		return nil
+	}
+}
+
+// waitSlot takes a concurrency slot and returns the slots to give it back to
+func (g *providerGuard) waitSlot(ctx context.Context, provider *entity.Provider) (chan struct{}, error) {
+	if g.slots == nil {
+		g.mu.Lock()
+		g.inFlight++
+		g.mu.Unlock()
+		return nil, nil
+	}
+
+	timer := time.NewTimer(secondsOr(g.cfg.MaxWaitSeconds, _limitsDefaultMaxWait))
+	defer timer.Stop()
+	select {
+	case g.slots <- struct{}{}:
+		g.mu.Lock()
+		g.inFlight++
+		g.mu.Unlock()
+		return g.slots, nil
+	case <-ctx.Done():
+		return nil, jsonrpc.Error{
+			Code:    jsonrpc.ErrCodeInternalError,
+			Message: "Provider concurrency limit wait is cancelled",
+			Data: map[string]any{
+				"reason":     ctx.Err().Error(),
+				"providerID": provider.ID,
+			},
+		}
+	case <-timer.C:
+		return nil, jsonrpc.Error{
+			Code:    jsonrpc.ErrCodeInternalError,
+			Message: "Provider concurrency limit is reached",
+			Data: map[string]any{
+				"providerID":     provider.ID,
+				"maxConcurrency": g.cfg.MaxConcurrency,
+			},
+		}
+	}
+}
+
+// abort gives the trial back when the request is not sent
+func (g *providerGuard) abort(trial bool) {
+	if !trial {
+		return
+	}
+	g.mu.Lock()
+	g.trial = false
+	g.mu.Unlock()
+}
+
+// release records the outcome of the request on the circuit and the upstream
+// rate limit headers of the response
+func (g *providerGuard) release(trial, failed bool, res *http.Response) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.inFlight--
+	if trial {
+		g.trial = false
+	}
+	if res != nil {
+		if until := throttledUntilOf(res, time.Now()); until.After(g.throttledUntil) {
+			g.throttledUntil = until
+		}
+	}
+
+	if !failed {
+		g.failures = 0
+		g.state = entity.BreakerStateClosed
+		return
+	}
+	g.failures++
+	if g.cfg.BreakerFailures <= 0 {
+		return
+	}
+	if trial || g.failures >= g.cfg.BreakerFailures {
+		g.state = entity.BreakerStateOpen
+		g.openedAt = time.Now()
+	}
+}
+
+func (g *providerGuard) cooldown() time.Duration {
+	return secondsOr(g.cfg.BreakerCooldownSeconds, _limitsDefaultBreakerCooldown)
+}
+
+func burstOf(cfg entity.ProviderLimitsConfig) int {
+	if cfg.Burst > 0 {
+		return cfg.Burst
+	}
+	return max(int(math.Ceil(cfg.RatePerSecond)), 1)
+}
+
+func circuitOpenError(provider *entity.Provider, failures int, retryAfter time.Duration) error {
+	return jsonrpc.Error{
+		Code:    jsonrpc.ErrCodeInternalError,
+		Message: "Provider circuit is open after consecutive failures, the calls fail fast until the provider recovers",
+		Data: map[string]any{
+			"providerID":          provider.ID,
+			"consecutiveFailures": failures,
+			"retryAfterSeconds":   int(math.Ceil(retryAfter.Seconds())),
+		},
+	}
+}
+
+// httpFailed reports whether the upstream response counts as a failure of the
+// circuit, the rate limited responses are throttled instead
+func httpFailed(res *http.Response, err error) bool {
+	if err != nil {
+		// the errors of the provider configs, the egress policy and the
+		// calls cancelled by the client are not failures of the provider
+		if _, ok := httpc.AsEgressError(err); ok {
+			return false
+		}
+		if errors.Is(err, context.Canceled) {
+			return false
+		}
+		_, ok := err.(jsonrpc.Error)
+		return !ok
+	}
+	return res.StatusCode >= http.StatusInternalServerError
+}
+
+// grpcFailed reports whether the gRPC status counts as a failure of the
+// circuit, the application errors do not
+func grpcFailed(err error) bool {
+	switch status.Code(err) {
+	case codes.Unavailable, codes.DeadlineExceeded, codes.Internal, codes.Unknown:
+		return true
+	default:
+		return false
+	}
+}
+
+// throttledUntilOf returns the time until the upstream asks to wait by the
+// Retry-After of the 429 and 503 responses or by the exhausted rate limit
+func throttledUntilOf(res *http.Response, now time.Time) time.Time {
+	if res.StatusCode == http.StatusTooManyRequests || res.StatusCode == http.StatusServiceUnavailable {
+		if v := res.Header.Get(_headerRetryAfter); v != "" {
+			if seconds, err := strconv.Atoi(v); err == nil {
+				return now.Add(time.Duration(seconds) * time.Second)
+			}
+			if at, err := http.ParseTime(v); err == nil {
+				return at
+			}
+		}
+	}
+
+	for _, names := range [][2]string{
+		{_headerRateLimitRemaining, _headerRateLimitReset},
+		{_headerRateLimitRemaining2, _headerRateLimitReset2},
+	} {
+		remaining, err := strconv.Atoi(res.Header.Get(names[0]))
+		if err != nil || remaining > 0 {
+			continue
+		}
+		reset, err := strconv.ParseInt(res.Header.Get(names[1]), 10, 64)
+		if err != nil || reset <= 0 {
+			continue
+		}
+		if reset >= _rateLimitResetEpochMin {
+			return time.Unix(reset, 0)
+		}
+		return now.Add(time.Duration(reset) * time.Second)
+	}
+	return time.Time{}
+}
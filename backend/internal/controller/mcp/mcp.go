@@ -2,6 +2,8 @@ package mcp
 
 import (
 	"context"
+	"encoding/json"
+	"net/http"
 	"sync"
 
 	"github.com/hasmcp/hasmcp-ce/backend/internal/controller/cache"
@@ -10,15 +12,19 @@ import (
 	entity "github.com/hasmcp/hasmcp-ce/backend/internal/data/entity/crud"
 	erre "github.com/hasmcp/hasmcp-ce/backend/internal/data/entity/err"
 	"github.com/hasmcp/hasmcp-ce/backend/internal/data/entity/jsonrpc"
+	"github.com/hasmcp/hasmcp-ce/backend/internal/repository/storage"
 	"github.com/hasmcp/hasmcp-ce/backend/internal/service/config"
+	"github.com/hasmcp/hasmcp-ce/backend/internal/service/grpcc"
 	"github.com/hasmcp/hasmcp-ce/backend/internal/service/httpc"
 	"github.com/hasmcp/hasmcp-ce/backend/internal/service/idgen"
 	"github.com/hasmcp/hasmcp-ce/backend/internal/service/locksmith"
+	"github.com/hasmcp/hasmcp-ce/backend/internal/service/mcpc"
 	"github.com/hasmcp/hasmcp-ce/backend/internal/service/memq"
 	"github.com/hasmcp/hasmcp-ce/backend/internal/service/pubsub"
 	"github.com/mustafaturan/monoflake"
 	"github.com/rs/zerolog"
 	zlog "github.com/rs/zerolog/log"
+	"golang.org/x/sync/singleflight"
 )
 
 type (
@@ -35,18 +41,62 @@ type (
 
 		// CRUD updates
 		HandleChanges(ctx context.Context, change entity.ResourceChange) error
+
+		// Response cache
+		PurgeResponseCache(ctx context.Context, req PurgeResponseCacheRequest) error
+
+		// Provider limits
+		GetProviderBreaker(ctx context.Context, req GetProviderBreakerRequest) entity.ProviderBreaker
+
+		// Provider transports of the calls made outside of the tools, they go
+		// through the egress allow and the transport config of the provider
+		ProviderHTTPClient(ctx context.Context, provider *entity.Provider) (*http.Client, error)
+		ProviderGRPCConnOptions(ctx context.Context, provider *entity.Provider) (grpcc.ConnOptions, error)
+
+		// UseVariableSaver sets the saver of the refreshed oauth2 tokens, the
+		// crud controller depends on this controller so it is set afterwards
+		UseVariableSaver(s VariableSaver)
+	}
+
+	// VariableSaver saves the variables with the crud validations
+	VariableSaver interface {
+		SaveVariable(ctx context.Context, req entity.SaveVariableRequest) error
 	}
 
 	controller struct {
+		cfg       mcpConfig
 		idgen     idgen.Service
 		httpc     httpc.Service
+		grpcc     grpcc.Service
+		mcpc      mcpc.Service
 		locksmith locksmith.Service
 		memq      memq.Service
 		pubsub    pubsub.Service
 		jwt       jwt.Controller
 		cache     cache.Controller
+		storage   storage.Repository
+		variables VariableSaver
+
+		servers  sync.Map
+		sessions sessionRegistry
+
+		// grpcFiles caches the decoded descriptor sets by provider version
+		grpcFiles sync.Map // map[grpcFilesKey]*protoregistry.Files
+
+		// oauth2Refreshes de-duplicates the access token refreshes by provider
+		oauth2Refreshes singleflight.Group
+
+		// oauth2Tokens caches the tokens of the non-interactive grants
+		oauth2Tokens sync.Map // map[oauth2TokenKey]*oauth2.Token
 
-		servers sync.Map
+		responseCache      responseCache
+		responseCacheStats sync.Map // map[responseCacheStatsKey]*responseCacheStats
+
+		// providerGuards applies the provider limits by provider ID
+		providerGuards sync.Map // map[int64]*providerGuard
+		// providerTransports are the transport options of the providers with
+		// the TLS or the proxy settings
+		providerTransports sync.Map // map[providerTransportKey]*httpc.TransportOptions
 
 		queueIDForResourceUpdates uint32
 	}
@@ -58,10 +108,15 @@ type (
 
 	server struct {
 		toolIDs                    []int64
+		toolOverrides              map[int64]entity.ServerToolOverride
+		argRoutes                  map[int64]map[string]argRoute
+		asyncToolIDs               map[int64]struct{} // tools accepting the async handle argument
 		resourceIDs                []int64
 		promptIDs                  []int64
+		upstreamProviderIDs        []int64 // providers of the MCP api type
 		requestHeadersProxyEnabled bool
-		sessions                   *sync.Map
+		mockEnabled                bool
+		trafficMode                entity.TrafficMode
 		protocol                   protocolComponents
 	}
 
@@ -76,9 +131,12 @@ type (
 		Config    config.Service
 		IDGen     idgen.Service
 		HTTPC     httpc.Service
+		GRPCC     grpcc.Service
+		MCPC      mcpc.Service
 		Locksmith locksmith.Service
 		Memq      memq.Service
 		PubSub    pubsub.Service
+		Storage   storage.Repository
 		Cache     cache.Controller
 		McpJWT    jwt.Controller
 	}
@@ -96,7 +154,10 @@ type (
 		McpSessionID       string
 		McpProtocolVersion string
 		Permissions        map[string]struct{}
-		Request            jsonrpc.Request
+		// Subject identifies the caller, the variables of the caller take
+		// precedence over the global ones on the tool calls
+		Subject string
+		Request jsonrpc.Request
 	}
 
 	CallSessionResponse struct {
@@ -107,6 +168,20 @@ type (
 	}
 
 	mcpConfig struct {
+		// SessionRegistry selects where the sessions are kept: memory (per
+		// replica) or database (shared between the replicas)
+		SessionRegistry string `yaml:"sessionRegistry"`
+
+		FlatSchema flatSchemaConfig `yaml:"flatSchema"`
+
+		ResponseCache responseCacheConfig `yaml:"responseCache"`
+	}
+
+	// resourceChange is the queued crud change, replicated changes are
+	// received from the other replicas
+	resourceChange struct {
+		change     entity.ResourceChange
+		replicated bool
 	}
 
 	Method string
@@ -148,17 +223,34 @@ func New(p Params) (Controller, error) {
 		return nil, err
 	}
 
+	sessions, err := newSessionRegistry(cfg.SessionRegistry, p.Storage)
+	if err != nil {
+		return nil, err
+	}
+
+	responseCache, err := newResponseCache(cfg.ResponseCache, p.Storage)
+	if err != nil {
+		return nil, err
+	}
+
 	c := &controller{
+		cfg:       cfg,
 		idgen:     p.IDGen,
 		httpc:     p.HTTPC,
+		grpcc:     p.GRPCC,
+		mcpc:      p.MCPC,
 		locksmith: p.Locksmith,
 		memq:      p.Memq,
 		pubsub:    p.PubSub,
 
-		jwt:   p.McpJWT,
-		cache: p.Cache,
+		jwt:     p.McpJWT,
+		cache:   p.Cache,
+		storage: p.Storage,
+
+		servers:  sync.Map{},
+		sessions: sessions,
 
-		servers: sync.Map{},
+		responseCache: responseCache,
 	}
 
 	res, err := c.memq.Create(context.Background(), memq.CreateRequest{
@@ -179,10 +271,46 @@ func New(p Params) (Controller, error) {
 	}
 
 	c.queueIDForResourceUpdates = res.ID
+
+	err = c.subscribeReplicaChanges(context.Background())
+	if err != nil {
+		return nil, err
+	}
+
 	return c, nil
 }
 
+func (c *controller) UseVariableSaver(s VariableSaver) {
+	c.variables = s
+}
+
 func (c *controller) HandleChanges(ctx context.Context, change entity.ResourceChange) error {
+	err := c.queueChange(ctx, resourceChange{change: change})
+	if err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(change)
+	if err != nil {
+		return err
+	}
+
+	// share the change with the other replicas
+	_, err = c.pubsub.Publish(ctx, pubsub.PublishRequest{
+		PubSubID: pubsub.IDReplicaResourceChanges,
+		Event: &event{
+			Type: "resource_change",
+			Data: data,
+		},
+		ReplicasOnly: true,
+	})
+	if err != nil {
+		zlog.Error().Err(err).Msg(_logPrefix + "failed to share the resource change with replicas")
+	}
+	return nil
+}
+
+func (c *controller) queueChange(ctx context.Context, change resourceChange) error {
 	err := c.memq.AddTask(ctx, memq.AddTaskRequest{
 		QueueID: c.queueIDForResourceUpdates,
 		Task: memq.Task{
@@ -197,12 +325,62 @@ func (c *controller) HandleChanges(ctx context.Context, change entity.ResourceCh
 	return nil
 }
 
+// subscribeReplicaChanges queues the resource changes of the other replicas
+func (c *controller) subscribeReplicaChanges(ctx context.Context) error {
+	_, err := c.pubsub.Create(ctx, pubsub.CreatePubSubRequest{
+		ID: pubsub.IDReplicaResourceChanges,
+	})
+	if err != nil {
+		return err
+	}
+
+	res, err := c.pubsub.Subscribe(ctx, pubsub.SubscribeRequest{
+		PubSubID: pubsub.IDReplicaResourceChanges,
+	})
+	if err != nil {
+		return err
+	}
+
+	go func() {
+		for e := range res.Events {
+			sse, ok := e.(SSE)
+			if !ok {
+				continue
+			}
+			data, _ := sse.GetData().([]byte)
+			if sse.GetType() == _eventTypeResponseCachePurge {
+				c.purgeReplicatedResponseCache(context.Background(), data)
+				continue
+			}
+
+			var change entity.ResourceChange
+			if err := json.Unmarshal(data, &change); err != nil {
+				zlog.Warn().Err(err).Msg(_logPrefix + "received malformed resource change")
+				continue
+			}
+			_ = c.queueChange(context.Background(), resourceChange{
+				change:     change,
+				replicated: true,
+			})
+		}
+	}()
+	return nil
+}
+
 func (c *controller) applyUpdatesOnChanges(ctx context.Context, t memq.Task) error {
-	change := t.Val.(entity.ResourceChange)
+	queued := t.Val.(resourceChange)
+	change := queued.change
 	serverID := change.ResourceOwnerID
+	if change.ObjectType == entity.ObjectTypeProvider {
+		c.evictProviderTransports(change.ResoureID)
+		c.grpcc.Evict(grpcConnKeyOf(change.ResoureID))
+	}
 	if change.ObjectType == entity.ObjectTypeServer && change.EventType == entity.ObjectEventTypeDelete {
 		// loop through sessions and close
 		c.servers.Delete(change.ResoureID)
+		if !queued.replicated || !c.sessions.Shared() {
+			_ = c.sessions.DeleteAll(ctx, change.ResoureID)
+		}
 		return nil
 	}
 
@@ -218,11 +396,15 @@ func (c *controller) applyUpdatesOnChanges(ctx context.Context, t memq.Task) err
 		return err
 	}
 
-	newServer.sessions = currentServer.sessions
 	c.servers.Store(serverID, newServer)
 
 	zlog.Info().Any("tools", newServer.toolIDs).Msg(_logPrefix + "saved new server with tools")
 
+	// the replica which made the change notifies the shared sessions
+	if queued.replicated && c.sessions.Shared() {
+		return nil
+	}
+
 	toolsListChanged := false
 	promptsListChanged := false
 	resourcesListChanged := false
@@ -292,52 +474,43 @@ func (c *controller) applyUpdatesOnChanges(ctx context.Context, t memq.Task) err
 	}
 
 	if toolsListChanged {
-		currentServer.sessions.Range(func(key, _ any) bool {
-			sessionID, ok := key.(int64)
-			if ok {
-				c.sendSessionNotification(ctx, CallSessionRequest{
-					ServerID:     serverID,
-					McpSessionID: monoflake.ID(sessionID).String(),
-					Request: jsonrpc.Request{
-						Method: MethodNotificationToolsListChanged,
-						Params: []byte(""),
-					},
-				})
-			}
+		c.sessions.Range(ctx, serverID, func(sessionID int64, _ *serverSession) bool {
+			c.sendSessionNotification(ctx, CallSessionRequest{
+				ServerID:     serverID,
+				McpSessionID: monoflake.ID(sessionID).String(),
+				Request: jsonrpc.Request{
+					Method: MethodNotificationToolsListChanged,
+					Params: []byte(""),
+				},
+			})
 			return true
 		})
 	}
 
 	if promptsListChanged {
-		currentServer.sessions.Range(func(key, _ any) bool {
-			sessionID, ok := key.(int64)
-			if ok {
-				c.sendSessionNotification(ctx, CallSessionRequest{
-					ServerID:     change.ResourceOwnerID,
-					McpSessionID: monoflake.ID(sessionID).String(),
-					Request: jsonrpc.Request{
-						Method: MethodNotificationPromptsListChanged,
-						Params: []byte(""),
-					},
-				})
-			}
+		c.sessions.Range(ctx, serverID, func(sessionID int64, _ *serverSession) bool {
+			c.sendSessionNotification(ctx, CallSessionRequest{
+				ServerID:     serverID,
+				McpSessionID: monoflake.ID(sessionID).String(),
+				Request: jsonrpc.Request{
+					Method: MethodNotificationPromptsListChanged,
+					Params: []byte(""),
+				},
+			})
 			return true
 		})
 	}
 
 	if resourcesListChanged {
-		currentServer.sessions.Range(func(key, _ any) bool {
-			sessionID, ok := key.(int64)
-			if ok {
-				c.sendSessionNotification(ctx, CallSessionRequest{
-					ServerID:     change.ResourceOwnerID,
-					McpSessionID: monoflake.ID(sessionID).String(),
-					Request: jsonrpc.Request{
-						Method: MethodNotificationResourcesListChanged,
-						Params: []byte(""),
-					},
-				})
-			}
+		c.sessions.Range(ctx, serverID, func(sessionID int64, _ *serverSession) bool {
+			c.sendSessionNotification(ctx, CallSessionRequest{
+				ServerID:     serverID,
+				McpSessionID: monoflake.ID(sessionID).String(),
+				Request: jsonrpc.Request{
+					Method: MethodNotificationResourcesListChanged,
+					Params: []byte(""),
+				},
+			})
 			return true
 		})
 	}
@@ -369,13 +542,12 @@ func (c *controller) getServer(id int64) (*server, error) {
 
 func (c *controller) getSession(serverID, sessionID int64) (*serverSession, error) {
 	// server
-	serv, err := c.getServer(serverID)
-	if err != nil {
+	if _, err := c.getServer(serverID); err != nil {
 		return nil, err
 	}
 
 	// load session
-	sessionVal, ok := serv.sessions.Load(sessionID)
+	session, ok := c.sessions.Load(context.Background(), serverID, sessionID)
 	if !ok {
 		return nil, erre.Error{
 			Code:    404,
@@ -383,7 +555,7 @@ func (c *controller) getSession(serverID, sessionID int64) (*serverSession, erro
 		}
 	}
 
-	return sessionVal.(*serverSession), nil
+	return session, nil
 }
 
 func (r CallSessionRequest) MarshalZerologObject(e *zerolog.Event) {
@@ -0,0 +1,400 @@
+package mcp
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	protocol "github.com/hasmcp/hasmcp-ce/backend/internal/controller/mcp/protocol/p250618"
+	entity "github.com/hasmcp/hasmcp-ce/backend/internal/data/entity/crud"
+	"github.com/hasmcp/hasmcp-ce/backend/internal/data/entity/jsonrpc"
+	"github.com/kaptinlin/jsonschema"
+)
+
+const (
+	_metaKeyMock = "hasmcp/mock"
+
+	_mockSourceSchema    = "schema"
+	_mockSourceExample   = "example"
+	_mockMaxDepth        = 8
+	_mockMaxArrayItems   = 3
+	_mockDefaultString   = "string"
+	_mockDefaultDateTime = "2025-01-01T00:00:00Z"
+)
+
+// _mockStringFormats are the sample values of the JSON schema string formats
+var _mockStringFormats = map[string]string{
+	"date-time":     _mockDefaultDateTime,
+	"date":          "2025-01-01",
+	"time":          "00:00:00Z",
+	"duration":      "PT1H",
+	"email":         "user@example.com",
+	"idn-email":     "user@example.com",
+	"hostname":      "example.com",
+	"idn-hostname":  "example.com",
+	"ipv4":          "192.0.2.1",
+	"ipv6":          "2001:db8::1",
+	"uri":           "https://example.com",
+	"uri-reference": "https://example.com",
+	"iri":           "https://example.com",
+	"iri-reference": "https://example.com",
+	"url":           "https://example.com",
+	"uuid":          "00000000-0000-4000-8000-000000000000",
+	"byte":          "ZXhhbXBsZQ==",
+	"binary":        "example",
+	"password":      "********",
+	"regex":         ".*",
+	"json-pointer":  "/example",
+}
+
+// isMocked reports whether the tool calls of the provider are answered by the
+// mock mode on the server
+func (s *server) isMocked(provider *entity.Provider) bool {
+	return s.mockEnabled || (provider.MockConfig != nil && provider.MockConfig.Enabled)
+}
+
+// callMock answers the tool call without calling the provider. The first
+// recorded example matching the arguments is returned, otherwise a response
+// is generated from the response body schema of the tool.
+func callMock(tool *entity.ProviderTool, pathArgs, queryArgs, bodyArgs json.RawMessage) (*protocol.CallToolResult, error) {
+	args := map[entity.ToolArgumentLocation]json.RawMessage{
+		entity.ToolArgumentLocationPath:  pathArgs,
+		entity.ToolArgumentLocationQuery: queryArgs,
+		entity.ToolArgumentLocationBody:  bodyArgs,
+	}
+	if err := validateMockArguments(tool, args); err != nil {
+		return nil, err
+	}
+
+	for _, ex := range tool.MockExamples {
+		if !mockExampleMatches(ex, args) {
+			continue
+		}
+		result := &protocol.CallToolResult{
+			Meta: protocol.CallToolResultMeta{
+				_metaKeyMock: _mockSourceExample,
+			},
+			Content: []protocol.ContentBlock{
+				protocol.TextContent{
+					Text: mockResponseText(ex.Response),
+					Type: "text",
+				},
+			},
+		}
+		if ex.Name != "" {
+			result.Meta[_metaKeyMock] = _mockSourceExample + ":" + ex.Name
+		}
+		if ex.IsError {
+			isError := true
+			result.IsError = &isError
+		}
+		return result, nil
+	}
+
+	var schema map[string]any
+	if len(tool.ResBodyJSONSchema) > 0 {
+		_ = json.Unmarshal(tool.ResBodyJSONSchema, &schema)
+	}
+	text := "{}"
+	if schema != nil {
+		res, err := json.Marshal(mockValueOf(schema, 0))
+		if err != nil {
+			return nil, jsonrpc.Error{
+				Code:    jsonrpc.ErrCodeInternalError,
+				Message: "Failed to generate the mock response",
+				Data: map[string]any{
+					"reason":   err.Error(),
+					"toolName": tool.Name,
+				},
+			}
+		}
+		text = string(res)
+	}
+
+	return &protocol.CallToolResult{
+		Meta: protocol.CallToolResultMeta{
+			_metaKeyMock: _mockSourceSchema,
+		},
+		Content: []protocol.ContentBlock{
+			protocol.TextContent{
+				Text: text,
+				Type: "text",
+			},
+		},
+	}, nil
+}
+
+// validateMockArguments validates the arguments against the schemas of the
+// tool like the provider would, the missing groups are only validated when
+// their schema has required properties
+func validateMockArguments(tool *entity.ProviderTool, args map[entity.ToolArgumentLocation]json.RawMessage) error {
+	schemas := map[entity.ToolArgumentLocation][]byte{
+		entity.ToolArgumentLocationPath:  tool.PathArgsJSONSchema,
+		entity.ToolArgumentLocationQuery: tool.QueryArgsJSONSchema,
+		entity.ToolArgumentLocationBody:  tool.ReqBodyJSONSchema,
+	}
+
+	compiler := jsonschema.NewCompiler()
+	for _, in := range []entity.ToolArgumentLocation{
+		entity.ToolArgumentLocationPath,
+		entity.ToolArgumentLocationQuery,
+		entity.ToolArgumentLocationBody,
+	} {
+		rawSchema := schemas[in]
+		if len(rawSchema) == 0 {
+			continue
+		}
+		arg := args[in]
+		if len(arg) == 0 || string(arg) == "null" {
+			var s struct {
+				Required []string `json:"required"`
+			}
+			if json.Unmarshal(rawSchema, &s) != nil || len(s.Required) == 0 {
+				continue
+			}
+			arg = json.RawMessage("{}")
+		}
+
+		schema, err := compiler.Compile(rawSchema)
+		if err != nil {
+			// the schemas are validated on save, the old ones are not enforced
+			continue
+		}
+		res := schema.ValidateJSON(arg)
+		if res.IsValid() {
+			continue
+		}
+		return jsonrpc.Error{
+			Code:    jsonrpc.ErrCodeInvalidParams,
+			Message: fmt.Sprintf("Invalid %s arguments", strings.ToLower(in.String())),
+			Data: map[string]any{
+				"toolName": tool.Name,
+				"errors":   mockValidationErrors(res.ToList()),
+			},
+		}
+	}
+	return nil
+}
+
+func mockValidationErrors(l *jsonschema.List) []string {
+	errs := make([]string, 0)
+	var walk func(l jsonschema.List)
+	walk = func(l jsonschema.List) {
+		for _, msg := range l.Errors {
+			errs = append(errs, strings.TrimSpace(l.InstanceLocation+" "+msg))
+		}
+		for _, d := range l.Details {
+			walk(d)
+		}
+	}
+	if l != nil {
+		walk(*l)
+	}
+	return errs
+}
+
+// mockExampleMatches reports whether all rules of the example match the
+// arguments, an example without rules matches every call
+func mockExampleMatches(ex entity.ToolMockExample, args map[entity.ToolArgumentLocation]json.RawMessage) bool {
+	decoded := make(map[entity.ToolArgumentLocation]any, len(args))
+	for _, r := range ex.Rules {
+		v, ok := decoded[r.In]
+		if !ok {
+			_ = json.Unmarshal(args[r.In], &v)
+			decoded[r.In] = v
+		}
+
+		arg, ok := lookupMockArgument(v, r.Name)
+		if !ok {
+			return false
+		}
+		if r.Value != nil && mockStringOf(arg) != mockStringOf(r.Value) {
+			return false
+		}
+		if r.Pattern != "" {
+			re, err := regexp.Compile(r.Pattern)
+			if err != nil || !re.MatchString(mockStringOf(arg)) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// lookupMockArgument follows the dot separated path in the decoded arguments,
+// the array items are addressed by their index
+func lookupMockArgument(v any, path string) (any, bool) {
+	for _, key := range strings.Split(path, ".") {
+		switch node := v.(type) {
+		case map[string]any:
+			next, ok := node[key]
+			if !ok {
+				return nil, false
+			}
+			v = next
+		case []any:
+			var i int
+			if _, err := fmt.Sscanf(key, "%d", &i); err != nil || i < 0 || i >= len(node) {
+				return nil, false
+			}
+			v = node[i]
+		default:
+			return nil, false
+		}
+	}
+	return v, true
+}
+
+// mockStringOf compares the path and query arguments which are sent as
+// strings with the typed values of the rules
+func mockStringOf(v any) string {
+	if s, ok := v.(string); ok {
+		return s
+	}
+	b, _ := json.Marshal(v)
+	return string(b)
+}
+
+// mockResponseText sends the JSON strings as plain text
+func mockResponseText(res []byte) string {
+	var s string
+	if json.Unmarshal(res, &s) == nil {
+		return s
+	}
+	return string(res)
+}
+
+// mockValueOf generates a sample value of the schema, the examples, defaults,
+// constants and enums are preferred over the generated values
+func mockValueOf(schema map[string]any, depth int) any {
+	if examples, ok := schema["examples"].([]any); ok && len(examples) > 0 {
+		return examples[0]
+	}
+	for _, key := range []string{"example", "const", "default"} {
+		if v, ok := schema[key]; ok {
+			return v
+		}
+	}
+	if enum, ok := schema["enum"].([]any); ok && len(enum) > 0 {
+		return enum[0]
+	}
+	if depth >= _mockMaxDepth {
+		return nil
+	}
+
+	for _, key := range []string{"oneOf", "anyOf"} {
+		if subs, ok := schema[key].([]any); ok && len(subs) > 0 {
+			if sub, ok := subs[0].(map[string]any); ok {
+				return mockValueOf(sub, depth+1)
+			}
+		}
+	}
+	if subs, ok := schema["allOf"].([]any); ok && len(subs) > 0 {
+		merged := map[string]any{}
+		for _, s := range subs {
+			if sub, ok := mockValueOf(asSchema(s), depth+1).(map[string]any); ok {
+				for k, v := range sub {
+					merged[k] = v
+				}
+			}
+		}
+		return merged
+	}
+
+	switch mockTypeOf(schema) {
+	case "object":
+		obj := map[string]any{}
+		if props, ok := schema["properties"].(map[string]any); ok {
+			for name, prop := range props {
+				obj[name] = mockValueOf(asSchema(prop), depth+1)
+			}
+		}
+		return obj
+	case "array":
+		n := 1
+		if minItems, ok := schema["minItems"].(float64); ok && int(minItems) > n {
+			n = min(int(minItems), _mockMaxArrayItems)
+		}
+		items := make([]any, n)
+		for i := range items {
+			items[i] = mockValueOf(asSchema(schema["items"]), depth+1)
+		}
+		return items
+	case "integer":
+		return mockNumberOf(schema, 1)
+	case "number":
+		return mockNumberOf(schema, 0.01)
+	case "boolean":
+		return true
+	case "null":
+		return nil
+	case "string":
+		return mockStringValueOf(schema)
+	}
+	return nil
+}
+
+// mockTypeOf returns the first non null type of the schema, the type is
+// inferred from the keywords when it is missing
+func mockTypeOf(schema map[string]any) string {
+	switch t := schema["type"].(type) {
+	case string:
+		return t
+	case []any:
+		for _, v := range t {
+			if s, ok := v.(string); ok && s != "null" {
+				return s
+			}
+		}
+		return "null"
+	}
+	switch {
+	case schema["properties"] != nil:
+		return "object"
+	case schema["items"] != nil:
+		return "array"
+	case schema["format"] != nil, schema["pattern"] != nil:
+		return "string"
+	}
+	return ""
+}
+
+func mockNumberOf(schema map[string]any, step float64) float64 {
+	if v, ok := schema["minimum"].(float64); ok {
+		return v
+	}
+	if v, ok := schema["exclusiveMinimum"].(float64); ok {
+		return v + step
+	}
+	if v, ok := schema["maximum"].(float64); ok && v < 0 {
+		return v
+	}
+	if v, ok := schema["exclusiveMaximum"].(float64); ok && v <= 0 {
+		return v - step
+	}
+	return 0
+}
+
+func mockStringValueOf(schema map[string]any) string {
+	s := _mockDefaultString
+	if format, ok := schema["format"].(string); ok {
+		if v, ok := _mockStringFormats[format]; ok {
+			s = v
+		}
+	}
+	if minLength, ok := schema["minLength"].(float64); ok && len(s) < int(minLength) {
+		s += strings.Repeat("x", int(minLength)-len(s))
+	}
+	if maxLength, ok := schema["maxLength"].(float64); ok && len(s) > int(maxLength) {
+		s = s[:int(maxLength)]
+	}
+	return s
+}
+
+func asSchema(v any) map[string]any {
+	if s, ok := v.(map[string]any); ok {
+		return s
+	}
+	return map[string]any{}
+}
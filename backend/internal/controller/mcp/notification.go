@@ -2,7 +2,9 @@ package mcp
 
 import (
 	"context"
+	"encoding/json"
 
+	"github.com/hasmcp/hasmcp-ce/backend/internal/data/entity/jsonrpc"
 	"github.com/hasmcp/hasmcp-ce/backend/internal/service/pubsub"
 	"github.com/mustafaturan/monoflake"
 	zlog "github.com/rs/zerolog/log"
@@ -28,6 +30,11 @@ const (
 	// Server to Client
 	MethodNotificationToolsListChanged = "notifications/tools/list_changed"
 
+	// MethodNotificationProgress notifies the progress of a long-running request
+	// https://modelcontextprotocol.io/specification/2025-06-18/basic/utilities/progress
+	// Server to Client
+	MethodNotificationProgress = "notifications/progress"
+
 	/* Below methods are client to server notifications */
 
 	// MethodNotificationInitialize notifies when the the initialization completes
@@ -110,3 +117,34 @@ func (c *controller) CallNotificationsToolsListChanged(ctx context.Context, req
 	}
 	return &CallSessionResponse{}, nil
 }
+
+// notifyProgress sends the progress of the request to the session stream when
+// the client asked for it with a progress token
+func (c *controller) notifyProgress(ctx context.Context, progress float64, message string) {
+	sessionID, token := sessionOf(ctx), progressTokenOf(ctx)
+	if sessionID == 0 || token == nil {
+		return
+	}
+
+	payload, err := json.Marshal(map[string]any{
+		"jsonrpc": jsonrpc.Version,
+		"method":  MethodNotificationProgress,
+		"params": map[string]any{
+			"progressToken": token,
+			"progress":      progress,
+			"message":       message,
+		},
+	})
+	if err != nil {
+		return
+	}
+	_, err = c.pubsub.Publish(ctx, pubsub.PublishRequest{
+		PubSubID: sessionID,
+		Event: &event{
+			Data: payload,
+		},
+	})
+	if err != nil {
+		zlog.Warn().Err(err).Msg("failed to send progress notification")
+	}
+}
@@ -0,0 +1,487 @@
+package mcp
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	entity "github.com/hasmcp/hasmcp-ce/backend/internal/data/entity/crud"
+	"github.com/hasmcp/hasmcp-ce/backend/internal/data/entity/jsonrpc"
+	"github.com/hasmcp/hasmcp-ce/backend/internal/service/locksmith"
+	zlog "github.com/rs/zerolog/log"
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/clientcredentials"
+	"golang.org/x/oauth2/jwt"
+)
+
+type (
+	oauth2TokenKey struct {
+		providerID int64
+		version    int32
+		scopes     string
+		// subject keeps the tokens of the callers apart since the grant
+		// credentials may come from their variables
+		subject string
+	}
+)
+
+const (
+	// _oauth2RefreshSkew refreshes the access tokens expiring within the
+	// duration before the call
+	_oauth2RefreshSkew = time.Minute
+)
+
+// doHTTP calls the upstream with the provider access token refreshed when it
+// is about to expire, or once more after a 401 response with a refreshed token.
+// The non-interactive grants get their tokens for the tool scopes instead. The
+// recorded responses are returned in the REPLAY traffic mode.
+func (c *controller) doHTTP(ctx context.Context, provider *entity.Provider, scopes []string, req *http.Request, body []byte) (*http.Response, error) {
+	if rp := replayerOf(ctx); rp != nil {
+		return rp.replayHTTP(req, body)
+	}
+	if provider.Oauth2Config.TokenURL == "" {
+		return c.sendHTTP(ctx, provider, req, body)
+	}
+	if !provider.Oauth2Config.GrantType.Interactive() {
+		return c.doHTTPWithGrant(ctx, provider, scopes, req, body)
+	}
+	if provider.Oauth2Config.ClientID == "" {
+		return c.sendHTTP(ctx, provider, req, body)
+	}
+
+	token, expiring := c.accessTokenOf(ctx, provider)
+	if token == "" || !usesToken(req, token) {
+		return c.sendHTTP(ctx, provider, req, body)
+	}
+
+	if expiring {
+		fresh, err := c.refreshAccessToken(ctx, provider, token)
+		if err != nil {
+			zlog.Warn().Err(err).Int64("providerID", provider.ID).Msg("failed to refresh the expiring access token")
+		} else {
+			replaceToken(req, token, fresh)
+			token = fresh
+		}
+	}
+
+	res, err := c.sendHTTP(ctx, provider, req, body)
+	if err != nil || res.StatusCode != http.StatusUnauthorized {
+		return res, err
+	}
+
+	fresh, err := c.refreshAccessToken(ctx, provider, token)
+	if err != nil {
+		zlog.Warn().Err(err).Int64("providerID", provider.ID).Msg("failed to refresh the rejected access token")
+		return res, nil
+	}
+	_ = res.Body.Close()
+
+	retry := req.Clone(ctx)
+	retry.Body = io.NopCloser(bytes.NewReader(body))
+	replaceToken(retry, token, fresh)
+	return c.sendHTTP(ctx, provider, retry, body)
+}
+
+// accessTokenOf returns the access token of the provider and whether it
+// expires soon
+func (c *controller) accessTokenOf(ctx context.Context, provider *entity.Provider) (string, bool) {
+	accessTokenName, _, expiresAtName := provider.Oauth2TokenVariables()
+	owner := c.tokenOwnerOf(ctx, provider)
+	token, err := c.ownerVariable(ctx, owner, accessTokenName)
+	if err != nil {
+		return "", false
+	}
+
+	raw, err := c.ownerVariable(ctx, owner, expiresAtName)
+	if err != nil {
+		return token, false
+	}
+	expiresAt, err := strconv.ParseInt(raw, 10, 64)
+	if err != nil {
+		return token, false
+	}
+	return token, time.Now().Add(_oauth2RefreshSkew).Unix() >= expiresAt
+}
+
+// tokenOwnerOf returns the caller when they authorized the provider with
+// their own account, empty for the global tokens
+func (c *controller) tokenOwnerOf(ctx context.Context, provider *entity.Provider) string {
+	subject := entity.SubjectOf(ctx)
+	if subject == "" {
+		return ""
+	}
+	accessTokenName, _, _ := provider.Oauth2TokenVariables()
+	if _, err := c.cache.GetUserVariable(ctx, subject, accessTokenName); err != nil {
+		return ""
+	}
+	return subject
+}
+
+// ownerVariable returns the variable of the owner, the global one if the owner
+// is empty
+func (c *controller) ownerVariable(ctx context.Context, owner, name string) (string, error) {
+	if owner == "" {
+		return c.cache.GetVariable(ctx, name)
+	}
+	return c.cache.GetUserVariable(ctx, owner, name)
+}
+
+// reloadOwnerVariable returns the stored variable of the owner skipping the
+// cached value
+func (c *controller) reloadOwnerVariable(ctx context.Context, owner, name string) (string, error) {
+	if owner == "" {
+		return c.cache.ReloadVariable(ctx, name)
+	}
+	c.cache.EvictUserVariables(ctx, owner)
+	return c.cache.GetUserVariable(ctx, owner, name)
+}
+
+// refreshAccessToken exchanges the refresh token for a new access token
+// unless the used token is already replaced. The concurrent refreshes of a
+// provider share a single exchange per token owner.
+func (c *controller) refreshAccessToken(ctx context.Context, provider *entity.Provider, used string) (string, error) {
+	ctx = context.WithoutCancel(ctx)
+	owner := c.tokenOwnerOf(ctx, provider)
+	sfKey := strconv.FormatInt(provider.ID, 10) + ":" + owner
+	token, err, _ := c.oauth2Refreshes.Do(sfKey, func() (any, error) {
+		accessTokenName, refreshTokenName, expiresAtName := provider.Oauth2TokenVariables()
+
+		// another call or replica may have refreshed it already
+		if current, err := c.reloadOwnerVariable(ctx, owner, accessTokenName); err == nil && current != used {
+			return current, nil
+		}
+
+		refreshToken, err := c.ownerVariable(ctx, owner, refreshTokenName)
+		if err != nil || refreshToken == "" {
+			return nil, errors.New("refresh token is not found")
+		}
+
+		oauth2Cfg := provider.Oauth2Config
+		clientSecret, err := c.clientSecretOf(ctx, provider)
+		if err != nil {
+			return nil, err
+		}
+
+		cfg := &oauth2.Config{
+			ClientID:     oauth2Cfg.ClientID,
+			ClientSecret: clientSecret,
+			Endpoint: oauth2.Endpoint{
+				AuthURL:   oauth2Cfg.AuthURL,
+				TokenURL:  oauth2Cfg.TokenURL,
+				AuthStyle: oauth2.AuthStyleAutoDetect,
+			},
+		}
+		tokenCtx, err := c.oauth2ContextOf(ctx, provider)
+		if err != nil {
+			return nil, err
+		}
+		token, err := cfg.TokenSource(tokenCtx, &oauth2.Token{RefreshToken: refreshToken}).Token()
+		if err != nil {
+			return nil, err
+		}
+
+		if err := c.saveVariable(ctx, owner, accessTokenName, entity.VariableTypeSecret, token.AccessToken); err != nil {
+			return nil, err
+		}
+		if token.RefreshToken != "" && token.RefreshToken != refreshToken {
+			// rotated refresh token
+			if err := c.saveVariable(ctx, owner, refreshTokenName, entity.VariableTypeSecret, token.RefreshToken); err != nil {
+				return nil, err
+			}
+		}
+		if !token.Expiry.IsZero() {
+			if err := c.saveVariable(ctx, owner, expiresAtName, entity.VariableTypeEnv, strconv.FormatInt(token.Expiry.Unix(), 10)); err != nil {
+				return nil, err
+			}
+		}
+
+		zlog.Info().Int64("providerID", provider.ID).Msg("refreshed the provider access token")
+		return token.AccessToken, nil
+	})
+	if err != nil {
+		return "", err
+	}
+	return token.(string), nil
+}
+
+// doHTTPWithGrant calls the upstream with the grant token, a rejected token
+// is fetched again once since it may be revoked before its expiry
+func (c *controller) doHTTPWithGrant(ctx context.Context, provider *entity.Provider, scopes []string, req *http.Request, body []byte) (*http.Response, error) {
+	token, err := c.applyGrantToken(ctx, provider, scopes, req.Header, "")
+	if err != nil {
+		return nil, err
+	}
+	res, err := c.sendHTTP(ctx, provider, req, body)
+	if err != nil || token == "" || res.StatusCode != http.StatusUnauthorized {
+		return res, err
+	}
+
+	retry := req.Clone(ctx)
+	retry.Header.Del(_headerAuthorization)
+	if _, err := c.applyGrantToken(ctx, provider, scopes, retry.Header, token); err != nil {
+		zlog.Warn().Err(err).Int64("providerID", provider.ID).Msg("failed to fetch the rejected grant token again")
+		return res, nil
+	}
+	_ = res.Body.Close()
+
+	retry.Body = io.NopCloser(bytes.NewReader(body))
+	return c.sendHTTP(ctx, provider, retry, body)
+}
+
+// applyGrantToken sets the Authorization header with the token of the
+// non-interactive grant and returns the token. The tool and caller headers
+// take precedence. A rejected token is not reused.
+func (c *controller) applyGrantToken(ctx context.Context, provider *entity.Provider, scopes []string, headers http.Header, rejected string) (string, error) {
+	if provider.Oauth2Config.TokenURL == "" || provider.Oauth2Config.GrantType.Interactive() ||
+		headers.Get(_headerAuthorization) != "" || replayerOf(ctx) != nil {
+		return "", nil
+	}
+
+	token, err := c.grantToken(ctx, provider, scopes, rejected)
+	if err != nil {
+		// the error may carry the token endpoint response, it is only logged
+		zlog.Error().Err(err).Int64("providerID", provider.ID).
+			Str("grantType", provider.Oauth2Config.GrantType.String()).
+			Msg("failed to fetch the provider grant token")
+		return "", jsonrpc.Error{
+			Code:    jsonrpc.ErrCodeInternalError,
+			Message: "Oauth2 token request failed",
+			Data: map[string]any{
+				"reason":     "the token endpoint did not issue a token, see the server logs",
+				"providerID": provider.ID,
+				"grantType":  provider.Oauth2Config.GrantType.String(),
+			},
+		}
+	}
+	headers.Set(_headerAuthorization, token.Type()+" "+token.AccessToken)
+	return token.AccessToken, nil
+}
+
+// grantToken returns the cached token of the provider scopes or fetches a new
+// one. The concurrent fetches of the same scopes share a single request.
+func (c *controller) grantToken(ctx context.Context, provider *entity.Provider, scopes []string, rejected string) (*oauth2.Token, error) {
+	key := oauth2TokenKey{
+		providerID: provider.ID,
+		version:    provider.Version,
+		scopes:     strings.Join(normalizeScopes(scopes), " "),
+		subject:    entity.SubjectOf(ctx),
+	}
+	usable := func() *oauth2.Token {
+		v, ok := c.oauth2Tokens.Load(key)
+		if !ok {
+			return nil
+		}
+		token := v.(*oauth2.Token)
+		if token.AccessToken == rejected {
+			return nil
+		}
+		if !token.Expiry.IsZero() && time.Now().Add(_oauth2RefreshSkew).After(token.Expiry) {
+			return nil
+		}
+		return token
+	}
+	if token := usable(); token != nil {
+		return token, nil
+	}
+
+	ctx = context.WithoutCancel(ctx)
+	sfKey := "grant:" + strconv.FormatInt(key.providerID, 10) + ":" + key.scopes + ":" + key.subject
+	token, err, _ := c.oauth2Refreshes.Do(sfKey, func() (any, error) {
+		// a concurrent call may have fetched it already
+		if token := usable(); token != nil {
+			return token, nil
+		}
+
+		token, err := c.fetchGrantToken(ctx, provider, strings.Fields(key.scopes))
+		if err != nil {
+			return nil, err
+		}
+
+		// the tokens of the previous provider versions are stale
+		c.oauth2Tokens.Range(func(k, _ any) bool {
+			if k := k.(oauth2TokenKey); k.providerID == key.providerID && k.version != key.version {
+				c.oauth2Tokens.Delete(k)
+			}
+			return true
+		})
+		c.oauth2Tokens.Store(key, token)
+
+		zlog.Info().Int64("providerID", provider.ID).
+			Str("grantType", provider.Oauth2Config.GrantType.String()).
+			Str("scopes", key.scopes).
+			Msg("fetched the provider grant token")
+		return token, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return token.(*oauth2.Token), nil
+}
+
+// fetchGrantToken requests a token from the token endpoint with the grant of
+// the provider
+func (c *controller) fetchGrantToken(ctx context.Context, provider *entity.Provider, scopes []string) (*oauth2.Token, error) {
+	oauth2Cfg := provider.Oauth2Config
+	resolve := func(s string) string {
+		return entity.ReplaceVariables(ctx, s, c.cache)
+	}
+	tokenCtx, err := c.oauth2ContextOf(ctx, provider)
+	if err != nil {
+		return nil, err
+	}
+
+	switch oauth2Cfg.GrantType {
+	case entity.GrantTypeClientCredentials:
+		clientSecret, err := c.clientSecretOf(ctx, provider)
+		if err != nil {
+			return nil, err
+		}
+		cfg := &clientcredentials.Config{
+			ClientID:     oauth2Cfg.ClientID,
+			ClientSecret: clientSecret,
+			TokenURL:     oauth2Cfg.TokenURL,
+			Scopes:       scopes,
+			AuthStyle:    oauth2.AuthStyleAutoDetect,
+		}
+		if oauth2Cfg.Audience != "" {
+			cfg.EndpointParams = url.Values{"audience": []string{oauth2Cfg.Audience}}
+		}
+		return cfg.Token(tokenCtx)
+	case entity.GrantTypeJWTBearer:
+		issuer := oauth2Cfg.Issuer
+		if issuer == "" {
+			issuer = oauth2Cfg.ClientID
+		}
+		privateKey, err := c.decryptSecret(ctx, oauth2Cfg.PrivateKey, oauth2Cfg.PrivateKeyEncrypted, oauth2Cfg.PrivateKeyEncryptionNonce)
+		if err != nil {
+			return nil, err
+		}
+		cfg := &jwt.Config{
+			Email:      resolve(issuer),
+			Subject:    resolve(oauth2Cfg.Subject),
+			PrivateKey: []byte(resolve(privateKey)),
+			Scopes:     scopes,
+			TokenURL:   oauth2Cfg.TokenURL,
+			Audience:   oauth2Cfg.Audience,
+		}
+		return cfg.TokenSource(tokenCtx).Token()
+	case entity.GrantTypePassword:
+		clientSecret, err := c.clientSecretOf(ctx, provider)
+		if err != nil {
+			return nil, err
+		}
+		password, err := c.decryptSecret(ctx, oauth2Cfg.Password, oauth2Cfg.PasswordEncrypted, oauth2Cfg.PasswordEncryptionNonce)
+		if err != nil {
+			return nil, err
+		}
+		cfg := &oauth2.Config{
+			ClientID:     oauth2Cfg.ClientID,
+			ClientSecret: clientSecret,
+			Scopes:       scopes,
+			Endpoint: oauth2.Endpoint{
+				TokenURL:  oauth2Cfg.TokenURL,
+				AuthStyle: oauth2.AuthStyleAutoDetect,
+			},
+		}
+		return cfg.PasswordCredentialsToken(tokenCtx, resolve(oauth2Cfg.Username), resolve(password))
+	default:
+		return nil, errors.New("unsupported grant type")
+	}
+}
+
+// oauth2ContextOf returns the context of the token requests of the provider,
+// they are sent through its egress allow and its transport config
+func (c *controller) oauth2ContextOf(ctx context.Context, provider *entity.Provider) (context.Context, error) {
+	client, err := c.ProviderHTTPClient(ctx, provider)
+	if err != nil {
+		return nil, err
+	}
+	return context.WithValue(ctx, oauth2.HTTPClient, client), nil
+}
+
+// clientSecretOf decrypts the oauth2 client secret of the provider
+func (c *controller) clientSecretOf(ctx context.Context, provider *entity.Provider) (string, error) {
+	oauth2Cfg := provider.Oauth2Config
+	return c.decryptSecret(ctx, oauth2Cfg.ClientSecret, oauth2Cfg.ClientSecretEncrypted, oauth2Cfg.ClientSecretEncryptionNonce)
+}
+
+// decryptSecret decrypts the oauth2 secret, the plaintext is returned when the
+// secret is not encrypted
+func (c *controller) decryptSecret(ctx context.Context, plaintext string, encrypted, nonce []byte) (string, error) {
+	if len(encrypted) == 0 {
+		return plaintext, nil
+	}
+	res, err := c.locksmith.Decrypt(ctx, &locksmith.DecryptRequest{
+		Nonce:      nonce,
+		Ciphertext: encrypted,
+	})
+	if err != nil {
+		return "", err
+	}
+	return string(res.Plaintext), nil
+}
+
+// normalizeScopes trims, de-duplicates and sorts the scopes so that the
+// tools requiring the same scopes share a token
+func normalizeScopes(scopes []string) []string {
+	set := make(map[string]struct{}, len(scopes))
+	normalized := make([]string, 0, len(scopes))
+	for _, s := range scopes {
+		s = strings.TrimSpace(s)
+		if s == "" {
+			continue
+		}
+		if _, ok := set[s]; ok {
+			continue
+		}
+		set[s] = struct{}{}
+		normalized = append(normalized, s)
+	}
+	sort.Strings(normalized)
+	return normalized
+}
+
+// saveVariable replaces the variable of the owner through the crud
+// controller, which validates, encrypts and evicts it on all the replicas
+func (c *controller) saveVariable(ctx context.Context, owner, name string, variableType entity.VariableType, value string) error {
+	if c.variables == nil {
+		return errors.New("variable saver is not set")
+	}
+	return c.variables.SaveVariable(ctx, entity.SaveVariableRequest{
+		Variable: entity.Variable{
+			Type:    variableType,
+			Name:    name,
+			Value:   []byte(value),
+			Subject: owner,
+		},
+	})
+}
+
+// usesToken reports whether the token is sent in a header or in the query
+func usesToken(req *http.Request, token string) bool {
+	for _, vals := range req.Header {
+		for _, v := range vals {
+			if strings.Contains(v, token) {
+				return true
+			}
+		}
+	}
+	return strings.Contains(req.URL.RawQuery, url.QueryEscape(token))
+}
+
+// replaceToken replaces the old token in the headers and in the query
+func replaceToken(req *http.Request, old, fresh string) {
+	for k, vals := range req.Header {
+		for i, v := range vals {
+			req.Header[k][i] = strings.ReplaceAll(v, old, fresh)
+		}
+	}
+	req.URL.RawQuery = strings.ReplaceAll(req.URL.RawQuery, url.QueryEscape(old), url.QueryEscape(fresh))
+}
@@ -0,0 +1,186 @@
+package mcp
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+
+	protocol "github.com/hasmcp/hasmcp-ce/backend/internal/controller/mcp/protocol/p250618"
+	entity "github.com/hasmcp/hasmcp-ce/backend/internal/data/entity/crud"
+	"github.com/hasmcp/hasmcp-ce/backend/internal/data/entity/jsonrpc"
+)
+
+func argsKeyOf(in entity.ToolArgumentLocation) string {
+	switch in {
+	case entity.ToolArgumentLocationPath:
+		return _argsPathArgs
+	case entity.ToolArgumentLocationQuery:
+		return _argsQueryArgs
+	case entity.ToolArgumentLocationBody:
+		return _argsBodyArgs
+	default:
+		return ""
+	}
+}
+
+// applySchemaOverride removes the hidden arguments from the input schema and
+// sets the defaults and the narrowed enums of the visible ones. It returns
+// the top level required list without the groups left empty.
+func applySchemaOverride(props protocol.ToolInputSchemaProperties, required []string, o entity.ServerToolOverride) []string {
+	for _, a := range o.Arguments {
+		key := argsKeyOf(a.In)
+		group, ok := props[key]
+		if !ok {
+			continue
+		}
+		groupProps, _ := group["properties"].(map[string]any)
+		argSchema, ok := groupProps[a.Name].(map[string]any)
+		if !ok {
+			continue
+		}
+
+		if a.Hidden {
+			delete(groupProps, a.Name)
+			group["required"] = withoutName(group["required"], a.Name)
+			if len(groupProps) == 0 {
+				delete(props, key)
+				required = withoutKey(required, key)
+			}
+			continue
+		}
+
+		if a.Default != nil {
+			argSchema["default"] = a.Default
+			// the default is sent when the argument is missing
+			group["required"] = withoutName(group["required"], a.Name)
+		}
+		if len(a.Enum) > 0 {
+			argSchema["enum"] = a.Enum
+		}
+	}
+	return required
+}
+
+// applyArgumentPresets injects the hidden argument values and the defaults
+// into the tool call arguments and verifies the narrowed enums. The variables
+// are interpolated only in the preset values, never in the values of the
+// caller, so that the callers cannot read the variables.
+func applyArgumentPresets(
+	args protocol.CallToolRequestParamsArguments,
+	o entity.ServerToolOverride,
+	interpolate func(val any, in entity.ToolArgumentLocation) (any, error),
+) (protocol.CallToolRequestParamsArguments, error) {
+	if len(o.Arguments) == 0 {
+		return args, nil
+	}
+
+	merged := make(protocol.CallToolRequestParamsArguments, len(args)+3)
+	for k, v := range args {
+		merged[k] = v
+	}
+
+	groups := make(map[string]map[string]json.RawMessage, 3)
+	for _, a := range o.Arguments {
+		key := argsKeyOf(a.In)
+		group, ok := groups[key]
+		if !ok {
+			group = make(map[string]json.RawMessage)
+			if raw := merged[key]; len(raw) > 0 && string(raw) != "null" {
+				if err := json.Unmarshal(raw, &group); err != nil {
+					return nil, jsonrpc.Error{
+						Code:    jsonrpc.ErrCodeInvalidParams,
+						Message: "arguments must be an object",
+						Data: map[string]any{
+							"arguments": key,
+						},
+					}
+				}
+			}
+			groups[key] = group
+		}
+
+		var val any
+		switch {
+		case a.Hidden:
+			val = a.Value
+		case len(group[a.Name]) > 0:
+			if len(a.Enum) > 0 && !inEnum(group[a.Name], a.Enum) {
+				return nil, jsonrpc.Error{
+					Code:    jsonrpc.ErrCodeInvalidParams,
+					Message: "argument value is not allowed",
+					Data: map[string]any{
+						"argument": a.Name,
+						"enum":     a.Enum,
+					},
+				}
+			}
+			continue
+		case a.Default != nil:
+			val = a.Default
+		default:
+			continue
+		}
+
+		// path and query arguments are sent as strings
+		if a.In != entity.ToolArgumentLocationBody {
+			if _, ok := val.(string); !ok {
+				val = fmt.Sprint(val)
+			}
+		}
+		val, err := interpolate(val, a.In)
+		if err != nil {
+			return nil, err
+		}
+		data, err := json.Marshal(val)
+		if err != nil {
+			return nil, err
+		}
+		group[a.Name] = data
+	}
+
+	for key, group := range groups {
+		data, err := json.Marshal(group)
+		if err != nil {
+			return nil, err
+		}
+		merged[key] = data
+	}
+	return merged, nil
+}
+
+func inEnum(raw json.RawMessage, enum []any) bool {
+	var val any
+	if err := json.Unmarshal(raw, &val); err != nil {
+		return false
+	}
+	for _, e := range enum {
+		if reflect.DeepEqual(val, e) || fmt.Sprint(val) == fmt.Sprint(e) {
+			return true
+		}
+	}
+	return false
+}
+
+func withoutName(required any, name string) any {
+	list, ok := required.([]any)
+	if !ok {
+		return required
+	}
+	filtered := make([]any, 0, len(list))
+	for _, r := range list {
+		if r != name {
+			filtered = append(filtered, r)
+		}
+	}
+	return filtered
+}
+
+func withoutKey(required []string, key string) []string {
+	filtered := make([]string, 0, len(required))
+	for _, r := range required {
+		if r != key {
+			filtered = append(filtered, r)
+		}
+	}
+	return filtered
+}
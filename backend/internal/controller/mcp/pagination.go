@@ -0,0 +1,285 @@
+package mcp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+
+	protocol "github.com/hasmcp/hasmcp-ce/backend/internal/controller/mcp/protocol/p250618"
+	entity "github.com/hasmcp/hasmcp-ce/backend/internal/data/entity/crud"
+	zlog "github.com/rs/zerolog/log"
+)
+
+const (
+	_metaKeyPagination = "hasmcp/pagination"
+
+	_paginationDefaultMaxPages = 10
+	_paginationDefaultMaxItems = 1000
+	_paginationDefaultStart    = 1
+
+	_headerLink = "Link"
+)
+
+var (
+	_regexLinkHeader = regexp.MustCompile(`<([^>]*)>([^<]*)`)
+	_regexLinkRel    = regexp.MustCompile(`(?i)\brel\s*=\s*"?([^";,]+)"?`)
+)
+
+// paginatedPage is the state of the last fetched page
+type paginatedPage struct {
+	url   *url.URL
+	res   *http.Response
+	body  []byte
+	items []any
+}
+
+// withFirstPage sets the configured page size on the first request when the
+// call has no page size
+func withFirstPage(u *url.URL, cfg *entity.ToolPaginationConfig) {
+	if cfg == nil || cfg.LimitParam == "" || cfg.PageSize <= 0 {
+		return
+	}
+	if cfg.Type != entity.PaginationTypeOffset && cfg.Type != entity.PaginationTypePage {
+		return
+	}
+	query := u.Query()
+	if !query.Has(cfg.LimitParam) {
+		query.Set(cfg.LimitParam, strconv.Itoa(cfg.PageSize))
+		u.RawQuery = query.Encode()
+	}
+}
+
+// paginate follows the next pages of the first page and returns their items
+// concatenated. It reports false when the first page is not a list so that the
+// body is returned as it is. The failing pages end the pagination as truncated.
+func (c *controller) paginate(
+	ctx context.Context,
+	provider *entity.Provider,
+	tool *entity.ProviderTool,
+	req *http.Request,
+	authQuery url.Values,
+	bodyArgs json.RawMessage,
+	res *http.Response,
+	resBody []byte,
+) (*protocol.CallToolResult, bool) {
+	cfg := tool.PaginationConfig
+	items, ok := pageItemsOf(resBody, cfg.ItemsPath)
+	if !ok {
+		return nil, false
+	}
+
+	maxPages := cfg.MaxPages
+	if maxPages <= 0 {
+		maxPages = _paginationDefaultMaxPages
+	}
+	maxItems := cfg.MaxItems
+	if maxItems <= 0 {
+		maxItems = _paginationDefaultMaxItems
+	}
+
+	page := paginatedPage{url: req.URL, res: res, body: resBody, items: items}
+	all := items
+	pages := 1
+	truncated := false
+	for {
+		next, err := c.nextPageURL(provider, cfg, page, authQuery)
+		if err != nil {
+			zlog.Warn().Err(err).Int64("toolID", tool.ID).Msg("failed to resolve the next page")
+			truncated = true
+			break
+		}
+		if len(all) >= maxItems {
+			truncated = len(all) > maxItems || next != nil
+			all = all[:maxItems]
+			break
+		}
+		if next == nil {
+			break
+		}
+		if pages >= maxPages {
+			truncated = true
+			break
+		}
+
+		nextPage, err := c.fetchPage(ctx, provider, tool, req.Header, bodyArgs, next)
+		if err != nil {
+			zlog.Warn().Err(err).Int64("toolID", tool.ID).Msg("failed to fetch the next page")
+			truncated = true
+			break
+		}
+		page = *nextPage
+		all = append(all, page.items...)
+		pages++
+	}
+
+	text, err := json.Marshal(map[string]any{
+		"items":     all,
+		"itemCount": len(all),
+		"pageCount": pages,
+		"truncated": truncated,
+	})
+	if err != nil {
+		return nil, false
+	}
+	return &protocol.CallToolResult{
+		Meta: protocol.CallToolResultMeta{
+			_metaKeyPagination: map[string]any{
+				"pageCount": pages,
+				"truncated": truncated,
+			},
+		},
+		Content: []protocol.ContentBlock{
+			protocol.TextContent{
+				Text: string(text),
+				Type: "text",
+			},
+		},
+	}, true
+}
+
+func (c *controller) fetchPage(
+	ctx context.Context,
+	provider *entity.Provider,
+	tool *entity.ProviderTool,
+	headers http.Header,
+	bodyArgs json.RawMessage,
+	target *url.URL,
+) (*paginatedPage, error) {
+	req := &http.Request{
+		Method: tool.Method.String(),
+		URL:    target,
+		Header: headers.Clone(),
+		Body:   io.NopCloser(bytes.NewReader(bodyArgs)),
+	}
+	res, err := c.doHTTP(ctx, provider, tool.Oauth2Scopes, req, bodyArgs)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	body, _ := io.ReadAll(res.Body)
+
+	items, ok := pageItemsOf(body, tool.PaginationConfig.ItemsPath)
+	if res.StatusCode >= http.StatusMultipleChoices || !ok {
+		return nil, fmt.Errorf("page is not a list, status code: %d", res.StatusCode)
+	}
+	return &paginatedPage{url: target, res: res, body: body, items: items}, nil
+}
+
+// nextPageURL returns the URL of the page after the given page, nil when it
+// is the last page
+func (c *controller) nextPageURL(
+	provider *entity.Provider,
+	cfg *entity.ToolPaginationConfig,
+	page paginatedPage,
+	authQuery url.Values,
+) (*url.URL, error) {
+	switch cfg.Type {
+	case entity.PaginationTypeLink:
+		link := nextLinkOf(page.res.Header.Values(_headerLink))
+		if link == "" {
+			return nil, nil
+		}
+		next, err := c.providerURLOf(provider, page.url, link)
+		if err != nil {
+			return nil, err
+		}
+		withQuery(next, authQuery)
+		return next, nil
+
+	case entity.PaginationTypeCursor:
+		var doc any
+		if err := json.Unmarshal(page.body, &doc); err != nil {
+			return nil, nil
+		}
+		cursor, ok := lookupJSONPath(doc, cfg.CursorPath)
+		if !ok || cursor == nil || cursor == false || cursor == "" {
+			return nil, nil
+		}
+		return withQueryParam(page.url, cfg.Param, mockStringOf(cursor)), nil
+
+	case entity.PaginationTypeOffset, entity.PaginationTypePage:
+		count := len(page.items)
+		if count == 0 {
+			return nil, nil
+		}
+		query := page.url.Query()
+		pageSize := cfg.PageSize
+		if cfg.LimitParam != "" {
+			if size, err := strconv.Atoi(query.Get(cfg.LimitParam)); err == nil {
+				pageSize = size
+			}
+		}
+		if pageSize > 0 && count < pageSize {
+			return nil, nil
+		}
+
+		current, err := strconv.Atoi(query.Get(cfg.Param))
+		if cfg.Type == entity.PaginationTypeOffset {
+			if err != nil {
+				current = 0
+			}
+			return withQueryParam(page.url, cfg.Param, strconv.Itoa(current+count)), nil
+		}
+		if err != nil {
+			current = cfg.StartPage
+			if current <= 0 {
+				current = _paginationDefaultStart
+			}
+		}
+		return withQueryParam(page.url, cfg.Param, strconv.Itoa(current+1)), nil
+	}
+	return nil, nil
+}
+
+// pageItemsOf returns the item array of the page body, the numbers are kept
+// as they are sent
+func pageItemsOf(body []byte, path string) ([]any, bool) {
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+	var doc any
+	if err := dec.Decode(&doc); err != nil {
+		return nil, false
+	}
+	if path != "" {
+		var ok bool
+		if doc, ok = lookupJSONPath(doc, path); !ok {
+			return nil, false
+		}
+	}
+	items, ok := doc.([]any)
+	return items, ok
+}
+
+// nextLinkOf returns the target of the rel="next" link of the RFC 8288 Link
+// header values
+func nextLinkOf(values []string) string {
+	for _, v := range values {
+		for _, m := range _regexLinkHeader.FindAllStringSubmatch(v, -1) {
+			rel := _regexLinkRel.FindStringSubmatch(m[2])
+			if rel == nil {
+				continue
+			}
+			for _, r := range strings.Fields(rel[1]) {
+				if strings.EqualFold(r, "next") {
+					return strings.TrimSpace(m[1])
+				}
+			}
+		}
+	}
+	return ""
+}
+
+func withQueryParam(u *url.URL, name, value string) *url.URL {
+	next := *u
+	query := next.Query()
+	query.Set(name, value)
+	next.RawQuery = query.Encode()
+	return &next
+}
@@ -62,6 +62,9 @@ func (c *controller) CallPromptsList(ctx context.Context, req CallSessionRequest
 	var nextCursor *string
 	if nextCursorIndex != -1 {
 		nextCursor = stringPtr(strconv.Itoa(nextCursorIndex))
+	} else {
+		// the upstream prompts follow the local ones on the last page
+		prompts = append(prompts, c.upstreamPrompts(ctx, srv, req)...)
 	}
 
 	response := protocol.ListPromptsResult{
@@ -104,6 +107,22 @@ func (c *controller) CallPromptsGet(ctx context.Context, req CallSessionRequest)
 		}
 	}
 
+	// The prompts of the upstream MCP servers are prefixed with the provider
+	if data, ok, err := c.getUpstreamPrompt(ctx, srv, req, params.Name, params.Arguments); ok {
+		if err != nil {
+			return nil, err
+		}
+		return &CallSessionResponse{
+			HTTPStatusCode: 200,
+			McpSessionID:   req.McpSessionID,
+			Result: &jsonrpc.ResultResponse{
+				JSONRpc: jsonrpc.Version,
+				Result:  data,
+				ID:      req.Request.ID,
+			},
+		}, nil
+	}
+
 	// Find the prompt by name (which is the monoflake ID string)
 	promptIDPart := ""
 	if len(params.Name) >= 12 {
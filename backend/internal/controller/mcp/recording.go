@@ -0,0 +1,457 @@
+package mcp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	protocol "github.com/hasmcp/hasmcp-ce/backend/internal/controller/mcp/protocol/p250618"
+	entity "github.com/hasmcp/hasmcp-ce/backend/internal/data/entity/crud"
+	"github.com/hasmcp/hasmcp-ce/backend/internal/data/entity/jsonrpc"
+	"github.com/hasmcp/hasmcp-ce/backend/internal/data/model"
+	modelmapper "github.com/hasmcp/hasmcp-ce/backend/internal/mapper/model"
+	zlog "github.com/rs/zerolog/log"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+type (
+	recorderCtxKey struct{}
+	replayerCtxKey struct{}
+
+	// recorder collects the upstream interactions of a tool call in the
+	// RECORD traffic mode
+	recorder struct {
+		mu           sync.Mutex
+		redactor     redactor
+		interactions []entity.RecordedInteraction
+	}
+
+	// replayer answers the upstream requests of a tool call from the recorded
+	// interactions of the tool in the REPLAY traffic mode
+	replayer struct {
+		mu           sync.Mutex
+		redactor     redactor
+		interactions []entity.RecordedInteraction
+		replayed     map[int]struct{}
+	}
+
+	// redactor masks the credentials of the provider in the recorded traffic,
+	// the live requests are redacted the same way to match the recordings
+	redactor struct {
+		headers map[string]struct{} // canonical header names
+		queries map[string]struct{} // lower case query names
+		secrets []string            // resolved credential values
+	}
+
+	// recordingBody records the response body once it is read by the caller,
+	// the event streams are recorded up to the point they were read
+	recordingBody struct {
+		io.ReadCloser
+		buf  bytes.Buffer
+		once sync.Once
+		done func(body []byte)
+	}
+)
+
+const (
+	_redacted = "[REDACTED]"
+
+	_recordedMethodGRPC      = "GRPC"
+	_recordedBodyMaxSize     = 1 << 20
+	_redactedSecretMinLength = 4
+)
+
+var (
+	_redactedHeaders = []string{
+		"Authorization",
+		"Proxy-Authorization",
+		"Cookie",
+		"Set-Cookie",
+		"X-Api-Key",
+		"X-Auth-Token",
+		"X-Amz-Security-Token",
+	}
+
+	_redactedQueries = []string{
+		"access_token",
+		"api_key",
+		"apikey",
+		"client_secret",
+		"key",
+		"signature",
+		"token",
+		"x-amz-credential",
+		"x-amz-security-token",
+		"x-amz-signature",
+	}
+)
+
+func withRecorder(ctx context.Context, rec *recorder) context.Context {
+	return context.WithValue(ctx, recorderCtxKey{}, rec)
+}
+
+func recorderOf(ctx context.Context) *recorder {
+	rec, _ := ctx.Value(recorderCtxKey{}).(*recorder)
+	return rec
+}
+
+func withReplayer(ctx context.Context, rp *replayer) context.Context {
+	return context.WithValue(ctx, replayerCtxKey{}, rp)
+}
+
+func replayerOf(ctx context.Context) *replayer {
+	rp, _ := ctx.Value(replayerCtxKey{}).(*replayer)
+	return rp
+}
+
+// withTraffic prepares the recording or the replay of the tool call upstream
+// traffic for the traffic mode of the server
+func (c *controller) withTraffic(ctx context.Context, srv *server, serverID int64, provider *entity.Provider, tool *entity.ProviderTool) (context.Context, *recorder, error) {
+	switch srv.trafficMode {
+	case entity.TrafficModeRecord:
+		rec := &recorder{redactor: c.redactorOf(ctx, provider, tool)}
+		return withRecorder(ctx, rec), rec, nil
+	case entity.TrafficModeReplay:
+		recordings, err := c.storage.ListServerRecordings(ctx, serverID)
+		if err != nil {
+			return ctx, nil, jsonrpc.Error{
+				Code:    jsonrpc.ErrCodeInternalError,
+				Message: "Failed to load the recorded traffic",
+				Data: map[string]any{
+					"reason":   err.Error(),
+					"toolName": tool.Name,
+				},
+			}
+		}
+		// the interactions of the tool are matched first, the others answer the
+		// shared requests like the upstream MCP session handshake
+		rp := &replayer{redactor: c.redactorOf(ctx, provider, tool)}
+		var others []entity.RecordedInteraction
+		for _, r := range modelmapper.FromServerRecordingModelsToServerRecordingEntities(recordings) {
+			if r.ToolID == tool.ID {
+				rp.interactions = append(rp.interactions, r.Interactions...)
+			} else {
+				others = append(others, r.Interactions...)
+			}
+		}
+		rp.interactions = append(rp.interactions, others...)
+		return withReplayer(ctx, rp), nil, nil
+	}
+	return ctx, nil, nil
+}
+
+// saveRecording stores the tool call with the upstream interactions it
+// produced, the failures are logged since the call itself succeeded
+func (c *controller) saveRecording(serverID int64, tool *entity.ProviderTool, arguments json.RawMessage, res *protocol.CallToolResult, callErr error, rec *recorder) {
+	recording := entity.ServerRecording{
+		ID:        c.idgen.Next(),
+		CreatedAt: time.Now().UTC(),
+		ServerID:  serverID,
+		ToolID:    tool.ID,
+		ToolName:  tool.Name,
+		Arguments: []byte(rec.redactor.redact(string(arguments))),
+	}
+
+	var result []byte
+	if callErr != nil {
+		e, ok := callErr.(jsonrpc.Error)
+		if !ok {
+			e = jsonrpc.Error{Code: jsonrpc.ErrCodeInternalError, Message: callErr.Error()}
+		}
+		result, _ = json.Marshal(e)
+		recording.IsError = true
+	} else {
+		result, _ = json.Marshal(res)
+	}
+	recording.Result = []byte(rec.redactor.redact(string(result)))
+
+	rec.mu.Lock()
+	recording.Interactions = append([]entity.RecordedInteraction(nil), rec.interactions...)
+	rec.mu.Unlock()
+
+	m := modelmapper.FromServerRecordingEntityToServerRecordingModel(recording)
+	if err := c.storage.CreateServerRecordings(context.Background(), []model.ServerRecording{m}); err != nil {
+		zlog.Error().Err(err).Int64("serverID", serverID).Int64("toolID", tool.ID).Msg("failed to save the tool call recording")
+	}
+}
+
+// redactorOf collects the credentials of the provider auth, the signer, the
+// tool headers and the variables of the base URL and the path to mask them in
+// the recorded traffic
+func (c *controller) redactorOf(ctx context.Context, provider *entity.Provider, tool *entity.ProviderTool) redactor {
+	r := redactor{
+		headers: make(map[string]struct{}, len(_redactedHeaders)+2),
+		queries: make(map[string]struct{}, len(_redactedQueries)+1),
+	}
+	for _, h := range _redactedHeaders {
+		r.headers[http.CanonicalHeaderKey(h)] = struct{}{}
+	}
+	for _, q := range _redactedQueries {
+		r.queries[q] = struct{}{}
+	}
+
+	refs := make([]string, 0, len(tool.Headers)+10)
+	refs = append(refs, provider.BaseURL, tool.Path)
+	for _, h := range tool.Headers {
+		refs = append(refs, h.Value)
+	}
+	if auth := provider.AuthConfig; auth != nil {
+		refs = append(refs, auth.Value, auth.Username, auth.Password)
+		switch auth.In {
+		case entity.AuthLocationHeader:
+			r.headers[http.CanonicalHeaderKey(auth.Name)] = struct{}{}
+		case entity.AuthLocationQuery:
+			r.queries[strings.ToLower(auth.Name)] = struct{}{}
+		}
+	}
+	if signer := provider.SignerConfig; signer != nil {
+		refs = append(refs, signer.AccessKeyID, signer.SecretAccessKey, signer.SessionToken, signer.Secret)
+		if signer.Header != "" {
+			r.headers[http.CanonicalHeaderKey(signer.Header)] = struct{}{}
+		}
+	}
+
+	for _, ref := range refs {
+		for _, name := range extractVariables(ref) {
+			v, err := entity.ResolveVariable(ctx, name, c.cache)
+			if err == nil && len(v) >= _redactedSecretMinLength {
+				r.secrets = append(r.secrets, v)
+			}
+		}
+	}
+	return r
+}
+
+func (r redactor) redact(s string) string {
+	for _, secret := range r.secrets {
+		s = strings.ReplaceAll(s, secret, _redacted)
+	}
+	return s
+}
+
+func (r redactor) redactHeaders(h http.Header) map[string][]string {
+	if len(h) == 0 {
+		return nil
+	}
+	headers := make(map[string][]string, len(h))
+	for k, vals := range h {
+		if _, ok := r.headers[http.CanonicalHeaderKey(k)]; ok {
+			headers[k] = []string{_redacted}
+			continue
+		}
+		redacted := make([]string, len(vals))
+		for i, v := range vals {
+			redacted[i] = r.redact(v)
+		}
+		headers[k] = redacted
+	}
+	return headers
+}
+
+// redactURL masks the credential query values, the query is encoded in the
+// key order so that the recorded and the live URLs compare equal
+func (r redactor) redactURL(u *url.URL) string {
+	redacted := *u
+	query := redacted.Query()
+	for k := range query {
+		if _, ok := r.queries[strings.ToLower(k)]; ok {
+			query[k] = []string{_redacted}
+		}
+	}
+	redacted.RawQuery = query.Encode()
+	return r.redact(redacted.String())
+}
+
+// recordHTTP records the request and the response once the caller reads the
+// response body
+func (rec *recorder) recordHTTP(req *http.Request, body []byte, res *http.Response) {
+	request := entity.RecordedRequest{
+		Method:  req.Method,
+		URL:     rec.redactor.redactURL(req.URL),
+		Headers: rec.redactor.redactHeaders(req.Header),
+		Body:    rec.redactor.redact(truncateRecorded(body)),
+	}
+	statusCode := res.StatusCode
+	headers := rec.redactor.redactHeaders(res.Header)
+
+	res.Body = &recordingBody{
+		ReadCloser: res.Body,
+		done: func(resBody []byte) {
+			rec.add(entity.RecordedInteraction{
+				Request: request,
+				Response: entity.RecordedResponse{
+					StatusCode: statusCode,
+					Headers:    headers,
+					Body:       rec.redactor.redact(truncateRecorded(resBody)),
+				},
+			})
+		},
+	}
+}
+
+// recordGRPC records the unary call with the messages in the JSON encoding
+// and the status code as the response status
+func (rec *recorder) recordGRPC(target string, headers http.Header, in, out proto.Message, types *dynamicpb.Types, callErr error) {
+	reqBody, _ := protojson.MarshalOptions{Resolver: types}.Marshal(in)
+	response := entity.RecordedResponse{StatusCode: int(codes.OK)}
+	if callErr != nil {
+		st := status.Convert(callErr)
+		response.StatusCode = int(st.Code())
+		response.Body = st.Message()
+	} else {
+		resBody, _ := protojson.MarshalOptions{Resolver: types}.Marshal(out)
+		response.Body = string(resBody)
+	}
+
+	rec.add(entity.RecordedInteraction{
+		Request: entity.RecordedRequest{
+			Method:  _recordedMethodGRPC,
+			URL:     rec.redactor.redact(target),
+			Headers: rec.redactor.redactHeaders(headers),
+			Body:    rec.redactor.redact(string(reqBody)),
+		},
+		Response: entity.RecordedResponse{
+			StatusCode: response.StatusCode,
+			Body:       rec.redactor.redact(response.Body),
+		},
+	})
+}
+
+func (rec *recorder) add(i entity.RecordedInteraction) {
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	rec.interactions = append(rec.interactions, i)
+}
+
+// replayHTTP returns the recorded response of the request, the requests
+// without a recording fail so that the configuration changes are caught
+func (rp *replayer) replayHTTP(req *http.Request, body []byte) (*http.Response, error) {
+	i, err := rp.lookup(req.Method, rp.redactor.redactURL(req.URL), rp.redactor.redact(string(body)))
+	if err != nil {
+		return nil, err
+	}
+	// the redacted bodies are shorter than the recorded length
+	header := http.Header(i.Response.Headers).Clone()
+	header.Del("Content-Length")
+	return &http.Response{
+		Status:        http.StatusText(i.Response.StatusCode),
+		StatusCode:    i.Response.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(strings.NewReader(i.Response.Body)),
+		ContentLength: int64(len(i.Response.Body)),
+		Request:       req,
+	}, nil
+}
+
+// replayGRPC fills the output message from the recorded unary call
+func (rp *replayer) replayGRPC(target string, in, out proto.Message, types *dynamicpb.Types) error {
+	reqBody, err := protojson.MarshalOptions{Resolver: types}.Marshal(in)
+	if err != nil {
+		return err
+	}
+	i, err := rp.lookup(_recordedMethodGRPC, rp.redactor.redact(target), rp.redactor.redact(string(reqBody)))
+	if err != nil {
+		return err
+	}
+	if code := codes.Code(i.Response.StatusCode); code != codes.OK {
+		return status.Error(code, i.Response.Body)
+	}
+	return protojson.UnmarshalOptions{Resolver: types}.Unmarshal([]byte(i.Response.Body), out)
+}
+
+// lookup returns the first matching interaction which is not replayed yet so
+// that the repeated requests like the status polls replay in the recorded
+// order, the last match is repeated once all of them are replayed
+func (rp *replayer) lookup(method, target, body string) (*entity.RecordedInteraction, error) {
+	rp.mu.Lock()
+	defer rp.mu.Unlock()
+
+	key := normalizeRecordedBody(body)
+	last := -1
+	for i := range rp.interactions {
+		r := rp.interactions[i].Request
+		if r.Method != method || r.URL != target || normalizeRecordedBody(r.Body) != key {
+			continue
+		}
+		if _, ok := rp.replayed[i]; !ok {
+			if rp.replayed == nil {
+				rp.replayed = make(map[int]struct{})
+			}
+			rp.replayed[i] = struct{}{}
+			return &rp.interactions[i], nil
+		}
+		last = i
+	}
+	if last >= 0 {
+		return &rp.interactions[last], nil
+	}
+	return nil, jsonrpc.Error{
+		Code:    jsonrpc.ErrCodeInternalError,
+		Message: "No recorded upstream interaction matches the request",
+		Data: map[string]any{
+			"method": method,
+			"url":    target,
+		},
+	}
+}
+
+// normalizeRecordedBody compares the JSON bodies regardless of the key order
+// and the whitespace, the ids of the JSON-RPC messages differ on every call
+func normalizeRecordedBody(body string) string {
+	var v any
+	if json.Unmarshal([]byte(body), &v) != nil {
+		return body
+	}
+	if m, ok := v.(map[string]any); ok {
+		if _, ok := m["jsonrpc"]; ok {
+			delete(m, "id")
+		}
+	}
+	normalized, err := json.Marshal(v)
+	if err != nil {
+		return body
+	}
+	return string(normalized)
+}
+
+func truncateRecorded(b []byte) string {
+	if len(b) > _recordedBodyMaxSize {
+		b = b[:_recordedBodyMaxSize]
+	}
+	return string(b)
+}
+
+func (b *recordingBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if n > 0 && b.buf.Len() < _recordedBodyMaxSize {
+		b.buf.Write(p[:n])
+	}
+	if err == io.EOF {
+		b.finish()
+	}
+	return n, err
+}
+
+func (b *recordingBody) Close() error {
+	b.finish()
+	return b.ReadCloser.Close()
+}
+
+func (b *recordingBody) finish() {
+	b.once.Do(func() {
+		b.done(b.buf.Bytes())
+	})
+}
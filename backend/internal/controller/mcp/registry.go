@@ -0,0 +1,145 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	protocol "github.com/hasmcp/hasmcp-ce/backend/internal/controller/mcp/protocol/p250618"
+	"github.com/hasmcp/hasmcp-ce/backend/internal/data/model"
+	"github.com/hasmcp/hasmcp-ce/backend/internal/repository/storage"
+	zlog "github.com/rs/zerolog/log"
+)
+
+type (
+	// sessionRegistry keeps track of the MCP sessions per server
+	sessionRegistry interface {
+		Store(ctx context.Context, serverID, sessionID int64, s *serverSession) error
+		Load(ctx context.Context, serverID, sessionID int64) (*serverSession, bool)
+		Delete(ctx context.Context, serverID, sessionID int64) error
+		DeleteAll(ctx context.Context, serverID int64) error
+		Range(ctx context.Context, serverID int64, fn func(sessionID int64, s *serverSession) bool)
+
+		// Shared reports whether the sessions are visible to all replicas
+		Shared() bool
+	}
+
+	memorySessionRegistry struct {
+		servers sync.Map
+	}
+
+	databaseSessionRegistry struct {
+		storage storage.Repository
+	}
+)
+
+const (
+	_sessionRegistryMemory   = "memory"
+	_sessionRegistryDatabase = "database"
+)
+
+func newSessionRegistry(kind string, s storage.Repository) (sessionRegistry, error) {
+	switch kind {
+	case "", _sessionRegistryMemory:
+		return &memorySessionRegistry{}, nil
+	case _sessionRegistryDatabase:
+		return &databaseSessionRegistry{storage: s}, nil
+	default:
+		return nil, fmt.Errorf("unknown session registry: %s", kind)
+	}
+}
+
+func (r *memorySessionRegistry) sessions(serverID int64) *sync.Map {
+	sessions, _ := r.servers.LoadOrStore(serverID, &sync.Map{})
+	return sessions.(*sync.Map)
+}
+
+func (r *memorySessionRegistry) Store(_ context.Context, serverID, sessionID int64, s *serverSession) error {
+	r.sessions(serverID).Store(sessionID, s)
+	return nil
+}
+
+func (r *memorySessionRegistry) Load(_ context.Context, serverID, sessionID int64) (*serverSession, bool) {
+	s, ok := r.sessions(serverID).Load(sessionID)
+	if !ok {
+		return nil, false
+	}
+	return s.(*serverSession), true
+}
+
+func (r *memorySessionRegistry) Delete(_ context.Context, serverID, sessionID int64) error {
+	r.sessions(serverID).Delete(sessionID)
+	return nil
+}
+
+func (r *memorySessionRegistry) DeleteAll(_ context.Context, serverID int64) error {
+	r.servers.Delete(serverID)
+	return nil
+}
+
+func (r *memorySessionRegistry) Range(_ context.Context, serverID int64, fn func(sessionID int64, s *serverSession) bool) {
+	r.sessions(serverID).Range(func(key, val any) bool {
+		return fn(key.(int64), val.(*serverSession))
+	})
+}
+
+func (r *memorySessionRegistry) Shared() bool {
+	return false
+}
+
+func (r *databaseSessionRegistry) Store(ctx context.Context, serverID, sessionID int64, s *serverSession) error {
+	params, err := json.Marshal(s.initializeParams)
+	if err != nil {
+		return err
+	}
+
+	return r.storage.SaveServerSession(ctx, model.ServerSession{
+		ServerID:         serverID,
+		SessionID:        sessionID,
+		InitializeParams: params,
+	})
+}
+
+func (r *databaseSessionRegistry) Load(ctx context.Context, serverID, sessionID int64) (*serverSession, bool) {
+	m, err := r.storage.GetServerSession(ctx, serverID, sessionID)
+	if err != nil {
+		return nil, false
+	}
+	return toServerSession(*m), true
+}
+
+func (r *databaseSessionRegistry) Delete(ctx context.Context, serverID, sessionID int64) error {
+	return r.storage.DeleteServerSession(ctx, serverID, sessionID)
+}
+
+func (r *databaseSessionRegistry) DeleteAll(ctx context.Context, serverID int64) error {
+	return r.storage.DeleteAllServerSessions(ctx, serverID)
+}
+
+func (r *databaseSessionRegistry) Range(ctx context.Context, serverID int64, fn func(sessionID int64, s *serverSession) bool) {
+	sessions, err := r.storage.ListServerSessions(ctx, serverID)
+	if err != nil {
+		zlog.Error().Err(err).Int64("serverID", serverID).Msg(_logPrefix + "failed to list server sessions")
+		return
+	}
+
+	for _, m := range sessions {
+		if !fn(m.SessionID, toServerSession(m)) {
+			return
+		}
+	}
+}
+
+func (r *databaseSessionRegistry) Shared() bool {
+	return true
+}
+
+func toServerSession(m model.ServerSession) *serverSession {
+	var params protocol.InitializeRequestParams
+	_ = json.Unmarshal(m.InitializeParams, &params)
+	return &serverSession{
+		pubsubID:         m.SessionID,
+		initializeParams: params,
+	}
+}
@@ -11,6 +11,7 @@ import (
 
 	protocol "github.com/hasmcp/hasmcp-ce/backend/internal/controller/mcp/protocol/p250618"
 	"github.com/hasmcp/hasmcp-ce/backend/internal/data/entity/jsonrpc"
+	"github.com/hasmcp/hasmcp-ce/backend/internal/service/httpc"
 )
 
 const (
@@ -71,6 +72,9 @@ func (c *controller) CallResourcesList(ctx context.Context, req CallSessionReque
 	var nextCursor *string
 	if nextCursorIndex != -1 {
 		nextCursor = stringPtr(strconv.Itoa(nextCursorIndex))
+	} else {
+		// the upstream resources follow the local ones on the last page
+		resources = append(resources, c.upstreamResources(ctx, srv, req)...)
 	}
 
 	response := protocol.ListResourcesResult{
@@ -130,11 +134,20 @@ func (c *controller) CallResourcesRead(ctx context.Context, req CallSessionReque
 	}
 
 	if foundResource == nil {
-		return nil, jsonrpc.Error{
-			Code:    jsonrpc.ErrCodeInvalidParams,
-			Message: "resource not found at specified URI",
-			Data:    map[string]any{"uri": params.Uri},
+		// The resources of the upstream MCP servers are read through them
+		data, err := c.readUpstreamResource(ctx, srv, req, params.Uri)
+		if err != nil {
+			return nil, err
 		}
+		return &CallSessionResponse{
+			HTTPStatusCode: 200,
+			McpSessionID:   req.McpSessionID,
+			Result: &jsonrpc.ResultResponse{
+				JSONRpc: jsonrpc.Version,
+				Result:  data,
+				ID:      req.Request.ID,
+			},
+		}, nil
 	}
 
 	// Fetch the resource content via HTTP
@@ -148,6 +161,13 @@ func (c *controller) CallResourcesRead(ctx context.Context, req CallSessionReque
 	}
 
 	httpRes, err := c.httpc.Call(ctx, httpReq)
+	if egressErr, ok := httpc.AsEgressError(err); ok {
+		return nil, jsonrpc.Error{
+			Code:    jsonrpc.ErrCodeServerError,
+			Message: "resource URI is denied by the egress policy",
+			Data:    map[string]any{"uri": params.Uri, "reason": egressErr.Error()},
+		}
+	}
 	if err != nil {
 		return nil, jsonrpc.Error{
 			Code:    jsonrpc.ErrCodeServerError,
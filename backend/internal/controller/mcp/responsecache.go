@@ -0,0 +1,529 @@
+package mcp
+
+import (
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	protocol "github.com/hasmcp/hasmcp-ce/backend/internal/controller/mcp/protocol/p250618"
+	entity "github.com/hasmcp/hasmcp-ce/backend/internal/data/entity/crud"
+	erre "github.com/hasmcp/hasmcp-ce/backend/internal/data/entity/err"
+	"github.com/hasmcp/hasmcp-ce/backend/internal/data/model"
+	"github.com/hasmcp/hasmcp-ce/backend/internal/repository/storage"
+	"github.com/hasmcp/hasmcp-ce/backend/internal/service/httpc"
+	"github.com/hasmcp/hasmcp-ce/backend/internal/service/pubsub"
+	zlog "github.com/rs/zerolog/log"
+)
+
+type (
+	PurgeResponseCacheRequest struct {
+		ServerID int64
+		ToolID   int64 // purges all tools of the server when zero
+	}
+
+	// responseCache keeps the cached responses of the tool calls
+	responseCache interface {
+		Load(ctx context.Context, key string) (*cachedResponse, bool)
+		Store(ctx context.Context, key string, r *cachedResponse) error
+		Purge(ctx context.Context, serverID, toolID int64) error
+
+		// Shared reports whether the responses are visible to all replicas
+		Shared() bool
+	}
+
+	cachedResponse struct {
+		serverID   int64
+		toolID     int64
+		freshUntil time.Time
+		expiresAt  time.Time
+		policy     httpc.CachePolicy
+		text       []byte
+		meta       protocol.CallToolResultMeta
+	}
+
+	// memoryResponseCache evicts the least recently used responses once the
+	// max size is exceeded
+	memoryResponseCache struct {
+		mu      sync.Mutex
+		maxSize int64
+		size    int64
+		lru     *list.List // of *memoryCacheEntry, the front is the most recent
+		entries map[string]*list.Element
+	}
+
+	memoryCacheEntry struct {
+		key  string
+		size int64
+		res  *cachedResponse
+	}
+
+	databaseResponseCache struct {
+		storage storage.Repository
+
+		// cleanedAt is the unix time of the last expired responses deletion
+		cleanedAt atomic.Int64
+	}
+
+	responseCacheConfig struct {
+		// Store selects where the responses are kept: memory (per replica) or
+		// database (shared between the replicas)
+		Store               string `yaml:"store"`
+		MaxSizeInBytes      int64  `yaml:"maxSizeInBytes"` // memory only
+		MaxEntrySizeInBytes int64  `yaml:"maxEntrySizeInBytes"`
+	}
+
+	// responseCacheLookup is the cache state of a REST tool call
+	responseCacheLookup struct {
+		key      string
+		serverID int64
+		tool     *entity.ProviderTool
+		cached   *cachedResponse
+	}
+
+	responseCacheStatsKey struct {
+		serverID int64
+		toolID   int64
+	}
+
+	responseCacheStats struct {
+		hits   atomic.Int64
+		misses atomic.Int64
+	}
+)
+
+const (
+	_metaKeyCache = "hasmcp/cache"
+
+	_responseCacheMemory   = "memory"
+	_responseCacheDatabase = "database"
+
+	_responseCacheDefaultMaxSize      = 64 << 20
+	_responseCacheDefaultMaxEntrySize = 1 << 20
+	_responseCacheDefaultTTL          = 5 * time.Minute
+	_responseCacheCleanupInterval     = time.Minute
+	// the stale responses with a validator are kept to revalidate them
+	_responseCacheStaleRetention = time.Hour
+	// the bookkeeping bytes counted for each entry besides its contents
+	_responseCacheEntryOverhead = 256
+
+	_responseCacheStatusHit         = "HIT"
+	_responseCacheStatusRevalidated = "REVALIDATED"
+	_responseCacheStatusMiss        = "MISS"
+
+	_eventTypeResponseCachePurge = "response_cache_purge"
+)
+
+func newResponseCache(cfg responseCacheConfig, s storage.Repository) (responseCache, error) {
+	switch cfg.Store {
+	case "", _responseCacheMemory:
+		maxSize := cfg.MaxSizeInBytes
+		if maxSize <= 0 {
+			maxSize = _responseCacheDefaultMaxSize
+		}
+		return &memoryResponseCache{
+			maxSize: maxSize,
+			lru:     list.New(),
+			entries: map[string]*list.Element{},
+		}, nil
+	case _responseCacheDatabase:
+		return &databaseResponseCache{storage: s}, nil
+	default:
+		return nil, fmt.Errorf("unknown response cache store: %s", cfg.Store)
+	}
+}
+
+func (r *cachedResponse) size() int64 {
+	return int64(len(r.text)+len(r.policy.ETag)+len(r.policy.LastModified)) + _responseCacheEntryOverhead
+}
+
+func (r *memoryResponseCache) Load(_ context.Context, key string) (*cachedResponse, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	el, ok := r.entries[key]
+	if !ok {
+		return nil, false
+	}
+	entry := el.Value.(*memoryCacheEntry)
+	if time.Now().After(entry.res.expiresAt) {
+		r.remove(el)
+		return nil, false
+	}
+	r.lru.MoveToFront(el)
+	return entry.res, true
+}
+
+func (r *memoryResponseCache) Store(_ context.Context, key string, res *cachedResponse) error {
+	size := res.size() + int64(len(key))
+	if size > r.maxSize {
+		return nil
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if el, ok := r.entries[key]; ok {
+		r.remove(el)
+	}
+	r.entries[key] = r.lru.PushFront(&memoryCacheEntry{key: key, size: size, res: res})
+	r.size += size
+	for r.size > r.maxSize {
+		r.remove(r.lru.Back())
+	}
+	return nil
+}
+
+func (r *memoryResponseCache) Purge(_ context.Context, serverID, toolID int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for el := r.lru.Front(); el != nil; {
+		next := el.Next()
+		res := el.Value.(*memoryCacheEntry).res
+		if res.serverID == serverID && (toolID == 0 || res.toolID == toolID) {
+			r.remove(el)
+		}
+		el = next
+	}
+	return nil
+}
+
+func (r *memoryResponseCache) Shared() bool {
+	return false
+}
+
+func (r *memoryResponseCache) remove(el *list.Element) {
+	entry := r.lru.Remove(el).(*memoryCacheEntry)
+	delete(r.entries, entry.key)
+	r.size -= entry.size
+}
+
+func (r *databaseResponseCache) Load(ctx context.Context, key string) (*cachedResponse, bool) {
+	m, err := r.storage.GetToolResponseCache(ctx, key)
+	if err != nil || time.Now().After(m.ExpiresAt) {
+		return nil, false
+	}
+
+	var meta protocol.CallToolResultMeta
+	if len(m.Meta) > 0 {
+		_ = json.Unmarshal(m.Meta, &meta)
+	}
+	return &cachedResponse{
+		serverID:   m.ServerID,
+		toolID:     m.ToolID,
+		freshUntil: m.FreshUntil,
+		expiresAt:  m.ExpiresAt,
+		policy: httpc.CachePolicy{
+			NoCache:      m.NoCache,
+			ETag:         m.ETag,
+			LastModified: m.LastModified,
+		},
+		text: m.Text,
+		meta: meta,
+	}, true
+}
+
+func (r *databaseResponseCache) Store(ctx context.Context, key string, res *cachedResponse) error {
+	r.deleteExpired(ctx)
+
+	var meta json.RawMessage
+	if len(res.meta) > 0 {
+		var err error
+		if meta, err = json.Marshal(res.meta); err != nil {
+			return err
+		}
+	}
+	return r.storage.SaveToolResponseCache(ctx, model.ToolResponseCache{
+		Key:          key,
+		CreatedAt:    time.Now().UTC(),
+		ExpiresAt:    res.expiresAt,
+		ServerID:     res.serverID,
+		ToolID:       res.toolID,
+		FreshUntil:   res.freshUntil,
+		NoCache:      res.policy.NoCache,
+		ETag:         res.policy.ETag,
+		LastModified: res.policy.LastModified,
+		Text:         res.text,
+		Meta:         meta,
+	})
+}
+
+func (r *databaseResponseCache) Purge(ctx context.Context, serverID, toolID int64) error {
+	return r.storage.DeleteToolResponseCaches(ctx, serverID, toolID)
+}
+
+func (r *databaseResponseCache) Shared() bool {
+	return true
+}
+
+// deleteExpired deletes the expired responses at most once per the cleanup
+// interval
+func (r *databaseResponseCache) deleteExpired(ctx context.Context) {
+	now := time.Now()
+	last := r.cleanedAt.Load()
+	if now.Unix()-last < int64(_responseCacheCleanupInterval/time.Second) || !r.cleanedAt.CompareAndSwap(last, now.Unix()) {
+		return
+	}
+	if err := r.storage.DeleteExpiredToolResponseCaches(ctx, now); err != nil {
+		zlog.Warn().Err(err).Msg(_logPrefix + "failed to delete the expired tool responses")
+	}
+}
+
+// PurgeResponseCache deletes the cached responses of the server or of one of
+// its tools, the memory caches of the other replicas are purged as well
+func (c *controller) PurgeResponseCache(ctx context.Context, req PurgeResponseCacheRequest) error {
+	if err := c.responseCache.Purge(ctx, req.ServerID, req.ToolID); err != nil {
+		return erre.Error{
+			Code:    erre.ErrorCodeInternalServerError,
+			Message: "failed to purge the response cache",
+			Data: map[string]any{
+				"reason":   err.Error(),
+				"serverID": req.ServerID,
+				"toolID":   req.ToolID,
+			},
+		}
+	}
+	if c.responseCache.Shared() {
+		return nil
+	}
+
+	data, err := json.Marshal(req)
+	if err != nil {
+		return err
+	}
+	_, err = c.pubsub.Publish(ctx, pubsub.PublishRequest{
+		PubSubID: pubsub.IDReplicaResourceChanges,
+		Event: &event{
+			Type: _eventTypeResponseCachePurge,
+			Data: data,
+		},
+		ReplicasOnly: true,
+	})
+	if err != nil {
+		zlog.Error().Err(err).Msg(_logPrefix + "failed to share the response cache purge with replicas")
+	}
+	return nil
+}
+
+// purgeReplicatedResponseCache purges the memory cache on the purge of another
+// replica
+func (c *controller) purgeReplicatedResponseCache(ctx context.Context, data []byte) {
+	var req PurgeResponseCacheRequest
+	if err := json.Unmarshal(data, &req); err != nil {
+		zlog.Warn().Err(err).Msg(_logPrefix + "received malformed response cache purge")
+		return
+	}
+	if err := c.responseCache.Purge(ctx, req.ServerID, req.ToolID); err != nil {
+		zlog.Warn().Err(err).Int64("serverID", req.ServerID).Msg(_logPrefix + "failed to purge the response cache")
+	}
+}
+
+// lookupResponseCache returns the cache state of the call, nil when the call
+// is not cached. The recorded and the replayed calls always reach the
+// upstream so that the recordings stay complete.
+func (c *controller) lookupResponseCache(
+	ctx context.Context,
+	serverID int64,
+	tool *entity.ProviderTool,
+	req *http.Request,
+) *responseCacheLookup {
+	if tool.CacheConfig == nil || (req.Method != http.MethodGet && req.Method != http.MethodHead) {
+		return nil
+	}
+	if recorderOf(ctx) != nil || replayerOf(ctx) != nil {
+		return nil
+	}
+
+	lookup := &responseCacheLookup{
+		key:      responseCacheKeyOf(ctx, serverID, tool, req),
+		serverID: serverID,
+		tool:     tool,
+	}
+	lookup.cached, _ = c.responseCache.Load(ctx, lookup.key)
+	return lookup
+}
+
+// responseCacheKeyOf hashes the resolved URL, the vary headers and the caller,
+// the authorization header always varies the key
+func responseCacheKeyOf(ctx context.Context, serverID int64, tool *entity.ProviderTool, req *http.Request) string {
+	headers := append([]string{"Authorization"}, tool.CacheConfig.VaryHeaders...)
+	for i, h := range headers {
+		headers[i] = http.CanonicalHeaderKey(h)
+	}
+	slices.Sort(headers)
+
+	h := sha256.New()
+	fmt.Fprintf(h, "%d\n%d\n%s\n%s\n%s\n", serverID, tool.ID, entity.SubjectOf(ctx), req.Method, req.URL.String())
+	for _, name := range slices.Compact(headers) {
+		fmt.Fprintf(h, "%s: %s\n", name, strings.Join(req.Header.Values(name), ", "))
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// fresh reports whether the cached response is used without asking the
+// upstream
+func (l *responseCacheLookup) fresh() bool {
+	return l.cached != nil && !l.cached.policy.NoCache && time.Now().Before(l.cached.freshUntil)
+}
+
+// revalidate makes the request conditional on the validators of the stale
+// response. The paginated responses are not revalidated since the first page
+// does not tell whether the next pages changed.
+func (l *responseCacheLookup) revalidate(req *http.Request) {
+	if l.cached == nil || !l.cached.policy.Revalidatable() || l.tool.PaginationConfig != nil {
+		return
+	}
+	// the headers are shared with the other requests of the call
+	req.Header = req.Header.Clone()
+	l.cached.policy.Revalidate(req)
+}
+
+// revalidated reports whether the upstream confirmed the stale response
+func (l *responseCacheLookup) revalidated(res *http.Response) bool {
+	return l.cached != nil && res.StatusCode == http.StatusNotModified && l.cached.policy.Revalidatable()
+}
+
+// storeResponse caches the result of the upstream response when its caching
+// policy allows and returns the result with the cache status
+func (c *controller) storeResponse(
+	ctx context.Context,
+	l *responseCacheLookup,
+	res *http.Response,
+	result *protocol.CallToolResult,
+) *protocol.CallToolResult {
+	text, ok := textOf(result)
+	policy := httpc.CachePolicyOf(res)
+	if ok && !policy.NoStore && res.StatusCode < http.StatusMultipleChoices && res.StatusCode != http.StatusAccepted {
+		if cached := l.cachedResponseOf(policy, []byte(text), result.Meta); cached != nil {
+			c.saveResponse(ctx, l.key, cached)
+		}
+	}
+	return c.withCacheStatus(l, result, _responseCacheStatusMiss)
+}
+
+// refreshResponse extends the freshness of the revalidated response, the
+// validators of the 304 response replace the stored ones
+func (c *controller) refreshResponse(
+	ctx context.Context,
+	l *responseCacheLookup,
+	res *http.Response,
+) *protocol.CallToolResult {
+	policy := httpc.CachePolicyOf(res)
+	if policy.ETag == "" {
+		policy.ETag = l.cached.policy.ETag
+	}
+	if policy.LastModified == "" {
+		policy.LastModified = l.cached.policy.LastModified
+	}
+	if cached := l.cachedResponseOf(policy, l.cached.text, l.cached.meta); cached != nil {
+		c.saveResponse(ctx, l.key, cached)
+	}
+	return c.withCacheStatus(l, l.cached.result(), _responseCacheStatusRevalidated)
+}
+
+// cachedResult returns the fresh cached response
+func (c *controller) cachedResult(l *responseCacheLookup) *protocol.CallToolResult {
+	return c.withCacheStatus(l, l.cached.result(), _responseCacheStatusHit)
+}
+
+// cachedResponseOf returns the response to cache, nil when it can not be used
+// later. The Cache-Control of the response shortens the TTL of the tool.
+func (l *responseCacheLookup) cachedResponseOf(policy httpc.CachePolicy, text []byte, meta protocol.CallToolResultMeta) *cachedResponse {
+	lifetime := secondsOr(l.tool.CacheConfig.TTLSeconds, _responseCacheDefaultTTL)
+	if policy.HasMaxAge {
+		lifetime = min(lifetime, policy.MaxAge)
+	}
+	if policy.NoCache {
+		lifetime = 0
+	}
+
+	now := time.Now()
+	cached := &cachedResponse{
+		serverID:   l.serverID,
+		toolID:     l.tool.ID,
+		freshUntil: now.Add(lifetime),
+		expiresAt:  now.Add(lifetime),
+		policy:     policy,
+		text:       text,
+		meta:       meta,
+	}
+	if policy.Revalidatable() {
+		cached.expiresAt = cached.expiresAt.Add(_responseCacheStaleRetention)
+	}
+	if !cached.expiresAt.After(now) {
+		return nil
+	}
+	return cached
+}
+
+func (c *controller) saveResponse(ctx context.Context, key string, cached *cachedResponse) {
+	maxSize := c.cfg.ResponseCache.MaxEntrySizeInBytes
+	if maxSize <= 0 {
+		maxSize = _responseCacheDefaultMaxEntrySize
+	}
+	if int64(len(cached.text)) > maxSize {
+		return
+	}
+	if err := c.responseCache.Store(ctx, key, cached); err != nil {
+		zlog.Warn().Err(err).Int64("toolID", cached.toolID).Msg(_logPrefix + "failed to cache the tool response")
+	}
+}
+
+// withCacheStatus counts the hit or the miss of the tool and adds the counts
+// to the result meta so that they are seen on the live tail
+func (c *controller) withCacheStatus(l *responseCacheLookup, result *protocol.CallToolResult, status string) *protocol.CallToolResult {
+	val, _ := c.responseCacheStats.LoadOrStore(
+		responseCacheStatsKey{serverID: l.serverID, toolID: l.tool.ID},
+		&responseCacheStats{},
+	)
+	stats := val.(*responseCacheStats)
+	if status == _responseCacheStatusMiss {
+		stats.misses.Add(1)
+	} else {
+		stats.hits.Add(1)
+	}
+
+	meta := maps.Clone(result.Meta)
+	if meta == nil {
+		meta = protocol.CallToolResultMeta{}
+	}
+	meta[_metaKeyCache] = map[string]any{
+		"status": status,
+		"hits":   stats.hits.Load(),
+		"misses": stats.misses.Load(),
+	}
+	result.Meta = meta
+	return result
+}
+
+func (r *cachedResponse) result() *protocol.CallToolResult {
+	return &protocol.CallToolResult{
+		Meta: maps.Clone(r.meta),
+		Content: []protocol.ContentBlock{
+			protocol.TextContent{
+				Text: string(r.text),
+				Type: "text",
+			},
+		},
+	}
+}
+
+// textOf returns the text of the single text content results, which are the
+// only ones the REST calls return
+func textOf(result *protocol.CallToolResult) (string, bool) {
+	if result == nil || result.IsError != nil && *result.IsError || len(result.Content) != 1 {
+		return "", false
+	}
+	content, ok := result.Content[0].(protocol.TextContent)
+	return content.Text, ok
+}
@@ -0,0 +1,268 @@
+package mcp
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	protocol "github.com/hasmcp/hasmcp-ce/backend/internal/controller/mcp/protocol/p250618"
+	entity "github.com/hasmcp/hasmcp-ce/backend/internal/data/entity/crud"
+	"github.com/hasmcp/hasmcp-ce/backend/internal/data/entity/jsonrpc"
+)
+
+type (
+	// argRoute points a flat argument back to its location on the request.
+	// An empty name on the body location routes the whole body.
+	argRoute struct {
+		in   entity.ToolArgumentLocation
+		name string
+	}
+
+	flatSchemaConfig struct {
+		Prefixes flatSchemaPrefixes `yaml:"prefixes"`
+	}
+
+	// flatSchemaPrefixes are prepended to the argument names which collide
+	// with the arguments on the other locations
+	flatSchemaPrefixes struct {
+		Path   string `yaml:"path"`
+		Query  string `yaml:"query"`
+		Header string `yaml:"header"`
+		Body   string `yaml:"body"`
+	}
+
+	flatArg struct {
+		route    argRoute
+		schema   map[string]any
+		required bool
+	}
+)
+
+const (
+	_flatArgBody = "body"
+
+	// _flatSchemaMaxPrefixRounds limits the prefixing of the colliding names,
+	// the names still colliding fail the server
+	_flatSchemaMaxPrefixRounds = 3
+)
+
+func (p flatSchemaPrefixes) of(in entity.ToolArgumentLocation) string {
+	switch in {
+	case entity.ToolArgumentLocationPath:
+		return p.Path
+	case entity.ToolArgumentLocationQuery:
+		return p.Query
+	case entity.ToolArgumentLocationHeader:
+		return p.Header
+	default:
+		return p.Body
+	}
+}
+
+// flattenInputSchema merges the nested path, query and body arguments and the
+// tool headers without a value into a single object. It returns the flat
+// properties, their required list and the routes to nest the arguments back,
+// or an error when the prefixes can not make the argument names unique.
+func flattenInputSchema(
+	props protocol.ToolInputSchemaProperties,
+	required []string,
+	headers []entity.ToolHeader,
+	prefixes flatSchemaPrefixes,
+) (protocol.ToolInputSchemaProperties, []string, map[string]argRoute, error) {
+	groupRequired := make(map[string]struct{}, len(required))
+	for _, r := range required {
+		groupRequired[r] = struct{}{}
+	}
+
+	args := make([]flatArg, 0)
+	for _, in := range []entity.ToolArgumentLocation{
+		entity.ToolArgumentLocationPath,
+		entity.ToolArgumentLocationQuery,
+		entity.ToolArgumentLocationBody,
+	} {
+		key := argsKeyOf(in)
+		group, ok := props[key]
+		if !ok {
+			continue
+		}
+
+		groupProps, isObject := group["properties"].(map[string]any)
+		if !isObject {
+			// non object bodies are sent as is
+			_, req := groupRequired[key]
+			args = append(args, flatArg{
+				route:    argRoute{in: in},
+				schema:   group,
+				required: req,
+			})
+			continue
+		}
+
+		requiredArgs := make(map[string]struct{})
+		if list, ok := group["required"].([]any); ok {
+			for _, r := range list {
+				if name, ok := r.(string); ok {
+					requiredArgs[name] = struct{}{}
+				}
+			}
+		}
+
+		names := make([]string, 0, len(groupProps))
+		for name := range groupProps {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			argSchema, _ := groupProps[name].(map[string]any)
+			_, req := requiredArgs[name]
+			args = append(args, flatArg{
+				route:    argRoute{in: in, name: name},
+				schema:   argSchema,
+				required: req,
+			})
+		}
+	}
+
+	for _, h := range headers {
+		if h.Value != "" {
+			continue
+		}
+		args = append(args, flatArg{
+			route: argRoute{in: entity.ToolArgumentLocationHeader, name: h.Key},
+			schema: map[string]any{
+				"type": "string",
+			},
+		})
+	}
+
+	names, err := uniqueFlatNames(args, prefixes)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	flatProps := make(protocol.ToolInputSchemaProperties, len(args))
+	flatRequired := make([]string, 0, len(args))
+	routes := make(map[string]argRoute, len(args))
+	for i, a := range args {
+		name := names[i]
+		flatProps[name] = a.schema
+		routes[name] = a.route
+		if a.required {
+			flatRequired = append(flatRequired, name)
+		}
+	}
+
+	return flatProps, flatRequired, routes, nil
+}
+
+// uniqueFlatNames prefixes the colliding argument names with their locations.
+// A prefixed name may collide again, e.g. the path `id` becomes `path_id` next
+// to a body `path_id`, so the names are prefixed until they are unique.
+func uniqueFlatNames(args []flatArg, prefixes flatSchemaPrefixes) ([]string, error) {
+	names := make([]string, len(args))
+	for i, a := range args {
+		names[i] = a.flatName()
+	}
+
+	for round := 0; ; round++ {
+		counts := make(map[string]int, len(names))
+		for _, name := range names {
+			counts[name]++
+		}
+		var collided []string
+		for _, name := range names {
+			if counts[name] > 1 && !slices.Contains(collided, name) {
+				collided = append(collided, name)
+			}
+		}
+		if len(collided) == 0 {
+			return names, nil
+		}
+		if round == _flatSchemaMaxPrefixRounds {
+			return nil, fmt.Errorf("flat argument names collide after prefixing: %s", strings.Join(collided, ", "))
+		}
+		for i, a := range args {
+			if counts[names[i]] > 1 {
+				names[i] = prefixes.of(a.route.in) + names[i]
+			}
+		}
+	}
+}
+
+func (a flatArg) flatName() string {
+	if a.route.name == "" {
+		return _flatArgBody
+	}
+	return a.route.name
+}
+
+// nestArguments routes the flat arguments back to the path, query and body
+// arguments, the header arguments are returned separately
+func nestArguments(
+	args protocol.CallToolRequestParamsArguments,
+	routes map[string]argRoute,
+) (protocol.CallToolRequestParamsArguments, map[string]string, error) {
+	groups := make(map[string]map[string]json.RawMessage, 3)
+	nested := make(protocol.CallToolRequestParamsArguments, 3)
+	headers := make(map[string]string)
+
+	for name, val := range args {
+		route, ok := routes[name]
+		if !ok {
+			return nil, nil, jsonrpc.Error{
+				Code:    jsonrpc.ErrCodeInvalidParams,
+				Message: "unknown argument",
+				Data: map[string]any{
+					"argument": name,
+				},
+			}
+		}
+
+		switch route.in {
+		case entity.ToolArgumentLocationHeader:
+			headers[route.name] = stringArgument(val)
+			continue
+		case entity.ToolArgumentLocationPath, entity.ToolArgumentLocationQuery:
+			// path and query arguments are sent as strings
+			data, _ := json.Marshal(stringArgument(val))
+			val = data
+		}
+
+		key := argsKeyOf(route.in)
+		if route.name == "" {
+			nested[key] = val
+			continue
+		}
+
+		group, ok := groups[key]
+		if !ok {
+			group = make(map[string]json.RawMessage)
+			groups[key] = group
+		}
+		group[route.name] = val
+	}
+
+	for key, group := range groups {
+		data, err := json.Marshal(group)
+		if err != nil {
+			return nil, nil, err
+		}
+		nested[key] = data
+	}
+
+	return nested, headers, nil
+}
+
+func stringArgument(raw json.RawMessage) string {
+	var val any
+	if err := json.Unmarshal(raw, &val); err != nil {
+		return string(raw)
+	}
+	if s, ok := val.(string); ok {
+		return s
+	}
+	return fmt.Sprint(val)
+}
@@ -6,6 +6,7 @@ import (
 	"fmt"
 
 	"github.com/hasmcp/hasmcp-ce/backend/internal/controller/mcp/jwt"
+	entity "github.com/hasmcp/hasmcp-ce/backend/internal/data/entity/crud"
 	erre "github.com/hasmcp/hasmcp-ce/backend/internal/data/entity/err"
 	"github.com/hasmcp/hasmcp-ce/backend/internal/data/entity/jsonrpc"
 	"github.com/hasmcp/hasmcp-ce/backend/internal/service/pubsub"
@@ -53,11 +54,16 @@ func (c *controller) DeleteSession(ctx context.Context, req DeleteSessionRequest
 		return nil
 	}
 
-	s, err := c.getServer(req.ServerID)
+	err = c.sessions.Delete(ctx, req.ServerID, sessionID)
 	if err != nil {
-		return err
+		return erre.Error{
+			Code:    500,
+			Message: "Failed to delete the session",
+			Data: map[string]any{
+				"details": err.Error(),
+			},
+		}
 	}
-	s.sessions.Delete(sessionID)
 
 	// delegate to hasmcp/pubsub
 	err = c.pubsub.Delete(ctx, pubsub.DeletePubSubRequest{
@@ -117,6 +123,12 @@ func (c *controller) CallSession(ctx context.Context, req CallSessionRequest) (*
 			}
 		}
 
+		// the tokens without a subject keep the user of the session
+		if req.Subject == "" {
+			req.Subject = sessionRes.Subject
+		}
+		ctx = withSession(ctx, sessionRes.SessionID)
+
 		sessionInfo = fmt.Sprintf(
 			"%s.%s/%s",
 			monoflake.ID(sessionRes.SessionID).String(),
@@ -125,6 +137,7 @@ func (c *controller) CallSession(ctx context.Context, req CallSessionRequest) (*
 		)
 	}
 
+	ctx = entity.WithSubject(ctx, req.Subject)
 	eventType := fmt.Sprintf("%s.%s", sessionInfo, req.Request.Method)
 
 	_, err = c.pubsub.Publish(ctx, pubsub.PublishRequest{
@@ -266,21 +279,23 @@ func (c *controller) SubscribeSession(ctx context.Context, req SubscribeSessionR
 	_, err = c.getSession(req.ServerID, sessionID)
 	if err != nil {
 		// Re-add the session in here to recover a broken session due to server restart
-		server, err := c.getServer(req.ServerID)
-		if err != nil {
+		if _, err := c.getServer(req.ServerID); err != nil {
 			return nil, err
 		}
-		server.sessions.Store(sessionID, &serverSession{
+		err = c.sessions.Store(ctx, req.ServerID, sessionID, &serverSession{
 			pubsubID:         sessionRes.SessionID,
 			initializeParams: sessionRes.InitializeParams,
 		})
-
-		// upsert pubsub
-		_, _ = c.pubsub.Create(ctx, pubsub.CreatePubSubRequest{
-			ID: sessionID,
-		})
+		if err != nil {
+			return nil, err
+		}
 	}
 
+	// upsert pubsub, the session might be initialized on another replica
+	_, _ = c.pubsub.Create(ctx, pubsub.CreatePubSubRequest{
+		ID: sessionID,
+	})
+
 	res, err := c.pubsub.Subscribe(ctx, pubsub.SubscribeRequest{
 		PubSubID: sessionID,
 	})
@@ -0,0 +1,358 @@
+package mcp
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"hash"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	entity "github.com/hasmcp/hasmcp-ce/backend/internal/data/entity/crud"
+	"github.com/hasmcp/hasmcp-ce/backend/internal/data/entity/jsonrpc"
+)
+
+type (
+	// requestSigner signs the upstream requests just before they are sent
+	requestSigner interface {
+		sign(req *http.Request, body []byte, now time.Time) error
+	}
+
+	// signerBuilder builds the signer of a config whose variable references
+	// are resolved
+	signerBuilder func(cfg *entity.ProviderSignerConfig) (requestSigner, error)
+
+	awsSigV4Signer struct {
+		region          string
+		service         string
+		accessKeyID     string
+		secretAccessKey string
+		sessionToken    string
+	}
+
+	hmacSigner struct {
+		hash            func() hash.Hash
+		secret          []byte
+		header          string
+		prefix          string
+		base64          bool
+		canonicalString string
+		timestampHeader string
+	}
+)
+
+const (
+	_awsSigV4Algorithm = "AWS4-HMAC-SHA256"
+	_awsSigV4Request   = "aws4_request"
+	_awsDateFormat     = "20060102"
+	_awsTimeFormat     = "20060102T150405Z"
+
+	_hmacDefaultCanonicalString = "{method}\n{path}\n{timestamp}\n{bodySHA256}"
+)
+
+var (
+	// _signers are the request signers by type, MTLS is applied by the
+	// transport instead
+	_signers = map[entity.SignerType]signerBuilder{
+		entity.SignerTypeAWSSigV4: newAWSSigV4Signer,
+		entity.SignerTypeHMAC:     newHMACSigner,
+	}
+
+	_regexHMACHeaderPlaceholder = regexp.MustCompile(`\{header:([^{}]+)\}`)
+)
+
+// sendHTTP calls the request within the provider limits and records the
+// exchange in the RECORD traffic mode, the request is recorded after signing
+func (c *controller) sendHTTP(ctx context.Context, provider *entity.Provider, req *http.Request, body []byte) (*http.Response, error) {
+	release, err := c.guardProvider(ctx, provider)
+	if err != nil {
+		return nil, err
+	}
+	res, err := c.signAndSendHTTP(ctx, provider, req, body)
+	release(httpFailed(res, err), res)
+	if rec := recorderOf(ctx); rec != nil && err == nil {
+		rec.recordHTTP(req, body, res)
+	}
+	return res, err
+}
+
+// signAndSendHTTP signs the request with the provider signer and calls it. The
+// MTLS certificate and the transport config of the provider are applied by a
+// dedicated client.
+func (c *controller) signAndSendHTTP(ctx context.Context, provider *entity.Provider, req *http.Request, body []byte) (*http.Response, error) {
+	ctx = withProviderEgress(ctx, provider)
+	opts, err := c.transportOf(ctx, provider)
+	if err != nil {
+		return nil, err
+	}
+	send := func() (*http.Response, error) {
+		if opts == nil {
+			return c.httpc.Call(ctx, req)
+		}
+		return c.httpc.CallWithTransport(ctx, req, *opts)
+	}
+
+	cfg := provider.SignerConfig
+	if cfg == nil {
+		return send()
+	}
+
+	build, ok := _signers[cfg.Type]
+	if !ok {
+		return send()
+	}
+	resolved, err := c.resolveSignerConfig(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+	signer, err := build(resolved)
+	if err != nil {
+		return nil, signerError(provider, err)
+	}
+	if err := signer.sign(req, body, time.Now()); err != nil {
+		return nil, signerError(provider, err)
+	}
+	return send()
+}
+
+// resolveSignerConfig returns a copy of the config with the variable
+// references of its keys and PEM materials replaced, the unresolved variables
+// fail the call instead of signing with the references
+func (c *controller) resolveSignerConfig(ctx context.Context, cfg *entity.ProviderSignerConfig) (*entity.ProviderSignerConfig, error) {
+	resolved := *cfg
+	values := []*string{
+		&resolved.Region,
+		&resolved.Service,
+		&resolved.AccessKeyID,
+		&resolved.SecretAccessKey,
+		&resolved.SessionToken,
+		&resolved.Secret,
+		&resolved.ClientCert,
+		&resolved.ClientKey,
+		&resolved.CACert,
+	}
+	for _, v := range values {
+		s, err := c.interpolate(ctx, *v, _interpolateInSigner, nil)
+		if err != nil {
+			return nil, err
+		}
+		*v = s
+	}
+	return &resolved, nil
+}
+
+func signerError(provider *entity.Provider, err error) error {
+	return jsonrpc.Error{
+		Code:    jsonrpc.ErrCodeInternalError,
+		Message: "Request signing failed",
+		Data: map[string]any{
+			"reason":     err.Error(),
+			"providerID": provider.ID,
+			"signerType": provider.SignerConfig.Type.String(),
+		},
+	}
+}
+
+func newAWSSigV4Signer(cfg *entity.ProviderSignerConfig) (requestSigner, error) {
+	s := &awsSigV4Signer{
+		region:          cfg.Region,
+		service:         cfg.Service,
+		accessKeyID:     cfg.AccessKeyID,
+		secretAccessKey: cfg.SecretAccessKey,
+		sessionToken:    cfg.SessionToken,
+	}
+	if s.accessKeyID == "" || s.secretAccessKey == "" {
+		return nil, errors.New("AWS credentials are not resolved")
+	}
+	return s, nil
+}
+
+// sign adds the AWS Signature Version 4 Authorization header
+// https://docs.aws.amazon.com/IAM/latest/UserGuide/create-signed-request.html
+func (s *awsSigV4Signer) sign(req *http.Request, body []byte, now time.Time) error {
+	now = now.UTC()
+	amzDate := now.Format(_awsTimeFormat)
+	date := now.Format(_awsDateFormat)
+	payloadHash := sha256Hex(body)
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	if s.sessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", s.sessionToken)
+	}
+	if s.service == "s3" {
+		req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+	}
+
+	host := req.Host
+	if host == "" {
+		host = req.URL.Host
+	}
+	headers := map[string]string{
+		"host": host,
+	}
+	for k, vals := range req.Header {
+		k = strings.ToLower(k)
+		if k == "content-type" || strings.HasPrefix(k, "x-amz-") {
+			headers[k] = strings.Join(strings.Fields(strings.Join(vals, ",")), " ")
+		}
+	}
+	names := make([]string, 0, len(headers))
+	for k := range headers {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	var canonicalHeaders strings.Builder
+	for _, k := range names {
+		canonicalHeaders.WriteString(k + ":" + headers[k] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		awsCanonicalPath(req.URL.Path, s.service != "s3"),
+		awsCanonicalQuery(req.URL.Query()),
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := date + "/" + s.region + "/" + s.service + "/" + _awsSigV4Request
+	stringToSign := strings.Join([]string{
+		_awsSigV4Algorithm,
+		amzDate,
+		scope,
+		sha256Hex([]byte(canonicalRequest)),
+	}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+s.secretAccessKey), date)
+	key = hmacSHA256(key, s.region)
+	key = hmacSHA256(key, s.service)
+	key = hmacSHA256(key, _awsSigV4Request)
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set(_headerAuthorization, _awsSigV4Algorithm+
+		" Credential="+s.accessKeyID+"/"+scope+
+		", SignedHeaders="+signedHeaders+
+		", Signature="+signature)
+	return nil
+}
+
+// awsCanonicalPath encodes the path segments, twice for the services other
+// than S3
+func awsCanonicalPath(path string, double bool) string {
+	if path == "" {
+		return "/"
+	}
+	segments := strings.Split(path, "/")
+	for i, s := range segments {
+		s = awsURIEncode(s)
+		if double {
+			s = awsURIEncode(s)
+		}
+		segments[i] = s
+	}
+	return strings.Join(segments, "/")
+}
+
+func awsCanonicalQuery(query map[string][]string) string {
+	pairs := make([]string, 0, len(query))
+	for k, vals := range query {
+		for _, v := range vals {
+			pairs = append(pairs, awsURIEncode(k)+"="+awsURIEncode(v))
+		}
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, "&")
+}
+
+// awsURIEncode encodes all the characters except the unreserved ones
+func awsURIEncode(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		ch := s[i]
+		if ch >= 'A' && ch <= 'Z' || ch >= 'a' && ch <= 'z' || ch >= '0' && ch <= '9' ||
+			ch == '-' || ch == '_' || ch == '.' || ch == '~' {
+			b.WriteByte(ch)
+			continue
+		}
+		b.WriteString("%" + strings.ToUpper(hex.EncodeToString([]byte{ch})))
+	}
+	return b.String()
+}
+
+func newHMACSigner(cfg *entity.ProviderSignerConfig) (requestSigner, error) {
+	s := &hmacSigner{
+		hash:            sha256.New,
+		secret:          []byte(cfg.Secret),
+		header:          cfg.Header,
+		prefix:          cfg.SignaturePrefix,
+		base64:          cfg.Encoding == "BASE64",
+		canonicalString: cfg.CanonicalString,
+		timestampHeader: cfg.TimestampHeader,
+	}
+	switch cfg.Algorithm {
+	case "SHA1":
+		s.hash = sha1.New
+	case "SHA512":
+		s.hash = sha512.New
+	}
+	if s.canonicalString == "" {
+		s.canonicalString = _hmacDefaultCanonicalString
+	}
+	if len(s.secret) == 0 {
+		return nil, errors.New("HMAC secret is not resolved")
+	}
+	return s, nil
+}
+
+// sign sets the signature of the canonical string on the signature header
+func (s *hmacSigner) sign(req *http.Request, body []byte, now time.Time) error {
+	timestamp := strconv.FormatInt(now.Unix(), 10)
+	if s.timestampHeader != "" {
+		req.Header.Set(s.timestampHeader, timestamp)
+	}
+
+	canonical := _regexHMACHeaderPlaceholder.ReplaceAllStringFunc(s.canonicalString, func(m string) string {
+		return req.Header.Get(_regexHMACHeaderPlaceholder.FindStringSubmatch(m)[1])
+	})
+	canonical = strings.NewReplacer(
+		"{method}", req.Method,
+		"{path}", req.URL.EscapedPath(),
+		"{query}", req.URL.RawQuery,
+		"{host}", req.URL.Host,
+		"{timestamp}", timestamp,
+		"{bodySHA256}", sha256Hex(body),
+		"{body}", string(body),
+	).Replace(canonical)
+
+	mac := hmac.New(s.hash, s.secret)
+	mac.Write([]byte(canonical))
+	sum := mac.Sum(nil)
+
+	signature := hex.EncodeToString(sum)
+	if s.base64 {
+		signature = base64.StdEncoding.EncodeToString(sum)
+	}
+	req.Header.Set(s.header, s.prefix+signature)
+	return nil
+}
+
+func sha256Hex(b []byte) string {
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
@@ -7,7 +7,6 @@ import (
 	"io"
 	"net/http"
 	"net/url"
-	"regexp"
 	"strconv"
 	"strings"
 
@@ -15,6 +14,7 @@ import (
 	protocol "github.com/hasmcp/hasmcp-ce/backend/internal/controller/mcp/protocol/p250618"
 	entity "github.com/hasmcp/hasmcp-ce/backend/internal/data/entity/crud"
 	"github.com/hasmcp/hasmcp-ce/backend/internal/data/entity/jsonrpc"
+	"github.com/hasmcp/hasmcp-ce/backend/internal/service/httpc"
 	"github.com/mustafaturan/monoflake"
 	zlog "github.com/rs/zerolog/log"
 )
@@ -43,10 +43,6 @@ const (
 	_argsBodyArgs  = "bodyArgs"
 )
 
-var (
-	_regexVariableName = regexp.MustCompile(`\$\{([A-Z0-9_]+)\}`)
-)
-
 func (c *controller) CallToolsList(ctx context.Context, req CallSessionRequest) (*CallSessionResponse, error) {
 	srv, err := c.getServer(req.ServerID)
 	if err != nil {
@@ -157,6 +153,44 @@ func (c *controller) CallToolsCall(ctx context.Context, req CallSessionRequest)
 		}
 	}
 
+	var asyncHandle string
+	if _, ok := server.asyncToolIDs[toolID]; ok {
+		if raw, ok := params.Arguments[_argsAsyncHandle]; ok {
+			_ = json.Unmarshal(raw, &asyncHandle)
+			delete(params.Arguments, _argsAsyncHandle)
+		}
+	}
+
+	var meta struct {
+		Meta struct {
+			ProgressToken any `json:"progressToken"`
+		} `json:"_meta"`
+	}
+	_ = json.Unmarshal(req.Request.Params, &meta)
+	ctx = withProgressToken(ctx, meta.Meta.ProgressToken)
+
+	// the recordings keep the arguments as the client sent them
+	var arguments json.RawMessage
+	if server.trafficMode == entity.TrafficModeRecord {
+		arguments, _ = json.Marshal(params.Arguments)
+	}
+
+	var argHeaders map[string]string
+	if routes, ok := server.argRoutes[toolID]; ok {
+		params.Arguments, argHeaders, err = nestArguments(params.Arguments, routes)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	params.Arguments, err = applyArgumentPresets(params.Arguments, server.toolOverrides[toolID],
+		func(val any, in entity.ToolArgumentLocation) (any, error) {
+			return c.interpolatePreset(ctx, val, in)
+		})
+	if err != nil {
+		return nil, err
+	}
+
 	// verify the tool schema
 	pathArgs := params.Arguments[_argsPathArgs]
 	queryArgs := params.Arguments[_argsQueryArgs]
@@ -193,47 +227,67 @@ func (c *controller) CallToolsCall(ctx context.Context, req CallSessionRequest)
 		}
 	}
 
-	url, err := buildURL(provider.BaseURL, tool.Path, pathArgs, queryArgs)
-	if err != nil {
-		return nil, jsonrpc.Error{
-			Code:    jsonrpc.ErrCodeInternalError,
-			Message: "Tool tool url is malformed",
-			Data: map[string]any{
-				"reason":   err.Error(),
-				"toolName": params.Name,
-			},
-		}
-	}
-
 	callerHeaders := map[string][]string{}
 	if server.requestHeadersProxyEnabled {
 		callerHeaders = req.Headers
 	}
 
-	headers := buildHeaders(callerHeaders, tool.Headers, c.cache)
-	remoteReq := &http.Request{
-		Method: tool.Method.String(),
-		URL:    url,
-		Header: headers,
-		Body:   io.NopCloser(bytes.NewReader(bodyArgs)),
+	headers := buildHeaders(ctx, callerHeaders, tool.Headers, c.cache)
+	for k, v := range argHeaders {
+		headers.Set(k, v)
 	}
+	authQuery := applyProviderAuth(ctx, provider, headers, c.cache)
 
-	res, err := c.httpc.Call(ctx, remoteReq)
+	ctx, rec, err := c.withTraffic(ctx, server, req.ServerID, provider, tool)
 	if err != nil {
 		return nil, err
 	}
 
-	body := res.Body
-	defer body.Close()
-	resBody, _ := io.ReadAll(body)
+	// the recorder redacts the variables of the base URL, so the provider is
+	// interpolated after it is set up
+	if !server.isMocked(provider) {
+		if provider, err = c.interpolateProvider(ctx, provider); err != nil {
+			return nil, err
+		}
+	}
 
-	resPayload := protocol.CallToolResult{
-		Content: []protocol.ContentBlock{
-			protocol.TextContent{
-				Text: string(resBody),
-				Type: "text",
-			},
-		},
+	var resPayload *protocol.CallToolResult
+	switch {
+	case server.isMocked(provider):
+		resPayload, err = callMock(tool, pathArgs, queryArgs, bodyArgs)
+	case asyncHandle != "":
+		resPayload, err = c.resumeAsync(ctx, provider, tool, headers, authQuery, asyncHandle)
+	case provider.ApiType == entity.ApiTypeGraphQL:
+		endpoint, parseErr := url.Parse(provider.BaseURL)
+		if parseErr != nil {
+			return nil, jsonrpc.Error{
+				Code:    jsonrpc.ErrCodeInternalError,
+				Message: "GraphQL endpoint is malformed",
+				Data: map[string]any{
+					"reason": parseErr.Error(),
+				},
+			}
+		}
+		withQuery(endpoint, authQuery)
+		resPayload, err = c.callGraphQL(ctx, provider, tool, endpoint.String(), headers, bodyArgs)
+	case provider.ApiType == entity.ApiTypeGRPC:
+		if _, err = c.applyGrantToken(ctx, provider, tool.Oauth2Scopes, headers, ""); err != nil {
+			return nil, err
+		}
+		resPayload, err = c.callGRPC(ctx, provider, tool, headers, bodyArgs)
+	case provider.ApiType == entity.ApiTypeMCP:
+		resPayload, err = c.callMCP(ctx, provider, tool, headers, authQuery, bodyArgs)
+	default:
+		resPayload, err = c.callREST(ctx, req.ServerID, provider, tool, headers, authQuery, pathArgs, queryArgs, bodyArgs)
+	}
+	if rec != nil {
+		c.saveRecording(req.ServerID, tool, arguments, resPayload, err, rec)
+	}
+	if egressErr, ok := httpc.AsEgressError(err); ok {
+		resPayload, err = egressDeniedResult(provider, egressErr), nil
+	}
+	if err != nil {
+		return nil, err
 	}
 
 	result, err := json.Marshal(resPayload)
@@ -260,7 +314,85 @@ func (c *controller) CallToolsCall(ctx context.Context, req CallSessionRequest)
 	}, nil
 }
 
-func buildHeaders(callerHeaders map[string][]string, toolHeaders []entity.ToolHeader, cache cache.Controller) http.Header {
+func (c *controller) callREST(
+	ctx context.Context,
+	serverID int64,
+	provider *entity.Provider,
+	tool *entity.ProviderTool,
+	headers http.Header,
+	authQuery url.Values,
+	pathArgs, queryArgs, bodyArgs json.RawMessage,
+) (*protocol.CallToolResult, error) {
+	path, err := c.interpolate(ctx, tool.Path, _interpolateInPath, url.PathEscape)
+	if err != nil {
+		return nil, err
+	}
+	url, err := buildURL(provider.BaseURL, path, pathArgs, queryArgs)
+	if err != nil {
+		return nil, jsonrpc.Error{
+			Code:    jsonrpc.ErrCodeInternalError,
+			Message: "Tool tool url is malformed",
+			Data: map[string]any{
+				"reason":   err.Error(),
+				"toolName": tool.Name,
+			},
+		}
+	}
+	withQuery(url, authQuery)
+	withFirstPage(url, tool.PaginationConfig)
+
+	remoteReq := &http.Request{
+		Method: tool.Method.String(),
+		URL:    url,
+		Header: headers,
+		Body:   io.NopCloser(bytes.NewReader(bodyArgs)),
+	}
+
+	cacheLookup := c.lookupResponseCache(ctx, serverID, tool, remoteReq)
+	if cacheLookup != nil {
+		if cacheLookup.fresh() {
+			return c.cachedResult(cacheLookup), nil
+		}
+		cacheLookup.revalidate(remoteReq)
+	}
+
+	res, err := c.doHTTP(ctx, provider, tool.Oauth2Scopes, remoteReq, bodyArgs)
+	if err != nil {
+		return nil, err
+	}
+
+	body := res.Body
+	defer body.Close()
+	resBody, _ := io.ReadAll(body)
+
+	if cacheLookup != nil && cacheLookup.revalidated(res) {
+		return c.refreshResponse(ctx, cacheLookup, res), nil
+	}
+	if tool.AsyncConfig != nil && res.StatusCode == http.StatusAccepted {
+		return c.awaitAsync(ctx, provider, tool, headers, authQuery, res, resBody)
+	}
+
+	var result *protocol.CallToolResult
+	if tool.PaginationConfig != nil && res.StatusCode < http.StatusMultipleChoices {
+		result, _ = c.paginate(ctx, provider, tool, remoteReq, authQuery, bodyArgs, res, resBody)
+	}
+	if result == nil {
+		result = &protocol.CallToolResult{
+			Content: []protocol.ContentBlock{
+				protocol.TextContent{
+					Text: string(resBody),
+					Type: "text",
+				},
+			},
+		}
+	}
+	if cacheLookup != nil {
+		return c.storeResponse(ctx, cacheLookup, res, result), nil
+	}
+	return result, nil
+}
+
+func buildHeaders(ctx context.Context, callerHeaders map[string][]string, toolHeaders []entity.ToolHeader, cache cache.Controller) http.Header {
 	headers := http.Header{}
 	// Pass proxy headers
 	for k, vals := range callerHeaders {
@@ -273,21 +405,13 @@ func buildHeaders(callerHeaders map[string][]string, toolHeaders []entity.ToolHe
 		if len(callerHeaders[key]) > 0 {
 			continue
 		}
-		names := extractVariables(val)
-		for _, n := range names {
-			v, err := cache.GetVariable(context.Background(), n)
-			if err != nil {
-				continue
-			}
-			val = strings.Replace(val, "${"+n+"}", v, 1)
-		}
-		headers.Add(key, val)
+		headers.Add(key, entity.ReplaceVariables(ctx, val, cache))
 	}
 	return headers
 }
 
 func extractVariables(s string) []string {
-	allMatches := _regexVariableName.FindAllStringSubmatch(s, -1)
+	allMatches := entity.RegexVariableReference.FindAllStringSubmatch(s, -1)
 
 	var names []string
 	for _, match := range allMatches {
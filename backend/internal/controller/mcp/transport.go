@@ -0,0 +1,211 @@
+package mcp
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	entity "github.com/hasmcp/hasmcp-ce/backend/internal/data/entity/crud"
+	"github.com/hasmcp/hasmcp-ce/backend/internal/data/entity/jsonrpc"
+	"github.com/hasmcp/hasmcp-ce/backend/internal/service/httpc"
+	zlog "github.com/rs/zerolog/log"
+)
+
+type (
+	// providerTransportKey identifies the transport of a provider by the
+	// fingerprint of its resolved materials, a rotated secret gets a new one
+	providerTransportKey struct {
+		providerID  int64
+		fingerprint string
+	}
+
+	// transportMaterials are the transport settings of a provider with the
+	// variables resolved
+	transportMaterials struct {
+		mtls                            bool
+		signerCert, signerKey, signerCA string
+		caCert, clientCert, clientKey   string
+		minTLSVersion                   string
+		insecureSkipVerify              bool
+		pinnedSHA256                    []string
+		proxyURL                        string
+	}
+)
+
+var (
+	_tlsVersions = map[string]uint16{
+		"1.2": tls.VersionTLS12,
+		"1.3": tls.VersionTLS13,
+	}
+)
+
+// transportOf returns the transport options of the provider, nil when the
+// provider is called through the shared client. The options are built once
+// per provider and materials, and dropped on the changes of the provider.
+func (c *controller) transportOf(ctx context.Context, provider *entity.Provider) (*httpc.TransportOptions, error) {
+	mtls := provider.SignerConfig != nil && provider.SignerConfig.Type == entity.SignerTypeMTLS
+	cfg := provider.TransportConfig
+	if cfg == nil && !mtls {
+		return nil, nil
+	}
+
+	resolve := func(s string) string {
+		return entity.ReplaceVariables(ctx, s, c.cache)
+	}
+	m := transportMaterials{mtls: mtls}
+	if mtls {
+		signerCfg, err := c.resolveSignerConfig(ctx, provider.SignerConfig)
+		if err != nil {
+			return nil, err
+		}
+		m.signerCert = signerCfg.ClientCert
+		m.signerKey = signerCfg.ClientKey
+		m.signerCA = signerCfg.CACert
+	}
+	if cfg != nil {
+		m.caCert = resolve(cfg.CACert)
+		m.clientCert = resolve(cfg.ClientCert)
+		m.clientKey = resolve(cfg.ClientKey)
+		m.minTLSVersion = cfg.MinTLSVersion
+		m.insecureSkipVerify = cfg.InsecureSkipVerify
+		m.pinnedSHA256 = cfg.PinnedSHA256
+		m.proxyURL = resolve(cfg.ProxyURL)
+	}
+
+	key := providerTransportKey{
+		providerID:  provider.ID,
+		fingerprint: m.fingerprint(),
+	}
+	if opts, ok := c.providerTransports.Load(key); ok {
+		return opts.(*httpc.TransportOptions), nil
+	}
+
+	opts := &httpc.TransportOptions{
+		Key: fmt.Sprintf("provider/%d/%s", provider.ID, key.fingerprint),
+	}
+	if mtls {
+		if err := m.applySigner(opts); err != nil {
+			return nil, signerError(provider, err)
+		}
+	}
+	if cfg != nil {
+		if err := m.applyTransport(opts); err != nil {
+			return nil, jsonrpc.Error{
+				Code:    jsonrpc.ErrCodeInternalError,
+				Message: "Provider transport config is invalid",
+				Data: map[string]any{
+					"reason":     err.Error(),
+					"providerID": provider.ID,
+				},
+			}
+		}
+	}
+	if opts.InsecureSkipVerify {
+		zlog.Warn().Int64("providerID", provider.ID).Str("baseURL", provider.BaseURL).
+			Msg(_logPrefix + "TLS certificate verification is DISABLED for the provider, its upstream requests can be intercepted, never use it in production")
+	}
+
+	c.providerTransports.Store(key, opts)
+	return opts, nil
+}
+
+// evictProviderTransports drops the transports of the provider so that the
+// next request builds them with the changed settings
+func (c *controller) evictProviderTransports(providerID int64) {
+	c.providerTransports.Range(func(k, v any) bool {
+		if k.(providerTransportKey).providerID == providerID {
+			c.providerTransports.Delete(k)
+			c.httpc.Evict(v.(*httpc.TransportOptions).Key)
+		}
+		return true
+	})
+}
+
+func (m transportMaterials) fingerprint() string {
+	h := sha256.New()
+	for _, s := range []string{
+		strconv.FormatBool(m.mtls), m.signerCert, m.signerKey, m.signerCA,
+		m.caCert, m.clientCert, m.clientKey, m.minTLSVersion,
+		strconv.FormatBool(m.insecureSkipVerify), strings.Join(m.pinnedSHA256, ","), m.proxyURL,
+	} {
+		h.Write([]byte(s))
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// applySigner sets the client certificate and the roots of the MTLS signer,
+// its CA certificate replaces the system roots
+func (m transportMaterials) applySigner(opts *httpc.TransportOptions) error {
+	cert, err := tls.X509KeyPair([]byte(m.signerCert), []byte(m.signerKey))
+	if err != nil {
+		return err
+	}
+	opts.Certificates = []tls.Certificate{cert}
+	if m.signerCA != "" {
+		opts.RootCAs = x509.NewCertPool()
+		if !opts.RootCAs.AppendCertsFromPEM([]byte(m.signerCA)) {
+			return errors.New("CA certificate is not a valid PEM")
+		}
+	}
+	return nil
+}
+
+// applyTransport sets the TLS settings and the proxy of the transport config,
+// its CA bundle is trusted on top of the roots
+func (m transportMaterials) applyTransport(opts *httpc.TransportOptions) error {
+	if m.caCert != "" {
+		if opts.RootCAs == nil {
+			pool, err := x509.SystemCertPool()
+			if err != nil {
+				pool = x509.NewCertPool()
+			}
+			opts.RootCAs = pool
+		}
+		if !opts.RootCAs.AppendCertsFromPEM([]byte(m.caCert)) {
+			return errors.New("CA bundle is not a valid PEM")
+		}
+	}
+
+	if m.clientCert != "" || m.clientKey != "" {
+		cert, err := tls.X509KeyPair([]byte(m.clientCert), []byte(m.clientKey))
+		if err != nil {
+			return fmt.Errorf("client certificate: %w", err)
+		}
+		opts.Certificates = append(opts.Certificates, cert)
+	}
+
+	if m.minTLSVersion != "" {
+		version, ok := _tlsVersions[m.minTLSVersion]
+		if !ok {
+			return fmt.Errorf("unsupported minimum TLS version: %s", m.minTLSVersion)
+		}
+		opts.MinVersion = version
+	}
+	opts.InsecureSkipVerify = m.insecureSkipVerify
+
+	for _, pin := range m.pinnedSHA256 {
+		sum, err := base64.StdEncoding.DecodeString(pin)
+		if err != nil || len(sum) != sha256.Size {
+			return fmt.Errorf("pinned SHA-256 is not a base64 SHA-256 hash: %s", pin)
+		}
+		opts.PinnedSHA256 = append(opts.PinnedSHA256, sum)
+	}
+
+	if m.proxyURL != "" {
+		proxy, err := url.Parse(m.proxyURL)
+		if err != nil || proxy.Host == "" {
+			return errors.New("proxy URL is malformed")
+		}
+		opts.Proxy = proxy
+	}
+	return nil
+}
@@ -0,0 +1,300 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	protocol "github.com/hasmcp/hasmcp-ce/backend/internal/controller/mcp/protocol/p250618"
+	entity "github.com/hasmcp/hasmcp-ce/backend/internal/data/entity/crud"
+	"github.com/hasmcp/hasmcp-ce/backend/internal/data/entity/jsonrpc"
+	"github.com/hasmcp/hasmcp-ce/backend/internal/service/mcpc"
+	"github.com/mustafaturan/monoflake"
+	zlog "github.com/rs/zerolog/log"
+)
+
+const (
+	_upstreamListMaxPages = 10
+)
+
+// callMCP forwards the tool call to the upstream MCP server, the body
+// arguments are the upstream tool arguments and the upstream result is
+// returned as it is
+func (c *controller) callMCP(
+	ctx context.Context,
+	provider *entity.Provider,
+	tool *entity.ProviderTool,
+	headers http.Header,
+	authQuery url.Values,
+	bodyArgs json.RawMessage,
+) (*protocol.CallToolResult, error) {
+	if len(bodyArgs) == 0 || string(bodyArgs) == "null" {
+		bodyArgs = json.RawMessage("{}")
+	}
+
+	raw, err := c.callUpstream(ctx, provider, tool.Oauth2Scopes, headers, authQuery, string(MethodToolsCall), map[string]any{
+		"name":      tool.Path,
+		"arguments": bodyArgs,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var result protocol.CallToolResult
+	if err := json.Unmarshal(raw, &result); err != nil {
+		return nil, jsonrpc.Error{
+			Code:    jsonrpc.ErrCodeInternalError,
+			Message: "Upstream MCP server returned a malformed tool result",
+			Data: map[string]any{
+				"reason":   err.Error(),
+				"toolName": tool.Name,
+			},
+		}
+	}
+	return &result, nil
+}
+
+// callUpstream sends the request to the upstream MCP server of the provider
+// with the provider auth. The upstream sessions are shared per provider
+// version and caller.
+func (c *controller) callUpstream(
+	ctx context.Context,
+	provider *entity.Provider,
+	scopes []string,
+	headers http.Header,
+	authQuery url.Values,
+	method string,
+	params any,
+) (json.RawMessage, error) {
+	endpoint, err := url.Parse(provider.BaseURL)
+	if err != nil {
+		return nil, jsonrpc.Error{
+			Code:    jsonrpc.ErrCodeInternalError,
+			Message: "MCP endpoint is malformed",
+			Data: map[string]any{
+				"reason": err.Error(),
+			},
+		}
+	}
+	withQuery(endpoint, authQuery)
+
+	// the recorded and the replayed traffic starts with its own session so that
+	// the session handshake is part of the recording
+	sessionKey := fmt.Sprintf("%d:%d:%s", provider.ID, provider.Version, entity.SubjectOf(ctx))
+	switch {
+	case recorderOf(ctx) != nil:
+		sessionKey += ":" + entity.TrafficModeRecord.String()
+	case replayerOf(ctx) != nil:
+		sessionKey += ":" + entity.TrafficModeReplay.String()
+	}
+
+	raw, err := c.mcpc.Call(ctx, mcpc.CallRequest{
+		SessionKey: sessionKey,
+		URL:        endpoint.String(),
+		Headers:    headers,
+		Method:     method,
+		Params:     params,
+		Do: func(req *http.Request, body []byte) (*http.Response, error) {
+			return c.doHTTP(ctx, provider, scopes, req, body)
+		},
+	})
+	if err == nil {
+		return raw, nil
+	}
+	if e, ok := err.(jsonrpc.Error); ok {
+		return nil, e
+	}
+	return nil, jsonrpc.Error{
+		Code:    jsonrpc.ErrCodeInternalError,
+		Message: "Upstream MCP server call failed",
+		Data: map[string]any{
+			"reason":     err.Error(),
+			"providerID": provider.ID,
+			"method":     method,
+		},
+	}
+}
+
+// upstreamHeaders returns the headers of the upstream calls which are not
+// bound to a tool, the provider auth is applied on top of the caller headers
+func (c *controller) upstreamHeaders(ctx context.Context, srv *server, provider *entity.Provider, req CallSessionRequest) (http.Header, url.Values) {
+	callerHeaders := map[string][]string{}
+	if srv.requestHeadersProxyEnabled {
+		callerHeaders = req.Headers
+	}
+	headers := buildHeaders(ctx, callerHeaders, nil, c.cache)
+	authQuery := applyProviderAuth(ctx, provider, headers, c.cache)
+	return headers, authQuery
+}
+
+// listUpstream follows the pages of the list method on the upstream MCP
+// servers of the server and returns the items of the key by provider. The
+// failing upstreams are skipped so the local items are still listed.
+func (c *controller) listUpstream(ctx context.Context, srv *server, req CallSessionRequest, method, key string) map[int64][]json.RawMessage {
+	items := make(map[int64][]json.RawMessage, len(srv.upstreamProviderIDs))
+	for _, providerID := range srv.upstreamProviderIDs {
+		provider, err := c.cache.GetProvider(ctx, providerID)
+		if err != nil {
+			zlog.Warn().Err(err).Int64("providerID", providerID).Msg("upstream MCP provider is not found")
+			continue
+		}
+		provider, err = c.interpolateProvider(ctx, provider)
+		if err != nil {
+			zlog.Warn().Err(err).Int64("providerID", providerID).Msg("upstream MCP provider base URL is not resolved")
+			continue
+		}
+		headers, authQuery := c.upstreamHeaders(ctx, srv, provider, req)
+
+		var cursor string
+		for page := 0; page < _upstreamListMaxPages; page++ {
+			var params any
+			if cursor != "" {
+				params = map[string]string{"cursor": cursor}
+			}
+			raw, err := c.callUpstream(ctx, provider, nil, headers, authQuery, method, params)
+			if err != nil {
+				zlog.Warn().Err(err).Int64("providerID", providerID).Str("method", method).Msg("failed to list the upstream MCP server items")
+				break
+			}
+
+			var res map[string]json.RawMessage
+			var pageItems []json.RawMessage
+			if err := json.Unmarshal(raw, &res); err != nil {
+				break
+			}
+			_ = json.Unmarshal(res[key], &pageItems)
+			items[providerID] = append(items[providerID], pageItems...)
+
+			cursor = ""
+			_ = json.Unmarshal(res["nextCursor"], &cursor)
+			if cursor == "" {
+				break
+			}
+		}
+	}
+	return items
+}
+
+// upstreamPrompts lists the prompts of the upstream MCP servers, the names are
+// prefixed with the provider to route prompts/get
+func (c *controller) upstreamPrompts(ctx context.Context, srv *server, req CallSessionRequest) []protocol.Prompt {
+	items := c.listUpstream(ctx, srv, req, string(MethodPromptsList), "prompts")
+	prompts := make([]protocol.Prompt, 0)
+	for _, providerID := range srv.upstreamProviderIDs {
+		for _, raw := range items[providerID] {
+			var p protocol.Prompt
+			if err := json.Unmarshal(raw, &p); err != nil {
+				continue
+			}
+			p.Name = upstreamName('P', providerID, p.Name)
+			prompts = append(prompts, p)
+		}
+	}
+	return prompts
+}
+
+// upstreamResources lists the resources of the upstream MCP servers
+func (c *controller) upstreamResources(ctx context.Context, srv *server, req CallSessionRequest) []protocol.Resource {
+	items := c.listUpstream(ctx, srv, req, string(MethodResourcesList), "resources")
+	resources := make([]protocol.Resource, 0)
+	for _, providerID := range srv.upstreamProviderIDs {
+		for _, raw := range items[providerID] {
+			var r protocol.Resource
+			if err := json.Unmarshal(raw, &r); err != nil {
+				continue
+			}
+			resources = append(resources, r)
+		}
+	}
+	return resources
+}
+
+// getUpstreamPrompt forwards prompts/get to the upstream MCP server of the
+// prefixed prompt name
+func (c *controller) getUpstreamPrompt(ctx context.Context, srv *server, req CallSessionRequest, name string, arguments map[string]string) (json.RawMessage, bool, error) {
+	providerID, upstream, ok := parseUpstreamName(name)
+	if !ok || !srv.hasUpstream(providerID) {
+		return nil, false, nil
+	}
+
+	provider, err := c.cache.GetProvider(ctx, providerID)
+	if err != nil {
+		return nil, true, jsonrpc.Error{
+			Code:    jsonrpc.ErrCodeInternalError,
+			Message: "Upstream MCP provider is not found",
+			Data: map[string]any{
+				"reason":     err.Error(),
+				"providerID": providerID,
+			},
+		}
+	}
+	provider, err = c.interpolateProvider(ctx, provider)
+	if err != nil {
+		return nil, true, err
+	}
+	headers, authQuery := c.upstreamHeaders(ctx, srv, provider, req)
+
+	params := map[string]any{"name": upstream}
+	if len(arguments) > 0 {
+		params["arguments"] = arguments
+	}
+	raw, err := c.callUpstream(ctx, provider, nil, headers, authQuery, string(MethodPromptsGet), params)
+	return raw, true, err
+}
+
+// readUpstreamResource forwards resources/read to the upstream MCP servers in
+// order until one of them has the resource
+func (c *controller) readUpstreamResource(ctx context.Context, srv *server, req CallSessionRequest, uri string) (json.RawMessage, error) {
+	var lastErr error = jsonrpc.Error{
+		Code:    jsonrpc.ErrCodeInvalidParams,
+		Message: "resource not found at specified URI",
+		Data:    map[string]any{"uri": uri},
+	}
+	for _, providerID := range srv.upstreamProviderIDs {
+		provider, err := c.cache.GetProvider(ctx, providerID)
+		if err != nil {
+			continue
+		}
+		if provider, err = c.interpolateProvider(ctx, provider); err != nil {
+			lastErr = err
+			continue
+		}
+		headers, authQuery := c.upstreamHeaders(ctx, srv, provider, req)
+		raw, err := c.callUpstream(ctx, provider, nil, headers, authQuery, string(MethodResourcesRead), map[string]string{
+			"uri": uri,
+		})
+		if err == nil {
+			return raw, nil
+		}
+		lastErr = err
+	}
+	return nil, lastErr
+}
+
+func (s *server) hasUpstream(providerID int64) bool {
+	for _, id := range s.upstreamProviderIDs {
+		if id == providerID {
+			return true
+		}
+	}
+	return false
+}
+
+// upstreamName prefixes the upstream item name with the provider ID like the
+// names of the local items
+func upstreamName(mcpFeaturePrefix rune, providerID int64, name string) string {
+	return string(mcpFeaturePrefix) + monoflake.ID(providerID).String() + "_" + name
+}
+
+func parseUpstreamName(name string) (int64, string, bool) {
+	if len(name) < 14 || name[12] != '_' {
+		return 0, "", false
+	}
+	providerID := monoflake.IDFromBase62(name[1:12]).Int64()
+	if providerID == 0 {
+		return 0, "", false
+	}
+	return providerID, name[13:], true
+}
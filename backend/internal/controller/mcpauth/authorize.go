@@ -0,0 +1,215 @@
+package mcpauth
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"net/url"
+	"slices"
+	"time"
+
+	erre "github.com/hasmcp/hasmcp-ce/backend/internal/data/entity/err"
+	"github.com/hasmcp/hasmcp-ce/backend/internal/data/model"
+	zlog "github.com/rs/zerolog/log"
+)
+
+type (
+	AuthorizeRequest struct {
+		ResponseType        string
+		ClientID            string
+		RedirectURI         string
+		CodeChallenge       string
+		CodeChallengeMethod string
+		State               string
+		Scope               string
+		Resource            string
+	}
+
+	// AuthorizeResponse has the details of the consent page
+	AuthorizeResponse struct {
+		ClientName string
+		ServerID   int64
+		Scope      string
+	}
+
+	ApproveRequest struct {
+		Authorize AuthorizeRequest
+		// AccessKey is the key of the HasMCP operator approving the client
+		AccessKey string
+		// Subject is the user the client acts for, the upstream credentials of
+		// the user are used on the tool calls
+		Subject string
+		Denied  bool
+	}
+
+	ApproveResponse struct {
+		RedirectURL string
+	}
+)
+
+const (
+	_validationAttrCodeChallengeMinLength = 43
+	_validationAttrCodeChallengeMaxLength = 128
+	_validationAttrSubjectMaxLength       = 128
+)
+
+// Authorize validates the authorization request of the client before the
+// consent of the operator
+func (c *controller) Authorize(ctx context.Context, req AuthorizeRequest) (*AuthorizeResponse, error) {
+	client, serverID, scope, err := c.validateAuthorizeRequest(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	name := client.Name
+	if name == "" {
+		name = client.ID
+	}
+	return &AuthorizeResponse{
+		ClientName: name,
+		ServerID:   serverID,
+		Scope:      scope,
+	}, nil
+}
+
+// Approve issues a single use authorization code for the client once the
+// operator approves the request with the access key
+func (c *controller) Approve(ctx context.Context, req ApproveRequest) (*ApproveResponse, error) {
+	_, serverID, scope, err := c.validateAuthorizeRequest(ctx, req.Authorize)
+	if err != nil {
+		return nil, err
+	}
+
+	redirect := func(values url.Values) *ApproveResponse {
+		if req.Authorize.State != "" {
+			values.Set("state", req.Authorize.State)
+		}
+		values.Set("iss", c.issuerOf())
+		return &ApproveResponse{
+			RedirectURL: withQuery(req.Authorize.RedirectURI, values),
+		}
+	}
+
+	if req.Denied {
+		return redirect(url.Values{"error": []string{ErrCodeAccessDenied}}), nil
+	}
+	if c.cfg.AccessKey == "" ||
+		subtle.ConstantTimeCompare([]byte(req.AccessKey), []byte(c.cfg.AccessKey)) != 1 {
+		return nil, oauth2Error(erre.ErrorCodeUnauthorized, ErrCodeAccessDenied, "invalid access key")
+	}
+	if len(req.Subject) > _validationAttrSubjectMaxLength {
+		return nil, oauth2Error(erre.ErrorCodeBadRequest, ErrCodeInvalidRequest, "subject exceeds maximum length")
+	}
+
+	code, err := c.locksmith.GenerateRandomString64(ctx)
+	if err != nil {
+		return nil, oauth2Error(erre.ErrorCodeInternalServerError, ErrCodeServerError, "couldn't generate authorization code")
+	}
+
+	now := time.Now().UTC()
+	if err := c.storage.DeleteExpiredOauth2AuthorizationCodes(ctx, now); err != nil {
+		zlog.Warn().Err(err).Msg("failed to delete the expired authorization codes")
+	}
+
+	err = c.storage.SaveOauth2AuthorizationCode(ctx, model.Oauth2AuthorizationCode{
+		ID:            hashCode(code),
+		CreatedAt:     now,
+		ExpiresAt:     now.Add(c.cfg.CodeTTL),
+		ClientID:      req.Authorize.ClientID,
+		RedirectURI:   req.Authorize.RedirectURI,
+		ServerID:      serverID,
+		Resource:      req.Authorize.Resource,
+		Scope:         scope,
+		Subject:       req.Subject,
+		CodeChallenge: req.Authorize.CodeChallenge,
+	})
+	if err != nil {
+		return nil, oauth2Error(erre.ErrorCodeInternalServerError, ErrCodeServerError, "couldn't save authorization code")
+	}
+
+	return redirect(url.Values{"code": []string{code}}), nil
+}
+
+// validateAuthorizeRequest returns the client, the MCP server of the resource
+// and the granted scope. PKCE with S256 is required for all clients.
+func (c *controller) validateAuthorizeRequest(ctx context.Context, req AuthorizeRequest) (*Client, int64, string, error) {
+	if req.ClientID == "" {
+		return nil, 0, "", oauth2Error(erre.ErrorCodeBadRequest, ErrCodeInvalidRequest, "client_id is required")
+	}
+	client, _, err := c.clientOf(ctx, req.ClientID)
+	if err != nil {
+		return nil, 0, "", err
+	}
+	if !matchesRedirectURI(client.RedirectURIs, req.RedirectURI) {
+		return nil, 0, "", oauth2Error(erre.ErrorCodeBadRequest, ErrCodeInvalidRequest, "redirect_uri is not registered for the client")
+	}
+
+	if req.ResponseType != _responseTypeCode {
+		return nil, 0, "", oauth2Error(erre.ErrorCodeBadRequest, ErrCodeUnsupportedResponse, "response_type must be code")
+	}
+	if req.CodeChallengeMethod != _codeChallengeMethodS256 ||
+		len(req.CodeChallenge) < _validationAttrCodeChallengeMinLength ||
+		len(req.CodeChallenge) > _validationAttrCodeChallengeMaxLength {
+		return nil, 0, "", oauth2Error(erre.ErrorCodeBadRequest, ErrCodeInvalidRequest, "code_challenge with S256 method is required")
+	}
+	if req.Resource == "" {
+		return nil, 0, "", oauth2Error(erre.ErrorCodeBadRequest, ErrCodeInvalidTarget, "resource is required")
+	}
+
+	serverID, err := c.serverOfResource(ctx, req.Resource)
+	if err != nil {
+		return nil, 0, "", err
+	}
+	scope, err := scopeOf(req.Scope)
+	if err != nil {
+		return nil, 0, "", err
+	}
+	return client, serverID, scope, nil
+}
+
+// matchesRedirectURI compares the redirect uri with the registered ones, the
+// port of the loopback redirect uris may vary for the native apps
+func matchesRedirectURI(registered []string, uri string) bool {
+	if uri == "" {
+		return false
+	}
+	if slices.Contains(registered, uri) {
+		return true
+	}
+
+	u, err := url.Parse(uri)
+	if err != nil || u.Scheme != "http" || !isLoopback(u.Hostname()) {
+		return false
+	}
+	for _, r := range registered {
+		ru, err := url.Parse(r)
+		if err != nil {
+			continue
+		}
+		if ru.Scheme == u.Scheme && ru.Hostname() == u.Hostname() &&
+			ru.Path == u.Path && ru.RawQuery == u.RawQuery {
+			return true
+		}
+	}
+	return false
+}
+
+func withQuery(uri string, values url.Values) string {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return uri
+	}
+	query := u.Query()
+	for k, vals := range values {
+		query[k] = vals
+	}
+	u.RawQuery = query.Encode()
+	return u.String()
+}
+
+// hashCode keeps the authorization codes out of the storage
+func hashCode(code string) string {
+	sum := sha256.Sum256([]byte(code))
+	return hex.EncodeToString(sum[:])
+}
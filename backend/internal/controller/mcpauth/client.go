@@ -0,0 +1,189 @@
+package mcpauth
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/url"
+	"slices"
+	"time"
+
+	erre "github.com/hasmcp/hasmcp-ce/backend/internal/data/entity/err"
+	"github.com/hasmcp/hasmcp-ce/backend/internal/data/model"
+	"github.com/hasmcp/hasmcp-ce/backend/internal/service/locksmith"
+)
+
+type (
+	// Client is an MCP client registered dynamically (RFC 7591)
+	Client struct {
+		ID                      string
+		CreatedAt               time.Time
+		Name                    string
+		RedirectURIs            []string
+		TokenEndpointAuthMethod string
+		GrantTypes              []string
+		ResponseTypes           []string
+
+		// Secret is only returned on the registration of the confidential
+		// clients
+		Secret string
+	}
+
+	RegisterClientRequest struct {
+		Client Client
+	}
+
+	RegisterClientResponse struct {
+		Client Client
+	}
+)
+
+const (
+	_tokenEndpointAuthMethodNone        = "none"
+	_tokenEndpointAuthMethodSecretPost  = "client_secret_post"
+	_tokenEndpointAuthMethodSecretBasic = "client_secret_basic"
+
+	_validationAttrClientRedirectURIsMax      = 10
+	_validationAttrClientRedirectURIMaxLength = 2048
+	_validationAttrClientNameMaxLength        = 255
+)
+
+var (
+	_tokenEndpointAuthMethods = []string{
+		_tokenEndpointAuthMethodNone,
+		_tokenEndpointAuthMethodSecretPost,
+		_tokenEndpointAuthMethodSecretBasic,
+	}
+)
+
+// RegisterClient registers the MCP client, the public clients are the default
+// since most MCP clients run on the user devices
+func (c *controller) RegisterClient(ctx context.Context, req RegisterClientRequest) (*RegisterClientResponse, error) {
+	client := req.Client
+	if client.TokenEndpointAuthMethod == "" {
+		client.TokenEndpointAuthMethod = _tokenEndpointAuthMethodNone
+	}
+	if err := validateClient(client); err != nil {
+		return nil, err
+	}
+
+	var secretHash []byte
+	if client.TokenEndpointAuthMethod != _tokenEndpointAuthMethodNone {
+		secret, err := c.locksmith.GenerateRandomString64(ctx)
+		if err != nil {
+			return nil, oauth2Error(erre.ErrorCodeInternalServerError, ErrCodeServerError, "couldn't generate client secret")
+		}
+		res, err := c.locksmith.BcryptHash(ctx, &locksmith.HashRequest{
+			Payload: []byte(secret),
+		})
+		if err != nil {
+			return nil, oauth2Error(erre.ErrorCodeInternalServerError, ErrCodeServerError, "couldn't hash client secret")
+		}
+		client.Secret = secret
+		secretHash = res.Output
+	}
+
+	redirectURIs, _ := json.Marshal(client.RedirectURIs)
+	client.ID = c.idgen.NextString()
+	client.CreatedAt = time.Now().UTC()
+	client.GrantTypes = []string{_grantTypeAuthorizationCode}
+	client.ResponseTypes = []string{_responseTypeCode}
+
+	err := c.storage.CreateOauth2Client(ctx, model.Oauth2Client{
+		ID:                      client.ID,
+		CreatedAt:               client.CreatedAt,
+		Name:                    client.Name,
+		RedirectURIs:            redirectURIs,
+		TokenEndpointAuthMethod: client.TokenEndpointAuthMethod,
+		SecretHash:              secretHash,
+	})
+	if err != nil {
+		return nil, oauth2Error(erre.ErrorCodeInternalServerError, ErrCodeServerError, "couldn't save client")
+	}
+
+	return &RegisterClientResponse{
+		Client: client,
+	}, nil
+}
+
+// clientOf returns the registered client
+func (c *controller) clientOf(ctx context.Context, clientID string) (*Client, []byte, error) {
+	m, err := c.storage.GetOauth2Client(ctx, clientID)
+	if err != nil {
+		return nil, nil, oauth2Error(erre.ErrorCodeUnauthorized, ErrCodeInvalidClient, "client is not registered")
+	}
+
+	var redirectURIs []string
+	_ = json.Unmarshal(m.RedirectURIs, &redirectURIs)
+	return &Client{
+		ID:                      m.ID,
+		CreatedAt:               m.CreatedAt,
+		Name:                    m.Name,
+		RedirectURIs:            redirectURIs,
+		TokenEndpointAuthMethod: m.TokenEndpointAuthMethod,
+	}, m.SecretHash, nil
+}
+
+func validateClient(client Client) error {
+	if !slices.Contains(_tokenEndpointAuthMethods, client.TokenEndpointAuthMethod) {
+		return oauth2Error(erre.ErrorCodeBadRequest, ErrCodeInvalidClientMeta, "token endpoint auth method is not supported")
+	}
+	for _, g := range client.GrantTypes {
+		if g != _grantTypeAuthorizationCode {
+			return oauth2Error(erre.ErrorCodeBadRequest, ErrCodeInvalidClientMeta, "grant type is not supported: "+g)
+		}
+	}
+	for _, r := range client.ResponseTypes {
+		if r != _responseTypeCode {
+			return oauth2Error(erre.ErrorCodeBadRequest, ErrCodeInvalidClientMeta, "response type is not supported: "+r)
+		}
+	}
+	if len(client.Name) > _validationAttrClientNameMaxLength {
+		return oauth2Error(erre.ErrorCodeBadRequest, ErrCodeInvalidClientMeta, "client name exceeds maximum length")
+	}
+
+	if len(client.RedirectURIs) == 0 || len(client.RedirectURIs) > _validationAttrClientRedirectURIsMax {
+		return oauth2Error(erre.ErrorCodeBadRequest, ErrCodeInvalidRedirectURI, "between 1 and 10 redirect uris are required")
+	}
+	for _, uri := range client.RedirectURIs {
+		if err := validateRedirectURI(uri); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validateRedirectURI accepts https, loopback http and the private-use
+// schemes of the native apps
+func validateRedirectURI(uri string) error {
+	invalid := oauth2Error(erre.ErrorCodeBadRequest, ErrCodeInvalidRedirectURI, "invalid redirect uri: "+uri)
+	if len(uri) > _validationAttrClientRedirectURIMaxLength {
+		return invalid
+	}
+
+	u, err := url.Parse(uri)
+	if err != nil || !u.IsAbs() || u.Fragment != "" {
+		return invalid
+	}
+	switch u.Scheme {
+	case "https":
+		if u.Host == "" {
+			return invalid
+		}
+	case "http":
+		if !isLoopback(u.Hostname()) {
+			return invalid
+		}
+	case "javascript", "data", "file", "vbscript":
+		return invalid
+	}
+	return nil
+}
+
+func isLoopback(host string) bool {
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
@@ -0,0 +1,258 @@
+package mcpauth
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/hasmcp/hasmcp-ce/backend/internal/controller/crud"
+	"github.com/hasmcp/hasmcp-ce/backend/internal/controller/mcp"
+	"github.com/hasmcp/hasmcp-ce/backend/internal/controller/mcp/jwt"
+	crude "github.com/hasmcp/hasmcp-ce/backend/internal/data/entity/crud"
+	erre "github.com/hasmcp/hasmcp-ce/backend/internal/data/entity/err"
+	"github.com/hasmcp/hasmcp-ce/backend/internal/repository/storage"
+	"github.com/hasmcp/hasmcp-ce/backend/internal/service/config"
+	"github.com/hasmcp/hasmcp-ce/backend/internal/service/idgen"
+	"github.com/hasmcp/hasmcp-ce/backend/internal/service/locksmith"
+	"github.com/mustafaturan/monoflake"
+)
+
+type (
+	// Controller is the built-in OAuth 2.1 authorization server of the MCP
+	// servers following the MCP authorization specification. The issued
+	// access tokens are the server tokens accepted by the MCP handler.
+	Controller interface {
+		ProtectedResourceMetadata(ctx context.Context, req ProtectedResourceMetadataRequest) (*ProtectedResourceMetadataResponse, error)
+		AuthorizationServerMetadata(ctx context.Context, req AuthorizationServerMetadataRequest) (*AuthorizationServerMetadataResponse, error)
+		RegisterClient(ctx context.Context, req RegisterClientRequest) (*RegisterClientResponse, error)
+		Authorize(ctx context.Context, req AuthorizeRequest) (*AuthorizeResponse, error)
+		Approve(ctx context.Context, req ApproveRequest) (*ApproveResponse, error)
+		Token(ctx context.Context, req TokenRequest) (*TokenResponse, error)
+	}
+
+	controller struct {
+		cfg       mcpAuthConfig
+		idgen     idgen.Service
+		locksmith locksmith.Service
+		crud      crud.Controller
+		jwt       jwt.IssuerController
+		storage   storage.Repository
+	}
+
+	Params struct {
+		Config    config.Service
+		IDGen     idgen.Service
+		Locksmith locksmith.Service
+		Crud      crud.Controller
+		JWT       jwt.IssuerController
+		Storage   storage.Repository
+	}
+
+	mcpAuthConfig struct {
+		// BaseURL is the public URL of the server, it is the issuer and the
+		// prefix of the canonical MCP server URIs
+		BaseURL string `yaml:"baseURL"`
+		// AccessKey approves the authorization requests on the consent page
+		AccessKey      string        `yaml:"accessKey"`
+		AccessTokenTTL time.Duration `yaml:"accessTokenTTL"`
+		CodeTTL        time.Duration `yaml:"codeTTL"`
+	}
+
+	ProtectedResourceMetadataRequest struct {
+		ServerID int64
+	}
+
+	// ProtectedResourceMetadataResponse is the RFC 9728 metadata of an MCP
+	// server
+	ProtectedResourceMetadataResponse struct {
+		Resource               string
+		AuthorizationServers   []string
+		ScopesSupported        []string
+		BearerMethodsSupported []string
+		ResourceName           string
+	}
+
+	AuthorizationServerMetadataRequest struct{}
+
+	// AuthorizationServerMetadataResponse is the RFC 8414 metadata of the
+	// authorization server
+	AuthorizationServerMetadataResponse struct {
+		Issuer                            string
+		AuthorizationEndpoint             string
+		TokenEndpoint                     string
+		RegistrationEndpoint              string
+		ScopesSupported                   []string
+		ResponseTypesSupported            []string
+		GrantTypesSupported               []string
+		TokenEndpointAuthMethodsSupported []string
+		CodeChallengeMethodsSupported     []string
+	}
+)
+
+const (
+	_cfgKey = "mcpAuthorizationServer"
+
+	_routePathMcp       = "/mcp/"
+	_routePathAuthorize = "/oauth2/mcp/authorize"
+	_routePathToken     = "/oauth2/mcp/token"
+	_routePathRegister  = "/oauth2/mcp/register"
+
+	_defaultAccessTokenTTL = 24 * time.Hour
+	_defaultCodeTTL        = time.Minute
+
+	// OAuth 2.1 error codes
+	ErrCodeInvalidRequest       = "invalid_request"
+	ErrCodeInvalidClient        = "invalid_client"
+	ErrCodeInvalidGrant         = "invalid_grant"
+	ErrCodeInvalidScope         = "invalid_scope"
+	ErrCodeInvalidTarget        = "invalid_target"
+	ErrCodeAccessDenied         = "access_denied"
+	ErrCodeUnsupportedGrantType = "unsupported_grant_type"
+	ErrCodeUnsupportedResponse  = "unsupported_response_type"
+	ErrCodeInvalidRedirectURI   = "invalid_redirect_uri"
+	ErrCodeInvalidClientMeta    = "invalid_client_metadata"
+	ErrCodeServerError          = "server_error"
+
+	_grantTypeAuthorizationCode = "authorization_code"
+	_responseTypeCode           = "code"
+	_codeChallengeMethodS256    = "S256"
+	_tokenTypeBearer            = "Bearer"
+)
+
+var (
+	_scopesSupported = []string{
+		mcp.ScopeSessionCreate,
+		mcp.ScopeSessionCall,
+		mcp.ScopeSessionStream,
+		mcp.ScopeSessionDelete,
+		mcp.ScopeServerTail,
+	}
+
+	// _defaultScopes are granted when the client does not ask for scopes, same
+	// as the server tokens created on the API
+	_defaultScopes = []string{
+		mcp.ScopeSessionCreate,
+		mcp.ScopeSessionCall,
+		mcp.ScopeSessionDelete,
+		mcp.ScopeSessionStream,
+	}
+)
+
+func New(p Params) (Controller, error) {
+	var cfg mcpAuthConfig
+	err := p.Config.Populate(_cfgKey, &cfg)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.AccessTokenTTL <= 0 {
+		cfg.AccessTokenTTL = _defaultAccessTokenTTL
+	}
+	if cfg.CodeTTL <= 0 {
+		cfg.CodeTTL = _defaultCodeTTL
+	}
+	cfg.BaseURL = strings.TrimSuffix(cfg.BaseURL, "/")
+	if cfg.BaseURL == "" {
+		return nil, errors.New("mcpAuthorizationServer.baseURL is required")
+	}
+
+	return &controller{
+		cfg:       cfg,
+		idgen:     p.IDGen,
+		locksmith: p.Locksmith,
+		crud:      p.Crud,
+		jwt:       p.JWT,
+		storage:   p.Storage,
+	}, nil
+}
+
+// ProtectedResourceMetadata returns the metadata of the MCP server pointing
+// the clients to the built-in authorization server
+func (c *controller) ProtectedResourceMetadata(ctx context.Context, req ProtectedResourceMetadataRequest) (*ProtectedResourceMetadataResponse, error) {
+	res, err := c.crud.GetServer(ctx, crude.GetServerRequest{
+		ID: req.ServerID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &ProtectedResourceMetadataResponse{
+		Resource:               c.resourceOf(req.ServerID),
+		AuthorizationServers:   []string{c.issuerOf()},
+		ScopesSupported:        _scopesSupported,
+		BearerMethodsSupported: []string{"header"},
+		ResourceName:           res.Server.Name,
+	}, nil
+}
+
+func (c *controller) AuthorizationServerMetadata(ctx context.Context, req AuthorizationServerMetadataRequest) (*AuthorizationServerMetadataResponse, error) {
+	issuer := c.issuerOf()
+	return &AuthorizationServerMetadataResponse{
+		Issuer:                            issuer,
+		AuthorizationEndpoint:             issuer + _routePathAuthorize,
+		TokenEndpoint:                     issuer + _routePathToken,
+		RegistrationEndpoint:              issuer + _routePathRegister,
+		ScopesSupported:                   _scopesSupported,
+		ResponseTypesSupported:            []string{_responseTypeCode},
+		GrantTypesSupported:               []string{_grantTypeAuthorizationCode},
+		TokenEndpointAuthMethodsSupported: _tokenEndpointAuthMethods,
+		CodeChallengeMethodsSupported:     []string{_codeChallengeMethodS256},
+	}, nil
+}
+
+// issuerOf returns the configured base URL, the Host header of the requests
+// is not trusted
+func (c *controller) issuerOf() string {
+	return c.cfg.BaseURL
+}
+
+// resourceOf returns the canonical URI of the MCP server
+func (c *controller) resourceOf(serverID int64) string {
+	return c.issuerOf() + _routePathMcp + monoflake.ID(serverID).String()
+}
+
+// serverOfResource returns the MCP server of the canonical URI
+func (c *controller) serverOfResource(ctx context.Context, resource string) (int64, error) {
+	prefix := c.issuerOf() + _routePathMcp
+	id, ok := strings.CutPrefix(strings.TrimSuffix(resource, "/"), prefix)
+	if !ok || id == "" || strings.Contains(id, "/") {
+		return 0, oauth2Error(erre.ErrorCodeBadRequest, ErrCodeInvalidTarget, "resource must be an MCP server of the authorization server")
+	}
+
+	serverID := monoflake.IDFromBase62(id).Int64()
+	if _, err := c.crud.GetServer(ctx, crude.GetServerRequest{ID: serverID}); err != nil {
+		return 0, oauth2Error(erre.ErrorCodeBadRequest, ErrCodeInvalidTarget, "MCP server of the resource is not found")
+	}
+	return serverID, nil
+}
+
+// scopeOf validates the requested scopes, the default scopes are granted when
+// none is requested
+func scopeOf(requested string) (string, error) {
+	scopes := strings.Fields(requested)
+	if len(scopes) == 0 {
+		return strings.Join(_defaultScopes, " "), nil
+	}
+
+	supported := make(map[string]struct{}, len(_scopesSupported))
+	for _, s := range _scopesSupported {
+		supported[s] = struct{}{}
+	}
+	for _, s := range scopes {
+		if _, ok := supported[s]; !ok {
+			return "", oauth2Error(erre.ErrorCodeBadRequest, ErrCodeInvalidScope, "scope is not supported: "+s)
+		}
+	}
+	return strings.Join(scopes, " "), nil
+}
+
+// oauth2Error returns the error with the OAuth 2.1 error code in its data
+func oauth2Error(code erre.ErrorCode, oauth2Code, description string) erre.Error {
+	return erre.Error{
+		Code:    code,
+		Message: description,
+		Data: map[string]any{
+			"error": oauth2Code,
+		},
+	}
+}
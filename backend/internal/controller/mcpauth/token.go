@@ -0,0 +1,125 @@
+package mcpauth
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"time"
+
+	jwtv5 "github.com/golang-jwt/jwt/v5"
+	"github.com/hasmcp/hasmcp-ce/backend/internal/controller/mcp/jwt"
+	erre "github.com/hasmcp/hasmcp-ce/backend/internal/data/entity/err"
+	"github.com/hasmcp/hasmcp-ce/backend/internal/service/locksmith"
+	"github.com/mustafaturan/monoflake"
+)
+
+type (
+	TokenRequest struct {
+		GrantType    string
+		Code         string
+		RedirectURI  string
+		ClientID     string
+		ClientSecret string
+		CodeVerifier string
+		Resource     string
+	}
+
+	TokenResponse struct {
+		AccessToken string
+		TokenType   string
+		ExpiresIn   int64
+		Scope       string
+	}
+)
+
+// Token exchanges the authorization code for a server token of the MCP server
+// bound to the resource with the approved scope and subject
+func (c *controller) Token(ctx context.Context, req TokenRequest) (*TokenResponse, error) {
+	if req.GrantType != _grantTypeAuthorizationCode {
+		return nil, oauth2Error(erre.ErrorCodeBadRequest, ErrCodeUnsupportedGrantType, "grant_type must be authorization_code")
+	}
+	if req.Code == "" || req.CodeVerifier == "" {
+		return nil, oauth2Error(erre.ErrorCodeBadRequest, ErrCodeInvalidRequest, "code and code_verifier are required")
+	}
+
+	if err := c.authenticateClient(ctx, req); err != nil {
+		return nil, err
+	}
+
+	// The code is single use, a replayed exchange finds no code
+	code, err := c.storage.ConsumeOauth2AuthorizationCode(ctx, hashCode(req.Code))
+	if err != nil {
+		return nil, oauth2Error(erre.ErrorCodeBadRequest, ErrCodeInvalidGrant, "code is already used or expired")
+	}
+
+	now := time.Now().UTC()
+	switch {
+	case code.ExpiresAt.Before(now):
+		return nil, oauth2Error(erre.ErrorCodeBadRequest, ErrCodeInvalidGrant, "code is already used or expired")
+	case code.ClientID != req.ClientID:
+		return nil, oauth2Error(erre.ErrorCodeBadRequest, ErrCodeInvalidGrant, "code is issued to another client")
+	case code.RedirectURI != req.RedirectURI:
+		return nil, oauth2Error(erre.ErrorCodeBadRequest, ErrCodeInvalidGrant, "redirect_uri does not match")
+	case req.Resource != "" && req.Resource != code.Resource:
+		return nil, oauth2Error(erre.ErrorCodeBadRequest, ErrCodeInvalidTarget, "resource does not match")
+	case !verifiesCodeChallenge(req.CodeVerifier, code.CodeChallenge):
+		return nil, oauth2Error(erre.ErrorCodeBadRequest, ErrCodeInvalidGrant, "code_verifier does not match")
+	}
+
+	expiresAt := now.Add(c.cfg.AccessTokenTTL)
+	tokenRes, err := c.jwt.Issue(ctx, jwt.IssueParams{
+		Claims: jwt.ServerClaims{
+			ServerID: monoflake.ID(code.ServerID).String(),
+			Scope:    code.Scope,
+			RegisteredClaims: jwtv5.RegisteredClaims{
+				ID:        c.idgen.NextString(),
+				Subject:   code.Subject,
+				Audience:  []string{code.Resource},
+				ExpiresAt: jwtv5.NewNumericDate(expiresAt),
+				Issuer:    c.issuerOf(),
+				IssuedAt:  jwtv5.NewNumericDate(now),
+			},
+		},
+	})
+	if err != nil {
+		return nil, oauth2Error(erre.ErrorCodeInternalServerError, ErrCodeServerError, "couldn't issue access token")
+	}
+
+	return &TokenResponse{
+		AccessToken: tokenRes.Token,
+		TokenType:   _tokenTypeBearer,
+		ExpiresIn:   int64(c.cfg.AccessTokenTTL.Seconds()),
+		Scope:       code.Scope,
+	}, nil
+}
+
+// authenticateClient checks the secret of the confidential clients
+func (c *controller) authenticateClient(ctx context.Context, req TokenRequest) error {
+	client, secretHash, err := c.clientOf(ctx, req.ClientID)
+	if err != nil {
+		return err
+	}
+	if client.TokenEndpointAuthMethod == _tokenEndpointAuthMethodNone {
+		return nil
+	}
+
+	if req.ClientSecret == "" {
+		return oauth2Error(erre.ErrorCodeUnauthorized, ErrCodeInvalidClient, "client_secret is required")
+	}
+	err = c.locksmith.CompareBcryptHashAndPassword(ctx, &locksmith.HashRequest{
+		Payload: []byte(req.ClientSecret),
+		Hash:    secretHash,
+	})
+	if err != nil {
+		return oauth2Error(erre.ErrorCodeUnauthorized, ErrCodeInvalidClient, "invalid client_secret")
+	}
+	return nil
+}
+
+// verifiesCodeChallenge checks the PKCE S256 code challenge
+func verifiesCodeChallenge(verifier, challenge string) bool {
+	sum := sha256.Sum256([]byte(verifier))
+	expected := base64.RawURLEncoding.EncodeToString(sum[:])
+	return subtle.ConstantTimeCompare([]byte(expected), []byte(challenge)) == 1
+}
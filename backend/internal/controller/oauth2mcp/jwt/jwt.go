@@ -37,8 +37,11 @@ type (
 	}
 
 	VerifyStateResult struct {
+		ID         string
 		ProviderID int64
 		ServerID   int64
+		// Subject is the user authorizing the provider, empty for globals
+		Subject string
 	}
 
 	ProviderClaims struct {
@@ -121,7 +124,9 @@ func (c *controller) VerifyState(ctx context.Context, p VerifyStateParams) (*Ver
 	}
 
 	return &VerifyStateResult{
+		ID:         claims.ID,
 		ProviderID: monoflake.IDFromBase62(claims.Audience[0]).Int64(),
 		ServerID:   monoflake.IDFromBase62(claims.Audience[1]).Int64(),
+		Subject:    claims.Subject,
 	}, nil
 }
@@ -2,18 +2,24 @@ package oauth2mcp
 
 import (
 	"context"
-	"regexp"
+	"net/url"
+	"strconv"
 	"strings"
 	"time"
 
 	jwtv5 "github.com/golang-jwt/jwt/v5"
 	"github.com/hasmcp/hasmcp-ce/backend/internal/controller/crud"
+	"github.com/hasmcp/hasmcp-ce/backend/internal/controller/mcp"
+	mcpjwt "github.com/hasmcp/hasmcp-ce/backend/internal/controller/mcp/jwt"
 	"github.com/hasmcp/hasmcp-ce/backend/internal/controller/oauth2mcp/jwt"
 	crude "github.com/hasmcp/hasmcp-ce/backend/internal/data/entity/crud"
 	erre "github.com/hasmcp/hasmcp-ce/backend/internal/data/entity/err"
+	"github.com/hasmcp/hasmcp-ce/backend/internal/data/model"
+	"github.com/hasmcp/hasmcp-ce/backend/internal/repository/storage"
 	"github.com/hasmcp/hasmcp-ce/backend/internal/service/config"
 	"github.com/hasmcp/hasmcp-ce/backend/internal/service/locksmith"
 	"github.com/mustafaturan/monoflake"
+	zlog "github.com/rs/zerolog/log"
 	"golang.org/x/oauth2"
 )
 
@@ -27,14 +33,20 @@ type (
 		locksmith    locksmith.Service
 		oauth2Config oauth2Config
 		crud         crud.Controller
+		mcp          mcp.Controller
 		jwt          jwt.Controller
+		mcpJWT       mcpjwt.AuthenticatorController
+		storage      storage.Repository
 	}
 
 	Params struct {
 		Config    config.Service
 		Locksmith locksmith.Service
 		Crud      crud.Controller
+		Mcp       mcp.Controller
 		JWT       jwt.Controller
+		McpJWT    mcpjwt.AuthenticatorController
+		Storage   storage.Repository
 	}
 
 	oauth2Config struct {
@@ -43,8 +55,15 @@ type (
 	}
 
 	AuthorizeRequest struct {
-		HostName string
-		ServerID int64
+		HostName   string
+		ServerID   int64
+		ProviderID int64
+		// Token is the optional server token of the user, the tokens with a
+		// subject save the credentials for the user instead of globally
+		Token string
+		// Ref is the subject reference of the authorize URLs listed for the
+		// servers with several providers, it replaces the token in the URLs
+		Ref string
 	}
 
 	AuthorizeResponse struct {
@@ -64,10 +83,13 @@ type (
 
 const (
 	_cfgKey = "oauth2McpProvider"
-)
 
-var (
-	_regexVariableName = regexp.MustCompile(`\$\{([A-Z0-9_]+)\}`)
+	// _stateTTL gives some time user to authenticate on the external service
+	_stateTTL = 180 * time.Second
+
+	// _subjectRefTTL gives some time user to authorize each provider listed
+	_subjectRefTTL    = 10 * time.Minute
+	_subjectRefPrefix = "ref_"
 )
 
 func New(p Params) (Controller, error) {
@@ -81,15 +103,24 @@ func New(p Params) (Controller, error) {
 		oauth2Config: cfg,
 		locksmith:    p.Locksmith,
 		crud:         p.Crud,
+		mcp:          p.Mcp,
 		jwt:          p.JWT,
+		mcpJWT:       p.McpJWT,
+		storage:      p.Storage,
 	}, nil
 }
 
 // Authorize authneticates user with the mcp server's provider oauth2 mechanism
-// with the necessary scopes. In the current version there is a catch: if there
-// are multiple mcp servers with different scope requirements then the new
-// request overrides the other for the current user.
+// with the necessary scopes. The servers combining several providers authorize
+// each provider separately. The credentials are saved for the subject of the
+// server token when given, otherwise they are saved globally and a new
+// authorization overrides the previous one.
 func (c *controller) Authorize(ctx context.Context, req AuthorizeRequest) (*AuthorizeResponse, error) {
+	subject, err := c.subjectOf(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
 	res, err := c.crud.GetServer(ctx, crude.GetServerRequest{
 		ID: req.ServerID,
 	})
@@ -99,28 +130,24 @@ func (c *controller) Authorize(ctx context.Context, req AuthorizeRequest) (*Auth
 
 	server := res.Server
 
-	// NOTE: It is possible to have a MCP server without a provider
-	// It is intended to auth only the ones have a provider
-	if len(server.Providers) != 1 {
-		return nil, erre.Error{
-			Code:    erre.ErrorCodeUnprocessableEntity,
-			Message: "a provider must be assigned to MCP server to authorize",
-			Data: map[string]any{
-				"serverID":      server.ID,
-				"providerCount": len(server.Providers),
-			},
-		}
-	}
-
-	providerRes, err := c.crud.GetProvider(ctx, crude.GetProviderRequest{
-		ID: server.Providers[0].ID,
-	})
+	serverProvider, providerRes, err := c.authorizationProviderOf(ctx, req, server, subject)
 	if err != nil {
 		return nil, err
 	}
 
 	provider := providerRes.Provider
 	oauth2Cfg := provider.Oauth2Config
+	if !oauth2Cfg.GrantType.Interactive() {
+		// the other grants are fetched at call time without the user
+		return nil, erre.Error{
+			Code:    erre.ErrorCodeUnprocessableEntity,
+			Message: "provider oauth2 grant does not need authorization",
+			Data: map[string]any{
+				"providerID": provider.ID,
+				"grantType":  oauth2Cfg.GrantType.String(),
+			},
+		}
+	}
 	if len(oauth2Cfg.ClientSecretEncrypted) > 0 {
 		res, err := c.locksmith.Decrypt(ctx, &locksmith.DecryptRequest{
 			Nonce:      oauth2Cfg.ClientSecretEncryptionNonce,
@@ -157,7 +184,7 @@ func (c *controller) Authorize(ctx context.Context, req AuthorizeRequest) (*Auth
 
 	scopeSet := map[string]struct{}{}
 	scopes := make([]string, 0)
-	for _, e := range server.Providers[0].Tools {
+	for _, e := range serverProvider.Tools {
 		var ok bool
 		for _, s := range toolSet[e.ID] {
 			s = strings.Trim(s, " ")
@@ -199,15 +226,17 @@ func (c *controller) Authorize(ctx context.Context, req AuthorizeRequest) (*Auth
 		}
 	}
 
-	// Give some time user to authenticate on the external service
-	expiresAt := time.Now().UTC().Add(time.Second * 180)
+	now := time.Now().UTC()
+	expiresAt := now.Add(_stateTTL)
 
 	serverID := monoflake.ID(server.ID).String()
+	stateID := rand[:16]
 	// Generate jwt token for state
 	token, err := c.jwt.Issue(ctx, jwt.IssueParams{
 		Claims: jwt.ProviderClaims{
 			RegisteredClaims: jwtv5.RegisteredClaims{
-				ID:        rand[:16],
+				ID:        stateID,
+				Subject:   subject,
 				ExpiresAt: jwtv5.NewNumericDate(expiresAt),
 				Audience:  []string{providerID, serverID},
 			},
@@ -224,12 +253,200 @@ func (c *controller) Authorize(ctx context.Context, req AuthorizeRequest) (*Auth
 		}
 	}
 
-	url := oauthConfig.AuthCodeURL(token.Token, oauth2.AccessTypeOffline)
+	if err := c.storage.DeleteExpiredOauth2States(ctx, now); err != nil {
+		zlog.Warn().Err(err).Msg("failed to delete the expired oauth2 states")
+	}
+
+	// The PKCE code verifier is bound to the state, the callback consumes it
+	verifier := oauth2.GenerateVerifier()
+	err = c.storage.SaveOauth2State(ctx, model.Oauth2State{
+		ID:           stateID,
+		CreatedAt:    now,
+		ExpiresAt:    expiresAt,
+		CodeVerifier: verifier,
+	})
+	if err != nil {
+		return nil, erre.Error{
+			Code:    erre.ErrorCodeInternalServerError,
+			Message: "couldn't save the state",
+			Data: map[string]any{
+				"reason": err.Error(),
+			},
+		}
+	}
+
+	url := oauthConfig.AuthCodeURL(token.Token, oauth2.AccessTypeOffline, oauth2.S256ChallengeOption(verifier))
 	return &AuthorizeResponse{
 		AuthCodeURL: url,
 	}, nil
 }
 
+// subjectOf returns the subject of the server token or of the subject
+// reference of the request, both must belong to the authorized server
+func (c *controller) subjectOf(ctx context.Context, req AuthorizeRequest) (string, error) {
+	if req.Ref != "" {
+		return c.consumeSubjectRef(ctx, req)
+	}
+	if req.Token == "" {
+		return "", nil
+	}
+
+	auth, err := c.mcpJWT.Authenticate(ctx, mcpjwt.AuthParams{
+		AccessToken: []byte(req.Token),
+	})
+	if err != nil {
+		return "", erre.Error{
+			Code:    erre.ErrorCodeUnauthorized,
+			Message: "invalid server token",
+			Data: map[string]any{
+				"serverID": req.ServerID,
+			},
+		}
+	}
+	if auth.ServerID != req.ServerID {
+		return "", erre.Error{
+			Code:    erre.ErrorCodeForbidden,
+			Message: "server token does not belong to MCP server",
+			Data: map[string]any{
+				"serverID": req.ServerID,
+			},
+		}
+	}
+	return auth.Subject, nil
+}
+
+// consumeSubjectRef returns the subject of the reference, a reference is used
+// once
+func (c *controller) consumeSubjectRef(ctx context.Context, req AuthorizeRequest) (string, error) {
+	state, err := c.storage.ConsumeOauth2State(ctx, _subjectRefPrefix+req.Ref)
+	if err != nil || time.Now().After(state.ExpiresAt) ||
+		state.ServerID != req.ServerID || state.ProviderID != req.ProviderID {
+		return "", erre.Error{
+			Code:    erre.ErrorCodeUnauthorized,
+			Message: "authorize URL is already used or expired",
+			Data: map[string]any{
+				"serverID": req.ServerID,
+			},
+		}
+	}
+	return state.Subject, nil
+}
+
+// subjectRefOf saves a short-lived reference of the subject for the authorize
+// URL of the provider
+func (c *controller) subjectRefOf(ctx context.Context, subject string, serverID, providerID int64) (string, error) {
+	rand, err := c.locksmith.GenerateRandomString64(ctx)
+	if err != nil {
+		return "", err
+	}
+	now := time.Now().UTC()
+	err = c.storage.SaveOauth2State(ctx, model.Oauth2State{
+		ID:         _subjectRefPrefix + rand,
+		CreatedAt:  now,
+		ExpiresAt:  now.Add(_subjectRefTTL),
+		Subject:    subject,
+		ServerID:   serverID,
+		ProviderID: providerID,
+	})
+	if err != nil {
+		return "", err
+	}
+	return rand, nil
+}
+
+// authorizationProviderOf returns the requested provider of the server, or the
+// only provider that needs the authorization when no provider is requested.
+// Each provider needing the authorization is listed with its authorize URL
+// otherwise, the URLs carry a reference of the subject instead of the token.
+func (c *controller) authorizationProviderOf(ctx context.Context, req AuthorizeRequest, server crude.Server, subject string) (*crude.Provider, *crude.GetProviderResponse, error) {
+	for i, p := range server.Providers {
+		if p.ID == req.ProviderID {
+			res, err := c.crud.GetProvider(ctx, crude.GetProviderRequest{
+				ID: p.ID,
+			})
+			if err != nil {
+				return nil, nil, err
+			}
+			return &server.Providers[i], res, nil
+		}
+	}
+	if req.ProviderID != 0 {
+		return nil, nil, erre.Error{
+			Code:    erre.ErrorCodeUnprocessableEntity,
+			Message: "provider is not assigned to MCP server",
+			Data: map[string]any{
+				"serverID":   server.ID,
+				"providerID": req.ProviderID,
+			},
+		}
+	}
+
+	// NOTE: It is possible to have a MCP server without a provider
+	// It is intended to auth only the ones have a provider
+	var (
+		serverProvider *crude.Provider
+		providerRes    *crude.GetProviderResponse
+	)
+	authorizeURLs := make(map[string]string)
+	var subjectProviderIDs []int64
+	for i, p := range server.Providers {
+		res, err := c.crud.GetProvider(ctx, crude.GetProviderRequest{
+			ID: p.ID,
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+		oauth2Cfg := res.Provider.Oauth2Config
+		if !oauth2Cfg.GrantType.Interactive() || oauth2Cfg.ClientID == "" || oauth2Cfg.AuthURL == "" {
+			continue
+		}
+		serverProvider, providerRes = &server.Providers[i], res
+
+		providerID := monoflake.ID(p.ID).String()
+		authorizeURLs[providerID] = c.oauth2Config.HTTPScheme + "://" + req.HostName +
+			"/oauth2/authorize?server_id=" + monoflake.ID(server.ID).String() + "&provider_id=" + providerID
+		if subject != "" {
+			subjectProviderIDs = append(subjectProviderIDs, p.ID)
+		}
+	}
+
+	switch len(authorizeURLs) {
+	case 0:
+		return nil, nil, erre.Error{
+			Code:    erre.ErrorCodeUnprocessableEntity,
+			Message: "a provider with oauth2 must be assigned to MCP server to authorize",
+			Data: map[string]any{
+				"serverID":      server.ID,
+				"providerCount": len(server.Providers),
+			},
+		}
+	case 1:
+		return serverProvider, providerRes, nil
+	default:
+		for _, id := range subjectProviderIDs {
+			ref, err := c.subjectRefOf(ctx, subject, server.ID, id)
+			if err != nil {
+				return nil, nil, erre.Error{
+					Code:    erre.ErrorCodeInternalServerError,
+					Message: "couldn't save the subject reference",
+					Data: map[string]any{
+						"reason": err.Error(),
+					},
+				}
+			}
+			authorizeURLs[monoflake.ID(id).String()] += "&ref=" + url.QueryEscape(ref)
+		}
+		return nil, nil, erre.Error{
+			Code:    erre.ErrorCodeUnprocessableEntity,
+			Message: "multiple providers of MCP server need authorization, authorize each provider",
+			Data: map[string]any{
+				"serverID":      server.ID,
+				"authorizeURLs": authorizeURLs,
+			},
+		}
+	}
+}
+
 func (c *controller) Callback(ctx context.Context, req CallbackRequest) (*CallbackResponse, error) {
 	if req.Code == "" {
 		return nil, erre.Error{
@@ -255,6 +472,19 @@ func (c *controller) Callback(ctx context.Context, req CallbackRequest) (*Callba
 	providerID := monoflake.ID(res.ProviderID).String()
 	serverID := monoflake.ID(res.ServerID).String()
 
+	// The state is single use, a replayed callback finds no state
+	state, err := c.storage.ConsumeOauth2State(ctx, res.ID)
+	if err != nil {
+		return nil, erre.Error{
+			Code:    erre.ErrorCodeUnauthorized,
+			Message: "state is already used or expired",
+			Data: map[string]any{
+				"providerID": providerID,
+				"serverID":   serverID,
+			},
+		}
+	}
+
 	// Get provider
 	providerRes, err := c.crud.GetProvider(ctx, crude.GetProviderRequest{
 		ID: res.ProviderID,
@@ -294,7 +524,14 @@ func (c *controller) Callback(ctx context.Context, req CallbackRequest) (*Callba
 		},
 	}
 
-	token, err := cfg.Exchange(ctx, req.Code)
+	// the token endpoint is called through the egress allow and the
+	// transport config of the provider
+	client, err := c.mcp.ProviderHTTPClient(ctx, &provider)
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := cfg.Exchange(context.WithValue(ctx, oauth2.HTTPClient, client), req.Code, oauth2.VerifierOption(state.CodeVerifier))
 	if err != nil {
 		return nil, erre.Error{
 			Code:    erre.ErrorCodeUnauthorized,
@@ -308,33 +545,14 @@ func (c *controller) Callback(ctx context.Context, req CallbackRequest) (*Callba
 		}
 	}
 
-	accessTokenName := provider.SecretPrefix + "_ACCESS_TOKEN"
-	refreshTokenName := provider.SecretPrefix + "_REFRESH_TOKEN"
-
-	if len(provider.Tools) > 0 {
-		foundAuthorizationVariable := false
-		for _, t := range provider.Tools {
-			for _, h := range t.Headers {
-				if h.Key == "Authorization" {
-					varNames := extractVariables(h.Value)
-					if len(varNames) > 0 {
-						accessTokenName = varNames[0]
-						foundAuthorizationVariable = true
-					}
-					break
-				}
-			}
-			if foundAuthorizationVariable {
-				break
-			}
-		}
-	}
+	accessTokenName, refreshTokenName, expiresAtName := provider.Oauth2TokenVariables()
 
 	err = c.crud.SaveVariable(ctx, crude.SaveVariableRequest{
 		Variable: crude.Variable{
-			Name:  accessTokenName,
-			Type:  crude.VariableTypeSecret,
-			Value: []byte(token.AccessToken),
+			Name:    accessTokenName,
+			Type:    crude.VariableTypeSecret,
+			Value:   []byte(token.AccessToken),
+			Subject: res.Subject,
 		},
 	})
 	if err != nil {
@@ -349,12 +567,36 @@ func (c *controller) Callback(ctx context.Context, req CallbackRequest) (*Callba
 		}
 	}
 
+	if !token.Expiry.IsZero() {
+		// the expiry lets the access token be refreshed before it expires
+		err = c.crud.SaveVariable(ctx, crude.SaveVariableRequest{
+			Variable: crude.Variable{
+				Name:    expiresAtName,
+				Type:    crude.VariableTypeEnv,
+				Value:   []byte(strconv.FormatInt(token.Expiry.Unix(), 10)),
+				Subject: res.Subject,
+			},
+		})
+		if err != nil {
+			return nil, erre.Error{
+				Code:    erre.ErrorCodeInternalServerError,
+				Message: "couldn't save access token expiry to db",
+				Data: map[string]any{
+					"providerID": providerID,
+					"serverID":   serverID,
+					"reason":     err.Error(),
+				},
+			}
+		}
+	}
+
 	if token.RefreshToken != "" {
 		err = c.crud.SaveVariable(ctx, crude.SaveVariableRequest{
 			Variable: crude.Variable{
-				Name:  refreshTokenName,
-				Type:  crude.VariableTypeSecret,
-				Value: []byte(token.RefreshToken),
+				Name:    refreshTokenName,
+				Type:    crude.VariableTypeSecret,
+				Value:   []byte(token.RefreshToken),
+				Subject: res.Subject,
 			},
 		})
 
@@ -375,16 +617,3 @@ func (c *controller) Callback(ctx context.Context, req CallbackRequest) (*Callba
 		InternalRedirectURL: "/servers/" + serverID + "?message=Succesfully+added+access+and+refresh+tokens+to+variables",
 	}, nil
 }
-
-func extractVariables(s string) []string {
-	allMatches := _regexVariableName.FindAllStringSubmatch(s, -1)
-
-	var names []string
-	for _, match := range allMatches {
-		if len(match) > 1 {
-			names = append(names, match[1])
-		}
-	}
-
-	return names
-}
@@ -1,7 +1,9 @@
 package crud
 
 import (
+	"context"
 	"encoding/json"
+	"regexp"
 	"strings"
 	"time"
 
@@ -10,12 +12,22 @@ import (
 
 type (
 	// Enummerations for types
-	VariableType    uint8
-	ApiType         uint8
-	VisibilityType  uint8
-	ObjectType      uint8
-	ObjectEventType uint8
-	MethodType      uint8
+	VariableType         uint8
+	ApiType              uint8
+	VisibilityType       uint8
+	ObjectType           uint8
+	ObjectEventType      uint8
+	MethodType           uint8
+	ToolArgumentLocation uint8
+	InputSchemaMode      uint8
+	ImportFormat         uint8
+	AuthType             uint8
+	AuthLocation         uint8
+	SignerType           uint8
+	GrantType            uint8
+	TrafficMode          uint8
+	PaginationType       uint8
+	BreakerState         uint8
 
 	ResourceChange struct {
 		ObjectType      ObjectType
@@ -34,6 +46,10 @@ type (
 		Value []byte
 		Nonce []byte
 		Name  string
+
+		// Subject is the user the variable belongs to, the global variables
+		// have no subject
+		Subject string
 	}
 
 	CreateVariableRequest struct {
@@ -71,7 +87,7 @@ type (
 		UpdatedAt time.Time
 
 		Version        int32
-		ApiType        ApiType        // 0: INVALID, 1: REST
+		ApiType        ApiType        // 0: INVALID, 1: REST, 2: GRAPHQL, 3: GRPC, 4: MCP
 		VisibilityType VisibilityType // 0: INVALID, 1: INTERNAL, 2: PUBLIC
 		BaseURL        string
 		DocumentURL    string
@@ -79,9 +95,118 @@ type (
 		SecretPrefix   string
 		Name           string
 		Description    string
-
-		Tools        []ProviderTool
-		Oauth2Config ProviderOauth2Config
+		GraphQLSchema  []byte // introspection result of the GraphQL providers
+
+		GRPCConfig        *ProviderGRPCConfig
+		GRPCDescriptorSet []byte // serialized FileDescriptorSet of the gRPC providers
+
+		AuthConfig      *ProviderAuthConfig
+		SignerConfig    *ProviderSignerConfig
+		MockConfig      *ProviderMockConfig
+		LimitsConfig    *ProviderLimitsConfig
+		EgressConfig    *ProviderEgressConfig
+		TransportConfig *ProviderTransportConfig
+		Tools           []ProviderTool
+		Oauth2Config    ProviderOauth2Config
+	}
+
+	// ProviderGRPCConfig hosts the transport options of the gRPC providers,
+	// the CA bundle, the client certificate and the proxy are set by the
+	// transport config
+	ProviderGRPCConfig struct {
+		Plaintext          bool
+		InsecureSkipVerify bool
+	}
+
+	// ProviderMockConfig answers the tool calls of the provider from the
+	// recorded examples and the response schemas without calling the provider
+	ProviderMockConfig struct {
+		Enabled bool
+	}
+
+	// ProviderLimitsConfig protects the provider from the tool calls, the zero
+	// values disable the limits. The upstream rate limit headers pause the
+	// calls on top of the rate.
+	ProviderLimitsConfig struct {
+		MaxConcurrency         int     // concurrent upstream requests
+		RatePerSecond          float64 // token bucket refill rate
+		Burst                  int     // token bucket size, the rate rounded up when zero
+		MaxWaitSeconds         int     // longest wait for a slot or a token, 10 when zero
+		BreakerFailures        int     // consecutive failures opening the circuit
+		BreakerCooldownSeconds int     // open circuit duration before a trial request, 30 when zero
+	}
+
+	// ProviderEgressConfig explicitly allows the destinations of the provider
+	// denied by default, e.g. the private networks. The denies of the egress
+	// policy still apply.
+	ProviderEgressConfig struct {
+		AllowedCIDRs []string // the single addresses are allowed too
+		AllowedHosts []string // exact names or *.example.com patterns
+		AllowedPorts []int
+	}
+
+	// ProviderTransportConfig hosts the TLS and the proxy settings of the
+	// upstream requests of the provider. The PEM materials and the proxy URL
+	// may refer to the secret variables like ${INTERNAL_CA}.
+	ProviderTransportConfig struct {
+		CACert             string   // PEM bundle trusted on top of the system roots
+		ClientCert         string   // PEM
+		ClientKey          string   // PEM
+		MinTLSVersion      string   // 1.2, 1.3, 1.2 when empty
+		InsecureSkipVerify bool     // skips the certificate verification, meant for the staging providers
+		PinnedSHA256       []string // base64 SHA-256 of the SubjectPublicKeyInfo of a certificate of the chain
+		ProxyURL           string   // http, https, socks5 or socks5h proxy
+	}
+
+	// ProviderBreaker is the circuit breaker state of a provider on this
+	// replica
+	ProviderBreaker struct {
+		ProviderID          int64
+		State               BreakerState // 0: INVALID, 1: CLOSED, 2: OPEN, 3: HALF_OPEN
+		ConsecutiveFailures int
+		OpenedAt            time.Time
+		RetryAt             time.Time // the open circuit lets a trial request after
+		InFlight            int
+		ThrottledUntil      time.Time // set by the upstream rate limit headers
+	}
+
+	// ProviderAuthConfig hosts the credentials applied to every tool call of
+	// the provider. The values may reference variables like `${API_KEY}`.
+	ProviderAuthConfig struct {
+		Type     AuthType     // 0: INVALID, 1: NONE, 2: API_KEY, 3: BASIC, 4: BEARER, 5: OAUTH2
+		In       AuthLocation // 0: INVALID, 1: HEADER, 2: QUERY, 3: COOKIE; API_KEY only
+		Name     string       // header, query or cookie name of the API_KEY
+		Value    string       // API key or bearer token, OAUTH2 defaults to the access token variable
+		Username string
+		Password string
+	}
+
+	// ProviderSignerConfig hosts the request signing of the provider. The
+	// secret keys and the client key must reference secret variables, the
+	// other values may reference variables.
+	ProviderSignerConfig struct {
+		Type SignerType // 0: INVALID, 1: NONE, 2: AWS_SIGV4, 3: HMAC, 4: MTLS
+
+		// AWS_SIGV4
+		Region          string
+		Service         string
+		AccessKeyID     string
+		SecretAccessKey string
+		SessionToken    string
+
+		// HMAC
+		Algorithm       string // SHA1, SHA256, SHA512
+		Secret          string
+		Header          string // header of the signature
+		SignaturePrefix string // prepended to the signature, e.g. `sha256=`
+		Encoding        string // HEX, BASE64
+		CanonicalString string // template with {method}, {path}, {query}, {host}, {timestamp}, {body}, {bodySHA256} and {header:Name}
+		TimestampHeader string // header of the unix timestamp used in the canonical string
+
+		// MTLS
+		ClientCert string // PEM
+		ClientKey  string // PEM
+		CACert     string // PEM, the system roots are used when empty
 	}
 
 	CreateProviderRequest struct {
@@ -117,6 +242,14 @@ type (
 		Provider Provider
 	}
 
+	GetProviderBreakerRequest struct {
+		ProviderID int64
+	}
+
+	GetProviderBreakerResponse struct {
+		Breaker ProviderBreaker
+	}
+
 	ProviderFilters struct {
 		NameContains    string
 		BaseURLContains string
@@ -139,12 +272,33 @@ type (
 	}
 
 	ProviderOauth2Config struct {
+		GrantType                   GrantType // 0: INVALID, 1: AUTHORIZATION_CODE, 2: CLIENT_CREDENTIALS, 3: JWT_BEARER, 4: PASSWORD
 		ClientID                    string
 		ClientSecret                string
 		ClientSecretEncrypted       []byte
 		ClientSecretEncryptionNonce []byte
 		AuthURL                     string
 		TokenURL                    string
+
+		// Audience is sent as the audience parameter of the client credentials
+		// grant and as the aud claim of the JWT bearer assertion
+		Audience string
+
+		// Username and Password of the resource owner password grant, the
+		// values may reference variables. The password is stored encrypted.
+		Username                string
+		Password                string
+		PasswordEncrypted       []byte
+		PasswordEncryptionNonce []byte
+
+		// Issuer, Subject and PrivateKey (PEM) sign the RS256 assertion of the
+		// JWT bearer grant (RFC 7523), the issuer defaults to the client ID.
+		// The private key is stored encrypted.
+		Issuer                    string
+		Subject                   string
+		PrivateKey                string
+		PrivateKeyEncrypted       []byte
+		PrivateKeyEncryptionNonce []byte
 	}
 
 	// ProviderTool hosts the tools for the provider
@@ -163,6 +317,83 @@ type (
 		ResBodyJSONSchema   []byte
 		Headers             []ToolHeader
 		Oauth2Scopes        []string
+		Operation           string // GraphQL operation document
+		Tags                []string
+
+		// MockExamples are the recorded responses of the mock mode, the first
+		// example matching the call arguments answers the call
+		MockExamples []ToolMockExample
+
+		// AsyncConfig follows the operations the REST APIs accept with the 202
+		// status, the tool call returns the final result of the operation
+		AsyncConfig *ToolAsyncConfig
+
+		// PaginationConfig follows the pages of the REST list endpoints, the
+		// tool call returns the items of all pages
+		PaginationConfig *ToolPaginationConfig
+
+		// CacheConfig caches the responses of the read-only REST calls, the
+		// same calls of the same caller are answered from the cache
+		CacheConfig *ToolCacheConfig
+	}
+
+	// ToolMockExample is a recorded response of a tool, it answers the calls
+	// which match all of its rules
+	ToolMockExample struct {
+		Name     string
+		Rules    []ToolMockRule
+		Response []byte // JSON result of the tool, a JSON string is sent as text
+		IsError  bool
+	}
+
+	// ToolMockRule matches an argument of the call by its Value when it is set
+	// and by the regular expression Pattern otherwise
+	ToolMockRule struct {
+		In      ToolArgumentLocation // 0: INVALID, 1: PATH, 2: QUERY, 3: BODY
+		Name    string               // dot separated path of the nested arguments
+		Value   any
+		Pattern string
+	}
+
+	// ToolAsyncConfig polls the status URL of an accepted operation until the
+	// success or the failure predicate matches or the wait is exceeded
+	ToolAsyncConfig struct {
+		StatusURLPath   string              // JSONPath of the status URL in the 202 body, the Location header when empty
+		ResultURLPath   string              // JSONPath of the result URL in the final status body, the status body when empty
+		IntervalSeconds int                 // the Retry-After header of the status response takes precedence
+		MaxWaitSeconds  int                 // a pollable handle is returned once the wait is exceeded
+		Success         *ToolAsyncPredicate // any status other than 202 succeeds when it is nil
+		Failure         *ToolAsyncPredicate
+	}
+
+	// ToolPaginationConfig fetches the next pages of a list endpoint until there
+	// are no more pages or a limit is reached
+	ToolPaginationConfig struct {
+		Type       PaginationType // 0: INVALID, 1: LINK, 2: CURSOR, 3: OFFSET, 4: PAGE
+		ItemsPath  string         // JSONPath of the item array in the page body, the body itself when empty
+		CursorPath string         // CURSOR only; JSONPath of the next cursor in the page body
+		Param      string         // query parameter of the cursor, the offset or the page number
+		LimitParam string         // OFFSET and PAGE only; query parameter of the page size
+		PageSize   int            // OFFSET and PAGE only; sent when the call has no page size
+		StartPage  int            // PAGE only; number of the first page, 1 when zero
+		MaxPages   int
+		MaxItems   int
+	}
+
+	// ToolCacheConfig keys the cached responses by the resolved URL, the vary
+	// headers and the caller. The Cache-Control of the response shortens the
+	// TTL and the responses with an ETag or a Last-Modified are revalidated
+	// once they are stale.
+	ToolCacheConfig struct {
+		TTLSeconds  int
+		VaryHeaders []string // request headers taking part in the key
+	}
+
+	// ToolAsyncPredicate matches the status body when the value at the
+	// JSONPath equals one of the Values, or exists when there are no Values
+	ToolAsyncPredicate struct {
+		Path   string
+		Values []any
 	}
 
 	CreateProviderToolRequest struct {
@@ -186,6 +417,92 @@ type (
 		ToolID     int64
 	}
 
+	GenerateProviderGraphQLToolsRequest struct {
+		ProviderID int64
+		Depth      int
+		Refresh    bool
+		Headers    []ToolHeader
+	}
+
+	GenerateProviderGraphQLToolsResponse struct {
+		Tools []ProviderTool
+		// Skipped are the fields whose tools fail the validation
+		Skipped []ImportSkippedOperation
+	}
+
+	ImportProviderGRPCToolsRequest struct {
+		ProviderID    int64
+		Refresh       bool
+		DescriptorSet []byte
+		Headers       []ToolHeader
+	}
+
+	ImportProviderGRPCToolsResponse struct {
+		Tools []ProviderTool
+		// Skipped are the methods whose tools fail the validation
+		Skipped []ImportSkippedOperation
+	}
+
+	ImportProviderMCPToolsRequest struct {
+		ProviderID int64
+		Headers    []ToolHeader
+	}
+
+	ImportProviderMCPToolsResponse struct {
+		Tools []ProviderTool
+		// Skipped are the upstream tools that fail the validation
+		Skipped []ImportSkippedOperation
+	}
+
+	// ImportProviderToolsRequest syncs the provider tools with an OpenAPI 3.x
+	// or Swagger 2.0 document. The diff is only applied when Apply is set.
+	ImportProviderToolsRequest struct {
+		ProviderID int64
+		Spec       []byte // JSON or YAML document
+		URL        string // fetched when there is no spec
+		Apply      bool
+		Version    int32 // provider version of the dry run, verified on apply when set
+	}
+
+	ImportProviderToolsResponse struct {
+		Version    int32 // provider version the diff is based on, or the new version when applied
+		Applied    bool
+		AuthConfig *ProviderAuthConfig // detected from the security schemes when the provider has none
+		Added      []ProviderTool
+		Changed    []ProviderToolChange
+		Removed    []ProviderTool
+		Skipped    []ImportSkippedOperation
+	}
+
+	// ProviderToolChange is an existing tool with the imported values
+	ProviderToolChange struct {
+		Tool   ProviderTool
+		Fields []string
+	}
+
+	// ImportProviderRequest creates a REST provider with its tools from a
+	// Postman v2.1 collection or a HAR file
+	ImportProviderRequest struct {
+		Format         ImportFormat
+		Document       []byte
+		Name           string
+		Description    string
+		BaseURL        string // overrides the detected base URL
+		VisibilityType VisibilityType
+	}
+
+	ImportProviderResponse struct {
+		Provider Provider
+		Skipped  []ImportSkippedOperation
+	}
+
+	// ImportSkippedOperation is an operation which can not become a tool
+	ImportSkippedOperation struct {
+		Method string
+		Path   string
+		Reason string
+	}
+
 	ToolHeader struct {
 		Key   string
 		Value string
@@ -219,6 +536,18 @@ type (
 		// true. The default value is false.
 		RequestHeadersProxyEnabled bool
 
+		// MockEnabled answers the tool calls of all providers of the server
+		// without calling them. The default value is false.
+		MockEnabled bool
+
+		// TrafficMode records the upstream traffic of the tool calls or replays
+		// the recorded traffic instead of calling the providers
+		TrafficMode TrafficMode
+
+		// InputSchemaMode selects whether the tool arguments are grouped by
+		// their location (NESTED) or merged into a single object (FLAT)
+		InputSchemaMode InputSchemaMode
+
 		Name           string
 		Instructions   string
 		Version        int32
@@ -226,6 +555,9 @@ type (
 		Resources      []Resource
 		Prompts        []Prompt
 		VisibilityType VisibilityType
+
+		// ToolOverrides hosts the per server customizations of the tools by tool ID
+		ToolOverrides map[int64]ServerToolOverride
 	}
 
 	CreateServerRequest struct {
@@ -271,9 +603,75 @@ type (
 		ExpiresAt time.Time
 		Scope     string
 
+		// Subject identifies the user of the token, the variables of the user
+		// take precedence over the global ones on the tool calls
+		Subject string
+
 		ActualValue []byte
 	}
 
+	// ServerRecording is a tool call recorded on a server in the RECORD
+	// traffic mode with the upstream interactions it produced
+	ServerRecording struct {
+		ID           int64
+		CreatedAt    time.Time
+		ServerID     int64
+		ToolID       int64
+		ToolName     string
+		Arguments    []byte // arguments of the MCP tool call
+		Result       []byte // result of the MCP tool call or the JSON-RPC error
+		IsError      bool   // the Result is a JSON-RPC error
+		Interactions []RecordedInteraction
+	}
+
+	// RecordedInteraction is an upstream request and response pair with the
+	// secrets redacted
+	RecordedInteraction struct {
+		Request  RecordedRequest
+		Response RecordedResponse
+	}
+
+	RecordedRequest struct {
+		Method  string // HTTP method or GRPC
+		URL     string
+		Headers map[string][]string
+		Body    string
+	}
+
+	RecordedResponse struct {
+		StatusCode int // HTTP status or the gRPC status code
+		Headers    map[string][]string
+		Body       string
+	}
+
+	ListServerRecordingsRequest struct {
+		ServerID int64
+	}
+
+	ListServerRecordingsResponse struct {
+		Recordings []ServerRecording
+	}
+
+	ImportServerRecordingsRequest struct {
+		ServerID   int64
+		Recordings []ServerRecording
+	}
+
+	ImportServerRecordingsResponse struct {
+		Recordings []ServerRecording
+	}
+
+	DeleteServerRecordingsRequest struct {
+		ServerID int64
+	}
+
+	// PurgeServerCacheRequest purges the cached tool responses of the server,
+	// only the ones of the tool when the ToolID is set
+	PurgeServerCacheRequest struct {
+		ServerID int64
+		ToolID   int64
+	}
+
 	CreateServerTokenRequest struct {
 		Token ServerToken
 	}
@@ -302,6 +700,38 @@ type (
 		ServerID   int64
 		ProviderID int64
 		ToolID     int64
+		Override   ServerToolOverride
+	}
+
+	// ServerToolOverride customizes how a provider tool is exposed on a server
+	ServerToolOverride struct {
+		Name        string
+		Title       string
+		Description string
+		Arguments   []ServerToolArgumentOverride
+
+		// InputSchemaMode overrides the server input schema mode when set
+		InputSchemaMode InputSchemaMode
+	}
+
+	// ServerToolArgumentOverride customizes a top level argument of a tool.
+	// Hidden arguments are removed from the input schema and always called
+	// with the Value, visible arguments get the Default and the narrowed Enum.
+	ServerToolArgumentOverride struct {
+		In      ToolArgumentLocation
+		Name    string
+		Hidden  bool
+		Value   any
+		Default any
+		Enum    []any
+	}
+
+	UpdateServerToolRequest struct {
+		Tool ServerTool
+	}
+
+	UpdateServerToolResponse struct {
+		Tool ServerTool
 	}
 
 	CreateServerToolResponse struct {
@@ -507,6 +937,9 @@ func StringToVariableType(s string) VariableType {
 const (
 	ApiTypeInvalid ApiType = iota
 	ApiTypeRest
+	ApiTypeGraphQL
+	ApiTypeGRPC
+	ApiTypeMCP
 	ApiTypeInvalidMax
 )
 
@@ -514,6 +947,12 @@ func (at ApiType) String() string {
 	switch at {
 	case ApiTypeRest:
 		return "REST"
+	case ApiTypeGraphQL:
+		return "GRAPHQL"
+	case ApiTypeGRPC:
+		return "GRPC"
+	case ApiTypeMCP:
+		return "MCP"
 	default:
 		return ""
 	}
@@ -524,6 +963,12 @@ func StringToApiType(s string) ApiType {
 	switch s {
 	case "REST":
 		return ApiTypeRest
+	case "GRAPHQL":
+		return ApiTypeGraphQL
+	case "GRPC":
+		return ApiTypeGRPC
+	case "MCP":
+		return ApiTypeMCP
 	default:
 		return ApiTypeInvalid
 	}
@@ -571,6 +1016,7 @@ const (
 	ObjectTypeServerResource
 	ObjectTypeResource
 	ObjectTypePrompt
+	ObjectTypeUserVariable
 )
 
 const (
@@ -596,6 +1042,8 @@ func (rt ObjectType) String() string {
 		return "RESOURCE"
 	case ObjectTypePrompt:
 		return "PROMPT"
+	case ObjectTypeUserVariable:
+		return "USER_VARIABLE"
 	default:
 		return ""
 	}
@@ -618,6 +1066,8 @@ func StringToObjectType(s string) ObjectType {
 		return ObjectTypeResource
 	case "PROMPT":
 		return ObjectTypePrompt
+	case "USER_VARIABLE":
+		return ObjectTypeUserVariable
 	default:
 		return ObjectTypeInvalid
 	}
@@ -687,6 +1137,100 @@ func StringToMethodType(method string) MethodType {
 	}
 }
 
+type (
+	subjectCtxKey struct{}
+
+	// VariableGetter returns the global and the user variables, e.g. the
+	// cache
+	VariableGetter interface {
+		GetVariable(ctx context.Context, name string) (string, error)
+		GetUserVariable(ctx context.Context, subject, name string) (string, error)
+	}
+)
+
+var (
+	// RegexVariableReference matches the `${NAME}` references of the
+	// variables, the name is the first submatch
+	RegexVariableReference = regexp.MustCompile(`\$\{([A-Z0-9_]+)\}`)
+)
+
+// WithSubject binds the caller to the context to resolve their variables
+func WithSubject(ctx context.Context, subject string) context.Context {
+	if subject == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, subjectCtxKey{}, subject)
+}
+
+// SubjectOf returns the caller bound to the context, empty if anonymous
+func SubjectOf(ctx context.Context) string {
+	subject, _ := ctx.Value(subjectCtxKey{}).(string)
+	return subject
+}
+
+// ResolveVariable returns the variable of the caller bound to the context,
+// falling back to the global variable when the caller does not have their own
+func ResolveVariable(ctx context.Context, name string, getter VariableGetter) (string, error) {
+	if subject := SubjectOf(ctx); subject != "" {
+		if v, err := getter.GetUserVariable(ctx, subject, name); err == nil {
+			return v, nil
+		}
+	}
+	return getter.GetVariable(ctx, name)
+}
+
+// IsVariableReference reports whether the value is a single `${NAME}`
+// reference, the reference itself is not a secret
+func IsVariableReference(s string) bool {
+	loc := RegexVariableReference.FindStringIndex(s)
+	return loc != nil && loc[0] == 0 && loc[1] == len(s)
+}
+
+// ReplaceVariables replaces the `${NAME}` references with the variable values,
+// the unknown variables are kept as is
+func ReplaceVariables(ctx context.Context, s string, getter VariableGetter) string {
+	if !strings.Contains(s, "${") {
+		return s
+	}
+	return RegexVariableReference.ReplaceAllStringFunc(s, func(ref string) string {
+		v, err := ResolveVariable(ctx, ref[2:len(ref)-1], getter)
+		if err != nil {
+			return ref
+		}
+		return v
+	})
+}
+
+// Oauth2TokenVariables returns the variable names of the oauth2 access token,
+// the refresh token and the access token expiry. The access token variable is
+// the one referenced by the auth config or by the first Authorization header
+// of the tools.
+func (p Provider) Oauth2TokenVariables() (accessToken, refreshToken, expiresAt string) {
+	accessToken = p.SecretPrefix + "_ACCESS_TOKEN"
+	refreshToken = p.SecretPrefix + "_REFRESH_TOKEN"
+
+	if p.AuthConfig != nil && p.AuthConfig.Type == AuthTypeOauth2 {
+		if m := RegexVariableReference.FindStringSubmatch(p.AuthConfig.Value); m != nil {
+			accessToken = m[1]
+		}
+		return accessToken, refreshToken, accessToken + "_EXPIRES_AT"
+	}
+
+	for _, t := range p.Tools {
+		for _, h := range t.Headers {
+			if h.Key != "Authorization" {
+				continue
+			}
+			if m := RegexVariableReference.FindStringSubmatch(h.Value); m != nil {
+				accessToken = m[1]
+				return accessToken, refreshToken, accessToken + "_EXPIRES_AT"
+			}
+			break
+		}
+	}
+	return accessToken, refreshToken, accessToken + "_EXPIRES_AT"
+}
+
 func (v Variable) MarshalZerologObject(e *zerolog.Event) {
 	e.
 		Int64("id", v.ID).
@@ -695,3 +1239,379 @@ func (v Variable) MarshalZerologObject(e *zerolog.Event) {
 		Str("type", v.Type.String()).
 		Uint16("type", uint16(v.Type))
 }
+
+const (
+	ToolArgumentLocationInvalid ToolArgumentLocation = iota
+	ToolArgumentLocationPath
+	ToolArgumentLocationQuery
+	ToolArgumentLocationBody
+	ToolArgumentLocationHeader
+	ToolArgumentLocationInvalidMax
+)
+
+func (l ToolArgumentLocation) String() string {
+	switch l {
+	case ToolArgumentLocationPath:
+		return "PATH"
+	case ToolArgumentLocationQuery:
+		return "QUERY"
+	case ToolArgumentLocationBody:
+		return "BODY"
+	case ToolArgumentLocationHeader:
+		return "HEADER"
+	default:
+		return ""
+	}
+}
+
+func StringToToolArgumentLocation(s string) ToolArgumentLocation {
+	s = strings.ToUpper(s)
+	switch s {
+	case "PATH":
+		return ToolArgumentLocationPath
+	case "QUERY":
+		return ToolArgumentLocationQuery
+	case "BODY":
+		return ToolArgumentLocationBody
+	case "HEADER":
+		return ToolArgumentLocationHeader
+	default:
+		return ToolArgumentLocationInvalid
+	}
+}
+
+const (
+	InputSchemaModeInvalid InputSchemaMode = iota
+	InputSchemaModeNested
+	InputSchemaModeFlat
+	InputSchemaModeInvalidMax
+)
+
+func (m InputSchemaMode) String() string {
+	switch m {
+	case InputSchemaModeNested:
+		return "NESTED"
+	case InputSchemaModeFlat:
+		return "FLAT"
+	default:
+		return ""
+	}
+}
+
+func StringToInputSchemaMode(s string) InputSchemaMode {
+	s = strings.ToUpper(s)
+	switch s {
+	case "NESTED":
+		return InputSchemaModeNested
+	case "FLAT":
+		return InputSchemaModeFlat
+	default:
+		return InputSchemaModeInvalid
+	}
+}
+
+const (
+	ImportFormatInvalid ImportFormat = iota
+	ImportFormatPostman
+	ImportFormatHAR
+	ImportFormatInvalidMax
+)
+
+func (f ImportFormat) String() string {
+	switch f {
+	case ImportFormatPostman:
+		return "POSTMAN"
+	case ImportFormatHAR:
+		return "HAR"
+	default:
+		return ""
+	}
+}
+
+func StringToImportFormat(s string) ImportFormat {
+	s = strings.ToUpper(s)
+	switch s {
+	case "POSTMAN":
+		return ImportFormatPostman
+	case "HAR":
+		return ImportFormatHAR
+	default:
+		return ImportFormatInvalid
+	}
+}
+
+const (
+	AuthTypeInvalid AuthType = iota
+	AuthTypeNone
+	AuthTypeAPIKey
+	AuthTypeBasic
+	AuthTypeBearer
+	AuthTypeOauth2
+	AuthTypeInvalidMax
+)
+
+func (t AuthType) String() string {
+	switch t {
+	case AuthTypeNone:
+		return "NONE"
+	case AuthTypeAPIKey:
+		return "API_KEY"
+	case AuthTypeBasic:
+		return "BASIC"
+	case AuthTypeBearer:
+		return "BEARER"
+	case AuthTypeOauth2:
+		return "OAUTH2"
+	default:
+		return ""
+	}
+}
+
+func StringToAuthType(s string) AuthType {
+	s = strings.ToUpper(s)
+	switch s {
+	case "NONE":
+		return AuthTypeNone
+	case "API_KEY":
+		return AuthTypeAPIKey
+	case "BASIC":
+		return AuthTypeBasic
+	case "BEARER":
+		return AuthTypeBearer
+	case "OAUTH2":
+		return AuthTypeOauth2
+	default:
+		return AuthTypeInvalid
+	}
+}
+
+const (
+	AuthLocationInvalid AuthLocation = iota
+	AuthLocationHeader
+	AuthLocationQuery
+	AuthLocationCookie
+	AuthLocationInvalidMax
+)
+
+func (l AuthLocation) String() string {
+	switch l {
+	case AuthLocationHeader:
+		return "HEADER"
+	case AuthLocationQuery:
+		return "QUERY"
+	case AuthLocationCookie:
+		return "COOKIE"
+	default:
+		return ""
+	}
+}
+
+func StringToAuthLocation(s string) AuthLocation {
+	s = strings.ToUpper(s)
+	switch s {
+	case "HEADER":
+		return AuthLocationHeader
+	case "QUERY":
+		return AuthLocationQuery
+	case "COOKIE":
+		return AuthLocationCookie
+	default:
+		return AuthLocationInvalid
+	}
+}
+
+const (
+	SignerTypeInvalid SignerType = iota
+	SignerTypeNone
+	SignerTypeAWSSigV4
+	SignerTypeHMAC
+	SignerTypeMTLS
+	SignerTypeInvalidMax
+)
+
+func (t SignerType) String() string {
+	switch t {
+	case SignerTypeNone:
+		return "NONE"
+	case SignerTypeAWSSigV4:
+		return "AWS_SIGV4"
+	case SignerTypeHMAC:
+		return "HMAC"
+	case SignerTypeMTLS:
+		return "MTLS"
+	default:
+		return ""
+	}
+}
+
+func StringToSignerType(s string) SignerType {
+	s = strings.ToUpper(s)
+	switch s {
+	case "NONE":
+		return SignerTypeNone
+	case "AWS_SIGV4":
+		return SignerTypeAWSSigV4
+	case "HMAC":
+		return SignerTypeHMAC
+	case "MTLS":
+		return SignerTypeMTLS
+	default:
+		return SignerTypeInvalid
+	}
+}
+
+const (
+	GrantTypeInvalid GrantType = iota
+	GrantTypeAuthorizationCode
+	GrantTypeClientCredentials
+	GrantTypeJWTBearer
+	GrantTypePassword
+	GrantTypeInvalidMax
+)
+
+func (t GrantType) String() string {
+	switch t {
+	case GrantTypeAuthorizationCode:
+		return "AUTHORIZATION_CODE"
+	case GrantTypeClientCredentials:
+		return "CLIENT_CREDENTIALS"
+	case GrantTypeJWTBearer:
+		return "JWT_BEARER"
+	case GrantTypePassword:
+		return "PASSWORD"
+	default:
+		return ""
+	}
+}
+
+// Interactive reports whether the grant needs the user to authorize in a
+// browser, the other grants are fetched at call time
+func (t GrantType) Interactive() bool {
+	return t == GrantTypeAuthorizationCode
+}
+
+func StringToGrantType(s string) GrantType {
+	s = strings.ToUpper(s)
+	switch s {
+	case "AUTHORIZATION_CODE":
+		return GrantTypeAuthorizationCode
+	case "CLIENT_CREDENTIALS":
+		return GrantTypeClientCredentials
+	case "JWT_BEARER":
+		return GrantTypeJWTBearer
+	case "PASSWORD":
+		return GrantTypePassword
+	default:
+		return GrantTypeInvalid
+	}
+}
+
+const (
+	TrafficModeInvalid TrafficMode = iota
+	TrafficModeLive
+	TrafficModeRecord
+	TrafficModeReplay
+	TrafficModeInvalidMax
+)
+
+func (m TrafficMode) String() string {
+	switch m {
+	case TrafficModeLive:
+		return "LIVE"
+	case TrafficModeRecord:
+		return "RECORD"
+	case TrafficModeReplay:
+		return "REPLAY"
+	default:
+		return ""
+	}
+}
+
+func StringToTrafficMode(s string) TrafficMode {
+	s = strings.ToUpper(s)
+	switch s {
+	case "LIVE":
+		return TrafficModeLive
+	case "RECORD":
+		return TrafficModeRecord
+	case "REPLAY":
+		return TrafficModeReplay
+	default:
+		return TrafficModeInvalid
+	}
+}
+
+const (
+	PaginationTypeInvalid PaginationType = iota
+	PaginationTypeLink
+	PaginationTypeCursor
+	PaginationTypeOffset
+	PaginationTypePage
+	PaginationTypeInvalidMax
+)
+
+func (t PaginationType) String() string {
+	switch t {
+	case PaginationTypeLink:
+		return "LINK"
+	case PaginationTypeCursor:
+		return "CURSOR"
+	case PaginationTypeOffset:
+		return "OFFSET"
+	case PaginationTypePage:
+		return "PAGE"
+	default:
+		return ""
+	}
+}
+
+func StringToPaginationType(s string) PaginationType {
+	s = strings.ToUpper(s)
+	switch s {
+	case "LINK":
+		return PaginationTypeLink
+	case "CURSOR":
+		return PaginationTypeCursor
+	case "OFFSET":
+		return PaginationTypeOffset
+	case "PAGE":
+		return PaginationTypePage
+	default:
+		return PaginationTypeInvalid
+	}
+}
+
+const (
+	BreakerStateInvalid BreakerState = iota
+	BreakerStateClosed
+	BreakerStateOpen
+	BreakerStateHalfOpen
+	BreakerStateInvalidMax
+)
+
+func (s BreakerState) String() string {
+	switch s {
+	case BreakerStateClosed:
+		return "CLOSED"
+	case BreakerStateOpen:
+		return "OPEN"
+	case BreakerStateHalfOpen:
+		return "HALF_OPEN"
+	default:
+		return ""
+	}
+}
+
+func StringToBreakerState(s string) BreakerState {
+	s = strings.ToUpper(s)
+	switch s {
+	case "CLOSED":
+		return BreakerStateClosed
+	case "OPEN":
+		return BreakerStateOpen
+	case "HALF_OPEN":
+		return BreakerStateHalfOpen
+	default:
+		return BreakerStateInvalid
+	}
+}
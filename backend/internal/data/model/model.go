@@ -13,9 +13,13 @@ type (
 		UpdatedAt time.Time
 
 		Type  uint8  // 0: INVALID, 1: ENV, 2: SECRET
-		Value string `gorm:"type:varchar(255)"`
+		Value string `gorm:"type:text"` // fits the encrypted PEM materials
 		Nonce string `gorm:"type:varchar(255)"`
 		Name  string `gorm:"type:varchar(128)"`
+
+		// Subject is the user the variable belongs to, empty for the global
+		// variables
+		Subject string `gorm:"type:varchar(128);not null;default:'';index"`
 	}
 
 	VariableAttribute string
@@ -27,7 +31,7 @@ type (
 		UpdatedAt time.Time
 
 		Version        int32
-		ApiType        uint8  `gorm:"default:1"` // 0: INVALID, 1: REST
+		ApiType        uint8  `gorm:"default:1"` // 0: INVALID, 1: REST, 2: GRAPHQL, 3: GRPC, 4: MCP
 		VisibilityType uint8  `gorm:"default:1"` // 0: INVALID, 1: INTERNAL, 2: PUBLIC
 		BaseURL        string `gorm:"varchar(255)"`
 		DocumentURL    string `gorm:"varchar(255)"`
@@ -36,6 +40,18 @@ type (
 		Name           string `gorm:"varchar(64)"`
 		Description    string `gorm:"type:text"`
 
+		GraphQLSchema json.RawMessage `gorm:"column:graphql_schema;type:bytea"` // Stores the introspection result
+
+		GRPCConfig        json.RawMessage `gorm:"column:grpc_config;type:bytea"`         // Stores ProviderGRPCConfig
+		GRPCDescriptorSet []byte          `gorm:"column:grpc_descriptor_set;type:bytea"` // Stores the serialized FileDescriptorSet
+
+		AuthConfig      json.RawMessage `gorm:"type:bytea"` // Stores ProviderAuthConfig
+		SignerConfig    json.RawMessage `gorm:"type:bytea"` // Stores ProviderSignerConfig
+		MockConfig      json.RawMessage `gorm:"type:bytea"` // Stores ProviderMockConfig
+		LimitsConfig    json.RawMessage `gorm:"type:bytea"` // Stores ProviderLimitsConfig
+		EgressConfig    json.RawMessage `gorm:"type:bytea"` // Stores ProviderEgressConfig
+		TransportConfig json.RawMessage `gorm:"type:bytea"` // Stores ProviderTransportConfig
+
 		Tools        []ProviderTool       `gorm:"foreignKey:provider_id"`
 		Oauth2Config ProviderOauth2Config `gorm:"foreignKey:provider_id"`
 	}
@@ -51,6 +67,17 @@ type (
 		ClientSecretEncryptionNonce string
 		AuthURL                     string
 		TokenURL                    string
+		GrantType                   uint8
+		Audience                    string
+		Username                    string
+		Password                    string // plaintext of the configs saved before the encryption
+		PasswordEncrypted           string
+		PasswordEncryptionNonce     string
+		Issuer                      string
+		Subject                     string
+		PrivateKey                  string // plaintext of the configs saved before the encryption
+		PrivateKeyEncrypted         string
+		PrivateKeyEncryptionNonce   string
 	}
 
 	// ProviderTool hosts the tools for the provider
@@ -71,6 +98,12 @@ type (
 		ResBodyJSONSchema   json.RawMessage `gorm:"type:bytea"`
 		Headers             json.RawMessage `gorm:"type:bytea"`
 		Oauth2Scopes        string
+		Operation           string          `gorm:"type:text"`  // GraphQL operation document
+		Tags                string          `gorm:"type:text"`  // Comma separated
+		MockExamples        json.RawMessage `gorm:"type:bytea"` // Stores []ToolMockExample
+		AsyncConfig         json.RawMessage `gorm:"type:bytea"` // Stores ToolAsyncConfig
+		PaginationConfig    json.RawMessage `gorm:"type:bytea"` // Stores ToolPaginationConfig
+		CacheConfig         json.RawMessage `gorm:"type:bytea"` // Stores ToolCacheConfig
 	}
 
 	ProviderToolAttribute string
@@ -84,6 +117,9 @@ type (
 		UpdatedAt time.Time
 
 		RequestHeadersProxyEnabled bool
+		MockEnabled                bool
+		TrafficMode                uint8 `gorm:"default:1"` // 0: INVALID, 1: LIVE, 2: RECORD, 3: REPLAY
+		InputSchemaMode            uint8 `gorm:"default:1"` // 0: INVALID, 1: NESTED, 2: FLAT
 
 		Name         string `gorm:"type:varchar(128)"`
 		Instructions string `gorm:"type:text"`
@@ -101,6 +137,8 @@ type (
 		ServerID   int64 `gorm:"primaryKey;autoIncrement:false"`
 		ProviderID int64 `gorm:"primaryKey;autoIncrement:false"`
 		ToolID     int64 `gorm:"primaryKey;autoIncrement:false"`
+
+		Override json.RawMessage `gorm:"type:bytea"` // Stores ServerToolOverride
 	}
 
 	// ServerResource hosts the resources that are used in the server
@@ -115,6 +153,94 @@ type (
 		PromptID int64 `gorm:"primaryKey;autoIncrement:false"`
 	}
 
+	// ServerSession hosts the MCP sessions of the server when the session
+	// registry is shared between the replicas
+	ServerSession struct {
+		ServerID  int64 `gorm:"primaryKey;autoIncrement:false"`
+		SessionID int64 `gorm:"primaryKey;autoIncrement:false"`
+		CreatedAt time.Time
+
+		InitializeParams json.RawMessage `gorm:"type:bytea"`
+	}
+
+	// ServerRecording hosts a tool call recorded on the server with the
+	// upstream interactions
+	ServerRecording struct {
+		ID        int64 `gorm:"primaryKey;autoIncrement:false"`
+		CreatedAt time.Time
+
+		ServerID     int64 `gorm:"index"`
+		ToolID       int64
+		ToolName     string          `gorm:"type:varchar(128)"`
+		Arguments    json.RawMessage `gorm:"type:bytea"`
+		Result       json.RawMessage `gorm:"type:bytea"`
+		IsError      bool
+		Interactions json.RawMessage `gorm:"type:bytea"` // Stores []RecordedInteraction
+	}
+
+	// ToolResponseCache hosts a cached tool response when the response cache
+	// is shared between the replicas
+	ToolResponseCache struct {
+		Key       string `gorm:"primaryKey;type:varchar(64)"`
+		CreatedAt time.Time
+		ExpiresAt time.Time `gorm:"index"`
+
+		ServerID     int64 `gorm:"index"`
+		ToolID       int64
+		FreshUntil   time.Time
+		NoCache      bool
+		ETag         string          `gorm:"type:varchar(256)"`
+		LastModified string          `gorm:"type:varchar(64)"`
+		Text         []byte          `gorm:"type:bytea"`
+		Meta         json.RawMessage `gorm:"type:bytea"`
+	}
+
+	// Oauth2State hosts the PKCE code verifier of a pending provider oauth2
+	// authorization by the state JWT ID, it is deleted on the callback so that
+	// a state is used once. The subject references of the authorize URLs are
+	// kept the same way so that the server tokens are not put in the URLs.
+	Oauth2State struct {
+		ID        string `gorm:"primaryKey"`
+		CreatedAt time.Time
+		ExpiresAt time.Time `gorm:"index"`
+
+		CodeVerifier string
+
+		// the subject reference of an authorize URL
+		Subject    string
+		ServerID   int64
+		ProviderID int64
+	}
+
+	// Oauth2Client hosts an MCP client registered dynamically to the built-in
+	// authorization server
+	Oauth2Client struct {
+		ID        string `gorm:"type:varchar(64);primaryKey"`
+		CreatedAt time.Time
+
+		Name                    string          `gorm:"type:varchar(255)"`
+		RedirectURIs            json.RawMessage `gorm:"type:bytea"` // Stores []string
+		TokenEndpointAuthMethod string          `gorm:"type:varchar(32)"`
+		SecretHash              []byte          `gorm:"type:bytea"` // empty for the public clients
+	}
+
+	// Oauth2AuthorizationCode hosts an authorization code issued by the
+	// built-in authorization server by the SHA-256 of the code, it is deleted
+	// on the token exchange so that a code is used once
+	Oauth2AuthorizationCode struct {
+		ID        string `gorm:"type:varchar(64);primaryKey"`
+		CreatedAt time.Time
+		ExpiresAt time.Time `gorm:"index"`
+
+		ClientID      string `gorm:"type:varchar(64)"`
+		RedirectURI   string `gorm:"type:text"`
+		ServerID      int64
+		Resource      string `gorm:"type:text"`
+		Scope         string `gorm:"type:varchar(255)"`
+		Subject       string `gorm:"type:varchar(128)"`
+		CodeChallenge string `gorm:"type:varchar(128)"`
+	}
+
 	// Resource hosts a known resource that the server is capable of reading.
 	Resource struct {
 		ID        int64 `gorm:"primaryKey;autoIncrement:false"`
@@ -152,13 +278,22 @@ type (
 
 // Provider mutable attributes
 const (
-	ProviderAttributeName         ProviderAttribute = "name"
-	ProviderAttributeDocumentURL  ProviderAttribute = "document_url"
-	ProviderAttributeIconURL      ProviderAttribute = "icon_url"
-	ProviderAttributeDescription  ProviderAttribute = "description"
-	ProviderAttributeUpdatedAt    ProviderAttribute = "updated_at"
-	ProviderAttributeVersion      ProviderAttribute = "version"
-	ProviderAttributeOauth2Config ProviderAttribute = "oauth2_config"
+	ProviderAttributeName              ProviderAttribute = "name"
+	ProviderAttributeDocumentURL       ProviderAttribute = "document_url"
+	ProviderAttributeIconURL           ProviderAttribute = "icon_url"
+	ProviderAttributeDescription       ProviderAttribute = "description"
+	ProviderAttributeUpdatedAt         ProviderAttribute = "updated_at"
+	ProviderAttributeVersion           ProviderAttribute = "version"
+	ProviderAttributeOauth2Config      ProviderAttribute = "oauth2_config"
+	ProviderAttributeGraphQLSchema     ProviderAttribute = "graphql_schema"
+	ProviderAttributeGRPCConfig        ProviderAttribute = "grpc_config"
+	ProviderAttributeGRPCDescriptorSet ProviderAttribute = "grpc_descriptor_set"
+	ProviderAttributeAuthConfig        ProviderAttribute = "auth_config"
+	ProviderAttributeSignerConfig      ProviderAttribute = "signer_config"
+	ProviderAttributeMockConfig        ProviderAttribute = "mock_config"
+	ProviderAttributeLimitsConfig      ProviderAttribute = "limits_config"
+	ProviderAttributeEgressConfig      ProviderAttribute = "egress_config"
+	ProviderAttributeTransportConfig   ProviderAttribute = "transport_config"
 )
 
 func (a ProviderAttribute) String() string {
@@ -168,6 +303,7 @@ func (a ProviderAttribute) String() string {
 // Provider mutable attributes
 const (
 	ProviderToolAttributeName                ProviderToolAttribute = "name"
+	ProviderToolAttributePath                ProviderToolAttribute = "path"
 	ProviderToolAttributeTitle               ProviderToolAttribute = "title"
 	ProviderToolAttributeDescription         ProviderToolAttribute = "description"
 	ProviderToolAttributePathArgsJSONSchema  ProviderToolAttribute = "path_args_json_schema"
@@ -176,6 +312,12 @@ const (
 	ProviderToolAttributeResBodyJSONSchema   ProviderToolAttribute = "res_body_json_schema"
 	ProviderToolAttributeHeaders             ProviderToolAttribute = "headers"
 	ProviderToolAttributeOauth2Scopes        ProviderToolAttribute = "oauth2_scopes"
+	ProviderToolAttributeOperation           ProviderToolAttribute = "operation"
+	ProviderToolAttributeTags                ProviderToolAttribute = "tags"
+	ProviderToolAttributeMockExamples        ProviderToolAttribute = "mock_examples"
+	ProviderToolAttributeAsyncConfig         ProviderToolAttribute = "async_config"
+	ProviderToolAttributePaginationConfig    ProviderToolAttribute = "pagination_config"
+	ProviderToolAttributeCacheConfig         ProviderToolAttribute = "cache_config"
 	ProviderToolAttributeUpdatedAt           ProviderToolAttribute = "updated_at"
 )
 
@@ -192,6 +334,9 @@ const (
 	ServerAttributeResources                  ServerAttribute = "resources"
 	ServerAttributePrompts                    ServerAttribute = "prompts"
 	ServerAttributeRequestHeadersProxyEnabled ServerAttribute = "request_headers_proxy_enabled"
+	ServerAttributeInputSchemaMode            ServerAttribute = "input_schema_mode"
+	ServerAttributeMockEnabled                ServerAttribute = "mock_enabled"
+	ServerAttributeTrafficMode                ServerAttribute = "traffic_mode"
 )
 
 func (a ServerAttribute) String() string {
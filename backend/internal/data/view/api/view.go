@@ -47,7 +47,7 @@ type (
 		UpdatedAt string `json:"updatedAt,omitempty"`
 
 		Version        int32  `json:"version,omitempty"`
-		ApiType        string `json:"apiType,omitempty"`        // 0: INVALID, 1: REST
+		ApiType        string `json:"apiType,omitempty"`        // 0: INVALID, 1: REST, 2: GRAPHQL, 3: GRPC, 4: MCP
 		VisibilityType string `json:"visibilityType,omitempty"` // 0: INVALID, 1: INTERNAL, 2: PUBLIC
 		BaseURL        string `json:"baseURL,omitempty"`
 		DocumentURL    string `json:"documentURL,omitempty"`
@@ -56,8 +56,19 @@ type (
 		Name           string `json:"name,omitempty"`
 		Description    string `json:"description,omitempty"`
 
-		Tools        []ProviderTool        `json:"tools,omitempty"`
-		Oauth2Config *ProviderOauth2Config `json:"oauth2Config,omitempty"`
+		GraphQLSchema json.RawMessage `json:"graphqlSchema,omitempty"`
+
+		GRPCConfig        *ProviderGRPCConfig `json:"grpcConfig,omitempty"`
+		GRPCDescriptorSet []byte              `json:"grpcDescriptorSet,omitempty"` // base64 encoded FileDescriptorSet
+
+		AuthConfig      *ProviderAuthConfig      `json:"authConfig,omitempty"`
+		SignerConfig    *ProviderSignerConfig    `json:"signerConfig,omitempty"`
+		MockConfig      *ProviderMockConfig      `json:"mockConfig,omitempty"`
+		LimitsConfig    *ProviderLimitsConfig    `json:"limitsConfig,omitempty"`
+		EgressConfig    *ProviderEgressConfig    `json:"egressConfig,omitempty"`
+		TransportConfig *ProviderTransportConfig `json:"transportConfig,omitempty"`
+		Tools           []ProviderTool           `json:"tools,omitempty"`
+		Oauth2Config    *ProviderOauth2Config    `json:"oauth2Config,omitempty"`
 	}
 
 	CreateProviderRequest struct {
@@ -94,6 +105,10 @@ type (
 		Provider Provider `json:"provider,omitempty"`
 	}
 
+	GetProviderBreakerResponse struct {
+		Breaker ProviderBreaker `json:"breaker"`
+	}
+
 	UpdateProviderResponse struct {
 		Provider Provider `json:"provider,omitempty"`
 	}
@@ -114,12 +129,106 @@ type (
 		NextToken  string     `json:"nextToken,omitempty"`
 	}
 
+	// ProviderGRPCConfig hosts the transport options of the gRPC providers
+	ProviderGRPCConfig struct {
+		Plaintext          bool `json:"plaintext"`
+		InsecureSkipVerify bool `json:"insecureSkipVerify"`
+	}
+
+	// ProviderMockConfig answers the tool calls without calling the provider
+	ProviderMockConfig struct {
+		Enabled bool `json:"enabled"`
+	}
+
+	// ProviderLimitsConfig protects the provider from the tool calls
+	ProviderLimitsConfig struct {
+		MaxConcurrency         int     `json:"maxConcurrency,omitempty"`
+		RatePerSecond          float64 `json:"ratePerSecond,omitempty"`
+		Burst                  int     `json:"burst,omitempty"`
+		MaxWaitSeconds         int     `json:"maxWaitSeconds,omitempty"`
+		BreakerFailures        int     `json:"breakerFailures,omitempty"`
+		BreakerCooldownSeconds int     `json:"breakerCooldownSeconds,omitempty"`
+	}
+
+	// ProviderEgressConfig allows the destinations of the provider denied by
+	// the default egress policy
+	ProviderEgressConfig struct {
+		AllowedCIDRs []string `json:"allowedCIDRs,omitempty"`
+		AllowedHosts []string `json:"allowedHosts,omitempty"`
+		AllowedPorts []int    `json:"allowedPorts,omitempty"`
+	}
+
+	// ProviderTransportConfig hosts the TLS and the proxy settings of the
+	// provider
+	ProviderTransportConfig struct {
+		CACert             string   `json:"caCert,omitempty"`
+		ClientCert         string   `json:"clientCert,omitempty"`
+		ClientKey          string   `json:"clientKey,omitempty"`
+		MinTLSVersion      string   `json:"minTLSVersion,omitempty"` // 1.2, 1.3
+		InsecureSkipVerify bool     `json:"insecureSkipVerify,omitempty"`
+		PinnedSHA256       []string `json:"pinnedSHA256,omitempty"`
+		ProxyURL           string   `json:"proxyURL,omitempty"`
+	}
+
+	// ProviderBreaker is the circuit breaker state of a provider
+	ProviderBreaker struct {
+		ProviderID          string `json:"providerID"`
+		State               string `json:"state"` // CLOSED, OPEN, HALF_OPEN
+		ConsecutiveFailures int    `json:"consecutiveFailures"`
+		OpenedAt            string `json:"openedAt,omitempty"`
+		RetryAt             string `json:"retryAt,omitempty"`
+		InFlight            int    `json:"inFlight"`
+		ThrottledUntil      string `json:"throttledUntil,omitempty"`
+	}
+
+	// ProviderAuthConfig hosts the credentials applied to every tool call
+	ProviderAuthConfig struct {
+		Type     string `json:"type"`         // NONE, API_KEY, BASIC, BEARER, OAUTH2
+		In       string `json:"in,omitempty"` // HEADER, QUERY, COOKIE
+		Name     string `json:"name,omitempty"`
+		Value    string `json:"value,omitempty"`
+		Username string `json:"username,omitempty"`
+		Password string `json:"password,omitempty"`
+	}
+
+	// ProviderSignerConfig hosts the request signing of the provider
+	ProviderSignerConfig struct {
+		Type string `json:"type"` // NONE, AWS_SIGV4, HMAC, MTLS
+
+		Region          string `json:"region,omitempty"`
+		Service         string `json:"service,omitempty"`
+		AccessKeyID     string `json:"accessKeyID,omitempty"`
+		SecretAccessKey string `json:"secretAccessKey,omitempty"`
+		SessionToken    string `json:"sessionToken,omitempty"`
+
+		Algorithm       string `json:"algorithm,omitempty"`
+		Secret          string `json:"secret,omitempty"`
+		Header          string `json:"header,omitempty"`
+		SignaturePrefix string `json:"signaturePrefix,omitempty"`
+		Encoding        string `json:"encoding,omitempty"`
+		CanonicalString string `json:"canonicalString,omitempty"`
+		TimestampHeader string `json:"timestampHeader,omitempty"`
+
+		ClientCert string `json:"clientCert,omitempty"`
+		ClientKey  string `json:"clientKey,omitempty"`
+		CACert     string `json:"caCert,omitempty"`
+	}
+
 	// ProviderOauth2Config hosts oauth2 configuration for the provider (1:1)
 	ProviderOauth2Config struct {
+		GrantType    string `json:"grantType,omitempty"`
 		ClientID     string `json:"clientID"`
 		ClientSecret string `json:"clientSecret"`
 		AuthURL      string `json:"authURL"`
 		TokenURL     string `json:"tokenURL"`
+		Audience     string `json:"audience,omitempty"`
+
+		Username string `json:"username,omitempty"`
+		Password string `json:"password,omitempty"`
+
+		Issuer     string `json:"issuer,omitempty"`
+		Subject    string `json:"subject,omitempty"`
+		PrivateKey string `json:"privateKey,omitempty"`
 	}
 
 	// ProviderTool hosts the tools for the provider
@@ -138,6 +247,65 @@ type (
 		ResBodyJSONSchema   json.RawMessage `json:"resBodyJSONSchema,omitempty"`
 		Headers             []ToolHeader    `json:"headers,omitempty"`
 		Oauth2Scopes        []string        `json:"oauth2Scopes,omitempty"`
+		Operation           string          `json:"operation,omitempty"`
+		Tags                []string        `json:"tags,omitempty"`
+
+		MockExamples []ToolMockExample `json:"mockExamples,omitempty"`
+		AsyncConfig  *ToolAsyncConfig  `json:"asyncConfig,omitempty"`
+
+		PaginationConfig *ToolPaginationConfig `json:"paginationConfig,omitempty"`
+		CacheConfig      *ToolCacheConfig      `json:"cacheConfig,omitempty"`
+	}
+
+	// ToolMockExample is a recorded response of the tool for the mock mode
+	ToolMockExample struct {
+		Name     string          `json:"name,omitempty"`
+		Rules    []ToolMockRule  `json:"rules,omitempty"`
+		Response json.RawMessage `json:"response"`
+		IsError  bool            `json:"isError,omitempty"`
+	}
+
+	// ToolMockRule matches an argument of the tool call
+	ToolMockRule struct {
+		In      string `json:"in"` // PATH, QUERY, BODY
+		Name    string `json:"name"`
+		Value   any    `json:"value,omitempty"`
+		Pattern string `json:"pattern,omitempty"`
+	}
+
+	// ToolAsyncConfig polls the status URL of the operations accepted with 202
+	ToolAsyncConfig struct {
+		StatusURLPath   string              `json:"statusURLPath,omitempty"`
+		ResultURLPath   string              `json:"resultURLPath,omitempty"`
+		IntervalSeconds int                 `json:"intervalSeconds,omitempty"`
+		MaxWaitSeconds  int                 `json:"maxWaitSeconds,omitempty"`
+		Success         *ToolAsyncPredicate `json:"success,omitempty"`
+		Failure         *ToolAsyncPredicate `json:"failure,omitempty"`
+	}
+
+	// ToolPaginationConfig follows the pages of a list endpoint
+	ToolPaginationConfig struct {
+		Type       string `json:"type"` // LINK, CURSOR, OFFSET, PAGE
+		ItemsPath  string `json:"itemsPath,omitempty"`
+		CursorPath string `json:"cursorPath,omitempty"`
+		Param      string `json:"param,omitempty"`
+		LimitParam string `json:"limitParam,omitempty"`
+		PageSize   int    `json:"pageSize,omitempty"`
+		StartPage  int    `json:"startPage,omitempty"`
+		MaxPages   int    `json:"maxPages,omitempty"`
+		MaxItems   int    `json:"maxItems,omitempty"`
+	}
+
+	// ToolCacheConfig caches the responses of a read-only tool
+	ToolCacheConfig struct {
+		TTLSeconds  int      `json:"ttlSeconds"`
+		VaryHeaders []string `json:"varyHeaders,omitempty"`
+	}
+
+	// ToolAsyncPredicate matches a value of the operation status body
+	ToolAsyncPredicate struct {
+		Path   string `json:"path"`
+		Values []any  `json:"values,omitempty"`
 	}
 
 	CreateProviderToolRequest struct {
@@ -169,13 +337,88 @@ type (
 		Tool ProviderTool `json:"tool,omitempty"`
 	}
 
+	GenerateProviderGraphQLToolsRequest struct {
+		Depth   int          `json:"depth,omitempty"`
+		Refresh bool         `json:"refresh,omitempty"`
+		Headers []ToolHeader `json:"headers,omitempty"`
+	}
+
+	GenerateProviderGraphQLToolsResponse struct {
+		Tools   []ProviderTool           `json:"tools"`
+		Skipped []ImportSkippedOperation `json:"skipped,omitempty"`
+	}
+
+	ImportProviderGRPCToolsRequest struct {
+		Refresh       bool         `json:"refresh,omitempty"`
+		DescriptorSet []byte       `json:"descriptorSet,omitempty"` // base64 encoded FileDescriptorSet
+		Headers       []ToolHeader `json:"headers,omitempty"`
+	}
+
+	ImportProviderGRPCToolsResponse struct {
+		Tools   []ProviderTool           `json:"tools"`
+		Skipped []ImportSkippedOperation `json:"skipped,omitempty"`
+	}
+
+	ImportProviderMCPToolsRequest struct {
+		Headers []ToolHeader `json:"headers,omitempty"`
+	}
+
+	ImportProviderMCPToolsResponse struct {
+		Tools   []ProviderTool           `json:"tools"`
+		Skipped []ImportSkippedOperation `json:"skipped,omitempty"`
+	}
+
+	ImportProviderToolsRequest struct {
+		Spec    json.RawMessage `json:"spec,omitempty"` // document object or its JSON/YAML text
+		URL     string          `json:"url,omitempty"`
+		Apply   bool            `json:"apply,omitempty"`
+		Version int32           `json:"version,omitempty"`
+	}
+
+	ImportProviderToolsResponse struct {
+		Version    int32                    `json:"version"`
+		Applied    bool                     `json:"applied"`
+		AuthConfig *ProviderAuthConfig      `json:"authConfig,omitempty"`
+		Added      []ProviderTool           `json:"added"`
+		Changed    []ProviderToolChange     `json:"changed"`
+		Removed    []ProviderTool           `json:"removed"`
+		Skipped    []ImportSkippedOperation `json:"skipped,omitempty"`
+	}
+
+	ProviderToolChange struct {
+		Tool   ProviderTool `json:"tool"`
+		Fields []string     `json:"fields"`
+	}
+
+	ImportProviderRequest struct {
+		Name           string          `json:"name,omitempty"`
+		Description    string          `json:"description,omitempty"`
+		BaseURL        string          `json:"baseURL,omitempty"`
+		VisibilityType string          `json:"visibilityType,omitempty"`
+		Document       json.RawMessage `json:"document"` // Postman v2.1 collection or HAR file
+	}
+
+	ImportProviderResponse struct {
+		Provider Provider                 `json:"provider"`
+		Skipped  []ImportSkippedOperation `json:"skipped,omitempty"`
+	}
+
+	ImportSkippedOperation struct {
+		Method string `json:"method"`
+		Path   string `json:"path"`
+		Reason string `json:"reason"`
+	}
+
 	// Server hosts a server of a set of provider tools
 	Server struct {
 		ID        string `json:"id,omitempty"`
 		CreatedAt string `json:"createdAt,omitempty"`
 		UpdatedAt string `json:"updatedAt,omitempty"`
 
-		RequestHeadersProxyEnabled bool `json:"requestHeadersProxyEnabled"`
+		RequestHeadersProxyEnabled bool   `json:"requestHeadersProxyEnabled"`
+		MockEnabled                bool   `json:"mockEnabled"`
+		TrafficMode                string `json:"trafficMode,omitempty"`     // LIVE, RECORD, REPLAY
+		InputSchemaMode            string `json:"inputSchemaMode,omitempty"` // NESTED, FLAT
 
 		Name         string     `json:"name,omitempty"`
 		Instructions string     `json:"instructions,omitempty"`
@@ -223,11 +466,56 @@ type (
 		CreatedAt string `json:"createdAt,omitempty"`
 		ExpiresAt string `json:"expiresAt,omitempty"`
 		Scope     string `json:"scope,omitempty"`
+		Subject   string `json:"subject,omitempty"`
 
 		Name  string `json:"name,omitempty"`
 		Value string `json:"value,omitempty"`
 	}
 
+	// ServerRecording is a tool call recorded on the server
+	ServerRecording struct {
+		ID           string                `json:"id,omitempty"`
+		CreatedAt    string                `json:"createdAt,omitempty"`
+		ServerID     string                `json:"serverID,omitempty"`
+		ToolID       string                `json:"toolID,omitempty"`
+		ToolName     string                `json:"toolName,omitempty"`
+		Arguments    json.RawMessage       `json:"arguments,omitempty"`
+		Result       json.RawMessage       `json:"result,omitempty"`
+		IsError      bool                  `json:"isError,omitempty"`
+		Interactions []RecordedInteraction `json:"interactions,omitempty"`
+	}
+
+	// RecordedInteraction is an upstream request and response pair
+	RecordedInteraction struct {
+		Request  RecordedRequest  `json:"request"`
+		Response RecordedResponse `json:"response"`
+	}
+
+	RecordedRequest struct {
+		Method  string              `json:"method"`
+		URL     string              `json:"url"`
+		Headers map[string][]string `json:"headers,omitempty"`
+		Body    string              `json:"body,omitempty"`
+	}
+
+	RecordedResponse struct {
+		StatusCode int                 `json:"statusCode"`
+		Headers    map[string][]string `json:"headers,omitempty"`
+		Body       string              `json:"body,omitempty"`
+	}
+
+	ListServerRecordingsResponse struct {
+		Recordings []ServerRecording `json:"recordings"`
+	}
+
+	ImportServerRecordingsRequest struct {
+		Recordings []ServerRecording `json:"recordings"`
+	}
+
+	ImportServerRecordingsResponse struct {
+		Recordings []ServerRecording `json:"recordings"`
+	}
+
 	CreateServerTokenRequest struct {
 		Token ServerToken `json:"token,omitempty"`
 	}
@@ -249,9 +537,37 @@ type (
 	}
 
 	ServerTool struct {
-		ServerID   string `json:"serverID,omitempty"`
-		ProviderID string `json:"providerID,omitempty"`
-		ToolID     string `json:"toolID,omitempty"`
+		ServerID   string              `json:"serverID,omitempty"`
+		ProviderID string              `json:"providerID,omitempty"`
+		ToolID     string              `json:"toolID,omitempty"`
+		Override   *ServerToolOverride `json:"override,omitempty"`
+	}
+
+	// ServerToolOverride customizes how the tool is exposed on the server
+	ServerToolOverride struct {
+		Name        string                       `json:"name,omitempty"`
+		Title       string                       `json:"title,omitempty"`
+		Description string                       `json:"description,omitempty"`
+		Arguments   []ServerToolArgumentOverride `json:"arguments,omitempty"`
+
+		InputSchemaMode string `json:"inputSchemaMode,omitempty"` // NESTED, FLAT
+	}
+
+	ServerToolArgumentOverride struct {
+		In      string `json:"in,omitempty"` // PATH, QUERY, BODY
+		Name    string `json:"name,omitempty"`
+		Hidden  bool   `json:"hidden,omitempty"`
+		Value   any    `json:"value,omitempty"`
+		Default any    `json:"default,omitempty"`
+		Enum    []any  `json:"enum,omitempty"`
+	}
+
+	UpdateServerToolRequest struct {
+		Tool ServerTool `json:"tool,omitempty"`
+	}
+
+	UpdateServerToolResponse struct {
+		Tool ServerTool `json:"tool,omitempty"`
 	}
 
 	DeleteServerToolsRequest struct {
@@ -0,0 +1,52 @@
+package mcpauth
+
+// The views follow the field names of the OAuth 2.1 RFCs
+type (
+	// ProtectedResourceMetadata is the RFC 9728 metadata of an MCP server
+	ProtectedResourceMetadata struct {
+		Resource               string   `json:"resource"`
+		AuthorizationServers   []string `json:"authorization_servers"`
+		ScopesSupported        []string `json:"scopes_supported,omitempty"`
+		BearerMethodsSupported []string `json:"bearer_methods_supported,omitempty"`
+		ResourceName           string   `json:"resource_name,omitempty"`
+	}
+
+	// AuthorizationServerMetadata is the RFC 8414 metadata of the
+	// authorization server
+	AuthorizationServerMetadata struct {
+		Issuer                            string   `json:"issuer"`
+		AuthorizationEndpoint             string   `json:"authorization_endpoint"`
+		TokenEndpoint                     string   `json:"token_endpoint"`
+		RegistrationEndpoint              string   `json:"registration_endpoint,omitempty"`
+		ScopesSupported                   []string `json:"scopes_supported,omitempty"`
+		ResponseTypesSupported            []string `json:"response_types_supported"`
+		GrantTypesSupported               []string `json:"grant_types_supported,omitempty"`
+		TokenEndpointAuthMethodsSupported []string `json:"token_endpoint_auth_methods_supported,omitempty"`
+		CodeChallengeMethodsSupported     []string `json:"code_challenge_methods_supported,omitempty"`
+	}
+
+	// Client is the RFC 7591 client metadata
+	Client struct {
+		ClientID                string   `json:"client_id,omitempty"`
+		ClientSecret            string   `json:"client_secret,omitempty"`
+		ClientIDIssuedAt        int64    `json:"client_id_issued_at,omitempty"`
+		ClientSecretExpiresAt   *int64   `json:"client_secret_expires_at,omitempty"`
+		ClientName              string   `json:"client_name,omitempty"`
+		RedirectURIs            []string `json:"redirect_uris"`
+		TokenEndpointAuthMethod string   `json:"token_endpoint_auth_method,omitempty"`
+		GrantTypes              []string `json:"grant_types,omitempty"`
+		ResponseTypes           []string `json:"response_types,omitempty"`
+	}
+
+	Token struct {
+		AccessToken string `json:"access_token"`
+		TokenType   string `json:"token_type"`
+		ExpiresIn   int64  `json:"expires_in,omitempty"`
+		Scope       string `json:"scope,omitempty"`
+	}
+
+	Error struct {
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description,omitempty"`
+	}
+)
@@ -84,6 +84,10 @@ func New(p Params) (Handler, error) {
 		return nil, err
 	}
 
+	if err := h.registerProviderBreakerRoutes(); err != nil {
+		return nil, err
+	}
+
 	if err := h.registerServerToolRoutes(); err != nil {
 		return nil, err
 	}
@@ -92,6 +96,14 @@ func New(p Params) (Handler, error) {
 		return nil, err
 	}
 
+	if err := h.registerServerRecordingRoutes(); err != nil {
+		return nil, err
+	}
+
+	if err := h.registerServerCacheRoutes(); err != nil {
+		return nil, err
+	}
+
 	if err := h.registerServerRoutes(); err != nil {
 		return nil, err
 	}
@@ -15,6 +15,7 @@ const (
 	_routePathGetProvider    = _routePathProviders + "/:id"
 	_routePathPatchProvider  = _routePathProviders + "/:id"
 	_routePathDeleteProvider = _routePathProviders + "/:id"
+	_routePathImportProvider = _routePathProviders + "/import/:format"
 )
 
 func (h *handler) registerProviderRoutes() error {
@@ -23,6 +24,7 @@ func (h *handler) registerProviderRoutes() error {
 	h.router.Get(_routePathGetProvider, h.getProvider())
 	h.router.Patch(_routePathPatchProvider, h.updateProvider())
 	h.router.Delete(_routePathDeleteProvider, h.deleteProvider())
+	h.router.Post(_routePathImportProvider, h.importProvider())
 
 	return nil
 }
@@ -135,3 +137,26 @@ func (h *handler) deleteProvider() fiber.Handler {
 		return c.Send([]byte(""))
 	}
 }
+
+func (h *handler) importProvider() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		c.Set(headerContentType, headerContentTypeValueApplicationJSON)
+		rq := mapper.FromHTTPRequestToImportProviderRequestEntity(c)
+		if rq == nil {
+			c.Status(http.StatusUnprocessableEntity)
+			return c.Send(_invalidRequestPayloadHTTPError)
+		}
+
+		rs, err := h.crud.ImportProvider(context.Background(), *rq)
+		if err != nil {
+			e, status := mapper.FromErrorToHTTPResponse(err)
+			c.Status(status)
+			return c.Send(e)
+		}
+
+		payload := mapper.FromImportProviderResponseEntityToHTTPResponse(rs)
+
+		c.Status(http.StatusCreated)
+		return c.Send(payload)
+	}
+}
@@ -0,0 +1,43 @@
+package api
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/gofiber/fiber/v2"
+	mapper "github.com/hasmcp/hasmcp-ce/backend/internal/mapper/api"
+)
+
+const (
+	_routePathGetProviderBreaker = _routePathProviders + "/:id/breaker"
+)
+
+func (h *handler) registerProviderBreakerRoutes() error {
+	h.router.Get(_routePathGetProviderBreaker, h.getProviderBreaker())
+
+	return nil
+}
+
+func (h *handler) getProviderBreaker() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		c.Set(headerContentType, headerContentTypeValueApplicationJSON)
+
+		rq := mapper.FromHTTPRequestToGetProviderBreakerRequestEntity(c)
+		if rq == nil {
+			c.Status(http.StatusUnprocessableEntity)
+			return c.Send(_invalidRequestPayloadHTTPError)
+		}
+
+		rs, err := h.crud.GetProviderBreaker(context.Background(), *rq)
+		if err != nil {
+			e, status := mapper.FromErrorToHTTPResponse(err)
+			c.Status(status)
+			return c.Send(e)
+		}
+
+		payload := mapper.FromGetProviderBreakerResponseEntityToHTTPResponse(rs)
+
+		c.Status(http.StatusOK)
+		return c.Send(payload)
+	}
+}
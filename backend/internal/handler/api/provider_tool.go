@@ -15,6 +15,11 @@ const (
 	_routePathGetProviderTool    = _routePathProviderTools + "/:toolID"
 	_routePathPatchProviderTool  = _routePathProviderTools + "/:toolID"
 	_routePathDeleteProviderTool = _routePathProviderTools + "/:toolID"
+
+	_routePathGenerateProviderGraphQLTools = _routePathProviders + "/:id/graphql/tools"
+	_routePathImportProviderGRPCTools      = _routePathProviders + "/:id/grpc/tools"
+	_routePathImportProviderMCPTools       = _routePathProviders + "/:id/mcp/tools"
+	_routePathImportProviderTools          = _routePathProviders + "/:id/import"
 )
 
 func (h *handler) registerProviderToolRoutes() error {
@@ -23,6 +28,10 @@ func (h *handler) registerProviderToolRoutes() error {
 	h.router.Get(_routePathGetProviderTool, h.getProviderTool())
 	h.router.Patch(_routePathPatchProviderTool, h.updateProviderTool())
 	h.router.Delete(_routePathDeleteProviderTool, h.deleteProviderTool())
+	h.router.Post(_routePathGenerateProviderGraphQLTools, h.generateProviderGraphQLTools())
+	h.router.Post(_routePathImportProviderGRPCTools, h.importProviderGRPCTools())
+	h.router.Post(_routePathImportProviderMCPTools, h.importProviderMCPTools())
+	h.router.Post(_routePathImportProviderTools, h.importProviderTools())
 	return nil
 }
 
@@ -135,3 +144,95 @@ func (h *handler) deleteProviderTool() fiber.Handler {
 		return c.Send([]byte(""))
 	}
 }
+
+func (h *handler) generateProviderGraphQLTools() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		c.Set(headerContentType, headerContentTypeValueApplicationJSON)
+		rq := mapper.FromHTTPRequestToGenerateProviderGraphQLToolsRequestEntity(c)
+		if rq == nil {
+			c.Status(http.StatusUnprocessableEntity)
+			return c.Send(_invalidRequestPayloadHTTPError)
+		}
+
+		rs, err := h.crud.GenerateProviderGraphQLTools(context.Background(), *rq)
+		if err != nil {
+			e, status := mapper.FromErrorToHTTPResponse(err)
+			c.Status(status)
+			return c.Send(e)
+		}
+
+		payload := mapper.FromGenerateProviderGraphQLToolsResponseEntityToHTTPResponse(rs)
+
+		c.Status(http.StatusCreated)
+		return c.Send(payload)
+	}
+}
+
+func (h *handler) importProviderGRPCTools() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		c.Set(headerContentType, headerContentTypeValueApplicationJSON)
+		rq := mapper.FromHTTPRequestToImportProviderGRPCToolsRequestEntity(c)
+		if rq == nil {
+			c.Status(http.StatusUnprocessableEntity)
+			return c.Send(_invalidRequestPayloadHTTPError)
+		}
+
+		rs, err := h.crud.ImportProviderGRPCTools(context.Background(), *rq)
+		if err != nil {
+			e, status := mapper.FromErrorToHTTPResponse(err)
+			c.Status(status)
+			return c.Send(e)
+		}
+
+		payload := mapper.FromImportProviderGRPCToolsResponseEntityToHTTPResponse(rs)
+
+		c.Status(http.StatusCreated)
+		return c.Send(payload)
+	}
+}
+
+func (h *handler) importProviderMCPTools() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		c.Set(headerContentType, headerContentTypeValueApplicationJSON)
+		rq := mapper.FromHTTPRequestToImportProviderMCPToolsRequestEntity(c)
+		if rq == nil {
+			c.Status(http.StatusUnprocessableEntity)
+			return c.Send(_invalidRequestPayloadHTTPError)
+		}
+
+		rs, err := h.crud.ImportProviderMCPTools(context.Background(), *rq)
+		if err != nil {
+			e, status := mapper.FromErrorToHTTPResponse(err)
+			c.Status(status)
+			return c.Send(e)
+		}
+
+		payload := mapper.FromImportProviderMCPToolsResponseEntityToHTTPResponse(rs)
+
+		c.Status(http.StatusCreated)
+		return c.Send(payload)
+	}
+}
+
+func (h *handler) importProviderTools() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		c.Set(headerContentType, headerContentTypeValueApplicationJSON)
+		rq := mapper.FromHTTPRequestToImportProviderToolsRequestEntity(c)
+		if rq == nil {
+			c.Status(http.StatusUnprocessableEntity)
+			return c.Send(_invalidRequestPayloadHTTPError)
+		}
+
+		rs, err := h.crud.ImportProviderTools(context.Background(), *rq)
+		if err != nil {
+			e, status := mapper.FromErrorToHTTPResponse(err)
+			c.Status(status)
+			return c.Send(e)
+		}
+
+		payload := mapper.FromImportProviderToolsResponseEntityToHTTPResponse(rs)
+
+		c.Status(http.StatusOK)
+		return c.Send(payload)
+	}
+}
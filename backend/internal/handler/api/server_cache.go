@@ -0,0 +1,43 @@
+package api
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/gofiber/fiber/v2"
+	mapper "github.com/hasmcp/hasmcp-ce/backend/internal/mapper/api"
+)
+
+const (
+	_routePathPurgeServerCache     = _routePathServers + "/:id/cache"
+	_routePathPurgeServerToolCache = _routePathServers + "/:id/tools/:toolID/cache"
+)
+
+func (h *handler) registerServerCacheRoutes() error {
+	h.router.Delete(_routePathPurgeServerCache, h.purgeServerCache())
+	h.router.Delete(_routePathPurgeServerToolCache, h.purgeServerCache())
+
+	return nil
+}
+
+func (h *handler) purgeServerCache() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		c.Set(headerContentType, headerContentTypeValueApplicationJSON)
+
+		rq := mapper.FromHTTPRequestToPurgeServerCacheRequestEntity(c)
+		if rq == nil {
+			c.Status(http.StatusUnprocessableEntity)
+			return c.Send(_invalidRequestPayloadHTTPError)
+		}
+
+		err := h.crud.PurgeServerCache(context.Background(), *rq)
+		if err != nil {
+			e, status := mapper.FromErrorToHTTPResponse(err)
+			c.Status(status)
+			return c.Send(e)
+		}
+
+		c.Status(http.StatusNoContent)
+		return c.Send([]byte(""))
+	}
+}
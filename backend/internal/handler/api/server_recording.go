@@ -0,0 +1,94 @@
+package api
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/gofiber/fiber/v2"
+	mapper "github.com/hasmcp/hasmcp-ce/backend/internal/mapper/api"
+)
+
+const (
+	_routePathServerRecordings       = _routePathServers + "/:id/recordings"
+	_routePathListServerRecordings   = _routePathServerRecordings
+	_routePathImportServerRecordings = _routePathServerRecordings
+	_routePathDeleteServerRecordings = _routePathServerRecordings
+)
+
+func (h *handler) registerServerRecordingRoutes() error {
+	h.router.Get(_routePathListServerRecordings, h.listServerRecordings())
+	h.router.Post(_routePathImportServerRecordings, h.importServerRecordings())
+	h.router.Delete(_routePathDeleteServerRecordings, h.deleteServerRecordings())
+
+	return nil
+}
+
+func (h *handler) listServerRecordings() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		c.Set(headerContentType, headerContentTypeValueApplicationJSON)
+
+		rq := mapper.FromHTTPRequestToListServerRecordingsRequestEntity(c)
+		if rq == nil {
+			c.Status(http.StatusUnprocessableEntity)
+			return c.Send(_invalidRequestPayloadHTTPError)
+		}
+
+		rs, err := h.crud.ListServerRecordings(context.Background(), *rq)
+		if err != nil {
+			e, status := mapper.FromErrorToHTTPResponse(err)
+			c.Status(status)
+			return c.Send(e)
+		}
+
+		payload := mapper.FromListServerRecordingsResponseEntityToHTTPResponse(rs)
+
+		c.Status(http.StatusOK)
+		return c.Send(payload)
+	}
+}
+
+func (h *handler) importServerRecordings() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		c.Set(headerContentType, headerContentTypeValueApplicationJSON)
+
+		rq := mapper.FromHTTPRequestToImportServerRecordingsRequestEntity(c)
+		if rq == nil {
+			c.Status(http.StatusUnprocessableEntity)
+			return c.Send(_invalidRequestPayloadHTTPError)
+		}
+
+		rs, err := h.crud.ImportServerRecordings(context.Background(), *rq)
+		if err != nil {
+			e, status := mapper.FromErrorToHTTPResponse(err)
+			c.Status(status)
+			return c.Send(e)
+		}
+
+		payload := mapper.FromImportServerRecordingsResponseEntityToHTTPResponse(rs)
+
+		c.Status(http.StatusCreated)
+		return c.Send(payload)
+	}
+}
+
+func (h *handler) deleteServerRecordings() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		c.Set(headerContentType, headerContentTypeValueApplicationJSON)
+
+		rq := mapper.FromHTTPRequestToDeleteServerRecordingsRequestEntity(c)
+		if rq == nil {
+			c.Status(http.StatusUnprocessableEntity)
+			return c.Send(_invalidRequestPayloadHTTPError)
+		}
+
+		err := h.crud.DeleteServerRecordings(context.Background(), *rq)
+		if err != nil {
+			e, status := mapper.FromErrorToHTTPResponse(err)
+			c.Status(status)
+			return c.Send(e)
+		}
+
+		c.Status(http.StatusNoContent)
+		return c.Send([]byte(""))
+	}
+}
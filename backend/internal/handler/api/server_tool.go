@@ -12,12 +12,14 @@ const (
 	_routePathServerTools      = _routePathServers + "/:id/tools"
 	_routePathCreateServerTool = _routePathServerTools
 	_routePathListServerTools  = _routePathServerTools
+	_routePathUpdateServerTool = _routePathServerTools + "/:toolID"
 	_routePathDeleteServerTool = _routePathServerTools + "/:toolID"
 )
 
 func (h *handler) registerServerToolRoutes() error {
 	h.router.Post(_routePathCreateServerTool, h.createServerTool())
 	h.router.Get(_routePathListServerTools, h.listServerTools())
+	h.router.Patch(_routePathUpdateServerTool, h.updateServerTool())
 	h.router.Delete(_routePathDeleteServerTool, h.deleteServerTool())
 
 	return nil
@@ -71,6 +73,30 @@ func (h *handler) listServerTools() fiber.Handler {
 	}
 }
 
+func (h *handler) updateServerTool() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		c.Set(headerContentType, headerContentTypeValueApplicationJSON)
+
+		rq := mapper.FromHTTPRequestToUpdateServerToolRequestEntity(c)
+		if rq == nil {
+			c.Status(http.StatusUnprocessableEntity)
+			return c.Send(_invalidRequestPayloadHTTPError)
+		}
+
+		rs, err := h.crud.UpdateServerTool(context.Background(), *rq)
+		if err != nil {
+			e, status := mapper.FromErrorToHTTPResponse(err)
+			c.Status(status)
+			return c.Send(e)
+		}
+
+		payload := mapper.FromUpdateServerToolResponseEntityToHTTPResponse(rs)
+
+		c.Status(http.StatusOK)
+		return c.Send(payload)
+	}
+}
+
 func (h *handler) deleteServerTool() fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		c.Set(headerContentType, headerContentTypeValueApplicationJSON)
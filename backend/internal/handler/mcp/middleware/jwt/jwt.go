@@ -3,6 +3,8 @@ package jwt
 import (
 	"context"
 	"errors"
+	"slices"
+	"strings"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/hasmcp/hasmcp-ce/backend/internal/controller/mcp/jwt"
@@ -18,6 +20,9 @@ type (
 	}
 
 	jwtConfig struct {
+		// BaseURL is the public URL of the server, the canonical URIs of the
+		// MCP servers and their resource metadata URLs are built from it
+		BaseURL string `yaml:"baseURL"`
 	}
 
 	ctxKey uint8
@@ -29,8 +34,12 @@ const (
 	_ctxKeyAuthResult ctxKey = iota
 
 	_headerKeyAuthorization     = "x-hasmcp-key"
+	_headerKeyBearer            = "authorization"
+	_headerKeyWWWAuthenticate   = "www-authenticate"
 	_headerValuePrefixBearer    = "Bearer "
 	_headerValuePrefixBearerLen = len(_headerValuePrefixBearer)
+
+	_routePathProtectedResourceMetadata = "/.well-known/oauth-protected-resource"
 )
 
 var (
@@ -44,18 +53,46 @@ func New(p Params) (fiber.Handler, error) {
 		return nil, err
 	}
 
+	baseURL := strings.TrimSuffix(cfg.BaseURL, "/")
+	if baseURL == "" {
+		return nil, errors.New("mcpjwtauth.baseURL is required")
+	}
+
 	auth := p.JWTAuth
+
+	// unauthorized points the clients to the protected resource metadata of
+	// the MCP server as the MCP authorization specification requires
+	unauthorized := func(c *fiber.Ctx) error {
+		path := c.Path()
+		if len(path) >= 16 {
+			c.Set(_headerKeyWWWAuthenticate, `Bearer resource_metadata="`+baseURL+
+				_routePathProtectedResourceMetadata+path[0:16]+`"`)
+		}
+		return c.Status(401).Send(_unauthorized)
+	}
+
 	handler := func(c *fiber.Ctx) error {
+		// the standard Authorization header is the last option since it is
+		// proxied to the upstream APIs when the other options are used
+		var fromBearerHeader bool
 		tokenVal := c.Get(_headerKeyAuthorization)
-		if tokenVal == "" {
+		switch {
+		case tokenVal != "":
+			if !hasBearerPrefix(tokenVal) {
+				return unauthorized(c)
+			}
+		case c.Query("token") != "":
 			tokenVal = c.Query("token")
-		} else if len(tokenVal) < _headerValuePrefixBearerLen ||
-			tokenVal[0:_headerValuePrefixBearerLen] != _headerValuePrefixBearer {
-			return c.Status(401).Send(_unauthorized)
+		default:
+			tokenVal = c.Get(_headerKeyBearer)
+			if !hasBearerPrefix(tokenVal) {
+				return unauthorized(c)
+			}
+			fromBearerHeader = true
 		}
 
-		if len(tokenVal) < 1 {
-			return c.Status(401).Send(_unauthorized)
+		if len(tokenVal) <= _headerValuePrefixBearerLen {
+			return unauthorized(c)
 		}
 
 		auth, err := auth.Authenticate(context.Background(), jwt.AuthParams{
@@ -63,7 +100,7 @@ func New(p Params) (fiber.Handler, error) {
 		})
 		if err != nil {
 			zlog.Error().Err(err).Msg("auth failed")
-			return c.Status(401).Send(_unauthorized)
+			return unauthorized(c)
 		}
 
 		path := c.Path()
@@ -78,9 +115,22 @@ func New(p Params) (fiber.Handler, error) {
 		if auth.ServerID != serverID {
 			zlog.Info().Str("actual", monoflake.ID(auth.ServerID).String()).
 				Str("param", c.Params("id")).Msg("server id mismatch")
-			return c.Status(401).Send(_unauthorized)
+			return unauthorized(c)
+		}
+
+		// the tokens of the authorization server are bound to the canonical
+		// URI of the MCP server (RFC 8707), the server tokens created on the
+		// API have no audience and are bound by the server ID only
+		if len(auth.Audience) > 0 && !slices.Contains(auth.Audience, baseURL+path[0:16]) {
+			zlog.Info().Strs("audience", auth.Audience).
+				Str("param", string(id)).Msg("audience mismatch")
+			return unauthorized(c)
 		}
 
+		if fromBearerHeader {
+			// the token of the MCP server is not passed to the upstream APIs
+			c.Request().Header.Del(_headerKeyBearer)
+		}
 		c.Locals(_ctxKeyAuthResult, *auth)
 
 		return c.Next()
@@ -89,6 +139,11 @@ func New(p Params) (fiber.Handler, error) {
 	return handler, nil
 }
 
+func hasBearerPrefix(val string) bool {
+	return len(val) >= _headerValuePrefixBearerLen &&
+		val[0:_headerValuePrefixBearerLen] == _headerValuePrefixBearer
+}
+
 func AuthResult(c *fiber.Ctx) jwt.AuthResult {
 	res, ok := c.Locals(_ctxKeyAuthResult).(jwt.AuthResult)
 	if !ok {
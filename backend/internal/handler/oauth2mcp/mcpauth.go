@@ -0,0 +1,217 @@
+package oauth2mcp
+
+import (
+	"bytes"
+	"context"
+	"html/template"
+	"net/http"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/hasmcp/hasmcp-ce/backend/internal/controller/mcpauth"
+	mapper "github.com/hasmcp/hasmcp-ce/backend/internal/mapper/mcpauth"
+	"github.com/mustafaturan/monoflake"
+	"github.com/valyala/fasthttp"
+)
+
+const (
+	_routeBasePathWellKnown                = "/.well-known"
+	_routePathGetProtectedResourceMetadata = "/oauth-protected-resource/mcp/:id"
+	_routePathGetAuthServerMetadata        = "/oauth-authorization-server"
+	_routePathMcpAuthorize                 = "/mcp/authorize"
+	_routePathPostMcpToken                 = "/mcp/token"
+	_routePathPostMcpRegister              = "/mcp/register"
+
+	headerCacheControl             = "cache-control"
+	headerCacheControlValueNoStore = "no-store"
+	headerContentTypeValueHTML     = "text/html; charset=utf-8"
+)
+
+var (
+	_consentPage = template.Must(template.New("consent").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>Authorize {{.ClientName}}</title>
+<style>
+body{font-family:sans-serif;max-width:28rem;margin:4rem auto;padding:0 1rem;color:#222}
+label{display:block;margin-top:1rem}
+input[type=text],input[type=password]{width:100%;padding:.5rem;box-sizing:border-box}
+button{margin-top:1.5rem;margin-right:.5rem;padding:.5rem 1rem}
+.error{color:#b00020}
+</style>
+</head>
+<body>
+<h2>Authorize {{.ClientName}}</h2>
+<p><strong>{{.ClientName}}</strong> asks to access the MCP server <code>{{.ServerID}}</code> with the scopes <code>{{.Scope}}</code>.</p>
+{{if .Error}}<p class="error">{{.Error}}</p>{{end}}
+<form method="post">
+{{range $k, $v := .Params}}<input type="hidden" name="{{$k}}" value="{{$v}}">
+{{end}}<label>Access key<input type="password" name="access_key" autocomplete="current-password" required></label>
+<label>User (optional, the upstream credentials of the user are used)<input type="text" name="subject" maxlength="128"></label>
+<button type="submit" name="decision" value="approve">Approve</button>
+<button type="submit" name="decision" value="deny" formnovalidate>Deny</button>
+</form>
+</body>
+</html>`))
+)
+
+type consentPage struct {
+	ClientName string
+	ServerID   string
+	Scope      string
+	Error      string
+	Params     map[string]string
+}
+
+func (h *handler) registerMcpAuthRoutes(wellKnown fiber.Router) error {
+	wellKnown.Get(_routePathGetProtectedResourceMetadata, h.protectedResourceMetadata())
+	wellKnown.Get(_routePathGetAuthServerMetadata, h.authorizationServerMetadata())
+
+	h.router.Get(_routePathMcpAuthorize, h.mcpAuthorize())
+	h.router.Post(_routePathMcpAuthorize, h.mcpApprove())
+	h.router.Post(_routePathPostMcpToken, h.mcpToken())
+	h.router.Post(_routePathPostMcpRegister, h.mcpRegister())
+
+	return nil
+}
+
+func (h *handler) protectedResourceMetadata() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		rq := mapper.FromHTTPRequestToProtectedResourceMetadataRequestEntity(c)
+		c.Set(headerContentType, headerContentTypeValueApplicationJSON)
+
+		rs, err := h.mcpAuth.ProtectedResourceMetadata(context.Background(), *rq)
+		if err != nil {
+			e, status := mapper.FromErrorToHTTPResponse(err)
+			c.Status(status)
+			return c.Send(e)
+		}
+
+		return c.Send(mapper.FromProtectedResourceMetadataResponseEntityToHTTPResponse(rs))
+	}
+}
+
+func (h *handler) authorizationServerMetadata() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		rq := mapper.FromHTTPRequestToAuthorizationServerMetadataRequestEntity(c)
+		c.Set(headerContentType, headerContentTypeValueApplicationJSON)
+
+		rs, err := h.mcpAuth.AuthorizationServerMetadata(context.Background(), *rq)
+		if err != nil {
+			e, status := mapper.FromErrorToHTTPResponse(err)
+			c.Status(status)
+			return c.Send(e)
+		}
+
+		return c.Send(mapper.FromAuthorizationServerMetadataResponseEntityToHTTPResponse(rs))
+	}
+}
+
+// mcpAuthorize renders the consent page of the authorization request
+func (h *handler) mcpAuthorize() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		rq := mapper.FromHTTPRequestToAuthorizeRequestEntity(c)
+
+		rs, err := h.mcpAuth.Authorize(context.Background(), *rq)
+		if err != nil {
+			e, status := mapper.FromErrorToHTTPResponse(err)
+			c.Set(headerContentType, headerContentTypeValueApplicationJSON)
+			c.Status(status)
+			return c.Send(e)
+		}
+
+		return renderConsentPage(c, *rq, rs, "")
+	}
+}
+
+// mcpApprove issues the authorization code once the consent is given
+func (h *handler) mcpApprove() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		rq := mapper.FromHTTPRequestToApproveRequestEntity(c)
+
+		rs, err := h.mcpAuth.Approve(context.Background(), *rq)
+		if err != nil {
+			// a wrong access key asks for the key again
+			if res, aerr := h.mcpAuth.Authorize(context.Background(), rq.Authorize); aerr == nil {
+				c.Status(http.StatusUnauthorized)
+				return renderConsentPage(c, rq.Authorize, res, err.Error())
+			}
+
+			e, status := mapper.FromErrorToHTTPResponse(err)
+			c.Set(headerContentType, headerContentTypeValueApplicationJSON)
+			c.Status(status)
+			return c.Send(e)
+		}
+
+		return c.Redirect(rs.RedirectURL, fasthttp.StatusFound)
+	}
+}
+
+func (h *handler) mcpToken() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		rq := mapper.FromHTTPRequestToTokenRequestEntity(c)
+		c.Set(headerContentType, headerContentTypeValueApplicationJSON)
+		c.Set(headerCacheControl, headerCacheControlValueNoStore)
+
+		rs, err := h.mcpAuth.Token(context.Background(), *rq)
+		if err != nil {
+			e, status := mapper.FromErrorToHTTPResponse(err)
+			c.Status(status)
+			return c.Send(e)
+		}
+
+		return c.Send(mapper.FromTokenResponseEntityToHTTPResponse(rs))
+	}
+}
+
+func (h *handler) mcpRegister() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		c.Set(headerContentType, headerContentTypeValueApplicationJSON)
+		rq := mapper.FromHTTPRequestToRegisterClientRequestEntity(c)
+		if rq == nil {
+			c.Status(http.StatusBadRequest)
+			return c.Send(_invalidRequestPayloadHTTPError)
+		}
+
+		rs, err := h.mcpAuth.RegisterClient(context.Background(), *rq)
+		if err != nil {
+			e, status := mapper.FromErrorToHTTPResponse(err)
+			c.Status(status)
+			return c.Send(e)
+		}
+
+		c.Status(http.StatusCreated)
+		return c.Send(mapper.FromRegisterClientResponseEntityToHTTPResponse(rs))
+	}
+}
+
+func renderConsentPage(c *fiber.Ctx, rq mcpauth.AuthorizeRequest, rs *mcpauth.AuthorizeResponse, errMessage string) error {
+	var buf bytes.Buffer
+	err := _consentPage.Execute(&buf, consentPage{
+		ClientName: rs.ClientName,
+		ServerID:   monoflake.ID(rs.ServerID).String(),
+		Scope:      rs.Scope,
+		Error:      errMessage,
+		Params: map[string]string{
+			"response_type":         rq.ResponseType,
+			"client_id":             rq.ClientID,
+			"redirect_uri":          rq.RedirectURI,
+			"code_challenge":        rq.CodeChallenge,
+			"code_challenge_method": rq.CodeChallengeMethod,
+			"state":                 rq.State,
+			"scope":                 rq.Scope,
+			"resource":              rq.Resource,
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	c.Set(headerContentType, headerContentTypeValueHTML)
+	c.Set(headerCacheControl, headerCacheControlValueNoStore)
+	// the consent page must not be framed by the other sites
+	c.Set("x-frame-options", "DENY")
+	c.Set("content-security-policy", "frame-ancestors 'none'")
+	return c.Send(buf.Bytes())
+}
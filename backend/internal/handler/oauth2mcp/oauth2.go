@@ -5,6 +5,7 @@ import (
 	"net/http"
 
 	"github.com/gofiber/fiber/v2"
+	"github.com/hasmcp/hasmcp-ce/backend/internal/controller/mcpauth"
 	oauth2 "github.com/hasmcp/hasmcp-ce/backend/internal/controller/oauth2mcp"
 	"github.com/hasmcp/hasmcp-ce/backend/internal/handler/oauth2mcp/middleware/cors"
 	"github.com/hasmcp/hasmcp-ce/backend/internal/handler/oauth2mcp/middleware/logger"
@@ -20,14 +21,17 @@ type (
 		Config config.Service
 		Server server.Service
 		Oauth2 oauth2.Controller
+		// McpAuth is the built-in authorization server of the MCP servers
+		McpAuth mcpauth.Controller
 	}
 
 	Handler interface {
 	}
 
 	handler struct {
-		oauth2 oauth2.Controller
-		router fiber.Router
+		oauth2  oauth2.Controller
+		mcpAuth mcpauth.Controller
+		router  fiber.Router
 	}
 )
 
@@ -68,13 +72,19 @@ func New(p Params) (Handler, error) {
 
 	router := p.Server.Group(_routeBasePath).Use(logger).Use(ratelimit).Use(cors)
 	h := &handler{
-		router: router,
-		oauth2: p.Oauth2,
+		router:  router,
+		oauth2:  p.Oauth2,
+		mcpAuth: p.McpAuth,
 	}
 	if err := h.registerOauth2Routes(); err != nil {
 		return nil, err
 	}
 
+	wellKnown := p.Server.Group(_routeBasePathWellKnown).Use(logger).Use(ratelimit).Use(cors)
+	if err := h.registerMcpAuthRoutes(wellKnown); err != nil {
+		return nil, err
+	}
+
 	return h, nil
 }
 
@@ -3,6 +3,7 @@ package api
 import (
 	"encoding/json"
 	"strconv"
+	"strings"
 
 	"github.com/gofiber/fiber/v2"
 	entity "github.com/hasmcp/hasmcp-ce/backend/internal/data/entity/crud"
@@ -22,25 +23,215 @@ func FromHTTPRequestToCreateProviderRequestEntity(c *fiber.Ctx) *entity.CreatePr
 }
 
 func FromProviderViewToProviderEntity(p view.Provider) entity.Provider {
+	var grpcConfig *entity.ProviderGRPCConfig
+	if p.GRPCConfig != nil {
+		grpcConfig = &entity.ProviderGRPCConfig{
+			Plaintext:          p.GRPCConfig.Plaintext,
+			InsecureSkipVerify: p.GRPCConfig.InsecureSkipVerify,
+		}
+	}
+	var mockConfig *entity.ProviderMockConfig
+	if p.MockConfig != nil {
+		mockConfig = &entity.ProviderMockConfig{
+			Enabled: p.MockConfig.Enabled,
+		}
+	}
 	var oauth2Config entity.ProviderOauth2Config
 	if p.Oauth2Config != nil {
+		grantType := entity.GrantTypeAuthorizationCode
+		if p.Oauth2Config.GrantType != "" {
+			grantType = entity.StringToGrantType(p.Oauth2Config.GrantType)
+		}
 		oauth2Config = entity.ProviderOauth2Config{
+			GrantType:    grantType,
 			ClientID:     p.Oauth2Config.ClientID,
 			ClientSecret: p.Oauth2Config.ClientSecret,
 			AuthURL:      p.Oauth2Config.AuthURL,
 			TokenURL:     p.Oauth2Config.TokenURL,
+			Audience:     p.Oauth2Config.Audience,
+			Username:     p.Oauth2Config.Username,
+			Password:     p.Oauth2Config.Password,
+			Issuer:       p.Oauth2Config.Issuer,
+			Subject:      p.Oauth2Config.Subject,
+			PrivateKey:   p.Oauth2Config.PrivateKey,
 		}
 	}
 	return entity.Provider{
-		ID:             monoflake.IDFromBase62(p.ID).Int64(),
-		ApiType:        entity.StringToApiType(p.ApiType),
-		VisibilityType: entity.StringToVisibilityType(p.VisibilityType),
-		BaseURL:        p.BaseURL,
-		DocumentURL:    p.DocumentURL,
-		IconURL:        p.IconURL,
-		Name:           p.Name,
-		Description:    p.Description,
-		Oauth2Config:   oauth2Config,
+		ID:              monoflake.IDFromBase62(p.ID).Int64(),
+		ApiType:         entity.StringToApiType(p.ApiType),
+		VisibilityType:  entity.StringToVisibilityType(p.VisibilityType),
+		BaseURL:         p.BaseURL,
+		DocumentURL:     p.DocumentURL,
+		IconURL:         p.IconURL,
+		Name:            p.Name,
+		Description:     p.Description,
+		GraphQLSchema:   p.GraphQLSchema,
+		Oauth2Config:    oauth2Config,
+		AuthConfig:      FromProviderAuthConfigViewToProviderAuthConfigEntity(p.AuthConfig),
+		SignerConfig:    FromProviderSignerConfigViewToProviderSignerConfigEntity(p.SignerConfig),
+		MockConfig:      mockConfig,
+		LimitsConfig:    FromProviderLimitsConfigViewToProviderLimitsConfigEntity(p.LimitsConfig),
+		EgressConfig:    FromProviderEgressConfigViewToProviderEgressConfigEntity(p.EgressConfig),
+		TransportConfig: FromProviderTransportConfigViewToProviderTransportConfigEntity(p.TransportConfig),
+
+		GRPCConfig:        grpcConfig,
+		GRPCDescriptorSet: p.GRPCDescriptorSet,
+	}
+}
+
+func FromProviderLimitsConfigViewToProviderLimitsConfigEntity(l *view.ProviderLimitsConfig) *entity.ProviderLimitsConfig {
+	if l == nil {
+		return nil
+	}
+	return &entity.ProviderLimitsConfig{
+		MaxConcurrency:         l.MaxConcurrency,
+		RatePerSecond:          l.RatePerSecond,
+		Burst:                  l.Burst,
+		MaxWaitSeconds:         l.MaxWaitSeconds,
+		BreakerFailures:        l.BreakerFailures,
+		BreakerCooldownSeconds: l.BreakerCooldownSeconds,
+	}
+}
+
+func FromProviderLimitsConfigEntityToProviderLimitsConfigView(l *entity.ProviderLimitsConfig) *view.ProviderLimitsConfig {
+	if l == nil {
+		return nil
+	}
+	return &view.ProviderLimitsConfig{
+		MaxConcurrency:         l.MaxConcurrency,
+		RatePerSecond:          l.RatePerSecond,
+		Burst:                  l.Burst,
+		MaxWaitSeconds:         l.MaxWaitSeconds,
+		BreakerFailures:        l.BreakerFailures,
+		BreakerCooldownSeconds: l.BreakerCooldownSeconds,
+	}
+}
+
+func FromProviderEgressConfigViewToProviderEgressConfigEntity(e *view.ProviderEgressConfig) *entity.ProviderEgressConfig {
+	if e == nil {
+		return nil
+	}
+	return &entity.ProviderEgressConfig{
+		AllowedCIDRs: e.AllowedCIDRs,
+		AllowedHosts: e.AllowedHosts,
+		AllowedPorts: e.AllowedPorts,
+	}
+}
+
+func FromProviderEgressConfigEntityToProviderEgressConfigView(e *entity.ProviderEgressConfig) *view.ProviderEgressConfig {
+	if e == nil {
+		return nil
+	}
+	return &view.ProviderEgressConfig{
+		AllowedCIDRs: e.AllowedCIDRs,
+		AllowedHosts: e.AllowedHosts,
+		AllowedPorts: e.AllowedPorts,
+	}
+}
+
+func FromProviderTransportConfigViewToProviderTransportConfigEntity(t *view.ProviderTransportConfig) *entity.ProviderTransportConfig {
+	if t == nil {
+		return nil
+	}
+	return &entity.ProviderTransportConfig{
+		CACert:             t.CACert,
+		ClientCert:         t.ClientCert,
+		ClientKey:          t.ClientKey,
+		MinTLSVersion:      t.MinTLSVersion,
+		InsecureSkipVerify: t.InsecureSkipVerify,
+		PinnedSHA256:       t.PinnedSHA256,
+		ProxyURL:           t.ProxyURL,
+	}
+}
+
+func FromProviderTransportConfigEntityToProviderTransportConfigView(t *entity.ProviderTransportConfig) *view.ProviderTransportConfig {
+	if t == nil {
+		return nil
+	}
+	return &view.ProviderTransportConfig{
+		CACert:             t.CACert,
+		ClientCert:         t.ClientCert,
+		ClientKey:          t.ClientKey,
+		MinTLSVersion:      t.MinTLSVersion,
+		InsecureSkipVerify: t.InsecureSkipVerify,
+		PinnedSHA256:       t.PinnedSHA256,
+		ProxyURL:           t.ProxyURL,
+	}
+}
+
+func FromProviderAuthConfigViewToProviderAuthConfigEntity(a *view.ProviderAuthConfig) *entity.ProviderAuthConfig {
+	if a == nil {
+		return nil
+	}
+	return &entity.ProviderAuthConfig{
+		Type:     entity.StringToAuthType(a.Type),
+		In:       entity.StringToAuthLocation(a.In),
+		Name:     a.Name,
+		Value:    a.Value,
+		Username: a.Username,
+		Password: a.Password,
+	}
+}
+
+func FromProviderAuthConfigEntityToProviderAuthConfigView(a *entity.ProviderAuthConfig) *view.ProviderAuthConfig {
+	if a == nil {
+		return nil
+	}
+	return &view.ProviderAuthConfig{
+		Type:     a.Type.String(),
+		In:       a.In.String(),
+		Name:     a.Name,
+		Value:    maskSecret(a.Value),
+		Username: a.Username,
+		Password: maskSecret(a.Password),
+	}
+}
+
+func FromProviderSignerConfigViewToProviderSignerConfigEntity(s *view.ProviderSignerConfig) *entity.ProviderSignerConfig {
+	if s == nil {
+		return nil
+	}
+	return &entity.ProviderSignerConfig{
+		Type:            entity.StringToSignerType(s.Type),
+		Region:          s.Region,
+		Service:         s.Service,
+		AccessKeyID:     s.AccessKeyID,
+		SecretAccessKey: s.SecretAccessKey,
+		SessionToken:    s.SessionToken,
+		Algorithm:       strings.ToUpper(s.Algorithm),
+		Secret:          s.Secret,
+		Header:          s.Header,
+		SignaturePrefix: s.SignaturePrefix,
+		Encoding:        strings.ToUpper(s.Encoding),
+		CanonicalString: s.CanonicalString,
+		TimestampHeader: s.TimestampHeader,
+		ClientCert:      s.ClientCert,
+		ClientKey:       s.ClientKey,
+		CACert:          s.CACert,
+	}
+}
+
+func FromProviderSignerConfigEntityToProviderSignerConfigView(s *entity.ProviderSignerConfig) *view.ProviderSignerConfig {
+	if s == nil {
+		return nil
+	}
+	return &view.ProviderSignerConfig{
+		Type:            s.Type.String(),
+		Region:          s.Region,
+		Service:         s.Service,
+		AccessKeyID:     s.AccessKeyID,
+		SecretAccessKey: maskSecret(s.SecretAccessKey),
+		SessionToken:    maskSecret(s.SessionToken),
+		Algorithm:       s.Algorithm,
+		Secret:          maskSecret(s.Secret),
+		Header:          s.Header,
+		SignaturePrefix: s.SignaturePrefix,
+		Encoding:        s.Encoding,
+		CanonicalString: s.CanonicalString,
+		TimestampHeader: s.TimestampHeader,
+		ClientCert:      s.ClientCert,
+		ClientKey:       maskSecret(s.ClientKey),
+		CACert:          s.CACert,
 	}
 }
 
@@ -143,29 +334,111 @@ func FromProviderEntityToProviderView(p entity.Provider) view.Provider {
 	}
 
 	var oauth2Config *view.ProviderOauth2Config
-	if p.Oauth2Config.ClientID != "" && p.Oauth2Config.AuthURL != "" && p.Oauth2Config.TokenURL != "" {
+	if p.Oauth2Config.TokenURL != "" && (p.Oauth2Config.ClientID != "" || p.Oauth2Config.Issuer != "") {
+		var clientSecret string
+		if len(p.Oauth2Config.ClientSecretEncrypted) > 0 {
+			clientSecret = "***"
+		}
+		password := maskSecret(p.Oauth2Config.Password)
+		if len(p.Oauth2Config.PasswordEncrypted) > 0 {
+			password = "***"
+		}
+		privateKey := maskSecret(p.Oauth2Config.PrivateKey)
+		if len(p.Oauth2Config.PrivateKeyEncrypted) > 0 {
+			privateKey = "***"
+		}
 		oauth2Config = &view.ProviderOauth2Config{
+			GrantType:    p.Oauth2Config.GrantType.String(),
 			ClientID:     p.Oauth2Config.ClientID,
-			ClientSecret: "***",
+			ClientSecret: clientSecret,
 			AuthURL:      p.Oauth2Config.AuthURL,
 			TokenURL:     p.Oauth2Config.TokenURL,
+			Audience:     p.Oauth2Config.Audience,
+			Username:     p.Oauth2Config.Username,
+			Password:     password,
+			Issuer:       p.Oauth2Config.Issuer,
+			Subject:      p.Oauth2Config.Subject,
+			PrivateKey:   privateKey,
+		}
+	}
+
+	var grpcConfig *view.ProviderGRPCConfig
+	if p.GRPCConfig != nil {
+		grpcConfig = &view.ProviderGRPCConfig{
+			Plaintext:          p.GRPCConfig.Plaintext,
+			InsecureSkipVerify: p.GRPCConfig.InsecureSkipVerify,
+		}
+	}
+
+	var mockConfig *view.ProviderMockConfig
+	if p.MockConfig != nil {
+		mockConfig = &view.ProviderMockConfig{
+			Enabled: p.MockConfig.Enabled,
 		}
 	}
 
 	return view.Provider{
-		ID:             monoflake.ID(p.ID).String(),
-		CreatedAt:      FromTimeToRFC3339String(p.CreatedAt),
-		UpdatedAt:      FromTimeToRFC3339String(p.UpdatedAt),
-		Version:        p.Version,
-		ApiType:        p.ApiType.String(),
-		VisibilityType: p.VisibilityType.String(),
-		BaseURL:        p.BaseURL,
-		DocumentURL:    p.DocumentURL,
-		IconURL:        p.IconURL,
-		SecretPrefix:   p.SecretPrefix,
-		Name:           p.Name,
-		Description:    p.Description,
-		Tools:          tools,
-		Oauth2Config:   oauth2Config,
+		ID:              monoflake.ID(p.ID).String(),
+		CreatedAt:       FromTimeToRFC3339String(p.CreatedAt),
+		UpdatedAt:       FromTimeToRFC3339String(p.UpdatedAt),
+		Version:         p.Version,
+		ApiType:         p.ApiType.String(),
+		VisibilityType:  p.VisibilityType.String(),
+		BaseURL:         p.BaseURL,
+		DocumentURL:     p.DocumentURL,
+		IconURL:         p.IconURL,
+		SecretPrefix:    p.SecretPrefix,
+		Name:            p.Name,
+		Description:     p.Description,
+		GraphQLSchema:   p.GraphQLSchema,
+		Tools:           tools,
+		Oauth2Config:    oauth2Config,
+		AuthConfig:      FromProviderAuthConfigEntityToProviderAuthConfigView(p.AuthConfig),
+		SignerConfig:    FromProviderSignerConfigEntityToProviderSignerConfigView(p.SignerConfig),
+		MockConfig:      mockConfig,
+		LimitsConfig:    FromProviderLimitsConfigEntityToProviderLimitsConfigView(p.LimitsConfig),
+		EgressConfig:    FromProviderEgressConfigEntityToProviderEgressConfigView(p.EgressConfig),
+		TransportConfig: FromProviderTransportConfigEntityToProviderTransportConfigView(p.TransportConfig),
+
+		GRPCConfig:        grpcConfig,
+		GRPCDescriptorSet: p.GRPCDescriptorSet,
+	}
+}
+
+func FromHTTPRequestToImportProviderRequestEntity(c *fiber.Ctx) *entity.ImportProviderRequest {
+	format := entity.StringToImportFormat(c.Params("format"))
+	if format == entity.ImportFormatInvalid {
+		return nil
+	}
+
+	var payload view.ImportProviderRequest
+	if err := json.Unmarshal(c.BodyRaw(), &payload); err != nil {
+		return nil
+	}
+
+	return &entity.ImportProviderRequest{
+		Format:         format,
+		Document:       payload.Document,
+		Name:           payload.Name,
+		Description:    payload.Description,
+		BaseURL:        payload.BaseURL,
+		VisibilityType: entity.StringToVisibilityType(payload.VisibilityType),
+	}
+}
+
+func FromImportProviderResponseEntityToHTTPResponse(rs *entity.ImportProviderResponse) []byte {
+	payload, _ := json.Marshal(view.ImportProviderResponse{
+		Provider: FromProviderEntityToProviderView(rs.Provider),
+		Skipped:  FromImportSkippedOperationEntitiesToViews(rs.Skipped),
+	})
+	return payload
+}
+
+// maskSecret hides the literal secrets, the updates keep the stored secret on
+// the mask. The variable references are kept to show what to set.
+func maskSecret(s string) string {
+	if s == "" || entity.IsVariableReference(s) {
+		return s
 	}
+	return "***"
 }
@@ -0,0 +1,40 @@
+package api
+
+import (
+	"encoding/json"
+
+	"github.com/gofiber/fiber/v2"
+	entity "github.com/hasmcp/hasmcp-ce/backend/internal/data/entity/crud"
+	view "github.com/hasmcp/hasmcp-ce/backend/internal/data/view/api"
+	"github.com/mustafaturan/monoflake"
+)
+
+func FromHTTPRequestToGetProviderBreakerRequestEntity(c *fiber.Ctx) *entity.GetProviderBreakerRequest {
+	providerIDParam := c.Params("id")
+	if providerIDParam == "" {
+		return nil
+	}
+
+	return &entity.GetProviderBreakerRequest{
+		ProviderID: monoflake.IDFromBase62(providerIDParam).Int64(),
+	}
+}
+
+func FromGetProviderBreakerResponseEntityToHTTPResponse(rs *entity.GetProviderBreakerResponse) []byte {
+	payload, _ := json.Marshal(view.GetProviderBreakerResponse{
+		Breaker: FromProviderBreakerEntityToProviderBreakerView(rs.Breaker),
+	})
+	return payload
+}
+
+func FromProviderBreakerEntityToProviderBreakerView(b entity.ProviderBreaker) view.ProviderBreaker {
+	return view.ProviderBreaker{
+		ProviderID:          monoflake.ID(b.ProviderID).String(),
+		State:               b.State.String(),
+		ConsecutiveFailures: b.ConsecutiveFailures,
+		OpenedAt:            FromTimeToRFC3339String(b.OpenedAt),
+		RetryAt:             FromTimeToRFC3339String(b.RetryAt),
+		InFlight:            b.InFlight,
+		ThrottledUntil:      FromTimeToRFC3339String(b.ThrottledUntil),
+	}
+}
@@ -2,6 +2,8 @@ package api
 
 import (
 	"encoding/json"
+	"strconv"
+	"strings"
 
 	"github.com/gofiber/fiber/v2"
 	entity "github.com/hasmcp/hasmcp-ce/backend/internal/data/entity/crud"
@@ -63,6 +65,12 @@ func FromProviderToolViewToProviderToolEntity(e view.ProviderTool) entity.Provid
 		ResBodyJSONSchema:   e.ResBodyJSONSchema,
 		Headers:             headers,
 		Oauth2Scopes:        e.Oauth2Scopes,
+		Operation:           e.Operation,
+		Tags:                e.Tags,
+		MockExamples:        FromToolMockExampleViewsToToolMockExampleEntities(e.MockExamples),
+		AsyncConfig:         FromToolAsyncConfigViewToToolAsyncConfigEntity(e.AsyncConfig),
+		PaginationConfig:    FromToolPaginationConfigViewToToolPaginationConfigEntity(e.PaginationConfig),
+		CacheConfig:         FromToolCacheConfigViewToToolCacheConfigEntity(e.CacheConfig),
 	}
 }
 
@@ -149,6 +157,154 @@ func FromProviderToolEntityToProviderToolView(e entity.ProviderTool) view.Provid
 		ResBodyJSONSchema:   e.ResBodyJSONSchema,
 		Headers:             headers,
 		Oauth2Scopes:        e.Oauth2Scopes,
+		Operation:           e.Operation,
+		Tags:                e.Tags,
+		MockExamples:        FromToolMockExampleEntitiesToToolMockExampleViews(e.MockExamples),
+		AsyncConfig:         FromToolAsyncConfigEntityToToolAsyncConfigView(e.AsyncConfig),
+		PaginationConfig:    FromToolPaginationConfigEntityToToolPaginationConfigView(e.PaginationConfig),
+		CacheConfig:         FromToolCacheConfigEntityToToolCacheConfigView(e.CacheConfig),
+	}
+}
+
+func FromToolMockExampleViewsToToolMockExampleEntities(vs []view.ToolMockExample) []entity.ToolMockExample {
+	if vs == nil {
+		return nil
+	}
+	examples := make([]entity.ToolMockExample, len(vs))
+	for i, v := range vs {
+		rules := make([]entity.ToolMockRule, len(v.Rules))
+		for j, r := range v.Rules {
+			rules[j] = entity.ToolMockRule{
+				In:      entity.StringToToolArgumentLocation(r.In),
+				Name:    r.Name,
+				Value:   r.Value,
+				Pattern: r.Pattern,
+			}
+		}
+		examples[i] = entity.ToolMockExample{
+			Name:     v.Name,
+			Rules:    rules,
+			Response: v.Response,
+			IsError:  v.IsError,
+		}
+	}
+	return examples
+}
+
+func FromToolMockExampleEntitiesToToolMockExampleViews(es []entity.ToolMockExample) []view.ToolMockExample {
+	if es == nil {
+		return nil
+	}
+	examples := make([]view.ToolMockExample, len(es))
+	for i, e := range es {
+		rules := make([]view.ToolMockRule, len(e.Rules))
+		for j, r := range e.Rules {
+			rules[j] = view.ToolMockRule{
+				In:      r.In.String(),
+				Name:    r.Name,
+				Value:   r.Value,
+				Pattern: r.Pattern,
+			}
+		}
+		examples[i] = view.ToolMockExample{
+			Name:     e.Name,
+			Rules:    rules,
+			Response: e.Response,
+			IsError:  e.IsError,
+		}
+	}
+	return examples
+}
+
+func FromToolAsyncConfigViewToToolAsyncConfigEntity(v *view.ToolAsyncConfig) *entity.ToolAsyncConfig {
+	if v == nil {
+		return nil
+	}
+	cfg := &entity.ToolAsyncConfig{
+		StatusURLPath:   v.StatusURLPath,
+		ResultURLPath:   v.ResultURLPath,
+		IntervalSeconds: v.IntervalSeconds,
+		MaxWaitSeconds:  v.MaxWaitSeconds,
+	}
+	if v.Success != nil {
+		cfg.Success = &entity.ToolAsyncPredicate{Path: v.Success.Path, Values: v.Success.Values}
+	}
+	if v.Failure != nil {
+		cfg.Failure = &entity.ToolAsyncPredicate{Path: v.Failure.Path, Values: v.Failure.Values}
+	}
+	return cfg
+}
+
+func FromToolAsyncConfigEntityToToolAsyncConfigView(e *entity.ToolAsyncConfig) *view.ToolAsyncConfig {
+	if e == nil {
+		return nil
+	}
+	cfg := &view.ToolAsyncConfig{
+		StatusURLPath:   e.StatusURLPath,
+		ResultURLPath:   e.ResultURLPath,
+		IntervalSeconds: e.IntervalSeconds,
+		MaxWaitSeconds:  e.MaxWaitSeconds,
+	}
+	if e.Success != nil {
+		cfg.Success = &view.ToolAsyncPredicate{Path: e.Success.Path, Values: e.Success.Values}
+	}
+	if e.Failure != nil {
+		cfg.Failure = &view.ToolAsyncPredicate{Path: e.Failure.Path, Values: e.Failure.Values}
+	}
+	return cfg
+}
+
+func FromToolPaginationConfigViewToToolPaginationConfigEntity(v *view.ToolPaginationConfig) *entity.ToolPaginationConfig {
+	if v == nil {
+		return nil
+	}
+	return &entity.ToolPaginationConfig{
+		Type:       entity.StringToPaginationType(v.Type),
+		ItemsPath:  v.ItemsPath,
+		CursorPath: v.CursorPath,
+		Param:      v.Param,
+		LimitParam: v.LimitParam,
+		PageSize:   v.PageSize,
+		StartPage:  v.StartPage,
+		MaxPages:   v.MaxPages,
+		MaxItems:   v.MaxItems,
+	}
+}
+
+func FromToolPaginationConfigEntityToToolPaginationConfigView(e *entity.ToolPaginationConfig) *view.ToolPaginationConfig {
+	if e == nil {
+		return nil
+	}
+	return &view.ToolPaginationConfig{
+		Type:       e.Type.String(),
+		ItemsPath:  e.ItemsPath,
+		CursorPath: e.CursorPath,
+		Param:      e.Param,
+		LimitParam: e.LimitParam,
+		PageSize:   e.PageSize,
+		StartPage:  e.StartPage,
+		MaxPages:   e.MaxPages,
+		MaxItems:   e.MaxItems,
+	}
+}
+
+func FromToolCacheConfigViewToToolCacheConfigEntity(v *view.ToolCacheConfig) *entity.ToolCacheConfig {
+	if v == nil {
+		return nil
+	}
+	return &entity.ToolCacheConfig{
+		TTLSeconds:  v.TTLSeconds,
+		VaryHeaders: v.VaryHeaders,
+	}
+}
+
+func FromToolCacheConfigEntityToToolCacheConfigView(e *entity.ToolCacheConfig) *view.ToolCacheConfig {
+	if e == nil {
+		return nil
+	}
+	return &view.ToolCacheConfig{
+		TTLSeconds:  e.TTLSeconds,
+		VaryHeaders: e.VaryHeaders,
 	}
 }
 
@@ -189,3 +345,184 @@ func FromHTTPRequestToDeleteProviderToolRequestEntity(c *fiber.Ctx) *entity.Dele
 		ToolID:     monoflake.IDFromBase62(toolIDParam).Int64(),
 	}
 }
+
+func FromHTTPRequestToGenerateProviderGraphQLToolsRequestEntity(c *fiber.Ctx) *entity.GenerateProviderGraphQLToolsRequest {
+	providerIDParam := c.Params("id")
+	if providerIDParam == "" {
+		return nil
+	}
+
+	var payload view.GenerateProviderGraphQLToolsRequest
+	if len(c.BodyRaw()) > 0 {
+		if err := json.Unmarshal(c.BodyRaw(), &payload); err != nil {
+			return nil
+		}
+	}
+
+	headers := make([]entity.ToolHeader, len(payload.Headers))
+	for i, h := range payload.Headers {
+		headers[i] = entity.ToolHeader{
+			Key:   h.Key,
+			Value: h.Value,
+		}
+	}
+
+	return &entity.GenerateProviderGraphQLToolsRequest{
+		ProviderID: monoflake.IDFromBase62(providerIDParam).Int64(),
+		Depth:      payload.Depth,
+		Refresh:    payload.Refresh,
+		Headers:    headers,
+	}
+}
+
+func FromGenerateProviderGraphQLToolsResponseEntityToHTTPResponse(rs *entity.GenerateProviderGraphQLToolsResponse) []byte {
+	payload, _ := json.Marshal(view.GenerateProviderGraphQLToolsResponse{
+		Tools:   FromProviderToolEntitiesToProviderToolViews(rs.Tools),
+		Skipped: FromImportSkippedOperationEntitiesToViews(rs.Skipped),
+	})
+
+	return payload
+}
+
+func FromImportSkippedOperationEntitiesToViews(es []entity.ImportSkippedOperation) []view.ImportSkippedOperation {
+	skipped := make([]view.ImportSkippedOperation, len(es))
+	for i, s := range es {
+		skipped[i] = view.ImportSkippedOperation{
+			Method: s.Method,
+			Path:   s.Path,
+			Reason: s.Reason,
+		}
+	}
+	return skipped
+}
+
+func FromHTTPRequestToImportProviderGRPCToolsRequestEntity(c *fiber.Ctx) *entity.ImportProviderGRPCToolsRequest {
+	providerIDParam := c.Params("id")
+	if providerIDParam == "" {
+		return nil
+	}
+
+	var payload view.ImportProviderGRPCToolsRequest
+	if len(c.BodyRaw()) > 0 {
+		if err := json.Unmarshal(c.BodyRaw(), &payload); err != nil {
+			return nil
+		}
+	}
+
+	headers := make([]entity.ToolHeader, len(payload.Headers))
+	for i, h := range payload.Headers {
+		headers[i] = entity.ToolHeader{
+			Key:   h.Key,
+			Value: h.Value,
+		}
+	}
+
+	return &entity.ImportProviderGRPCToolsRequest{
+		ProviderID:    monoflake.IDFromBase62(providerIDParam).Int64(),
+		Refresh:       payload.Refresh,
+		DescriptorSet: payload.DescriptorSet,
+		Headers:       headers,
+	}
+}
+
+func FromImportProviderGRPCToolsResponseEntityToHTTPResponse(rs *entity.ImportProviderGRPCToolsResponse) []byte {
+	payload, _ := json.Marshal(view.ImportProviderGRPCToolsResponse{
+		Tools:   FromProviderToolEntitiesToProviderToolViews(rs.Tools),
+		Skipped: FromImportSkippedOperationEntitiesToViews(rs.Skipped),
+	})
+
+	return payload
+}
+
+func FromHTTPRequestToImportProviderMCPToolsRequestEntity(c *fiber.Ctx) *entity.ImportProviderMCPToolsRequest {
+	providerIDParam := c.Params("id")
+	if providerIDParam == "" {
+		return nil
+	}
+
+	var payload view.ImportProviderMCPToolsRequest
+	if len(c.BodyRaw()) > 0 {
+		if err := json.Unmarshal(c.BodyRaw(), &payload); err != nil {
+			return nil
+		}
+	}
+
+	headers := make([]entity.ToolHeader, len(payload.Headers))
+	for i, h := range payload.Headers {
+		headers[i] = entity.ToolHeader{
+			Key:   h.Key,
+			Value: h.Value,
+		}
+	}
+
+	return &entity.ImportProviderMCPToolsRequest{
+		ProviderID: monoflake.IDFromBase62(providerIDParam).Int64(),
+		Headers:    headers,
+	}
+}
+
+func FromImportProviderMCPToolsResponseEntityToHTTPResponse(rs *entity.ImportProviderMCPToolsResponse) []byte {
+	payload, _ := json.Marshal(view.ImportProviderMCPToolsResponse{
+		Tools:   FromProviderToolEntitiesToProviderToolViews(rs.Tools),
+		Skipped: FromImportSkippedOperationEntitiesToViews(rs.Skipped),
+	})
+
+	return payload
+}
+
+func FromHTTPRequestToImportProviderToolsRequestEntity(c *fiber.Ctx) *entity.ImportProviderToolsRequest {
+	providerIDParam := c.Params("id")
+	if providerIDParam == "" {
+		return nil
+	}
+
+	rq := &entity.ImportProviderToolsRequest{
+		ProviderID: monoflake.IDFromBase62(providerIDParam).Int64(),
+	}
+
+	// YAML documents can be posted as they are with the options on the query
+	if strings.Contains(c.Get(fiber.HeaderContentType), "yaml") {
+		version, _ := strconv.ParseInt(c.Query("version"), 10, 32)
+		rq.Spec = c.BodyRaw()
+		rq.Apply = c.QueryBool("apply")
+		rq.Version = int32(version)
+		return rq
+	}
+
+	var payload view.ImportProviderToolsRequest
+	if err := json.Unmarshal(c.BodyRaw(), &payload); err != nil {
+		return nil
+	}
+
+	rq.Spec = payload.Spec
+	var text string
+	if err := json.Unmarshal(payload.Spec, &text); err == nil {
+		rq.Spec = []byte(text)
+	}
+	rq.URL = payload.URL
+	rq.Apply = payload.Apply
+	rq.Version = payload.Version
+	return rq
+}
+
+func FromImportProviderToolsResponseEntityToHTTPResponse(rs *entity.ImportProviderToolsResponse) []byte {
+	changed := make([]view.ProviderToolChange, len(rs.Changed))
+	for i, ch := range rs.Changed {
+		changed[i] = view.ProviderToolChange{
+			Tool:   FromProviderToolEntityToProviderToolView(ch.Tool),
+			Fields: ch.Fields,
+		}
+	}
+
+	payload, _ := json.Marshal(view.ImportProviderToolsResponse{
+		Version:    rs.Version,
+		Applied:    rs.Applied,
+		AuthConfig: FromProviderAuthConfigEntityToProviderAuthConfigView(rs.AuthConfig),
+		Added:      FromProviderToolEntitiesToProviderToolViews(rs.Added),
+		Changed:    changed,
+		Removed:    FromProviderToolEntitiesToProviderToolViews(rs.Removed),
+		Skipped:    FromImportSkippedOperationEntitiesToViews(rs.Skipped),
+	})
+
+	return payload
+}
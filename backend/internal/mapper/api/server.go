@@ -52,6 +52,9 @@ func FromServerViewToServerEntity(s view.Server) entity.Server {
 	return entity.Server{
 		ID:                         monoflake.IDFromBase62(s.ID).Int64(),
 		RequestHeadersProxyEnabled: s.RequestHeadersProxyEnabled,
+		MockEnabled:                s.MockEnabled,
+		TrafficMode:                entity.StringToTrafficMode(s.TrafficMode),
+		InputSchemaMode:            entity.StringToInputSchemaMode(s.InputSchemaMode),
 		Name:                       s.Name,
 		Instructions:               s.Instructions,
 		Version:                    s.Version,
@@ -82,6 +85,9 @@ func FromServerEntityToServerView(s entity.Server) view.Server {
 		CreatedAt:                  FromTimeToRFC3339String(s.CreatedAt),
 		UpdatedAt:                  FromTimeToRFC3339String(s.UpdatedAt),
 		RequestHeadersProxyEnabled: s.RequestHeadersProxyEnabled,
+		MockEnabled:                s.MockEnabled,
+		TrafficMode:                s.TrafficMode.String(),
+		InputSchemaMode:            s.InputSchemaMode.String(),
 		Name:                       s.Name,
 		Instructions:               s.Instructions,
 		Version:                    s.Version,
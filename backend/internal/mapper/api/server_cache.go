@@ -0,0 +1,24 @@
+package api
+
+import (
+	"github.com/gofiber/fiber/v2"
+	entity "github.com/hasmcp/hasmcp-ce/backend/internal/data/entity/crud"
+	"github.com/mustafaturan/monoflake"
+)
+
+func FromHTTPRequestToPurgeServerCacheRequestEntity(c *fiber.Ctx) *entity.PurgeServerCacheRequest {
+	serverIDParam := c.Params("id")
+	if serverIDParam == "" {
+		return nil
+	}
+
+	var toolID int64
+	if toolIDParam := c.Params("toolID"); toolIDParam != "" {
+		toolID = monoflake.IDFromBase62(toolIDParam).Int64()
+	}
+
+	return &entity.PurgeServerCacheRequest{
+		ServerID: monoflake.IDFromBase62(serverIDParam).Int64(),
+		ToolID:   toolID,
+	}
+}
@@ -0,0 +1,156 @@
+package api
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	entity "github.com/hasmcp/hasmcp-ce/backend/internal/data/entity/crud"
+	view "github.com/hasmcp/hasmcp-ce/backend/internal/data/view/api"
+	"github.com/mustafaturan/monoflake"
+)
+
+func FromHTTPRequestToListServerRecordingsRequestEntity(c *fiber.Ctx) *entity.ListServerRecordingsRequest {
+	serverIDParam := c.Params("id")
+	if serverIDParam == "" {
+		return nil
+	}
+
+	return &entity.ListServerRecordingsRequest{
+		ServerID: monoflake.IDFromBase62(serverIDParam).Int64(),
+	}
+}
+
+func FromHTTPRequestToImportServerRecordingsRequestEntity(c *fiber.Ctx) *entity.ImportServerRecordingsRequest {
+	serverIDParam := c.Params("id")
+	if serverIDParam == "" {
+		return nil
+	}
+
+	var payload view.ImportServerRecordingsRequest
+	if err := json.Unmarshal(c.BodyRaw(), &payload); err != nil {
+		return nil
+	}
+
+	return &entity.ImportServerRecordingsRequest{
+		ServerID:   monoflake.IDFromBase62(serverIDParam).Int64(),
+		Recordings: FromServerRecordingViewsToServerRecordingEntities(payload.Recordings),
+	}
+}
+
+func FromHTTPRequestToDeleteServerRecordingsRequestEntity(c *fiber.Ctx) *entity.DeleteServerRecordingsRequest {
+	serverIDParam := c.Params("id")
+	if serverIDParam == "" {
+		return nil
+	}
+
+	return &entity.DeleteServerRecordingsRequest{
+		ServerID: monoflake.IDFromBase62(serverIDParam).Int64(),
+	}
+}
+
+func FromServerRecordingViewsToServerRecordingEntities(rs []view.ServerRecording) []entity.ServerRecording {
+	recordings := make([]entity.ServerRecording, len(rs))
+	for i, r := range rs {
+		recordings[i] = FromServerRecordingViewToServerRecordingEntity(r)
+	}
+	return recordings
+}
+
+func FromServerRecordingViewToServerRecordingEntity(r view.ServerRecording) entity.ServerRecording {
+	createdAt, _ := time.Parse(time.RFC3339, r.CreatedAt)
+	interactions := make([]entity.RecordedInteraction, len(r.Interactions))
+	for i, in := range r.Interactions {
+		interactions[i] = entity.RecordedInteraction{
+			Request: entity.RecordedRequest{
+				Method:  in.Request.Method,
+				URL:     in.Request.URL,
+				Headers: in.Request.Headers,
+				Body:    in.Request.Body,
+			},
+			Response: entity.RecordedResponse{
+				StatusCode: in.Response.StatusCode,
+				Headers:    in.Response.Headers,
+				Body:       in.Response.Body,
+			},
+		}
+	}
+
+	return entity.ServerRecording{
+		ID:           monoflake.IDFromBase62(r.ID).Int64(),
+		CreatedAt:    createdAt,
+		ServerID:     monoflake.IDFromBase62(r.ServerID).Int64(),
+		ToolID:       monoflake.IDFromBase62(r.ToolID).Int64(),
+		ToolName:     r.ToolName,
+		Arguments:    r.Arguments,
+		Result:       r.Result,
+		IsError:      r.IsError,
+		Interactions: interactions,
+	}
+}
+
+func FromServerRecordingEntitiesToServerRecordingViews(rs []entity.ServerRecording) []view.ServerRecording {
+	recordings := make([]view.ServerRecording, len(rs))
+	for i, r := range rs {
+		recordings[i] = FromServerRecordingEntityToServerRecordingView(r)
+	}
+	return recordings
+}
+
+func FromServerRecordingEntityToServerRecordingView(r entity.ServerRecording) view.ServerRecording {
+	interactions := make([]view.RecordedInteraction, len(r.Interactions))
+	for i, in := range r.Interactions {
+		interactions[i] = view.RecordedInteraction{
+			Request: view.RecordedRequest{
+				Method:  in.Request.Method,
+				URL:     in.Request.URL,
+				Headers: in.Request.Headers,
+				Body:    in.Request.Body,
+			},
+			Response: view.RecordedResponse{
+				StatusCode: in.Response.StatusCode,
+				Headers:    in.Response.Headers,
+				Body:       in.Response.Body,
+			},
+		}
+	}
+
+	return view.ServerRecording{
+		ID:           monoflake.ID(r.ID).String(),
+		CreatedAt:    FromTimeToRFC3339String(r.CreatedAt),
+		ServerID:     monoflake.ID(r.ServerID).String(),
+		ToolID:       monoflake.ID(r.ToolID).String(),
+		ToolName:     r.ToolName,
+		Arguments:    rawJSONOf(r.Arguments),
+		Result:       rawJSONOf(r.Result),
+		IsError:      r.IsError,
+		Interactions: interactions,
+	}
+}
+
+func FromListServerRecordingsResponseEntityToHTTPResponse(rs *entity.ListServerRecordingsResponse) []byte {
+	data := view.ListServerRecordingsResponse{
+		Recordings: FromServerRecordingEntitiesToServerRecordingViews(rs.Recordings),
+	}
+
+	payload, _ := json.Marshal(data)
+	return payload
+}
+
+func FromImportServerRecordingsResponseEntityToHTTPResponse(rs *entity.ImportServerRecordingsResponse) []byte {
+	data := view.ImportServerRecordingsResponse{
+		Recordings: FromServerRecordingEntitiesToServerRecordingViews(rs.Recordings),
+	}
+
+	payload, _ := json.Marshal(data)
+	return payload
+}
+
+// rawJSONOf drops the values which are not JSON so that the export stays
+// valid JSON
+func rawJSONOf(b []byte) json.RawMessage {
+	if len(b) == 0 || !json.Valid(b) {
+		return nil
+	}
+	return b
+}
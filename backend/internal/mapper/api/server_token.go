@@ -30,6 +30,7 @@ func FromServerTokenViewToServerTokenEntity(t view.ServerToken) entity.ServerTok
 		ServerID:  monoflake.IDFromBase62(t.ServerID).Int64(),
 		ExpiresAt: expiresAt,
 		Scope:     t.Scope,
+		Subject:   t.Subject,
 	}
 }
 
@@ -48,6 +49,7 @@ func FromServerTokenEntityToServerTokenView(t entity.ServerToken) view.ServerTok
 		CreatedAt: FromTimeToRFC3339String(t.CreatedAt),
 		ExpiresAt: FromTimeToRFC3339String(t.ExpiresAt),
 		Scope:     t.Scope,
+		Subject:   t.Subject,
 		Value:     string(t.ActualValue),
 	}
 }
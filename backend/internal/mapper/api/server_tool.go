@@ -22,19 +22,95 @@ func FromHTTPRequestToCreateServerToolRequestEntity(c *fiber.Ctx) *entity.Create
 	}
 }
 
+func FromHTTPRequestToUpdateServerToolRequestEntity(c *fiber.Ctx) *entity.UpdateServerToolRequest {
+	var payload view.UpdateServerToolRequest
+	if err := json.Unmarshal(c.BodyRaw(), &payload); err != nil {
+		return nil
+	}
+	data := payload.Tool
+	data.ServerID = c.Params("id")
+	data.ToolID = c.Params("toolID")
+
+	return &entity.UpdateServerToolRequest{
+		Tool: FromServerToolViewToServerToolEntity(data),
+	}
+}
+
+func FromUpdateServerToolResponseEntityToHTTPResponse(rs *entity.UpdateServerToolResponse) []byte {
+	resp := view.UpdateServerToolResponse{
+		Tool: FromServerToolEntityToServerToolView(rs.Tool),
+	}
+	payload, _ := json.Marshal(resp)
+	return payload
+}
+
 func FromServerToolViewToServerToolEntity(e view.ServerTool) entity.ServerTool {
+	var override entity.ServerToolOverride
+	if e.Override != nil {
+		override = FromServerToolOverrideViewToServerToolOverrideEntity(*e.Override)
+	}
 	return entity.ServerTool{
 		ServerID:   monoflake.IDFromBase62(e.ServerID).Int64(),
 		ProviderID: monoflake.IDFromBase62(e.ProviderID).Int64(),
 		ToolID:     monoflake.IDFromBase62(e.ToolID).Int64(),
+		Override:   override,
 	}
 }
 
 func FromServerToolEntityToServerToolView(e entity.ServerTool) view.ServerTool {
+	var override *view.ServerToolOverride
+	if e.Override.Name != "" || e.Override.Title != "" || e.Override.Description != "" || len(e.Override.Arguments) > 0 ||
+		e.Override.InputSchemaMode != entity.InputSchemaModeInvalid {
+		o := FromServerToolOverrideEntityToServerToolOverrideView(e.Override)
+		override = &o
+	}
 	return view.ServerTool{
 		ServerID:   monoflake.ID(e.ServerID).String(),
 		ProviderID: monoflake.ID(e.ProviderID).String(),
 		ToolID:     monoflake.ID(e.ToolID).String(),
+		Override:   override,
+	}
+}
+
+func FromServerToolOverrideViewToServerToolOverrideEntity(o view.ServerToolOverride) entity.ServerToolOverride {
+	args := make([]entity.ServerToolArgumentOverride, len(o.Arguments))
+	for i, a := range o.Arguments {
+		args[i] = entity.ServerToolArgumentOverride{
+			In:      entity.StringToToolArgumentLocation(a.In),
+			Name:    a.Name,
+			Hidden:  a.Hidden,
+			Value:   a.Value,
+			Default: a.Default,
+			Enum:    a.Enum,
+		}
+	}
+	return entity.ServerToolOverride{
+		Name:            o.Name,
+		Title:           o.Title,
+		Description:     o.Description,
+		Arguments:       args,
+		InputSchemaMode: entity.StringToInputSchemaMode(o.InputSchemaMode),
+	}
+}
+
+func FromServerToolOverrideEntityToServerToolOverrideView(o entity.ServerToolOverride) view.ServerToolOverride {
+	args := make([]view.ServerToolArgumentOverride, len(o.Arguments))
+	for i, a := range o.Arguments {
+		args[i] = view.ServerToolArgumentOverride{
+			In:      a.In.String(),
+			Name:    a.Name,
+			Hidden:  a.Hidden,
+			Value:   a.Value,
+			Default: a.Default,
+			Enum:    a.Enum,
+		}
+	}
+	return view.ServerToolOverride{
+		Name:            o.Name,
+		Title:           o.Title,
+		Description:     o.Description,
+		Arguments:       args,
+		InputSchemaMode: o.InputSchemaMode.String(),
 	}
 }
 
@@ -62,6 +62,7 @@ func FromHTTPRequestToMcpCallSessionRequest(c *fiber.Ctx) *mcp.CallSessionReques
 		McpProtocolVersion: mcpProtocolVersion,
 		Request:            request,
 		Permissions:        authRes.Permissions,
+		Subject:            authRes.Subject,
 	}
 }
 
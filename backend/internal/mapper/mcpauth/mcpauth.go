@@ -0,0 +1,167 @@
+package mcpauth
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/hasmcp/hasmcp-ce/backend/internal/controller/mcpauth"
+	erre "github.com/hasmcp/hasmcp-ce/backend/internal/data/entity/err"
+	view "github.com/hasmcp/hasmcp-ce/backend/internal/data/view/mcpauth"
+	"github.com/mustafaturan/monoflake"
+)
+
+func FromHTTPRequestToProtectedResourceMetadataRequestEntity(c *fiber.Ctx) *mcpauth.ProtectedResourceMetadataRequest {
+	return &mcpauth.ProtectedResourceMetadataRequest{
+		ServerID: monoflake.IDFromBase62(c.Params("id")).Int64(),
+	}
+}
+
+func FromProtectedResourceMetadataResponseEntityToHTTPResponse(rs *mcpauth.ProtectedResourceMetadataResponse) []byte {
+	payload, _ := json.Marshal(view.ProtectedResourceMetadata{
+		Resource:               rs.Resource,
+		AuthorizationServers:   rs.AuthorizationServers,
+		ScopesSupported:        rs.ScopesSupported,
+		BearerMethodsSupported: rs.BearerMethodsSupported,
+		ResourceName:           rs.ResourceName,
+	})
+	return payload
+}
+
+func FromHTTPRequestToAuthorizationServerMetadataRequestEntity(c *fiber.Ctx) *mcpauth.AuthorizationServerMetadataRequest {
+	return &mcpauth.AuthorizationServerMetadataRequest{}
+}
+
+func FromAuthorizationServerMetadataResponseEntityToHTTPResponse(rs *mcpauth.AuthorizationServerMetadataResponse) []byte {
+	payload, _ := json.Marshal(view.AuthorizationServerMetadata{
+		Issuer:                            rs.Issuer,
+		AuthorizationEndpoint:             rs.AuthorizationEndpoint,
+		TokenEndpoint:                     rs.TokenEndpoint,
+		RegistrationEndpoint:              rs.RegistrationEndpoint,
+		ScopesSupported:                   rs.ScopesSupported,
+		ResponseTypesSupported:            rs.ResponseTypesSupported,
+		GrantTypesSupported:               rs.GrantTypesSupported,
+		TokenEndpointAuthMethodsSupported: rs.TokenEndpointAuthMethodsSupported,
+		CodeChallengeMethodsSupported:     rs.CodeChallengeMethodsSupported,
+	})
+	return payload
+}
+
+func FromHTTPRequestToRegisterClientRequestEntity(c *fiber.Ctx) *mcpauth.RegisterClientRequest {
+	var payload view.Client
+	if err := json.Unmarshal(c.BodyRaw(), &payload); err != nil {
+		return nil
+	}
+
+	return &mcpauth.RegisterClientRequest{
+		Client: mcpauth.Client{
+			Name:                    payload.ClientName,
+			RedirectURIs:            payload.RedirectURIs,
+			TokenEndpointAuthMethod: payload.TokenEndpointAuthMethod,
+			GrantTypes:              payload.GrantTypes,
+			ResponseTypes:           payload.ResponseTypes,
+		},
+	}
+}
+
+func FromRegisterClientResponseEntityToHTTPResponse(rs *mcpauth.RegisterClientResponse) []byte {
+	client := rs.Client
+	v := view.Client{
+		ClientID:                client.ID,
+		ClientSecret:            client.Secret,
+		ClientIDIssuedAt:        client.CreatedAt.Unix(),
+		ClientName:              client.Name,
+		RedirectURIs:            client.RedirectURIs,
+		TokenEndpointAuthMethod: client.TokenEndpointAuthMethod,
+		GrantTypes:              client.GrantTypes,
+		ResponseTypes:           client.ResponseTypes,
+	}
+	if client.Secret != "" {
+		// the client secrets do not expire
+		var never int64
+		v.ClientSecretExpiresAt = &never
+	}
+
+	payload, _ := json.Marshal(v)
+	return payload
+}
+
+// FromHTTPRequestToAuthorizeRequestEntity reads the authorization request from
+// the query or from the consent form
+func FromHTTPRequestToAuthorizeRequestEntity(c *fiber.Ctx) *mcpauth.AuthorizeRequest {
+	return &mcpauth.AuthorizeRequest{
+		ResponseType:        c.FormValue("response_type"),
+		ClientID:            c.FormValue("client_id"),
+		RedirectURI:         c.FormValue("redirect_uri"),
+		CodeChallenge:       c.FormValue("code_challenge"),
+		CodeChallengeMethod: c.FormValue("code_challenge_method"),
+		State:               c.FormValue("state"),
+		Scope:               c.FormValue("scope"),
+		Resource:            c.FormValue("resource"),
+	}
+}
+
+func FromHTTPRequestToApproveRequestEntity(c *fiber.Ctx) *mcpauth.ApproveRequest {
+	return &mcpauth.ApproveRequest{
+		Authorize: *FromHTTPRequestToAuthorizeRequestEntity(c),
+		AccessKey: c.FormValue("access_key"),
+		Subject:   c.FormValue("subject"),
+		Denied:    c.FormValue("decision") == "deny",
+	}
+}
+
+// FromHTTPRequestToTokenRequestEntity reads the token request, the client
+// credentials come from the basic auth header or from the form
+func FromHTTPRequestToTokenRequestEntity(c *fiber.Ctx) *mcpauth.TokenRequest {
+	rq := &mcpauth.TokenRequest{
+		GrantType:    c.FormValue("grant_type"),
+		Code:         c.FormValue("code"),
+		RedirectURI:  c.FormValue("redirect_uri"),
+		ClientID:     c.FormValue("client_id"),
+		ClientSecret: c.FormValue("client_secret"),
+		CodeVerifier: c.FormValue("code_verifier"),
+		Resource:     c.FormValue("resource"),
+	}
+
+	req := http.Request{Header: http.Header{}}
+	req.Header.Set("Authorization", c.Get("Authorization"))
+	if clientID, clientSecret, ok := req.BasicAuth(); ok {
+		rq.ClientID, rq.ClientSecret = clientID, clientSecret
+	}
+	return rq
+}
+
+func FromTokenResponseEntityToHTTPResponse(rs *mcpauth.TokenResponse) []byte {
+	payload, _ := json.Marshal(view.Token{
+		AccessToken: rs.AccessToken,
+		TokenType:   rs.TokenType,
+		ExpiresIn:   rs.ExpiresIn,
+		Scope:       rs.Scope,
+	})
+	return payload
+}
+
+// FromErrorToHTTPResponse returns the OAuth 2.1 error response
+func FromErrorToHTTPResponse(err error) ([]byte, int) {
+	e, ok := err.(erre.Error)
+	if !ok {
+		e = erre.Error{
+			Code:    erre.ErrorCodeInternalServerError,
+			Message: "internal server error",
+		}
+	}
+
+	code, _ := e.Data["error"].(string)
+	if code == "" {
+		code = mcpauth.ErrCodeInvalidRequest
+		if e.Code >= erre.ErrorCodeInternalServerError {
+			code = mcpauth.ErrCodeServerError
+		}
+	}
+
+	payload, _ := json.Marshal(view.Error{
+		Error:            code,
+		ErrorDescription: e.Message,
+	})
+	return payload, int(e.Code)
+}
@@ -1,6 +1,8 @@
 package model
 
 import (
+	"encoding/json"
+
 	"github.com/hasmcp/hasmcp-ce/backend/internal/data/entity/crud"
 	"github.com/hasmcp/hasmcp-ce/backend/internal/data/model"
 )
@@ -13,6 +15,7 @@ func FromServerEntityServerModel(s crud.Server) model.Server {
 				ToolID:     e.ID,
 				ProviderID: p.ID,
 				ServerID:   s.ID,
+				Override:   FromServerToolOverrideEntityToModel(s.ToolOverrides[e.ID]),
 			})
 		}
 	}
@@ -38,6 +41,9 @@ func FromServerEntityServerModel(s crud.Server) model.Server {
 		CreatedAt:                  s.CreatedAt,
 		UpdatedAt:                  s.UpdatedAt,
 		RequestHeadersProxyEnabled: s.RequestHeadersProxyEnabled,
+		MockEnabled:                s.MockEnabled,
+		TrafficMode:                uint8(s.TrafficMode),
+		InputSchemaMode:            uint8(s.InputSchemaMode),
 		Name:                       s.Name,
 		Instructions:               s.Instructions,
 		Version:                    s.Version,
@@ -46,3 +52,30 @@ func FromServerEntityServerModel(s crud.Server) model.Server {
 		Prompts:                    prompts,
 	}
 }
+
+func FromServerToolOverrideEntityToModel(o crud.ServerToolOverride) json.RawMessage {
+	if o.Name == "" && o.Title == "" && o.Description == "" && len(o.Arguments) == 0 &&
+		o.InputSchemaMode == crud.InputSchemaModeInvalid {
+		return nil
+	}
+	data, _ := json.Marshal(o)
+	return data
+}
+
+func FromServerRecordingEntityToServerRecordingModel(r crud.ServerRecording) model.ServerRecording {
+	var interactions json.RawMessage
+	if len(r.Interactions) > 0 {
+		interactions, _ = json.Marshal(r.Interactions)
+	}
+	return model.ServerRecording{
+		ID:           r.ID,
+		CreatedAt:    r.CreatedAt,
+		ServerID:     r.ServerID,
+		ToolID:       r.ToolID,
+		ToolName:     r.ToolName,
+		Arguments:    r.Arguments,
+		Result:       r.Result,
+		IsError:      r.IsError,
+		Interactions: interactions,
+	}
+}
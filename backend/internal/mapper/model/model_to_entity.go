@@ -20,6 +20,57 @@ func FromProviderModelsToProviderEntities(ps []model.Provider) []crud.Provider {
 func FromProviderModelToProviderEntity(p model.Provider) crud.Provider {
 	clientSecretEncrypted, _ := hex.DecodeString(p.Oauth2Config.ClientSecretEncrypted)
 	clientSecretEncryptionNonce, _ := hex.DecodeString(p.Oauth2Config.ClientSecretEncryptionNonce)
+	passwordEncrypted, _ := hex.DecodeString(p.Oauth2Config.PasswordEncrypted)
+	passwordEncryptionNonce, _ := hex.DecodeString(p.Oauth2Config.PasswordEncryptionNonce)
+	privateKeyEncrypted, _ := hex.DecodeString(p.Oauth2Config.PrivateKeyEncrypted)
+	privateKeyEncryptionNonce, _ := hex.DecodeString(p.Oauth2Config.PrivateKeyEncryptionNonce)
+	var grpcConfig *crud.ProviderGRPCConfig
+	if len(p.GRPCConfig) > 0 {
+		grpcConfig = &crud.ProviderGRPCConfig{}
+		_ = json.Unmarshal(p.GRPCConfig, grpcConfig)
+	}
+	var authConfig *crud.ProviderAuthConfig
+	if len(p.AuthConfig) > 0 {
+		authConfig = &crud.ProviderAuthConfig{}
+		_ = json.Unmarshal(p.AuthConfig, authConfig)
+		// NONE is stored to clear the config
+		if authConfig.Type == crud.AuthTypeNone {
+			authConfig = nil
+		}
+	}
+	var signerConfig *crud.ProviderSignerConfig
+	if len(p.SignerConfig) > 0 {
+		signerConfig = &crud.ProviderSignerConfig{}
+		_ = json.Unmarshal(p.SignerConfig, signerConfig)
+		if signerConfig.Type == crud.SignerTypeNone {
+			signerConfig = nil
+		}
+	}
+	var mockConfig *crud.ProviderMockConfig
+	if len(p.MockConfig) > 0 {
+		mockConfig = &crud.ProviderMockConfig{}
+		_ = json.Unmarshal(p.MockConfig, mockConfig)
+	}
+	var limitsConfig *crud.ProviderLimitsConfig
+	if len(p.LimitsConfig) > 0 {
+		limitsConfig = &crud.ProviderLimitsConfig{}
+		_ = json.Unmarshal(p.LimitsConfig, limitsConfig)
+	}
+	var egressConfig *crud.ProviderEgressConfig
+	if len(p.EgressConfig) > 0 {
+		egressConfig = &crud.ProviderEgressConfig{}
+		_ = json.Unmarshal(p.EgressConfig, egressConfig)
+	}
+	var transportConfig *crud.ProviderTransportConfig
+	if len(p.TransportConfig) > 0 {
+		transportConfig = &crud.ProviderTransportConfig{}
+		_ = json.Unmarshal(p.TransportConfig, transportConfig)
+	}
+	grantType := crud.GrantType(p.Oauth2Config.GrantType)
+	if grantType == crud.GrantTypeInvalid {
+		// the configs stored before the grant types use the authorization code
+		grantType = crud.GrantTypeAuthorizationCode
+	}
 	return crud.Provider{
 		ID:             p.ID,
 		CreatedAt:      p.CreatedAt,
@@ -33,13 +84,35 @@ func FromProviderModelToProviderEntity(p model.Provider) crud.Provider {
 		SecretPrefix:   p.SecretPrefix,
 		Name:           p.Name,
 		Description:    p.Description,
-		Tools:          FromProviderToolModelsToProviderToolEntities(p.Tools),
+		GraphQLSchema:  p.GraphQLSchema,
+
+		GRPCConfig:        grpcConfig,
+		GRPCDescriptorSet: p.GRPCDescriptorSet,
+
+		AuthConfig:      authConfig,
+		SignerConfig:    signerConfig,
+		MockConfig:      mockConfig,
+		LimitsConfig:    limitsConfig,
+		EgressConfig:    egressConfig,
+		TransportConfig: transportConfig,
+		Tools:           FromProviderToolModelsToProviderToolEntities(p.Tools),
 		Oauth2Config: crud.ProviderOauth2Config{
+			GrantType:                   grantType,
 			ClientID:                    p.Oauth2Config.ClientID,
 			ClientSecretEncrypted:       clientSecretEncrypted,
 			ClientSecretEncryptionNonce: clientSecretEncryptionNonce,
 			AuthURL:                     p.Oauth2Config.AuthURL,
 			TokenURL:                    p.Oauth2Config.TokenURL,
+			Audience:                    p.Oauth2Config.Audience,
+			Username:                    p.Oauth2Config.Username,
+			Password:                    p.Oauth2Config.Password,
+			PasswordEncrypted:           passwordEncrypted,
+			PasswordEncryptionNonce:     passwordEncryptionNonce,
+			Issuer:                      p.Oauth2Config.Issuer,
+			Subject:                     p.Oauth2Config.Subject,
+			PrivateKey:                  p.Oauth2Config.PrivateKey,
+			PrivateKeyEncrypted:         privateKeyEncrypted,
+			PrivateKeyEncryptionNonce:   privateKeyEncryptionNonce,
 		},
 	}
 }
@@ -57,6 +130,26 @@ func FromProviderToolModelToProviderToolEntity(e model.ProviderTool) crud.Provid
 	if e.Headers != nil {
 		_ = json.Unmarshal(e.Headers, &headers)
 	}
+	var tags []string
+	if e.Tags != "" {
+		tags = strings.Split(e.Tags, ",")
+	}
+	var mockExamples []crud.ToolMockExample
+	if len(e.MockExamples) > 0 {
+		_ = json.Unmarshal(e.MockExamples, &mockExamples)
+	}
+	var asyncConfig *crud.ToolAsyncConfig
+	if len(e.AsyncConfig) > 0 {
+		_ = json.Unmarshal(e.AsyncConfig, &asyncConfig)
+	}
+	var paginationConfig *crud.ToolPaginationConfig
+	if len(e.PaginationConfig) > 0 {
+		_ = json.Unmarshal(e.PaginationConfig, &paginationConfig)
+	}
+	var cacheConfig *crud.ToolCacheConfig
+	if len(e.CacheConfig) > 0 {
+		_ = json.Unmarshal(e.CacheConfig, &cacheConfig)
+	}
 	return crud.ProviderTool{
 		ID:                  e.ID,
 		ProviderID:          e.ProviderID,
@@ -71,6 +164,12 @@ func FromProviderToolModelToProviderToolEntity(e model.ProviderTool) crud.Provid
 		ResBodyJSONSchema:   e.ResBodyJSONSchema,
 		Headers:             headers,
 		Oauth2Scopes:        strings.Split(e.Oauth2Scopes, ","),
+		Operation:           e.Operation,
+		Tags:                tags,
+		MockExamples:        mockExamples,
+		AsyncConfig:         asyncConfig,
+		PaginationConfig:    paginationConfig,
+		CacheConfig:         cacheConfig,
 	}
 }
 
@@ -86,6 +185,7 @@ func FromVariableModelToVariableEntity(v model.Variable) crud.Variable {
 		Value:     val,
 		Nonce:     nonce,
 		Name:      v.Name,
+		Subject:   v.Subject,
 	}
 }
 
@@ -142,7 +242,15 @@ func FromServerModelsToServerEntities(servers []model.Server) []crud.Server {
 func FromServerModelToServerEntity(s model.Server) crud.Server {
 	providers := make([]crud.Provider, 0, len(s.Tools))
 	providerIndex := map[int64]int{}
+	var toolOverrides map[int64]crud.ServerToolOverride
 	for _, e := range s.Tools {
+		if len(e.Override) > 0 {
+			if toolOverrides == nil {
+				toolOverrides = make(map[int64]crud.ServerToolOverride)
+			}
+			toolOverrides[e.ToolID] = FromServerToolOverrideModelToEntity(e.Override)
+		}
+
 		index, ok := providerIndex[e.ProviderID]
 		if !ok {
 			index = len(providers)
@@ -176,11 +284,58 @@ func FromServerModelToServerEntity(s model.Server) crud.Server {
 		CreatedAt:                  s.CreatedAt,
 		UpdatedAt:                  s.UpdatedAt,
 		RequestHeadersProxyEnabled: s.RequestHeadersProxyEnabled,
+		MockEnabled:                s.MockEnabled,
+		TrafficMode:                crud.TrafficMode(s.TrafficMode),
+		InputSchemaMode:            crud.InputSchemaMode(s.InputSchemaMode),
 		Name:                       s.Name,
 		Instructions:               s.Instructions,
 		Version:                    s.Version,
 		Providers:                  providers,
 		Resources:                  resources,
 		Prompts:                    prompts,
+		ToolOverrides:              toolOverrides,
+	}
+}
+
+func FromServerToolModelToServerToolEntity(e model.ServerTool) crud.ServerTool {
+	return crud.ServerTool{
+		ServerID:   e.ServerID,
+		ProviderID: e.ProviderID,
+		ToolID:     e.ToolID,
+		Override:   FromServerToolOverrideModelToEntity(e.Override),
+	}
+}
+
+func FromServerToolOverrideModelToEntity(data json.RawMessage) crud.ServerToolOverride {
+	var o crud.ServerToolOverride
+	if len(data) > 0 {
+		_ = json.Unmarshal(data, &o)
+	}
+	return o
+}
+
+func FromServerRecordingModelsToServerRecordingEntities(rs []model.ServerRecording) []crud.ServerRecording {
+	recordings := make([]crud.ServerRecording, len(rs))
+	for i, r := range rs {
+		recordings[i] = FromServerRecordingModelToServerRecordingEntity(r)
+	}
+	return recordings
+}
+
+func FromServerRecordingModelToServerRecordingEntity(r model.ServerRecording) crud.ServerRecording {
+	var interactions []crud.RecordedInteraction
+	if len(r.Interactions) > 0 {
+		_ = json.Unmarshal(r.Interactions, &interactions)
+	}
+	return crud.ServerRecording{
+		ID:           r.ID,
+		CreatedAt:    r.CreatedAt,
+		ServerID:     r.ServerID,
+		ToolID:       r.ToolID,
+		ToolName:     r.ToolName,
+		Arguments:    r.Arguments,
+		Result:       r.Result,
+		IsError:      r.IsError,
+		Interactions: interactions,
 	}
 }
@@ -9,8 +9,11 @@ import (
 func FromHTTPRequestToOauth2AuthorizeRequestEntity(c *fiber.Ctx) *oauth2.AuthorizeRequest {
 	uri := c.Context().URI()
 	return &oauth2.AuthorizeRequest{
-		ServerID: monoflake.IDFromBase62(c.Query("server_id")).Int64(),
-		HostName: string(uri.Host()),
+		ServerID:   monoflake.IDFromBase62(c.Query("server_id")).Int64(),
+		ProviderID: monoflake.IDFromBase62(c.Query("provider_id")).Int64(),
+		Token:      c.Query("token"),
+		Ref:        c.Query("ref"),
+		HostName:   string(uri.Host()),
 	}
 }
 
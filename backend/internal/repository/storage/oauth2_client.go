@@ -0,0 +1,61 @@
+package storage
+
+import (
+	"context"
+	"time"
+
+	"github.com/hasmcp/hasmcp-ce/backend/internal/data/model"
+	"gorm.io/gorm"
+)
+
+type Oauth2ClientStorage interface {
+	CreateOauth2Client(ctx context.Context, e model.Oauth2Client) error
+	GetOauth2Client(ctx context.Context, id string) (*model.Oauth2Client, error)
+	SaveOauth2AuthorizationCode(ctx context.Context, e model.Oauth2AuthorizationCode) error
+	ConsumeOauth2AuthorizationCode(ctx context.Context, id string) (*model.Oauth2AuthorizationCode, error)
+	DeleteExpiredOauth2AuthorizationCodes(ctx context.Context, before time.Time) error
+}
+
+// Oauth2Client methods
+func (r *repository) CreateOauth2Client(ctx context.Context, e model.Oauth2Client) error {
+	return r.db.Conn(ctx).Create(&e).Error
+}
+
+func (r *repository) GetOauth2Client(ctx context.Context, id string) (*model.Oauth2Client, error) {
+	var client model.Oauth2Client
+	err := r.db.Conn(ctx).Where("id = ?", id).First(&client).Error
+	if err != nil {
+		return nil, err
+	}
+	return &client, nil
+}
+
+// Oauth2AuthorizationCode methods
+func (r *repository) SaveOauth2AuthorizationCode(ctx context.Context, e model.Oauth2AuthorizationCode) error {
+	return r.db.Conn(ctx).Create(&e).Error
+}
+
+// ConsumeOauth2AuthorizationCode returns and deletes the code, only one of the
+// concurrent consumers gets the code
+func (r *repository) ConsumeOauth2AuthorizationCode(ctx context.Context, id string) (*model.Oauth2AuthorizationCode, error) {
+	var code model.Oauth2AuthorizationCode
+	err := r.db.Conn(ctx).Where("id = ?", id).First(&code).Error
+	if err != nil {
+		return nil, err
+	}
+
+	res := r.db.Conn(ctx).Where("id = ?", id).Delete(&model.Oauth2AuthorizationCode{})
+	if res.Error != nil {
+		return nil, res.Error
+	}
+	if res.RowsAffected == 0 {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &code, nil
+}
+
+func (r *repository) DeleteExpiredOauth2AuthorizationCodes(ctx context.Context, before time.Time) error {
+	return r.db.Conn(ctx).
+		Where("expires_at < ?", before).
+		Delete(&model.Oauth2AuthorizationCode{}).Error
+}
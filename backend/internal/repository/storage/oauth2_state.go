@@ -0,0 +1,45 @@
+package storage
+
+import (
+	"context"
+	"time"
+
+	"github.com/hasmcp/hasmcp-ce/backend/internal/data/model"
+	"gorm.io/gorm"
+)
+
+type Oauth2StateStorage interface {
+	SaveOauth2State(ctx context.Context, e model.Oauth2State) error
+	ConsumeOauth2State(ctx context.Context, id string) (*model.Oauth2State, error)
+	DeleteExpiredOauth2States(ctx context.Context, before time.Time) error
+}
+
+// Oauth2State methods
+func (r *repository) SaveOauth2State(ctx context.Context, e model.Oauth2State) error {
+	return r.db.Conn(ctx).Create(&e).Error
+}
+
+// ConsumeOauth2State returns and deletes the state, only one of the concurrent
+// consumers gets the state
+func (r *repository) ConsumeOauth2State(ctx context.Context, id string) (*model.Oauth2State, error) {
+	var state model.Oauth2State
+	err := r.db.Conn(ctx).Where("id = ?", id).First(&state).Error
+	if err != nil {
+		return nil, err
+	}
+
+	res := r.db.Conn(ctx).Where("id = ?", id).Delete(&model.Oauth2State{})
+	if res.Error != nil {
+		return nil, res.Error
+	}
+	if res.RowsAffected == 0 {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &state, nil
+}
+
+func (r *repository) DeleteExpiredOauth2States(ctx context.Context, before time.Time) error {
+	return r.db.Conn(ctx).
+		Where("expires_at < ?", before).
+		Delete(&model.Oauth2State{}).Error
+}
@@ -132,6 +132,10 @@ func (r *repository) DeleteServer(ctx context.Context, id int64) error {
 	if err != nil {
 		return err
 	}
+	err = db.Where("server_id = ?", id).Delete(&model.ServerRecording{}).Error
+	if err != nil {
+		return err
+	}
 
 	// Delete mcp server
 	err = db.Where("id = ?", id).Delete(&model.Server{}).Error
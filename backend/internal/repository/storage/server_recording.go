@@ -0,0 +1,36 @@
+package storage
+
+import (
+	"context"
+
+	"github.com/hasmcp/hasmcp-ce/backend/internal/data/model"
+)
+
+type ServerRecordingStorage interface {
+	CreateServerRecordings(ctx context.Context, es []model.ServerRecording) error
+	ListServerRecordings(ctx context.Context, serverID int64) ([]model.ServerRecording, error)
+	DeleteServerRecordings(ctx context.Context, serverID int64) error
+}
+
+// ServerRecording methods
+func (r *repository) CreateServerRecordings(ctx context.Context, es []model.ServerRecording) error {
+	if len(es) == 0 {
+		return nil
+	}
+	return r.db.Conn(ctx).Create(&es).Error
+}
+
+func (r *repository) ListServerRecordings(ctx context.Context, serverID int64) ([]model.ServerRecording, error) {
+	var recordings []model.ServerRecording
+	err := r.db.Conn(ctx).
+		Where("server_id = ?", serverID).
+		Order("id").
+		Find(&recordings).Error
+	return recordings, err
+}
+
+func (r *repository) DeleteServerRecordings(ctx context.Context, serverID int64) error {
+	return r.db.Conn(ctx).
+		Where("server_id = ?", serverID).
+		Delete(&model.ServerRecording{}).Error
+}
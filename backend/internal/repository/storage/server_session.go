@@ -0,0 +1,51 @@
+package storage
+
+import (
+	"context"
+
+	"github.com/hasmcp/hasmcp-ce/backend/internal/data/model"
+)
+
+type ServerSessionStorage interface {
+	SaveServerSession(ctx context.Context, e model.ServerSession) error
+	GetServerSession(ctx context.Context, serverID, sessionID int64) (*model.ServerSession, error)
+	DeleteServerSession(ctx context.Context, serverID, sessionID int64) error
+	ListServerSessions(ctx context.Context, serverID int64) ([]model.ServerSession, error)
+	DeleteAllServerSessions(ctx context.Context, serverID int64) error
+}
+
+// ServerSession methods
+func (r *repository) SaveServerSession(ctx context.Context, e model.ServerSession) error {
+	return r.db.Conn(ctx).Save(&e).Error
+}
+
+func (r *repository) GetServerSession(ctx context.Context, serverID, sessionID int64) (*model.ServerSession, error) {
+	var session model.ServerSession
+	err := r.db.Conn(ctx).
+		Where("server_id = ?", serverID).
+		Where("session_id = ?", sessionID).
+		First(&session).Error
+	if err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+func (r *repository) DeleteServerSession(ctx context.Context, serverID, sessionID int64) error {
+	return r.db.Conn(ctx).
+		Where("server_id = ?", serverID).
+		Where("session_id = ?", sessionID).
+		Delete(&model.ServerSession{}).Error
+}
+
+func (r *repository) ListServerSessions(ctx context.Context, serverID int64) ([]model.ServerSession, error) {
+	var sessions []model.ServerSession
+	err := r.db.Conn(ctx).Where("server_id = ?", serverID).Find(&sessions).Error
+	return sessions, err
+}
+
+func (r *repository) DeleteAllServerSessions(ctx context.Context, serverID int64) error {
+	return r.db.Conn(ctx).
+		Where("server_id = ?", serverID).
+		Delete(&model.ServerSession{}).Error
+}
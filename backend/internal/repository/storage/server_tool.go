@@ -4,11 +4,13 @@ import (
 	"context"
 
 	"github.com/hasmcp/hasmcp-ce/backend/internal/data/model"
+	"gorm.io/gorm"
 )
 
 type ServerToolStorage interface {
 	AddToolToServer(ctx context.Context, e model.ServerTool) error
 	RemoveServerTool(ctx context.Context, e model.ServerTool) error
+	UpdateServerToolOverride(ctx context.Context, e model.ServerTool) error
 	ListServerTools(ctx context.Context, serverID int64) ([]model.ServerTool, error)
 	DeleteAllServerTools(ctx context.Context, serverID int64) error
 	ListServerIDsByToolID(ctx context.Context, toolID int64) ([]int64, error)
@@ -34,6 +36,21 @@ func (r *repository) RemoveServerTool(ctx context.Context, e model.ServerTool) e
 	return nil
 }
 
+func (r *repository) UpdateServerToolOverride(ctx context.Context, e model.ServerTool) error {
+	res := r.db.Conn(ctx).
+		Model(&model.ServerTool{}).
+		Where("server_id = ?", e.ServerID).
+		Where("tool_id = ?", e.ToolID).
+		Update("override", e.Override)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
 func (r *repository) DeleteAllServerTools(ctx context.Context, serverID int64) error {
 	err := r.db.Conn(ctx).
 		Where("server_id = ?", serverID).
@@ -34,6 +34,12 @@ type (
 		ServerToolStorage
 		ServerPromptStorage
 		ServerResourceStorage
+		ServerSessionStorage
+		ServerRecordingStorage
+		ToolResponseCacheStorage
+
+		Oauth2StateStorage
+		Oauth2ClientStorage
 	}
 
 	repository struct {
@@ -84,6 +90,30 @@ func New(p Params) (Repository, error) {
 		return nil, err
 	}
 
+	if err := p.DB.Conn(ctx).AutoMigrate(&model.ServerSession{}); err != nil {
+		return nil, err
+	}
+
+	if err := p.DB.Conn(ctx).AutoMigrate(&model.ServerRecording{}); err != nil {
+		return nil, err
+	}
+
+	if err := p.DB.Conn(ctx).AutoMigrate(&model.ToolResponseCache{}); err != nil {
+		return nil, err
+	}
+
+	if err := p.DB.Conn(ctx).AutoMigrate(&model.Oauth2State{}); err != nil {
+		return nil, err
+	}
+
+	if err := p.DB.Conn(ctx).AutoMigrate(&model.Oauth2Client{}); err != nil {
+		return nil, err
+	}
+
+	if err := p.DB.Conn(ctx).AutoMigrate(&model.Oauth2AuthorizationCode{}); err != nil {
+		return nil, err
+	}
+
 	return &repository{
 		db: p.DB,
 	}, nil
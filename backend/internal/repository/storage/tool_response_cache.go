@@ -0,0 +1,45 @@
+package storage
+
+import (
+	"context"
+	"time"
+
+	"github.com/hasmcp/hasmcp-ce/backend/internal/data/model"
+)
+
+type ToolResponseCacheStorage interface {
+	SaveToolResponseCache(ctx context.Context, e model.ToolResponseCache) error
+	GetToolResponseCache(ctx context.Context, key string) (*model.ToolResponseCache, error)
+	DeleteToolResponseCaches(ctx context.Context, serverID, toolID int64) error
+	DeleteExpiredToolResponseCaches(ctx context.Context, before time.Time) error
+}
+
+// ToolResponseCache methods
+func (r *repository) SaveToolResponseCache(ctx context.Context, e model.ToolResponseCache) error {
+	return r.db.Conn(ctx).Save(&e).Error
+}
+
+func (r *repository) GetToolResponseCache(ctx context.Context, key string) (*model.ToolResponseCache, error) {
+	var cached model.ToolResponseCache
+	err := r.db.Conn(ctx).Where("key = ?", key).First(&cached).Error
+	if err != nil {
+		return nil, err
+	}
+	return &cached, nil
+}
+
+// DeleteToolResponseCaches deletes the cached responses of the server, only
+// the ones of the tool when the tool ID is set
+func (r *repository) DeleteToolResponseCaches(ctx context.Context, serverID, toolID int64) error {
+	tx := r.db.Conn(ctx).Where("server_id = ?", serverID)
+	if toolID > 0 {
+		tx = tx.Where("tool_id = ?", toolID)
+	}
+	return tx.Delete(&model.ToolResponseCache{}).Error
+}
+
+func (r *repository) DeleteExpiredToolResponseCaches(ctx context.Context, before time.Time) error {
+	return r.db.Conn(ctx).
+		Where("expires_at < ?", before).
+		Delete(&model.ToolResponseCache{}).Error
+}
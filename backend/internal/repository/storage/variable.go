@@ -12,7 +12,9 @@ type VariableStorage interface {
 	SaveVariable(ctx context.Context, v model.Variable) error
 	GetVariable(ctx context.Context, id int64) (*model.Variable, error)
 	GetVariableByName(ctx context.Context, name string) (*model.Variable, error)
+	GetUserVariableByName(ctx context.Context, subject, name string) (*model.Variable, error)
 	ListVariables(ctx context.Context) ([]model.Variable, error)
+	ListUserVariables(ctx context.Context, subject string) ([]model.Variable, error)
 	DeleteVariable(ctx context.Context, id int64) error
 	UpdateVariable(ctx context.Context, id int64, attrs map[model.VariableAttribute]any) error
 }
@@ -28,9 +30,10 @@ func (r *repository) CreateVariable(ctx context.Context, v model.Variable) error
 	return nil
 }
 
-// SaveVariable saves a new variable in storage.
+// SaveVariable saves a new variable in storage, replacing the variable of the
+// same name and subject.
 func (r *repository) SaveVariable(ctx context.Context, v model.Variable) error {
-	err := r.db.Conn(ctx).Where("name = ?", v.Name).Delete(&model.Variable{}).Error
+	err := r.db.Conn(ctx).Where("name = ?", v.Name).Where("subject = ?", v.Subject).Delete(&model.Variable{}).Error
 	if err != nil {
 		return err
 	}
@@ -44,10 +47,20 @@ func (r *repository) SaveVariable(ctx context.Context, v model.Variable) error {
 	return nil
 }
 
-// ListVariables lists all variables from storage.
+// ListVariables lists all global variables from storage.
 func (r *repository) ListVariables(ctx context.Context) ([]model.Variable, error) {
 	var variables []model.Variable
-	err := r.db.Conn(ctx).Find(&variables).Error
+	err := r.db.Conn(ctx).Where("subject = ?", "").Find(&variables).Error
+	if err != nil {
+		return nil, err
+	}
+	return variables, nil
+}
+
+// ListUserVariables lists the variables of the user from storage.
+func (r *repository) ListUserVariables(ctx context.Context, subject string) ([]model.Variable, error) {
+	var variables []model.Variable
+	err := r.db.Conn(ctx).Where("subject = ?", subject).Find(&variables).Error
 	if err != nil {
 		return nil, err
 	}
@@ -64,10 +77,15 @@ func (r *repository) GetVariable(ctx context.Context, id int64) (*model.Variable
 	return &variable, nil
 }
 
-// GetVariable finds a variable by its ID.
+// GetVariableByName finds a global variable by its name.
 func (r *repository) GetVariableByName(ctx context.Context, name string) (*model.Variable, error) {
+	return r.GetUserVariableByName(ctx, "", name)
+}
+
+// GetUserVariableByName finds a variable of the user by its name.
+func (r *repository) GetUserVariableByName(ctx context.Context, subject, name string) (*model.Variable, error) {
 	var variable model.Variable
-	err := r.db.Conn(ctx).Where("name = ?", name).First(&variable).Error
+	err := r.db.Conn(ctx).Where("name = ?", name).Where("subject = ?", subject).First(&variable).Error
 	if err != nil {
 		return nil, err
 	}
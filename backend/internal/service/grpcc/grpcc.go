@@ -0,0 +1,268 @@
+package grpcc
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hasmcp/hasmcp-ce/backend/internal/service/config"
+	"github.com/hasmcp/hasmcp-ce/backend/internal/service/httpc"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+)
+
+type (
+	Service interface {
+		// Conn returns a shared client connection for the target
+		Conn(target string, opts ConnOptions) (grpc.ClientConnInterface, error)
+		// Evict drops the connections of the options key, they are closed once
+		// their in flight calls are done
+		Evict(key string)
+	}
+
+	Params struct {
+		Config config.Service
+		HTTPC  httpc.Service
+	}
+
+	// ConnOptions are the transport options of a connection
+	ConnOptions struct {
+		Key                string // identifies the owner of the connection, e.g. the provider
+		Plaintext          bool
+		InsecureSkipVerify bool
+		// Transport is the CA bundle, the client certificates and the proxy
+		// of the connection, the defaults are used when nil
+		Transport *httpc.TransportOptions
+		// Egress is the explicit allow of the owner on top of the egress
+		// policy
+		Egress httpc.EgressAllow
+	}
+
+	service struct {
+		cfg   grpccConfig
+		httpc httpc.Service
+		conns sync.Map // map[string]*sharedConn
+		mu    sync.Mutex
+	}
+
+	// sharedConn counts the in flight calls of a connection to close it after
+	// they are done when it is evicted
+	sharedConn struct {
+		cc      *grpc.ClientConn
+		mu      sync.Mutex
+		calls   int
+		evicted bool
+	}
+
+	grpccConfig struct {
+		UserAgent string        `yaml:"userAgent"`
+		Timeout   time.Duration `yaml:"timeout"`
+	}
+)
+
+const (
+	_cfgKey = "grpcc"
+
+	// TargetScheme is the base URL scheme of the gRPC providers
+	TargetScheme = "grpc"
+
+	_passthroughScheme = "passthrough:///"
+)
+
+var (
+	// _reservedHeaders are not forwarded as metadata, they are either set by
+	// the transport or only meaningful for the HTTP/1 callers
+	_reservedHeaders = map[string]struct{}{
+		"accept":            {},
+		"accept-encoding":   {},
+		"connection":        {},
+		"content-length":    {},
+		"content-type":      {},
+		"host":              {},
+		"keep-alive":        {},
+		"te":                {},
+		"trailer":           {},
+		"transfer-encoding": {},
+		"upgrade":           {},
+		"user-agent":        {},
+	}
+)
+
+// New inits a new gRPC client service
+func New(p Params) (Service, error) {
+	var cfg grpccConfig
+	if err := p.Config.Populate(_cfgKey, &cfg); err != nil {
+		return nil, err
+	}
+
+	return &service{
+		cfg:   cfg,
+		httpc: p.HTTPC,
+	}, nil
+}
+
+func (s *service) Conn(target string, opts ConnOptions) (grpc.ClientConnInterface, error) {
+	var transportKey string
+	if opts.Transport != nil {
+		transportKey = opts.Transport.Key
+	}
+	key := fmt.Sprintf("%s|%s|%t|%t|%s|%v", opts.Key, target, opts.Plaintext, opts.InsecureSkipVerify, transportKey, opts.Egress)
+	if conn, ok := s.conns.Load(key); ok {
+		return conn.(*sharedConn), nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if conn, ok := s.conns.Load(key); ok {
+		return conn.(*sharedConn), nil
+	}
+
+	var proxy *url.URL
+	creds := insecure.NewCredentials()
+	if opts.Transport != nil {
+		proxy = opts.Transport.Proxy
+	}
+	if !opts.Plaintext {
+		tlsCfg := &tls.Config{
+			MinVersion:         tls.VersionTLS12,
+			InsecureSkipVerify: opts.InsecureSkipVerify,
+		}
+		if opts.Transport != nil {
+			tlsCfg = opts.Transport.TLSConfig()
+			tlsCfg.InsecureSkipVerify = tlsCfg.InsecureSkipVerify || opts.InsecureSkipVerify
+		}
+		creds = credentials.NewTLS(tlsCfg)
+	}
+
+	dialOpts := []grpc.DialOption{
+		grpc.WithTransportCredentials(creds),
+		// the dialer enforces the egress policy and tunnels through the
+		// proxy, it gets the host names with the passthrough resolver
+		grpc.WithContextDialer(s.httpc.Dialer(opts.Egress, proxy)),
+		grpc.WithUnaryInterceptor(s.timeoutInterceptor),
+	}
+	if s.cfg.UserAgent != "" {
+		dialOpts = append(dialOpts, grpc.WithUserAgent(s.cfg.UserAgent))
+	}
+
+	cc, err := grpc.NewClient(_passthroughScheme+target, dialOpts...)
+	if err != nil {
+		return nil, err
+	}
+	conn := &sharedConn{cc: cc}
+	s.conns.Store(key, conn)
+	return conn, nil
+}
+
+func (s *service) Evict(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.conns.Range(func(k, v any) bool {
+		if strings.HasPrefix(k.(string), key+"|") {
+			s.conns.Delete(k)
+			v.(*sharedConn).evict()
+		}
+		return true
+	})
+}
+
+func (c *sharedConn) Invoke(ctx context.Context, method string, args, reply any, opts ...grpc.CallOption) error {
+	c.acquire()
+	defer c.release()
+	return c.cc.Invoke(ctx, method, args, reply, opts...)
+}
+
+func (c *sharedConn) NewStream(ctx context.Context, desc *grpc.StreamDesc, method string, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+	c.acquire()
+	stream, err := c.cc.NewStream(ctx, desc, method, opts...)
+	if err != nil {
+		c.release()
+		return nil, err
+	}
+	// the context of the stream is done when the stream is finished
+	go func() {
+		<-stream.Context().Done()
+		c.release()
+	}()
+	return stream, nil
+}
+
+func (c *sharedConn) acquire() {
+	c.mu.Lock()
+	c.calls++
+	c.mu.Unlock()
+}
+
+func (c *sharedConn) release() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.calls--
+	if c.evicted && c.calls == 0 {
+		_ = c.cc.Close()
+	}
+}
+
+// evict closes the connection now or after its last in flight call, the calls
+// started after the close fail with the closing error of the connection
+func (c *sharedConn) evict() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.evicted = true
+	if c.calls == 0 {
+		_ = c.cc.Close()
+	}
+}
+
+// timeoutInterceptor limits the unary calls without a deadline
+func (s *service) timeoutInterceptor(
+	ctx context.Context,
+	method string,
+	req, reply any,
+	cc *grpc.ClientConn,
+	invoker grpc.UnaryInvoker,
+	opts ...grpc.CallOption,
+) error {
+	if _, ok := ctx.Deadline(); !ok && s.cfg.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.cfg.Timeout)
+		defer cancel()
+	}
+	return invoker(ctx, method, req, reply, cc, opts...)
+}
+
+// Target converts the provider base URL `grpc://host:port` into the dial
+// target
+func Target(baseURL string) (string, error) {
+	parsed, err := url.Parse(baseURL)
+	if err != nil {
+		return "", err
+	}
+	if parsed.Scheme != TargetScheme {
+		return "", errors.New("gRPC base URL must be grpc://host:port")
+	}
+	if parsed.Hostname() == "" || parsed.Port() == "" {
+		return "", errors.New("gRPC base URL must have a host and a port")
+	}
+	return parsed.Host, nil
+}
+
+// MetadataFromHeader converts the HTTP headers into the outgoing metadata
+func MetadataFromHeader(headers http.Header) metadata.MD {
+	md := metadata.MD{}
+	for k, vals := range headers {
+		key := strings.ToLower(k)
+		if _, ok := _reservedHeaders[key]; ok || strings.HasPrefix(key, "grpc-") {
+			continue
+		}
+		md.Append(key, vals...)
+	}
+	return md
+}
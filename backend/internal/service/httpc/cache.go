@@ -0,0 +1,104 @@
+package httpc
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type (
+	// CachePolicy is the caching directives and the validators of a response
+	CachePolicy struct {
+		NoStore bool
+		NoCache bool // the stored response is revalidated before every use
+
+		// MaxAge is the freshness lifetime left, it is valid when HasMaxAge
+		MaxAge    time.Duration
+		HasMaxAge bool
+
+		ETag         string
+		LastModified string
+	}
+)
+
+const (
+	_headerCacheControl    = "Cache-Control"
+	_headerPragma          = "Pragma"
+	_headerExpires         = "Expires"
+	_headerDate            = "Date"
+	_headerAge             = "Age"
+	_headerETag            = "ETag"
+	_headerLastModified    = "Last-Modified"
+	_headerIfNoneMatch     = "If-None-Match"
+	_headerIfModifiedSince = "If-Modified-Since"
+)
+
+// CachePolicyOf returns the caching policy of the response, the max-age
+// directive takes precedence over the Expires header
+func CachePolicyOf(res *http.Response) CachePolicy {
+	p := CachePolicy{
+		ETag:         res.Header.Get(_headerETag),
+		LastModified: res.Header.Get(_headerLastModified),
+	}
+
+	for _, directive := range strings.Split(strings.Join(res.Header.Values(_headerCacheControl), ","), ",") {
+		name, value, _ := strings.Cut(strings.TrimSpace(directive), "=")
+		switch strings.ToLower(name) {
+		case "no-store":
+			p.NoStore = true
+		case "no-cache":
+			p.NoCache = true
+		case "max-age":
+			if seconds, err := strconv.Atoi(strings.Trim(value, `"`)); err == nil {
+				p.MaxAge = time.Duration(max(seconds, 0)) * time.Second
+				p.HasMaxAge = true
+			}
+		}
+	}
+	if strings.Contains(strings.ToLower(res.Header.Get(_headerPragma)), "no-cache") && len(res.Header.Values(_headerCacheControl)) == 0 {
+		p.NoCache = true
+	}
+
+	if !p.HasMaxAge {
+		if expires := res.Header.Get(_headerExpires); expires != "" {
+			date, err := http.ParseTime(res.Header.Get(_headerDate))
+			if err != nil {
+				date = time.Now()
+			}
+			// the malformed Expires means already expired
+			p.MaxAge = 0
+			if at, err := http.ParseTime(expires); err == nil && at.After(date) {
+				p.MaxAge = at.Sub(date)
+			}
+			p.HasMaxAge = true
+		}
+	}
+
+	if p.HasMaxAge {
+		if age, err := strconv.Atoi(res.Header.Get(_headerAge)); err == nil && age > 0 {
+			p.MaxAge = max(p.MaxAge-time.Duration(age)*time.Second, 0)
+		}
+	}
+	return p
+}
+
+// Revalidatable reports whether the response has a validator to make a
+// conditional request with
+func (p CachePolicy) Revalidatable() bool {
+	return p.ETag != "" || p.LastModified != ""
+}
+
+// Revalidate sets the conditional headers of the validators on the request,
+// the server answers with 304 when the stored response is still valid
+func (p CachePolicy) Revalidate(req *http.Request) {
+	if req.Header == nil {
+		req.Header = http.Header{}
+	}
+	if p.ETag != "" {
+		req.Header.Set(_headerIfNoneMatch, p.ETag)
+	}
+	if p.LastModified != "" {
+		req.Header.Set(_headerIfModifiedSince, p.LastModified)
+	}
+}
@@ -0,0 +1,163 @@
+package httpc
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"encoding/base64"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"time"
+
+	"golang.org/x/net/proxy"
+)
+
+type (
+	// tunnelDialer dials the addresses through the egress guard, directly or
+	// through the tunnel of a proxy
+	tunnelDialer struct {
+		guard *egressGuard
+		proxy *url.URL // the environment proxy when nil
+	}
+
+	// dialFunc adapts a dial to the forward dialer of the SOCKS5 proxies
+	dialFunc func(ctx context.Context, network, addr string) (net.Conn, error)
+)
+
+func (c *service) Dialer(allow EgressAllow, proxy *url.URL) func(ctx context.Context, addr string) (net.Conn, error) {
+	// the allow is validated when it is saved, the invalid entries are skipped
+	rules, _ := egressRulesOf(allow.CIDRs, allow.Hosts, allow.Ports)
+	d := &tunnelDialer{
+		guard: &egressGuard{
+			policy: c.egress,
+			allow:  rules,
+		},
+		proxy: proxy,
+	}
+	return d.dial
+}
+
+func (d *tunnelDialer) dial(ctx context.Context, addr string) (net.Conn, error) {
+	proxyURL, trusted := d.proxy, false
+	if proxyURL == nil {
+		// the environment proxy of the operator is dialed without the checks
+		var err error
+		proxyURL, err = http.ProxyFromEnvironment(&http.Request{URL: &url.URL{Scheme: "https", Host: addr}})
+		if err != nil {
+			return nil, err
+		}
+		trusted = true
+	}
+	if proxyURL == nil {
+		return d.guard.dialContext(ctx, "tcp", addr)
+	}
+
+	// the proxy dials the target, only its name and address literal are known
+	host, port, err := splitHostPort(addr)
+	if err != nil {
+		return nil, err
+	}
+	level, err := d.guard.checkHost(host, port)
+	if err != nil {
+		return nil, err
+	}
+	if ip, err := netip.ParseAddr(host); err == nil {
+		if err := d.guard.checkAddr(host, ip, level); err != nil {
+			return nil, err
+		}
+	}
+
+	dial := d.guard.dialContext
+	if trusted {
+		dial = (&net.Dialer{
+			Timeout:   _egressDialTimeout,
+			KeepAlive: _egressDialKeepAlive,
+		}).DialContext
+	}
+	proxyAddr := proxyAddrOf(proxyURL.Scheme, proxyURL.Host)
+
+	switch proxyURL.Scheme {
+	case "socks5", "socks5h":
+		var auth *proxy.Auth
+		if u := proxyURL.User; u != nil {
+			password, _ := u.Password()
+			auth = &proxy.Auth{User: u.Username(), Password: password}
+		}
+		socks, err := proxy.SOCKS5("tcp", proxyAddr, auth, dialFunc(dial))
+		if err != nil {
+			return nil, err
+		}
+		return socks.(proxy.ContextDialer).DialContext(ctx, "tcp", addr)
+	case "http", "https":
+		conn, err := dial(ctx, "tcp", proxyAddr)
+		if err != nil {
+			return nil, err
+		}
+		if proxyURL.Scheme == "https" {
+			tlsConn := tls.Client(conn, &tls.Config{
+				ServerName: proxyURL.Hostname(),
+				MinVersion: tls.VersionTLS12,
+			})
+			if err := tlsConn.HandshakeContext(ctx); err != nil {
+				_ = conn.Close()
+				return nil, err
+			}
+			conn = tlsConn
+		}
+		if err := connectTunnel(ctx, conn, proxyURL, addr); err != nil {
+			_ = conn.Close()
+			return nil, err
+		}
+		return conn, nil
+	}
+	return nil, fmt.Errorf("httpc: unsupported proxy scheme: %s", proxyURL.Scheme)
+}
+
+// connectTunnel asks the HTTP proxy to tunnel the connection to the address
+func connectTunnel(ctx context.Context, conn net.Conn, proxyURL *url.URL, addr string) error {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Now().Add(_egressDialTimeout)
+	}
+	if err := conn.SetDeadline(deadline); err != nil {
+		return err
+	}
+
+	req := &http.Request{
+		Method: http.MethodConnect,
+		URL:    &url.URL{Opaque: addr},
+		Host:   addr,
+		Header: http.Header{},
+	}
+	if u := proxyURL.User; u != nil {
+		password, _ := u.Password()
+		req.Header.Set("Proxy-Authorization", "Basic "+
+			base64.StdEncoding.EncodeToString([]byte(u.Username()+":"+password)))
+	}
+	if err := req.Write(conn); err != nil {
+		return err
+	}
+
+	// the client speaks first on the tunnel, nothing is buffered past the
+	// response
+	res, err := http.ReadResponse(bufio.NewReader(conn), req)
+	if err != nil {
+		return err
+	}
+	_ = res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("httpc: proxy refused the tunnel to %s: %s", addr, res.Status)
+	}
+	return conn.SetDeadline(time.Time{})
+}
+
+func (f dialFunc) Dial(network, addr string) (net.Conn, error) {
+	return f(context.Background(), network, addr)
+}
+
+func (f dialFunc) DialContext(ctx context.Context, network, addr string) (net.Conn, error) {
+	return f(ctx, network, addr)
+}
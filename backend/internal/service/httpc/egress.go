@@ -0,0 +1,394 @@
+package httpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	zlog "github.com/rs/zerolog/log"
+)
+
+type (
+	// EgressAllow is the explicit allow of a caller on top of the egress
+	// policy, e.g. the internal network of a provider. It lifts the default
+	// denied ranges but not the denies of the policy.
+	EgressAllow struct {
+		CIDRs []string // the single addresses are allowed too
+		Hosts []string // exact names or *.example.com patterns
+		Ports []int
+	}
+
+	// EgressError is the violation of the egress policy
+	EgressError struct {
+		Host    string
+		Address string // empty when the host is denied before the resolution
+		Reason  string
+	}
+
+	egressConfig struct {
+		// AllowPrivate lifts the default deny of the private, loopback and
+		// link-local ranges
+		AllowPrivate bool     `yaml:"allowPrivate"`
+		AllowedCIDRs []string `yaml:"allowedCIDRs"`
+		DeniedCIDRs  []string `yaml:"deniedCIDRs"`
+		AllowedHosts []string `yaml:"allowedHosts"`
+		DeniedHosts  []string `yaml:"deniedHosts"`
+		// AllowedPorts limits the ports when it is not empty
+		AllowedPorts []int `yaml:"allowedPorts"`
+		DeniedPorts  []int `yaml:"deniedPorts"`
+	}
+
+	egressRules struct {
+		cidrs []netip.Prefix
+		hosts []string
+		ports []int
+	}
+
+	egressPolicy struct {
+		allowPrivate bool
+		allowed      egressRules
+		denied       egressRules
+	}
+
+	// egressGuard enforces the policy with the allow of a client
+	egressGuard struct {
+		policy     *egressPolicy
+		allow      egressRules
+		trustProxy bool     // the environment proxy of the operator is not checked
+		proxies    sync.Map // map[string]bool, the trusted proxy addresses dialed for the requests
+	}
+
+	egressRoundTripper struct {
+		guard   *egressGuard
+		tripper *http.Transport
+	}
+
+	// egressLevel is how far a host is allowed before its addresses are checked
+	egressLevel uint8
+
+	egressAllowCtxKey struct{}
+)
+
+const (
+	egressLevelDefault egressLevel = iota
+	egressLevelAllowed             // by the caller, the default denied ranges are lifted
+	egressLevelTrusted             // by the policy, the addresses are not checked
+)
+
+const (
+	_egressDialTimeout   = 30 * time.Second
+	_egressDialKeepAlive = 30 * time.Second
+)
+
+var (
+	// the ranges denied by default on top of the private, loopback,
+	// link-local and unspecified ones of netip
+	_egressDefaultDeniedCIDRs = []netip.Prefix{
+		netip.MustParsePrefix("0.0.0.0/8"),
+		netip.MustParsePrefix("100.64.0.0/10"),
+		netip.MustParsePrefix("192.0.0.0/24"),
+		netip.MustParsePrefix("198.18.0.0/15"),
+	}
+
+	// the NAT64 prefix embeds the IPv4 addresses
+	_egressNAT64Prefix = netip.MustParsePrefix("64:ff9b::/96")
+)
+
+// WithEgressAllow returns the context carrying the explicit allow for the
+// calls made with it
+func WithEgressAllow(ctx context.Context, allow EgressAllow) context.Context {
+	return context.WithValue(ctx, egressAllowCtxKey{}, allow)
+}
+
+// ValidateEgressAllow checks the CIDRs, the host patterns and the ports of the
+// allow
+func ValidateEgressAllow(allow EgressAllow) error {
+	_, err := egressRulesOf(allow.CIDRs, allow.Hosts, allow.Ports)
+	return err
+}
+
+// AsEgressError returns the egress violation wrapped by the error of a call
+func AsEgressError(err error) (*EgressError, bool) {
+	var egressErr *EgressError
+	if errors.As(err, &egressErr) {
+		return egressErr, true
+	}
+	return nil, false
+}
+
+func (e *EgressError) Error() string {
+	if e.Address != "" {
+		return fmt.Sprintf("egress to %s (%s) is denied: %s", e.Host, e.Address, e.Reason)
+	}
+	return fmt.Sprintf("egress to %s is denied: %s", e.Host, e.Reason)
+}
+
+func egressAllowOf(ctx context.Context) (EgressAllow, bool) {
+	allow, ok := ctx.Value(egressAllowCtxKey{}).(EgressAllow)
+	return allow, ok
+}
+
+// key identifies the clients of the allow
+func (a EgressAllow) key() string {
+	ports := make([]string, 0, len(a.Ports))
+	for _, p := range a.Ports {
+		ports = append(ports, strconv.Itoa(p))
+	}
+	return strings.Join(a.CIDRs, ",") + "|" + strings.Join(a.Hosts, ",") + "|" + strings.Join(ports, ",")
+}
+
+func newEgressPolicy(cfg egressConfig) (*egressPolicy, error) {
+	allowed, err := egressRulesOf(cfg.AllowedCIDRs, cfg.AllowedHosts, cfg.AllowedPorts)
+	if err != nil {
+		return nil, fmt.Errorf("egress allowed: %w", err)
+	}
+	denied, err := egressRulesOf(cfg.DeniedCIDRs, cfg.DeniedHosts, cfg.DeniedPorts)
+	if err != nil {
+		return nil, fmt.Errorf("egress denied: %w", err)
+	}
+	return &egressPolicy{
+		allowPrivate: cfg.AllowPrivate,
+		allowed:      allowed,
+		denied:       denied,
+	}, nil
+}
+
+func egressRulesOf(cidrs, hosts []string, ports []int) (egressRules, error) {
+	var rules egressRules
+	for _, c := range cidrs {
+		prefix, err := parseEgressCIDR(c)
+		if err != nil {
+			return rules, err
+		}
+		rules.cidrs = append(rules.cidrs, prefix)
+	}
+	for _, h := range hosts {
+		pattern := normalizeHost(h)
+		name := strings.TrimPrefix(pattern, "*.")
+		if name == "" || strings.ContainsAny(name, "*/: ") {
+			return rules, fmt.Errorf("invalid host pattern: %q", h)
+		}
+		rules.hosts = append(rules.hosts, pattern)
+	}
+	for _, p := range ports {
+		if p < 1 || p > 65535 {
+			return rules, fmt.Errorf("invalid port: %d", p)
+		}
+		rules.ports = append(rules.ports, p)
+	}
+	return rules, nil
+}
+
+func parseEgressCIDR(s string) (netip.Prefix, error) {
+	s = strings.TrimSpace(s)
+	if strings.Contains(s, "/") {
+		prefix, err := netip.ParsePrefix(s)
+		if err != nil {
+			return prefix, fmt.Errorf("invalid CIDR: %q", s)
+		}
+		return prefix.Masked(), nil
+	}
+	addr, err := netip.ParseAddr(s)
+	if err != nil {
+		return netip.Prefix{}, fmt.Errorf("invalid CIDR: %q", s)
+	}
+	addr = addr.Unmap()
+	return netip.PrefixFrom(addr, addr.BitLen()), nil
+}
+
+// dialContext checks the host before the resolution and every resolved
+// address right before the connect so that a rebinding of the name is
+// caught too
+func (g *egressGuard) dialContext(ctx context.Context, network, addr string) (net.Conn, error) {
+	d := &net.Dialer{
+		Timeout:   _egressDialTimeout,
+		KeepAlive: _egressDialKeepAlive,
+	}
+	if _, ok := g.proxies.Load(addr); ok {
+		// the proxy resolves the hosts which are checked by the round tripper
+		return d.DialContext(ctx, network, addr)
+	}
+
+	host, port, err := splitHostPort(addr)
+	if err != nil {
+		return nil, err
+	}
+	level, err := g.checkHost(host, port)
+	if err != nil {
+		return nil, err
+	}
+	d.Control = func(_, address string, _ syscall.RawConn) error {
+		ap, err := netip.ParseAddrPort(address)
+		if err != nil {
+			return g.deny(host, address, "unresolvable address")
+		}
+		return g.checkAddr(host, ap.Addr(), level)
+	}
+	return d.DialContext(ctx, network, addr)
+}
+
+// RoundTrip checks the target of every request including the redirects, the
+// addresses are checked once they are dialed
+func (t egressRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	host := req.URL.Hostname()
+	port, err := strconv.Atoi(req.URL.Port())
+	if err != nil {
+		port = 80
+		if req.URL.Scheme == "https" {
+			port = 443
+		}
+	}
+	level, err := t.guard.checkHost(host, port)
+	if err != nil {
+		return nil, err
+	}
+
+	if t.tripper.Proxy != nil {
+		proxy, err := t.tripper.Proxy(req)
+		if err == nil && proxy != nil {
+			// the proxy dials the target, only its address literal is known
+			if addr, err := netip.ParseAddr(host); err == nil {
+				if err := t.guard.checkAddr(host, addr, level); err != nil {
+					return nil, err
+				}
+			}
+			if t.guard.trustProxy {
+				t.guard.proxies.Store(proxyAddrOf(proxy.Scheme, proxy.Host), true)
+			}
+		}
+	}
+	return t.tripper.RoundTrip(req)
+}
+
+// checkHost checks the port and the name of the host, it returns how far the
+// host is allowed for the checks of its addresses
+func (g *egressGuard) checkHost(host string, port int) (egressLevel, error) {
+	p := g.policy
+	if slices.Contains(p.denied.ports, port) {
+		return 0, g.deny(host, "", fmt.Sprintf("port %d is denied", port))
+	}
+	if len(p.allowed.ports) > 0 && !slices.Contains(p.allowed.ports, port) && !slices.Contains(g.allow.ports, port) {
+		return 0, g.deny(host, "", fmt.Sprintf("port %d is not allowed", port))
+	}
+
+	name := normalizeHost(host)
+	if _, err := netip.ParseAddr(name); err == nil {
+		return egressLevelDefault, nil
+	}
+	switch {
+	case matchesHost(p.allowed.hosts, name):
+		return egressLevelTrusted, nil
+	case matchesHost(p.denied.hosts, name):
+		return 0, g.deny(host, "", "host is denied")
+	case matchesHost(g.allow.hosts, name):
+		return egressLevelAllowed, nil
+	}
+	return egressLevelDefault, nil
+}
+
+// checkAddr checks the resolved address of the host, the denied CIDRs of the
+// policy win over the allow of the caller
+func (g *egressGuard) checkAddr(host string, addr netip.Addr, level egressLevel) error {
+	if level == egressLevelTrusted {
+		return nil
+	}
+	p := g.policy
+	addr = addr.Unmap()
+	switch {
+	case containsAddr(p.allowed.cidrs, addr):
+		return nil
+	case containsAddr(p.denied.cidrs, addr):
+		return g.deny(host, addr.String(), "address is in a denied CIDR")
+	case level == egressLevelAllowed, containsAddr(g.allow.cidrs, addr):
+		return nil
+	case !p.allowPrivate && isDefaultDenied(addr):
+		return g.deny(host, addr.String(), "private, loopback and link-local addresses are denied by default")
+	}
+	return nil
+}
+
+func (g *egressGuard) deny(host, address, reason string) error {
+	err := &EgressError{
+		Host:    host,
+		Address: address,
+		Reason:  reason,
+	}
+	zlog.Warn().Str("host", host).Str("address", address).Str("reason", reason).Msg("httpc: egress denied")
+	return err
+}
+
+func isDefaultDenied(addr netip.Addr) bool {
+	if _egressNAT64Prefix.Contains(addr) {
+		b := addr.As16()
+		addr = netip.AddrFrom4([4]byte{b[12], b[13], b[14], b[15]})
+	}
+	if addr.IsPrivate() || addr.IsLoopback() || addr.IsUnspecified() ||
+		addr.IsLinkLocalUnicast() || addr.IsLinkLocalMulticast() || addr.IsInterfaceLocalMulticast() {
+		return true
+	}
+	return containsAddr(_egressDefaultDeniedCIDRs, addr)
+}
+
+func containsAddr(prefixes []netip.Prefix, addr netip.Addr) bool {
+	for _, p := range prefixes {
+		if p.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesHost matches the exact names and the *.example.com patterns of the
+// subdomains
+func matchesHost(patterns []string, name string) bool {
+	for _, p := range patterns {
+		if suffix, ok := strings.CutPrefix(p, "*"); ok {
+			if strings.HasSuffix(name, suffix) {
+				return true
+			}
+			continue
+		}
+		if name == p {
+			return true
+		}
+	}
+	return false
+}
+
+func normalizeHost(host string) string {
+	return strings.TrimSuffix(strings.ToLower(strings.TrimSpace(host)), ".")
+}
+
+func splitHostPort(addr string) (string, int, error) {
+	host, portStr, err := net.SplitHostPort(addr)
+	if err != nil {
+		return "", 0, err
+	}
+	port, err := strconv.Atoi(portStr)
+	if err != nil {
+		return "", 0, err
+	}
+	return host, port, nil
+}
+
+func proxyAddrOf(scheme, host string) string {
+	if _, _, err := net.SplitHostPort(host); err == nil {
+		return host
+	}
+	switch scheme {
+	case "https":
+		return net.JoinHostPort(host, "443")
+	case "socks5", "socks5h":
+		return net.JoinHostPort(host, "1080")
+	}
+	return net.JoinHostPort(host, "80")
+}
@@ -1,16 +1,55 @@
 package httpc
 
 import (
+	"bytes"
 	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"net"
 	"net/http"
+	"net/url"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/hasmcp/hasmcp-ce/backend/internal/service/config"
 )
 
 type (
+	// Service calls through the clients enforcing the egress policy, the
+	// explicit allow of the calls is taken from the context
 	Service interface {
 		Call(ctx context.Context, req *http.Request) (*http.Response, error)
+		// CallWithTransport calls through a client with the transport
+		// options, the clients are shared by the options key
+		CallWithTransport(ctx context.Context, req *http.Request, opts TransportOptions) (*http.Response, error)
+		// Client returns the client of the transport options and the egress
+		// allow of the context for the libraries making the calls themselves,
+		// e.g. oauth2. The shared client is returned when both are not set.
+		Client(ctx context.Context, opts *TransportOptions) *http.Client
+		// Dialer returns the dial of the egress guard with the allow for the
+		// clients which are not HTTP, e.g. gRPC. The connections are tunneled
+		// through the proxy, or through the environment proxy when it is nil.
+		Dialer(allow EgressAllow, proxy *url.URL) func(ctx context.Context, addr string) (net.Conn, error)
+		// Evict closes the idle connections of the clients of the options key
+		// and drops them
+		Evict(key string)
+	}
+
+	// TransportOptions are the TLS settings and the proxy of a dedicated
+	// client
+	TransportOptions struct {
+		Key                string // identifies the options, e.g. a hash of the materials
+		Certificates       []tls.Certificate
+		RootCAs            *x509.CertPool // the system roots are used when nil
+		MinVersion         uint16         // TLS 1.2 when zero
+		InsecureSkipVerify bool
+		// PinnedSHA256 are the SHA-256 hashes of the SubjectPublicKeyInfo, a
+		// certificate of the chain must match one of them
+		PinnedSHA256 [][]byte
+		Proxy        *url.URL // http, https or socks5, the environment proxy when nil
 	}
 
 	Params struct {
@@ -23,7 +62,12 @@ type (
 	}
 
 	service struct {
-		doer *http.Client
+		cfg       httpcConfig
+		egress    *egressPolicy
+		transport *http.Transport
+		doer      *http.Client
+		clients   sync.Map // map[string]*http.Client
+		mu        sync.Mutex
 	}
 
 	Option func(*http.Transport)
@@ -31,6 +75,7 @@ type (
 	httpcConfig struct {
 		UserAgent string        `yaml:"userAgent"`
 		Timeout   time.Duration `yaml:"timeout"`
+		Egress    egressConfig  `yaml:"egress"`
 	}
 )
 
@@ -45,19 +90,23 @@ func New(p Params, options ...Option) (Service, error) {
 		return nil, err
 	}
 
+	egress, err := newEgressPolicy(cfg.Egress)
+	if err != nil {
+		return nil, err
+	}
+
 	t := http.DefaultTransport.(*http.Transport).Clone()
 	for _, o := range options {
 		o(t)
 	}
 
-	rt := ObserverRoundTripper{
-		userAgent: cfg.UserAgent,
-		tripper:   t,
+	s := &service{
+		cfg:       cfg,
+		egress:    egress,
+		transport: t,
 	}
-
-	return &service{
-		doer: &http.Client{Transport: rt, Timeout: cfg.Timeout},
-	}, nil
+	s.doer = s.newClient(t.Clone(), egressRules{}, true)
+	return s, nil
 }
 
 // WithMaxIdleConns returns an option which sets the idle conns per host
@@ -89,5 +138,118 @@ func (t ObserverRoundTripper) RoundTrip(req *http.Request) (res *http.Response,
 }
 
 func (c *service) Call(ctx context.Context, req *http.Request) (*http.Response, error) {
-	return c.doer.Do(req)
+	return c.Client(ctx, nil).Do(req)
+}
+
+func (c *service) CallWithTransport(ctx context.Context, req *http.Request, opts TransportOptions) (*http.Response, error) {
+	return c.Client(ctx, &opts).Do(req)
+}
+
+func (c *service) Client(ctx context.Context, opts *TransportOptions) *http.Client {
+	allow, ok := egressAllowOf(ctx)
+	if !ok && opts == nil {
+		return c.doer
+	}
+	return c.clientOf(opts, allow)
+}
+
+func (c *service) Evict(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.clients.Range(func(k, v any) bool {
+		if strings.HasPrefix(k.(string), key+"|") {
+			c.clients.Delete(k)
+			v.(*http.Client).CloseIdleConnections()
+		}
+		return true
+	})
+}
+
+// clientOf returns the client of the transport options and the egress allow,
+// the clients are shared by their keys
+func (c *service) clientOf(opts *TransportOptions, allow EgressAllow) *http.Client {
+	key := "|" + allow.key()
+	if opts != nil {
+		key = opts.Key + key
+	}
+	if client, ok := c.clients.Load(key); ok {
+		return client.(*http.Client)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if client, ok := c.clients.Load(key); ok {
+		return client.(*http.Client)
+	}
+
+	// the allow is validated when it is saved, the invalid entries are skipped
+	rules, _ := egressRulesOf(allow.CIDRs, allow.Hosts, allow.Ports)
+
+	t := c.transport.Clone()
+	trustProxy := true
+	if opts != nil {
+		t.TLSClientConfig = opts.TLSConfig()
+		if opts.Proxy != nil {
+			// the proxy of the caller is dialed through the egress checks
+			t.Proxy = http.ProxyURL(opts.Proxy)
+			trustProxy = false
+		}
+	}
+	client := c.newClient(t, rules, trustProxy)
+	c.clients.Store(key, client)
+	return client
+}
+
+// newClient returns the client dialing through the egress guard of the allow
+func (c *service) newClient(t *http.Transport, allow egressRules, trustProxy bool) *http.Client {
+	guard := &egressGuard{
+		policy:     c.egress,
+		allow:      allow,
+		trustProxy: trustProxy,
+	}
+	t.DialContext = guard.dialContext
+	return &http.Client{
+		Transport: ObserverRoundTripper{
+			userAgent: c.cfg.UserAgent,
+			tripper: egressRoundTripper{
+				guard:   guard,
+				tripper: t,
+			},
+		},
+		Timeout: c.cfg.Timeout,
+	}
+}
+
+// TLSConfig returns the TLS config of the options
+func (o *TransportOptions) TLSConfig() *tls.Config {
+	minVersion := o.MinVersion
+	if minVersion == 0 {
+		minVersion = tls.VersionTLS12
+	}
+	cfg := &tls.Config{
+		MinVersion:         minVersion,
+		Certificates:       o.Certificates,
+		RootCAs:            o.RootCAs,
+		InsecureSkipVerify: o.InsecureSkipVerify,
+	}
+	if len(o.PinnedSHA256) > 0 {
+		cfg.VerifyConnection = verifyPins(o.PinnedSHA256)
+	}
+	return cfg
+}
+
+// verifyPins returns the check of the pinned public keys, it runs after the
+// chain verification unless the verification is skipped
+func verifyPins(pins [][]byte) func(tls.ConnectionState) error {
+	return func(cs tls.ConnectionState) error {
+		for _, cert := range cs.PeerCertificates {
+			sum := sha256.Sum256(cert.RawSubjectPublicKeyInfo)
+			for _, pin := range pins {
+				if bytes.Equal(sum[:], pin) {
+					return nil
+				}
+			}
+		}
+		return errors.New("httpc: no certificate of the chain matches the pinned public keys")
+	}
 }
@@ -0,0 +1,378 @@
+package mcpc
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/hasmcp/hasmcp-ce/backend/internal/data/entity/jsonrpc"
+	"github.com/hasmcp/hasmcp-ce/backend/internal/service/config"
+	"github.com/hasmcp/hasmcp-ce/backend/internal/service/httpc"
+	"golang.org/x/sync/singleflight"
+)
+
+type (
+	// Service is a Streamable HTTP client of the upstream MCP servers
+	Service interface {
+		// Call sends the request over the session of the key and returns the
+		// result. The session is initialized on the first call and once more
+		// when the upstream server expires it. Upstream JSON-RPC errors are
+		// returned as jsonrpc.Error.
+		Call(ctx context.Context, req CallRequest) (json.RawMessage, error)
+	}
+
+	Params struct {
+		Config config.Service
+		HTTPC  httpc.Service
+	}
+
+	// Doer sends the request, the body is given for the retries and the
+	// request signatures
+	Doer func(req *http.Request, body []byte) (*http.Response, error)
+
+	CallRequest struct {
+		// SessionKey shares the session between the calls, a new session is
+		// initialized for each call when empty
+		SessionKey string
+		URL        string
+		Headers    http.Header
+		Method     string
+		Params     any
+		// Do sends the requests through the HTTP client service when nil
+		Do Doer
+	}
+
+	session struct {
+		id              string
+		protocolVersion string
+		createdAt       time.Time
+	}
+
+	service struct {
+		cfg      mcpcConfig
+		httpc    httpc.Service
+		sessions sync.Map // map[string]*session
+		inits    singleflight.Group
+		nextID   atomic.Int64
+	}
+
+	mcpcConfig struct {
+		ClientName    string        `yaml:"clientName"`
+		ClientVersion string        `yaml:"clientVersion"`
+		Timeout       time.Duration `yaml:"timeout"`
+		SessionTTL    time.Duration `yaml:"sessionTTL"`
+	}
+
+	message struct {
+		ID     json.RawMessage `json:"id,omitempty"`
+		Method string          `json:"method,omitempty"`
+		Result json.RawMessage `json:"result,omitempty"`
+		Error  *messageError   `json:"error,omitempty"`
+	}
+
+	messageError struct {
+		Code    int             `json:"code"`
+		Message string          `json:"message"`
+		Data    json.RawMessage `json:"data,omitempty"`
+	}
+)
+
+const (
+	_cfgKey = "mcpc"
+
+	// ProtocolVersion is the MCP revision requested on the initialization
+	ProtocolVersion = "2025-06-18"
+
+	MethodInitialize               = "initialize"
+	MethodNotificationsInitialized = "notifications/initialized"
+
+	HeaderMcpSessionID       = "Mcp-Session-Id"
+	HeaderMcpProtocolVersion = "Mcp-Protocol-Version"
+
+	_contentTypeJSON        = "application/json"
+	_contentTypeEventStream = "text/event-stream"
+
+	_maxEventSize = 16 << 20
+)
+
+var (
+	errSessionExpired = errors.New("upstream session expired")
+
+	// _hopHeaders are set by the client, the ones of the callers are dropped
+	_hopHeaders = []string{
+		"Accept",
+		"Content-Length",
+		"Content-Type",
+		"Last-Event-Id",
+		HeaderMcpSessionID,
+		HeaderMcpProtocolVersion,
+	}
+)
+
+// New inits a new MCP client service
+func New(p Params) (Service, error) {
+	var cfg mcpcConfig
+	if err := p.Config.Populate(_cfgKey, &cfg); err != nil {
+		return nil, err
+	}
+
+	return &service{
+		cfg:   cfg,
+		httpc: p.HTTPC,
+	}, nil
+}
+
+func (s *service) Call(ctx context.Context, req CallRequest) (json.RawMessage, error) {
+	if _, ok := ctx.Deadline(); !ok && s.cfg.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.cfg.Timeout)
+		defer cancel()
+	}
+
+	sess, err := s.sessionOf(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := s.request(ctx, req, sess, req.Method, req.Params)
+	if !errors.Is(err, errSessionExpired) {
+		return res, err
+	}
+
+	s.sessions.CompareAndDelete(req.SessionKey, sess)
+	sess, err = s.sessionOf(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return s.request(ctx, req, sess, req.Method, req.Params)
+}
+
+// sessionOf returns the live session of the key or initializes a new one, the
+// concurrent initializations of a key share a single handshake
+func (s *service) sessionOf(ctx context.Context, req CallRequest) (*session, error) {
+	if req.SessionKey == "" {
+		return s.initialize(ctx, req)
+	}
+
+	if v, ok := s.sessions.Load(req.SessionKey); ok {
+		sess := v.(*session)
+		if s.cfg.SessionTTL <= 0 || time.Since(sess.createdAt) < s.cfg.SessionTTL {
+			return sess, nil
+		}
+		s.sessions.CompareAndDelete(req.SessionKey, sess)
+	}
+
+	v, err, _ := s.inits.Do(req.SessionKey, func() (any, error) {
+		sess, err := s.initialize(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+		s.sessions.Store(req.SessionKey, sess)
+		return sess, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return v.(*session), nil
+}
+
+// initialize runs the lifecycle handshake of a new session
+func (s *service) initialize(ctx context.Context, req CallRequest) (*session, error) {
+	sess := &session{
+		protocolVersion: ProtocolVersion,
+		createdAt:       time.Now(),
+	}
+
+	params := map[string]any{
+		"protocolVersion": ProtocolVersion,
+		"capabilities":    map[string]any{},
+		"clientInfo": map[string]string{
+			"name":    s.cfg.ClientName,
+			"version": s.cfg.ClientVersion,
+		},
+	}
+	res, err := s.request(ctx, req, sess, MethodInitialize, params)
+	if err != nil {
+		return nil, fmt.Errorf("initialize: %w", err)
+	}
+
+	var result struct {
+		ProtocolVersion string `json:"protocolVersion"`
+	}
+	if err := json.Unmarshal(res, &result); err != nil {
+		return nil, fmt.Errorf("initialize: %w", err)
+	}
+	if result.ProtocolVersion != "" {
+		sess.protocolVersion = result.ProtocolVersion
+	}
+
+	if _, err := s.post(ctx, req, sess, MethodNotificationsInitialized, nil, nil); err != nil {
+		return nil, fmt.Errorf("initialized notification: %w", err)
+	}
+	return sess, nil
+}
+
+func (s *service) request(ctx context.Context, req CallRequest, sess *session, method string, params any) (json.RawMessage, error) {
+	id := s.nextID.Add(1)
+	msg, err := s.post(ctx, req, sess, method, params, &id)
+	if err != nil {
+		return nil, err
+	}
+	if msg.Error != nil {
+		return nil, msg.Error.toJSONRPCError()
+	}
+	return msg.Result, nil
+}
+
+// post sends the message and reads the response of the requests from the JSON
+// body or from the event stream, the notifications have no id
+func (s *service) post(ctx context.Context, req CallRequest, sess *session, method string, params any, id *int64) (*message, error) {
+	var rawParams json.RawMessage
+	if params != nil {
+		var err error
+		if rawParams, err = json.Marshal(params); err != nil {
+			return nil, err
+		}
+	}
+
+	rpc := jsonrpc.Request{
+		JSONRpc: jsonrpc.Version,
+		Method:  method,
+		Params:  rawParams,
+	}
+	if id != nil {
+		rpc.ID = *id
+	}
+	body, err := json.Marshal(rpc)
+	if err != nil {
+		return nil, err
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, req.URL, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	for k, vals := range req.Headers {
+		for _, v := range vals {
+			httpReq.Header.Add(k, v)
+		}
+	}
+	for _, h := range _hopHeaders {
+		httpReq.Header.Del(h)
+	}
+	httpReq.Header.Set("Content-Type", _contentTypeJSON)
+	httpReq.Header.Set("Accept", _contentTypeJSON+", "+_contentTypeEventStream)
+	if method != MethodInitialize {
+		httpReq.Header.Set(HeaderMcpProtocolVersion, sess.protocolVersion)
+	}
+	if sess.id != "" {
+		httpReq.Header.Set(HeaderMcpSessionID, sess.id)
+	}
+
+	do := req.Do
+	if do == nil {
+		do = func(r *http.Request, _ []byte) (*http.Response, error) {
+			return s.httpc.Call(ctx, r)
+		}
+	}
+	res, err := do(httpReq, body)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	if method == MethodInitialize {
+		sess.id = res.Header.Get(HeaderMcpSessionID)
+	}
+
+	switch {
+	case res.StatusCode == http.StatusNotFound && sess.id != "" && method != MethodInitialize:
+		return nil, errSessionExpired
+	case res.StatusCode >= http.StatusBadRequest:
+		resBody, _ := io.ReadAll(io.LimitReader(res.Body, 1024))
+		return nil, fmt.Errorf("upstream responded with status %d: %s", res.StatusCode, strings.TrimSpace(string(resBody)))
+	case id == nil:
+		return &message{}, nil
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(res.Header.Get("Content-Type"))
+	if mediaType == _contentTypeEventStream {
+		return readEventStream(res.Body, *id)
+	}
+
+	var msg message
+	if err := json.NewDecoder(res.Body).Decode(&msg); err != nil {
+		return nil, fmt.Errorf("malformed upstream response: %w", err)
+	}
+	return &msg, nil
+}
+
+// readEventStream returns the response of the request from the event stream,
+// the server requests and the notifications sent before it are skipped
+func readEventStream(r io.Reader, id int64) (*message, error) {
+	want := strconv.FormatInt(id, 10)
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), _maxEventSize)
+
+	var data bytes.Buffer
+	dispatch := func() *message {
+		defer data.Reset()
+		if data.Len() == 0 {
+			return nil
+		}
+		var msg message
+		if err := json.Unmarshal(data.Bytes(), &msg); err != nil || msg.Method != "" {
+			return nil
+		}
+		if strings.Trim(string(msg.ID), `"`) != want {
+			return nil
+		}
+		return &msg
+	}
+
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" {
+			if msg := dispatch(); msg != nil {
+				return msg, nil
+			}
+			continue
+		}
+		if value, ok := strings.CutPrefix(line, "data:"); ok {
+			if data.Len() > 0 {
+				data.WriteByte('\n')
+			}
+			data.WriteString(strings.TrimPrefix(value, " "))
+		}
+	}
+	if msg := dispatch(); msg != nil {
+		return msg, nil
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return nil, errors.New("upstream event stream ended without a response")
+}
+
+func (e *messageError) toJSONRPCError() jsonrpc.Error {
+	data := map[string]any{}
+	if len(e.Data) > 0 && json.Unmarshal(e.Data, &data) != nil {
+		data = map[string]any{"data": e.Data}
+	}
+	return jsonrpc.Error{
+		Code:    e.Code,
+		Message: e.Message,
+		Data:    data,
+	}
+}
@@ -16,6 +16,10 @@ type (
 	PublishRequest struct {
 		PubSubID int64
 		Event    any
+
+		// ReplicasOnly skips the local subscribers and delivers the event only
+		// to the subscribers on the other replicas
+		ReplicasOnly bool
 	}
 
 	PublishResponse struct {
@@ -35,4 +39,31 @@ type (
 		PubSubID int64
 		ID       int64
 	}
+
+	// Event is implemented by the events which can be delivered to the other
+	// replicas
+	Event interface {
+		GetID() string
+		GetType() string
+		GetData() any
+	}
+
+	// Message is the event received from another replica
+	Message struct {
+		ID   string `json:"id,omitempty"`
+		Type string `json:"type,omitempty"`
+		Data []byte `json:"data,omitempty"`
+	}
 )
+
+func (m *Message) GetID() string {
+	return m.ID
+}
+
+func (m *Message) GetType() string {
+	return m.Type
+}
+
+func (m *Message) GetData() any {
+	return m.Data
+}
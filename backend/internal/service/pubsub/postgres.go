@@ -0,0 +1,268 @@
+package pubsub
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/hasmcp/hasmcp-ce/backend/internal/data/entity/err"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	zlog "github.com/rs/zerolog/log"
+)
+
+type (
+	// postgresService delivers the events to the local subscribers and shares
+	// them with the other replicas using postgres LISTEN/NOTIFY
+	postgresService struct {
+		*service
+
+		cfg    postgresConfig
+		origin string
+		pool   *pgxpool.Pool
+	}
+
+	postgresConfig struct {
+		DSN     string `yaml:"dsn"`
+		Channel string `yaml:"channel"`
+	}
+
+	// envelope is the notification payload shared between the replicas
+	envelope struct {
+		Origin   string       `json:"o"`
+		Kind     envelopeKind `json:"k,omitempty"`
+		PubSubID int64        `json:"p,omitempty"`
+		Message  *Message     `json:"m,omitempty"`
+		Ref      int64        `json:"r,omitempty"`
+	}
+
+	envelopeKind uint8
+)
+
+const (
+	envelopeKindPublish envelopeKind = iota
+	envelopeKindDelete
+)
+
+const (
+	_postgresDefaultChannel = "hasmcp_pubsub"
+
+	// postgres rejects notification payloads larger than 8000 bytes, larger
+	// payloads are stored in a table and the notification carries a reference
+	_postgresMaxNotifyPayloadSize = 7900
+	_postgresPayloadsTable        = "pubsub_payloads"
+	_postgresPayloadsRetention    = time.Minute
+	_postgresReconnectDelay       = 3 * time.Second
+)
+
+func newPostgres(cfg postgresConfig, s *service) (Service, error) {
+	if cfg.Channel == "" {
+		cfg.Channel = _postgresDefaultChannel
+	}
+
+	ctx := context.Background()
+	pool, err := pgxpool.New(ctx, cfg.DSN)
+	if err != nil {
+		zlog.Error().Err(err).Msg(_logPrefix + "failed to connect postgres")
+		return nil, err
+	}
+
+	_, err = pool.Exec(ctx, "CREATE TABLE IF NOT EXISTS "+_postgresPayloadsTable+
+		" (id BIGINT PRIMARY KEY, payload BYTEA NOT NULL, created_at TIMESTAMPTZ NOT NULL DEFAULT now())")
+	if err != nil {
+		pool.Close()
+		zlog.Error().Err(err).Msg(_logPrefix + "failed to create payloads table")
+		return nil, err
+	}
+
+	c := &postgresService{
+		service: s,
+		cfg:     cfg,
+		origin:  s.idgen.NextString(),
+		pool:    pool,
+	}
+
+	go c.listen(ctx)
+
+	zlog.Info().Str("channel", cfg.Channel).Str("origin", c.origin).Msg(_logPrefix + "postgres backend is initialized")
+	return c, nil
+}
+
+func (c *postgresService) Delete(ctx context.Context, req DeletePubSubRequest) error {
+	if err := c.service.Delete(ctx, req); err != nil {
+		return err
+	}
+
+	return c.notify(ctx, envelope{
+		Origin:   c.origin,
+		Kind:     envelopeKindDelete,
+		PubSubID: req.ID,
+	})
+}
+
+func (c *postgresService) Publish(ctx context.Context, req PublishRequest) (*PublishResponse, error) {
+	if !req.ReplicasOnly {
+		_, e := c.service.publish(req.PubSubID, req.Event)
+		// The subscribers of the pubsub might be on the other replicas
+		var pe err.Error
+		if e != nil && (!errors.As(e, &pe) || pe.Code != 404) {
+			return nil, e
+		}
+	}
+
+	event, ok := req.Event.(Event)
+	if !ok {
+		return nil, err.Error{
+			Code:    500,
+			Message: "event can not be shared with the other replicas",
+			Data: map[string]any{
+				"id": req.PubSubID,
+			},
+		}
+	}
+
+	data, _ := event.GetData().([]byte)
+	e := c.notify(ctx, envelope{
+		Origin:   c.origin,
+		Kind:     envelopeKindPublish,
+		PubSubID: req.PubSubID,
+		Message: &Message{
+			ID:   event.GetID(),
+			Type: event.GetType(),
+			Data: data,
+		},
+	})
+	if e != nil {
+		return nil, e
+	}
+
+	return &PublishResponse{
+		ID: c.idgen.Next(),
+	}, nil
+}
+
+func (c *postgresService) notify(ctx context.Context, e envelope) error {
+	payload, jsonErr := json.Marshal(e)
+	if jsonErr != nil {
+		return jsonErr
+	}
+
+	if len(payload) > _postgresMaxNotifyPayloadSize {
+		ref := c.idgen.Next()
+		_, dbErr := c.pool.Exec(ctx, "INSERT INTO "+_postgresPayloadsTable+" (id, payload) VALUES ($1, $2)", ref, payload)
+		if dbErr != nil {
+			return err.Error{
+				Code:    500,
+				Message: "failed to store pubsub payload",
+				Data: map[string]any{
+					"id":     e.PubSubID,
+					"reason": dbErr.Error(),
+				},
+			}
+		}
+		payload, _ = json.Marshal(envelope{
+			Origin: c.origin,
+			Ref:    ref,
+		})
+	}
+
+	_, dbErr := c.pool.Exec(ctx, "SELECT pg_notify($1, $2)", c.cfg.Channel, string(payload))
+	if dbErr != nil {
+		return err.Error{
+			Code:    500,
+			Message: "failed to notify replicas",
+			Data: map[string]any{
+				"id":     e.PubSubID,
+				"reason": dbErr.Error(),
+			},
+		}
+	}
+	return nil
+}
+
+func (c *postgresService) listen(ctx context.Context) {
+	for {
+		e := c.listenUntilFailure(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		zlog.Error().Err(e).Msg(_logPrefix + "postgres listener failed, reconnecting")
+		time.Sleep(_postgresReconnectDelay)
+	}
+}
+
+func (c *postgresService) listenUntilFailure(ctx context.Context) error {
+	conn, e := pgx.Connect(ctx, c.cfg.DSN)
+	if e != nil {
+		return e
+	}
+	defer conn.Close(context.Background())
+
+	_, e = conn.Exec(ctx, "LISTEN "+pgx.Identifier{c.cfg.Channel}.Sanitize())
+	if e != nil {
+		return e
+	}
+
+	cleanupAt := time.Now().Add(_postgresPayloadsRetention)
+	for {
+		waitCtx, cancel := context.WithTimeout(ctx, _postgresPayloadsRetention)
+		n, e := conn.WaitForNotification(waitCtx)
+		cancel()
+		if e != nil && !errors.Is(e, context.DeadlineExceeded) {
+			return e
+		}
+
+		if n != nil {
+			c.receive(ctx, []byte(n.Payload))
+		}
+
+		if time.Now().After(cleanupAt) {
+			cleanupAt = time.Now().Add(_postgresPayloadsRetention)
+			_, e = c.pool.Exec(ctx, "DELETE FROM "+_postgresPayloadsTable+" WHERE created_at < $1",
+				time.Now().UTC().Add(-_postgresPayloadsRetention))
+			if e != nil {
+				zlog.Warn().Err(e).Msg(_logPrefix + "failed to clean up expired payloads")
+			}
+		}
+	}
+}
+
+func (c *postgresService) receive(ctx context.Context, payload []byte) {
+	var e envelope
+	if jsonErr := json.Unmarshal(payload, &e); jsonErr != nil {
+		zlog.Warn().Err(jsonErr).Msg(_logPrefix + "received malformed notification")
+		return
+	}
+
+	// Own events are already delivered to the local subscribers
+	if e.Origin == c.origin {
+		return
+	}
+
+	if e.Ref > 0 {
+		var stored []byte
+		dbErr := c.pool.QueryRow(ctx, "SELECT payload FROM "+_postgresPayloadsTable+" WHERE id = $1", e.Ref).Scan(&stored)
+		if dbErr != nil {
+			zlog.Warn().Err(dbErr).Int64("ref", e.Ref).Msg(_logPrefix + "failed to load the stored payload")
+			return
+		}
+		if jsonErr := json.Unmarshal(stored, &e); jsonErr != nil {
+			zlog.Warn().Err(jsonErr).Int64("ref", e.Ref).Msg(_logPrefix + "received malformed stored payload")
+			return
+		}
+	}
+
+	switch e.Kind {
+	case envelopeKindPublish:
+		if e.Message == nil {
+			return
+		}
+		// Not having the pubsub on this replica is expected
+		_, _ = c.service.publish(e.PubSubID, e.Message)
+	case envelopeKindDelete:
+		_ = c.service.Delete(ctx, DeletePubSubRequest{
+			ID: e.PubSubID,
+		})
+	}
+}
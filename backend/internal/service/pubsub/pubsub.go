@@ -47,6 +47,11 @@ type (
 
 	pubsubConfig struct {
 		MaxDurationForSubscriberToReceive time.Duration `yaml:"maxDurationForSubscriberToReceive"`
+
+		// Backend selects where the events are distributed: memory (single
+		// replica) or postgres (LISTEN/NOTIFY across replicas)
+		Backend  string         `yaml:"backend"`
+		Postgres postgresConfig `yaml:"postgres"`
 	}
 )
 
@@ -54,6 +59,19 @@ const (
 	_cfgKey = "pubsub"
 
 	_logPrefix = "[pubsub] "
+
+	_backendMemory   = "memory"
+	_backendPostgres = "postgres"
+)
+
+const (
+	// IDReplicaCacheEvictions is the reserved pubsub to share cache evictions
+	// with the other replicas
+	IDReplicaCacheEvictions int64 = iota + 1
+
+	// IDReplicaResourceChanges is the reserved pubsub to share crud resource
+	// changes with the other replicas
+	IDReplicaResourceChanges
 )
 
 func New(p Params) (Service, error) {
@@ -68,7 +86,15 @@ func New(p Params) (Service, error) {
 		idgen:   p.IDGen,
 		pubsubs: sync.Map{},
 	}
-	return c, nil
+
+	switch cfg.Backend {
+	case "", _backendMemory:
+		return c, nil
+	case _backendPostgres:
+		return newPostgres(cfg.Postgres, c)
+	default:
+		return nil, fmt.Errorf("unknown pubsub backend: %s", cfg.Backend)
+	}
 }
 
 func (c *service) Create(ctx context.Context, req CreatePubSubRequest) (*CreatePubSubResponse, error) {
@@ -122,6 +148,13 @@ func (c *service) Delete(ctx context.Context, req DeletePubSubRequest) error {
 }
 
 func (c *service) Publish(ctx context.Context, req PublishRequest) (*PublishResponse, error) {
+	// There are no other replicas to deliver for the memory backend
+	if req.ReplicasOnly {
+		return &PublishResponse{
+			ID: c.idgen.Next(),
+		}, nil
+	}
+
 	_, err := c.publish(req.PubSubID, req.Event)
 	if err != nil {
 		return nil, err